@@ -52,6 +52,24 @@ var _ = Describe("Fleet apply targets", func() {
 		})
 	})
 
+	When("Targets file is empty, and overrideTargets limits the number of clusters", func() {
+		BeforeEach(func() {
+			dirs = []string{cli.AssetsPath + "targets/override-max-clusters"}
+		})
+
+		It("Bundle target contains maxClusters and spreadByLabel, but the targetRestriction does not", func() {
+			bundle, err := cli.GetBundleFromOutput(buf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bundle.Spec.Targets).To(HaveLen(1))
+			Expect(bundle.Spec.Targets[0].MaxClusters).To(HaveValue(Equal(2)))
+			Expect(bundle.Spec.Targets[0].SpreadByLabel).To(Equal("region"))
+			Expect(bundle.Spec.TargetRestrictions).To(Equal([]fleet.BundleTargetRestriction{{
+				Name:            "canary",
+				ClusterSelector: bundle.Spec.Targets[0].ClusterSelector,
+			}}))
+		})
+	})
+
 	When("Targets file contains one target, and overrideTargets is not provided", func() {
 		var (
 			targets            []fleet.BundleTarget
@@ -0,0 +1,118 @@
+package capi
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/rancher/fleet/integrationtests/utils"
+	"github.com/rancher/fleet/internal/cmd/controller/reconciler"
+	"github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newCAPIObject(kind, name string, labels map[string]string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(reconciler.CAPIClusterGVK.GroupVersion().WithKind(kind))
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	u.SetLabels(labels)
+	return u
+}
+
+var _ = Describe("Cluster API clusters", func() {
+	var (
+		name        string
+		capiCluster *unstructured.Unstructured
+		cluster     *v1alpha1.Cluster
+	)
+
+	getCluster := func(g Gomega) {
+		g.Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, cluster)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		name, err = utils.NewNamespaceName()
+		Expect(err).ToNot(HaveOccurred())
+		cluster = &v1alpha1.Cluster{}
+
+		clusterClass := newCAPIObject("ClusterClass", name+"-class", map[string]string{"provider": "docker", "env": "base"})
+		Expect(k8sClient.Create(ctx, clusterClass)).To(Succeed())
+
+		capiCluster = newCAPIObject("Cluster", name, map[string]string{"env": "dev"})
+		Expect(unstructured.SetNestedField(capiCluster.Object, name+"-class", "spec", "topology", "class")).To(Succeed())
+		Expect(k8sClient.Create(ctx, capiCluster)).To(Succeed())
+
+		DeferCleanup(func() {
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, capiCluster))).To(Succeed())
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, clusterClass))).To(Succeed())
+		})
+	})
+
+	It("registers them as fleet clusters once the control plane is ready", func() {
+		By("creating a fleet cluster with the labels of the cluster and its ClusterClass")
+		Eventually(func(g Gomega) {
+			getCluster(g)
+			g.Expect(cluster.Labels).To(HaveKeyWithValue("env", "dev"))
+			g.Expect(cluster.Labels).To(HaveKeyWithValue("provider", "docker"))
+			g.Expect(metav1.IsControlledBy(cluster, capiCluster)).To(BeTrue())
+		}).Should(Succeed())
+		Consistently(func(g Gomega) {
+			getCluster(g)
+			g.Expect(cluster.Spec.KubeConfigSecret).To(BeEmpty())
+		}).Should(Succeed())
+
+		By("setting the kubeconfig secret once the control plane is ready")
+		Expect(unstructured.SetNestedField(capiCluster.Object, true, "status", "controlPlaneReady")).To(Succeed())
+		Expect(k8sClient.Status().Update(ctx, capiCluster)).To(Succeed())
+		Expect(k8sClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name + "-kubeconfig"},
+			Data:       map[string][]byte{"value": []byte("kubeconfig")},
+		})).To(Succeed())
+		Eventually(func(g Gomega) {
+			getCluster(g)
+			g.Expect(cluster.Spec.KubeConfigSecret).To(Equal(name + "-kubeconfig"))
+		}).Should(Succeed())
+
+		By("copying label changes of the ClusterClass")
+		clusterClass := newCAPIObject("ClusterClass", name+"-class", nil)
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(clusterClass), clusterClass)).To(Succeed())
+		clusterClass.SetLabels(map[string]string{"provider": "aws"})
+		Expect(k8sClient.Update(ctx, clusterClass)).To(Succeed())
+		Eventually(func(g Gomega) {
+			getCluster(g)
+			g.Expect(cluster.Labels).To(HaveKeyWithValue("provider", "aws"))
+			g.Expect(cluster.Labels).To(HaveKeyWithValue("env", "dev"))
+		}).Should(Succeed())
+
+		By("deleting the fleet cluster with the Cluster API cluster")
+		Expect(k8sClient.Delete(ctx, capiCluster)).To(Succeed())
+		Eventually(func(g Gomega) {
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, cluster)
+			g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		}).Should(Succeed())
+	})
+
+	It("doesn't take over existing fleet clusters", func() {
+		other := newCAPIObject("Cluster", name+"-other", map[string]string{"env": "dev"})
+		existing := &v1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name + "-other"}}
+		Expect(k8sClient.Create(ctx, existing)).To(Succeed())
+		Expect(k8sClient.Create(ctx, other)).To(Succeed())
+		DeferCleanup(func() {
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, other))).To(Succeed())
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, existing))).To(Succeed())
+		})
+
+		Consistently(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(existing), existing)).To(Succeed())
+			g.Expect(existing.OwnerReferences).To(BeEmpty())
+			g.Expect(existing.Labels).ToNot(HaveKey("env"))
+		}).Should(Succeed())
+	})
+})
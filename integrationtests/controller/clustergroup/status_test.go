@@ -86,4 +86,87 @@ var _ = Describe("ClusterGroup Status Fields", func() {
 			}).ShouldNot(HaveOccurred())
 		})
 	})
+
+	When("a child group selects a cluster", func() {
+		BeforeEach(func() {
+			_, err := createClusterGroup("parent", namespace, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			child := &v1alpha1.ClusterGroup{
+				ObjectMeta: metav1.ObjectMeta{Name: "child", Namespace: namespace},
+				Spec: v1alpha1.ClusterGroupSpec{
+					Parent:   "parent",
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, child)).ToNot(HaveOccurred())
+		})
+
+		It("rolls up the cluster into the parent status", func() {
+			_, err := utils.CreateCluster(ctx, k8sClient, clusterName, namespace, map[string]string{"env": "prod"}, namespace)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(func(g Gomega) {
+				parent := &v1alpha1.ClusterGroup{}
+				g.Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "parent"}, parent)).To(Succeed())
+				g.Expect(parent.Status.ClusterCount).To(Equal(1))
+				g.Expect(parent.Status.Display.ReadyClusters).To(Equal("1/1"))
+			}).Should(Succeed())
+		})
+
+		It("removes the cluster from the old parent status when the child moves", func() {
+			_, err := utils.CreateCluster(ctx, k8sClient, clusterName, namespace, map[string]string{"env": "prod"}, namespace)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = createClusterGroup("other", namespace, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(func(g Gomega) {
+				parent := &v1alpha1.ClusterGroup{}
+				g.Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "parent"}, parent)).To(Succeed())
+				g.Expect(parent.Status.ClusterCount).To(Equal(1))
+			}).Should(Succeed())
+
+			Eventually(func() error {
+				child := &v1alpha1.ClusterGroup{}
+				if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "child"}, child); err != nil {
+					return err
+				}
+				child.Spec.Parent = "other"
+				return k8sClient.Update(ctx, child)
+			}).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				parent := &v1alpha1.ClusterGroup{}
+				g.Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "parent"}, parent)).To(Succeed())
+				g.Expect(parent.Status.ClusterCount).To(Equal(0))
+
+				other := &v1alpha1.ClusterGroup{}
+				g.Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "other"}, other)).To(Succeed())
+				g.Expect(other.Status.ClusterCount).To(Equal(1))
+			}).Should(Succeed())
+		})
+	})
+
+	When("parent references form a cycle", func() {
+		BeforeEach(func() {
+			for name, parent := range map[string]string{"a": "b", "b": "a"} {
+				cg := &v1alpha1.ClusterGroup{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+					Spec:       v1alpha1.ClusterGroupSpec{Parent: parent},
+				}
+				Expect(k8sClient.Create(ctx, cg)).ToNot(HaveOccurred())
+			}
+		})
+
+		It("reports the cycle in the processed condition", func() {
+			Eventually(func(g Gomega) {
+				cg := &v1alpha1.ClusterGroup{}
+				g.Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "a"}, cg)).To(Succeed())
+				g.Expect(cg.Status.Conditions).To(ContainElement(And(
+					HaveField("Type", v1alpha1.ClusterGroupConditionProcessed),
+					HaveField("Message", ContainSubstring("cycle in cluster group parents: a -> b -> a")),
+				)))
+			}).Should(Succeed())
+		})
+	})
 })
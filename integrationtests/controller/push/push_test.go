@@ -0,0 +1,131 @@
+package push
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/rancher/fleet/integrationtests/utils"
+	"github.com/rancher/fleet/internal/config"
+	"github.com/rancher/fleet/internal/manifest"
+	"github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("Push mode", func() {
+	var (
+		namespace        string
+		clusterNamespace string
+		deploymentID     string
+	)
+
+	BeforeEach(func() {
+		var err error
+		namespace, err = utils.NewNamespaceName()
+		Expect(err).ToNot(HaveOccurred())
+		clusterNamespace, err = utils.NewNamespaceName()
+		Expect(err).ToNot(HaveOccurred())
+
+		// an agent, which was deployed while the cluster was in agent mode
+		err = downstreamClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: config.DefaultNamespace}})
+		Expect(client.IgnoreAlreadyExists(err)).To(Succeed())
+		labels := map[string]string{"app": "fleet-agent"}
+		Expect(downstreamClient.Create(ctx, &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: config.AgentConfigName, Namespace: config.DefaultNamespace},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: ptr.To[int32](1),
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels},
+					Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "agent", Image: "rancher/fleet-agent"}}},
+				},
+			},
+		})).To(Succeed())
+
+		Expect(k8sClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})).To(Succeed())
+		Expect(k8sClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name: clusterNamespace,
+			Annotations: map[string]string{
+				v1alpha1.ClusterAnnotation:          "pushed",
+				v1alpha1.ClusterNamespaceAnnotation: namespace,
+			},
+		}})).To(Succeed())
+
+		Expect(k8sClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "kubeconfig", Namespace: namespace},
+			Data:       map[string][]byte{config.KubeConfigSecretValueKey: utils.FromEnvTestConfig(downstreamCfg)},
+		})).To(Succeed())
+		Expect(k8sClient.Create(ctx, &v1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "pushed", Namespace: namespace},
+			Spec: v1alpha1.ClusterSpec{
+				KubeConfigSecret: "kubeconfig",
+				DeploymentMode:   v1alpha1.DeploymentModePush,
+			},
+		})).To(Succeed())
+		Expect(retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+			cluster := &v1alpha1.Cluster{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "pushed"}, cluster); err != nil {
+				return err
+			}
+			cluster.Status.Namespace = clusterNamespace
+			return k8sClient.Status().Update(ctx, cluster)
+		})).To(Succeed())
+
+		m := manifest.New([]v1alpha1.BundleResource{{
+			Name:    "cm.yaml",
+			Content: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: pushed\n  namespace: default\ndata:\n  key: value\n",
+		}})
+		Expect(manifest.NewStore(k8sClient).Store(ctx, m)).To(Succeed())
+		deploymentID, err = m.ID()
+		Expect(err).ToNot(HaveOccurred())
+
+		DeferCleanup(func() {
+			Expect(k8sClient.Delete(ctx, &v1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "pushed", Namespace: namespace}})).To(Succeed())
+			Expect(k8sClient.Delete(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})).To(Succeed())
+		})
+	})
+
+	It("removes the agent, deploys the bundledeployment to the cluster and reports its status", func() {
+		Expect(k8sClient.Create(ctx, &v1alpha1.BundleDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "pushed-bundle", Namespace: clusterNamespace},
+			Spec:       v1alpha1.BundleDeploymentSpec{DeploymentID: deploymentID},
+		})).To(Succeed())
+
+		By("removing the agent before deploying")
+		Eventually(func(g Gomega) {
+			err := downstreamClient.Get(ctx, types.NamespacedName{Namespace: config.DefaultNamespace, Name: config.AgentConfigName}, &appsv1.Deployment{})
+			g.Expect(apierrors.IsNotFound(err)).To(BeTrue(), "agent deployment still exists: %v", err)
+		}).Should(Succeed())
+
+		By("deploying the resources to the second API server")
+		Eventually(func(g Gomega) {
+			cm := &corev1.ConfigMap{}
+			g.Expect(downstreamClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "pushed"}, cm)).To(Succeed())
+			g.Expect(cm.Data).To(HaveKeyWithValue("key", "value"))
+		}).Should(Succeed())
+		err := k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "pushed"}, &corev1.ConfigMap{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue(), "expected the resources not to be deployed upstream")
+
+		By("reporting the status of the bundledeployment")
+		Eventually(func(g Gomega) {
+			bd := &v1alpha1.BundleDeployment{}
+			g.Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: clusterNamespace, Name: "pushed-bundle"}, bd)).To(Succeed())
+			g.Expect(bd.Status.AppliedDeploymentID).To(Equal(deploymentID))
+			g.Expect(bd.Status.Ready).To(BeTrue())
+		}).Should(Succeed())
+
+		By("reporting the cluster status in place of the agent")
+		Eventually(func(g Gomega) {
+			cluster := &v1alpha1.Cluster{}
+			g.Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "pushed"}, cluster)).To(Succeed())
+			g.Expect(cluster.Status.Agent.LastSeen.IsZero()).To(BeFalse())
+		}).Should(Succeed())
+	})
+})
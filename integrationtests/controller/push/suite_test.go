@@ -0,0 +1,85 @@
+package push
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/rancher/fleet/integrationtests/utils"
+	"github.com/rancher/fleet/internal/cmd/controller/push"
+	"github.com/rancher/fleet/internal/config"
+
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+)
+
+var (
+	cancel    context.CancelFunc
+	ctx       context.Context
+	k8sClient client.Client
+	testenv   *envtest.Environment
+
+	// downstream is the API server of the cluster in push mode
+	downstream       *envtest.Environment
+	downstreamCfg    *rest.Config
+	downstreamClient client.Client
+)
+
+func TestFleet(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Fleet Push Mode Suite")
+}
+
+var _ = BeforeSuite(func() {
+	SetDefaultEventuallyTimeout(60 * time.Second)
+	SetDefaultEventuallyPollingInterval(1 * time.Second)
+
+	ctx, cancel = context.WithCancel(context.TODO())
+	testenv = utils.NewEnvTest("../../..")
+
+	cfg, err := utils.StartTestEnv(testenv)
+	Expect(err).NotTo(HaveOccurred())
+
+	downstream = &envtest.Environment{}
+	downstreamCfg, err = downstream.Start()
+	Expect(err).NotTo(HaveOccurred())
+
+	k8sClient, err = utils.NewClient(cfg)
+	Expect(err).NotTo(HaveOccurred())
+	downstreamClient, err = client.New(downstreamCfg, client.Options{Scheme: scheme.Scheme})
+	Expect(err).NotTo(HaveOccurred())
+
+	fleetCfg := config.DefaultConfig()
+	fleetCfg.AgentTLSMode = config.AgentTLSModeStrict
+	config.Set(fleetCfg)
+
+	mgr, err := utils.NewManager(cfg)
+	Expect(err).ToNot(HaveOccurred())
+
+	err = (&push.BundleDeploymentReconciler{
+		Client:          mgr.GetClient(),
+		Reader:          mgr.GetAPIReader(),
+		Scheme:          mgr.GetScheme(),
+		SystemNamespace: config.DefaultNamespace,
+		Workers:         5,
+		ClusterWorkers:  2,
+	}).SetupWithManager(mgr)
+	Expect(err).ToNot(HaveOccurred(), "failed to set up manager")
+
+	go func() {
+		defer GinkgoRecover()
+		err = mgr.Start(ctx)
+		Expect(err).ToNot(HaveOccurred(), "failed to run manager")
+	}()
+})
+
+var _ = AfterSuite(func() {
+	cancel()
+	Expect(downstream.Stop()).ToNot(HaveOccurred())
+	Expect(testenv.Stop()).ToNot(HaveOccurred())
+})
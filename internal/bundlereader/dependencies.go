@@ -0,0 +1,163 @@
+package bundlereader
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rancher/fleet/internal/helmupdater"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Dependencies are the inputs of a bundle found in the repository. They are used to decide if a bundle needs to be
+// regenerated, when only some paths of the repository changed.
+type Dependencies struct {
+	// Name is the bundle name set in the fleet.yaml, if any.
+	Name string
+	// Paths are the files and directories the bundle is built from, relative to the current directory. Changes
+	// to a directory include changes to all files below it.
+	Paths []string
+	// Remote is true if the bundle is also built from content outside the repository, like Helm charts from a
+	// Helm repository or chart dependencies, which have to be downloaded again.
+	Remote bool
+}
+
+type chartDependencies struct {
+	Dependencies []struct {
+		Repository string `json:"repository,omitempty"`
+	} `json:"dependencies,omitempty"`
+}
+
+// ReadDependencies reads the fleet.yaml, or the given file in baseDir, and returns the dependencies of the bundle in
+// baseDir.
+func ReadDependencies(baseDir, file string) (*Dependencies, error) {
+	if baseDir == "" {
+		baseDir = "."
+	}
+
+	var in io.Reader
+	if file == "" {
+		f, err := setupIOReader(baseDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open existing fleet.yaml in %q: %w", baseDir, err)
+		}
+		if f != nil {
+			defer f.Close()
+			in = f
+		}
+	} else {
+		f, err := os.Open(filepath.Join(baseDir, file))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open file %q: %w", file, err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	fy := &fleet.FleetYAML{}
+	if in != nil {
+		data, err := io.ReadAll(in)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(data, fy); err != nil {
+			return nil, fmt.Errorf("reading fleet.yaml: %w", err)
+		}
+	}
+
+	deps := &Dependencies{
+		Name:  fy.Name,
+		Paths: []string{filepath.Clean(baseDir)},
+	}
+	if file != "" {
+		deps.Paths = append(deps.Paths, filepath.Join(baseDir, file))
+	}
+
+	charts := []*fleet.HelmOptions{fy.Helm}
+	for _, target := range append(fy.Targets, fy.TargetCustomizations...) {
+		charts = append(charts, target.Helm)
+	}
+	for _, chart := range charts {
+		if chart == nil {
+			continue
+		}
+		for _, values := range chart.ValuesFiles {
+			deps.Paths = append(deps.Paths, filepath.Join(baseDir, values))
+		}
+		if chart.Chart == "" {
+			continue
+		}
+		chartDir := filepath.Join(baseDir, chart.Chart)
+		if _, err := os.Stat(chartDir); err != nil || chart.Repo != "" {
+			deps.Remote = true
+			continue
+		}
+		deps.Paths = append(deps.Paths, chartDir)
+	}
+
+	// local charts may depend on other local charts or charts from Helm repositories
+	disableDepsUpdate := fy.Helm != nil && fy.Helm.DisableDependencyUpdate
+	walked := map[string]bool{}
+	for i := 0; i < len(deps.Paths); i++ {
+		dir := deps.Paths[i]
+		if walked[dir] {
+			continue
+		}
+		walked[dir] = true
+		err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() {
+				return nil
+			}
+			// hidden directories, like .git, are not part of the bundle
+			if path != dir && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if !helmupdater.ChartYAMLExists(path) {
+				return nil
+			}
+			data, err := os.ReadFile(filepath.Join(path, helmupdater.ChartYaml))
+			if err != nil {
+				return err
+			}
+			chart := &chartDependencies{}
+			if err := yaml.Unmarshal(data, chart); err != nil {
+				return fmt.Errorf("reading %s in %q: %w", helmupdater.ChartYaml, path, err)
+			}
+			for _, dep := range chart.Dependencies {
+				if local, ok := strings.CutPrefix(dep.Repository, "file://"); ok {
+					deps.Paths = append(deps.Paths, filepath.Join(path, local))
+				} else if !disableDepsUpdate {
+					deps.Remote = true
+				}
+			}
+			return nil
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	return deps, nil
+}
+
+// Changed returns true if any of the changed paths, relative to the current directory, is one of the dependencies or
+// below one of them.
+func (d *Dependencies) Changed(changedPaths []string) bool {
+	for _, changed := range changedPaths {
+		changed = filepath.Clean(changed)
+		for _, path := range d.Paths {
+			if path == "." || changed == path || strings.HasPrefix(changed, path+string(filepath.Separator)) {
+				return true
+			}
+		}
+	}
+	return false
+}
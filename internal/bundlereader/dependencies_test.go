@@ -0,0 +1,76 @@
+package bundlereader_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/rancher/fleet/internal/bundlereader"
+)
+
+func TestReadDependencies(t *testing.T) {
+	files := map[string]string{
+		"local/fleet.yaml": `
+name: custom
+helm:
+  chart: ../charts/app
+  valuesFiles:
+  - ../values/common.yaml
+`,
+		"charts/app/Chart.yaml": `
+name: app
+dependencies:
+- name: lib
+  repository: file://../lib
+`,
+		"charts/lib/Chart.yaml": "name: lib",
+		"remote/fleet.yaml": `
+helm:
+  repo: https://charts.example.com
+  chart: app
+`,
+	}
+	t.Chdir(t.TempDir())
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deps, err := bundlereader.ReadDependencies("local", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &bundlereader.Dependencies{
+		Name:  "custom",
+		Paths: []string{"local", "values/common.yaml", "charts/app", "charts/lib"},
+	}
+	if diff := cmp.Diff(expected, deps); diff != "" {
+		t.Errorf("unexpected dependencies (-want +got):\n%s", diff)
+	}
+
+	for changed, expected := range map[string]bool{
+		"local/cm.yaml":          true,
+		"charts/lib/values.yaml": true,
+		"values/common.yaml":     true,
+		"values/other.yaml":      false,
+		"localized/cm.yaml":      false,
+	} {
+		if got := deps.Changed([]string{changed}); got != expected {
+			t.Errorf("expected change of %s to be %v, got %v", changed, expected, got)
+		}
+	}
+
+	deps, err = bundlereader.ReadDependencies("remote", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !deps.Remote {
+		t.Errorf("expected bundle with chart from Helm repository to have remote dependencies")
+	}
+}
@@ -186,7 +186,7 @@ func bundleFromDir(ctx context.Context, name, baseDir string, bundleData []byte,
 		meta.Name = fy.Name
 	}
 
-	setTargetNames(&fy.BundleSpec)
+	SetTargetNames(&fy.BundleSpec)
 
 	propagateHelmChartProperties(&fy.BundleSpec)
 
@@ -234,8 +234,20 @@ func bundleFromDir(ctx context.Context, name, baseDir string, bundleData []byte,
 				ClusterSelector:      target.ClusterSelector,
 				ClusterGroup:         target.ClusterGroup,
 				ClusterGroupSelector: target.ClusterGroupSelector,
+				ClusterFactSelector:  target.ClusterFactSelector,
+				ClusterExpression:    target.ClusterExpression,
+				MaxClusters:          target.MaxClusters,
+				SpreadByLabel:        target.SpreadByLabel,
+			})
+			bundle.Spec.TargetRestrictions = append(bundle.Spec.TargetRestrictions, fleet.BundleTargetRestriction{
+				Name:                 target.Name,
+				ClusterName:          target.ClusterName,
+				ClusterSelector:      target.ClusterSelector,
+				ClusterGroup:         target.ClusterGroup,
+				ClusterGroupSelector: target.ClusterGroupSelector,
+				ClusterFactSelector:  target.ClusterFactSelector,
+				ClusterExpression:    target.ClusterExpression,
 			})
-			bundle.Spec.TargetRestrictions = append(bundle.Spec.TargetRestrictions, fleet.BundleTargetRestriction(target))
 		}
 	} else {
 		bundle, err = appendTargets(bundle, opts.TargetsFile)
@@ -324,7 +336,8 @@ func appendTargets(def *fleet.Bundle, targetsFile string) (*fleet.Bundle, error)
 	return def, nil
 }
 
-func setTargetNames(spec *fleet.BundleSpec) {
+// SetTargetNames names unnamed targets after their position, e.g. "target000".
+func SetTargetNames(spec *fleet.BundleSpec) {
 	for i, target := range spec.Targets {
 		if target.Name == "" {
 			spec.Targets[i].Name = fmt.Sprintf("target%03d", i)
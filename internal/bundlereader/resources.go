@@ -10,6 +10,7 @@ import (
 	"sort"
 	"sync"
 
+	"github.com/rancher/fleet/internal/helmdeployer/sops"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/sync/semaphore"
@@ -161,6 +162,10 @@ func addDirectory(base, customDir, defaultDir string) ([]directory, error) {
 }
 
 func parseValuesFiles(base string, chart *fleet.HelmOptions) (err error) {
+	if err := separateSopsValuesFiles(base, chart); err != nil {
+		return err
+	}
+
 	if len(chart.ValuesFiles) != 0 {
 		valuesMap, err := generateValues(base, chart)
 		if err != nil {
@@ -175,6 +180,27 @@ func parseValuesFiles(base string, chart *fleet.HelmOptions) (err error) {
 	return nil
 }
 
+// separateSopsValuesFiles moves SOPS encrypted values files from ValuesFiles to SopsValuesFiles. Encrypted files
+// are not merged into the bundle's values, instead they are kept encrypted in the bundle's resources and only
+// decrypted by the agent.
+func separateSopsValuesFiles(base string, chart *fleet.HelmOptions) error {
+	var plain []string
+	for _, value := range chart.ValuesFiles {
+		valuesByte, err := os.ReadFile(base + "/" + value)
+		if err != nil {
+			return fmt.Errorf("reading values file: %s/%s: %w", base, value, err)
+		}
+		if sops.IsEncrypted(value, valuesByte) {
+			chart.SopsValuesFiles = append(chart.SopsValuesFiles, value)
+			continue
+		}
+		plain = append(plain, value)
+	}
+	chart.ValuesFiles = plain
+
+	return nil
+}
+
 func generateValues(base string, chart *fleet.HelmOptions) (valuesMap *fleet.GenericMap, err error) {
 	valuesMap = &fleet.GenericMap{}
 	if chart.Values != nil {
@@ -2,6 +2,8 @@ package bundlereader
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
@@ -95,3 +97,43 @@ func TestValueMerge(t *testing.T) {
 		}
 	}
 }
+
+func TestParseValuesFilesKeepsSopsEncryptedFiles(t *testing.T) {
+	base := t.TempDir()
+	encrypted := `password: ENC[AES256_GCM,data:abc=,iv:def=,tag:ghi=,type:str]
+sops:
+    lastmodified: "2025-01-01T00:00:00Z"
+    mac: ENC[AES256_GCM,data:abc=,iv:def=,tag:ghi=,type:str]
+    version: 3.10.2
+`
+	if err := os.WriteFile(filepath.Join(base, "secrets.yaml"), []byte(encrypted), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(base, "values.yaml"), []byte(valuesTwoYaml), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &v1alpha1.HelmOptions{}
+	opts.ValuesFiles = []string{"values.yaml", "secrets.yaml"}
+	if err := parseValuesFiles(base, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(opts.ValuesFiles) != 1 || opts.ValuesFiles[0] != "values.yaml" {
+		t.Errorf("expected only the plain values file in ValuesFiles, got %v", opts.ValuesFiles)
+	}
+	if len(opts.SopsValuesFiles) != 1 || opts.SopsValuesFiles[0] != "secrets.yaml" {
+		t.Errorf("expected the encrypted values file in SopsValuesFiles, got %v", opts.SopsValuesFiles)
+	}
+	if _, ok := opts.Values.Data["password"]; ok {
+		t.Error("encrypted values must not be merged into the bundle values")
+	}
+	if _, ok := opts.Values.Data["microService1"]; !ok {
+		t.Error("expected plain values to be merged")
+	}
+	for _, f := range ignoreApplyConfigs("fleet.yaml", opts) {
+		if f == "secrets.yaml" {
+			t.Error("encrypted values file must be kept in the bundle resources")
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package clustertemplate
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"text/template/parse"
+
+	"github.com/rancher/fleet/internal/content"
+	"github.com/rancher/fleet/internal/fleetyaml"
+	"github.com/rancher/fleet/internal/manifest"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+// RenderManifest renders the raw YAML and kustomize resources of the manifest with the template context from the
+// resource templating options. This includes inputs of kustomize generators, like env files. Helm charts, fleet.yaml
+// files and the "templates/" directory are left untouched, as they are rendered by helm.
+// The manifest is returned unchanged if resource templating is disabled.
+func RenderManifest(m *manifest.Manifest, opts fleet.BundleDeploymentOptions) (*manifest.Manifest, error) {
+	if opts.ResourceTemplating == nil || !opts.ResourceTemplating.Enabled {
+		return m, nil
+	}
+
+	templateContext := map[string]interface{}{}
+	if opts.ResourceTemplating.Context != nil && opts.ResourceTemplating.Context.Data != nil {
+		templateContext = opts.ResourceTemplating.Context.Data
+	}
+
+	dirs := chartDirs(m.Resources)
+	var (
+		modified  bool
+		resources = make([]fleet.BundleResource, 0, len(m.Resources))
+	)
+	for _, resource := range m.Resources {
+		if skip(resource.Name, dirs) {
+			resources = append(resources, resource)
+			continue
+		}
+
+		data, err := content.Decode(resource.Content, resource.Encoding)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.Contains(string(data), "${") {
+			resources = append(resources, resource)
+			continue
+		}
+
+		rendered, err := Render(resource.Name, string(data), templateContext)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render resource %s: %w", resource.Name, err)
+		}
+		modified = true
+		resources = append(resources, fleet.BundleResource{
+			Name:    resource.Name,
+			Content: string(rendered),
+		})
+	}
+
+	if !modified {
+		return m, nil
+	}
+
+	result := manifest.New(resources)
+	result.Commit = m.Commit
+
+	return result, nil
+}
+
+// ResourceContext returns the keys of the template context, which are referenced by the resources rendered by
+// RenderManifest. Cluster facts referenced by name, e.g. "${ .ClusterFacts.kubernetesVersion }", are included on
+// their own, as facts like the node count change often and the context is part of the deployment ID. All facts are
+// included if the facts are used in any other way, e.g. by "with", "range", "index" or a variable.
+// If resources is nil, e.g. because they are stored in an OCI registry, all keys but the facts are returned.
+func ResourceContext(resources []fleet.BundleResource, templateContext map[string]interface{}) map[string]interface{} {
+	refs := &references{
+		all:   resources == nil,
+		keys:  map[string]bool{},
+		facts: map[string]bool{},
+	}
+
+	dirs := chartDirs(resources)
+	for _, resource := range resources {
+		if skip(resource.Name, dirs) {
+			continue
+		}
+		// resources which cannot be parsed are not rendered by the agent either, it reports the error
+		data, err := content.Decode(resource.Content, resource.Encoding)
+		if err != nil || !strings.Contains(string(data), "${") {
+			continue
+		}
+		tmpl, err := New(resource.Name).Parse(string(data))
+		if err != nil {
+			continue
+		}
+		for _, t := range tmpl.Templates() {
+			refs.walk(t.Root, true)
+		}
+	}
+
+	result := map[string]interface{}{}
+	for k, v := range templateContext {
+		if k == factsKey {
+			continue
+		}
+		if refs.all || refs.keys[k] {
+			result[k] = v
+		}
+	}
+	if facts, ok := templateContext[factsKey].(map[string]interface{}); ok && (refs.all || refs.keys[factsKey]) {
+		referenced := map[string]interface{}{}
+		for k, v := range facts {
+			if refs.allFacts || refs.facts[k] {
+				referenced[k] = v
+			}
+		}
+		result[factsKey] = referenced
+	}
+	return result
+}
+
+const factsKey = "ClusterFacts"
+
+// references are the keys of the template context, which are referenced by templates.
+type references struct {
+	// all is true if the whole context is referenced, e.g. by "${ toJson . }"
+	all bool
+	// allFacts is true if the facts are not only referenced by name, e.g. by "${ index .ClusterFacts "k" }"
+	allFacts bool
+	keys     map[string]bool
+	facts    map[string]bool
+}
+
+// walk collects the references of the node. Fields are only relative to the template context at the root, inside
+// of "range" and "with" only references via "$" are collected.
+func (r *references) walk(node parse.Node, root bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			r.walk(child, root)
+		}
+	case *parse.ActionNode:
+		r.walk(n.Pipe, root)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			r.walk(cmd, root)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			r.walk(arg, root)
+		}
+	case *parse.ChainNode:
+		r.walk(n.Node, root)
+	case *parse.DotNode:
+		if root {
+			r.all = true
+			r.allFacts = true
+		}
+	case *parse.FieldNode:
+		if root {
+			r.field(n.Ident)
+		}
+	case *parse.VariableNode:
+		if n.Ident[0] == "$" {
+			r.field(n.Ident[1:])
+		}
+	case *parse.IfNode:
+		r.walk(n.Pipe, root)
+		r.walk(n.List, root)
+		r.walk(n.ElseList, root)
+	case *parse.RangeNode:
+		r.walk(n.Pipe, root)
+		r.walk(n.List, false)
+		r.walk(n.ElseList, root)
+	case *parse.WithNode:
+		r.walk(n.Pipe, root)
+		r.walk(n.List, false)
+		r.walk(n.ElseList, root)
+	case *parse.TemplateNode:
+		r.walk(n.Pipe, root)
+	}
+}
+
+// field records a reference to a field of the template context.
+func (r *references) field(ident []string) {
+	if len(ident) == 0 {
+		r.all = true
+		r.allFacts = true
+		return
+	}
+	r.keys[ident[0]] = true
+	if ident[0] != factsKey {
+		return
+	}
+	if len(ident) > 1 {
+		r.facts[ident[1]] = true
+	} else {
+		// the facts are passed on, e.g. to "with", "index" or a variable
+		r.allFacts = true
+	}
+}
+
+// chartDirs returns the directories of the helm charts in the resources.
+func chartDirs(resources []fleet.BundleResource) []string {
+	var dirs []string
+	for _, resource := range resources {
+		if path.Base(resource.Name) == "Chart.yaml" {
+			dirs = append(dirs, path.Dir(resource.Name))
+		}
+	}
+	return dirs
+}
+
+// skip returns true for resources, which are not rendered.
+func skip(name string, chartDirs []string) bool {
+	if name == "" || fleetyaml.IsFleetYaml(path.Base(name)) || strings.HasPrefix(name, "templates/") {
+		return true
+	}
+	for _, dir := range chartDirs {
+		if dir == "." || strings.HasPrefix(name, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
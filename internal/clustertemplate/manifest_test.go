@@ -0,0 +1,265 @@
+package clustertemplate
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/rancher/fleet/internal/manifest"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+const configMapYAML = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ${ .ClusterName }
+data:
+  region: ${ get .ClusterLabels "region" | default "none" }
+  replicas: "${ .ClusterValues.replicas }"
+`
+
+func options(cluster *fleet.Cluster) fleet.BundleDeploymentOptions {
+	return fleet.BundleDeploymentOptions{
+		ResourceTemplating: &fleet.ResourceTemplating{
+			Enabled: true,
+			Context: &fleet.GenericMap{Data: Context(cluster, nil)},
+		},
+	}
+}
+
+func TestRenderManifest(t *testing.T) {
+	cluster := &fleet.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "prod",
+			Namespace: "fleet-default",
+			Labels:    map[string]string{"region": "eu-west"},
+		},
+		Spec: fleet.ClusterSpec{
+			TemplateValues: &fleet.GenericMap{Data: map[string]interface{}{"replicas": 3}},
+		},
+	}
+	helmTemplate := `name: ${ .Release.Name }`
+	m := manifest.New([]fleet.BundleResource{
+		{Name: "fleet.yaml", Content: "helm:\n  releaseName: ${ .ClusterName }\n"},
+		{Name: "configmap.yaml", Content: configMapYAML},
+		{Name: "app.env", Content: "CLUSTER=${ .ClusterName | upper }\n"},
+		{Name: "plain.yaml", Content: "kind: Namespace\n"},
+		{Name: "templates/cm.yaml", Content: helmTemplate},
+		{Name: "chart/Chart.yaml", Content: "name: chart\n"},
+		{Name: "chart/templates/cm.yaml", Content: helmTemplate},
+	})
+
+	result, err := RenderManifest(m, options(cluster))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []fleet.BundleResource{
+		m.Resources[0],
+		{Name: "configmap.yaml", Content: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: prod
+data:
+  region: eu-west
+  replicas: "3"
+`},
+		{Name: "app.env", Content: "CLUSTER=PROD\n"},
+		m.Resources[3],
+		m.Resources[4],
+		m.Resources[5],
+		m.Resources[6],
+	}
+	if diff := cmp.Diff(expected, result.Resources); diff != "" {
+		t.Errorf("unexpected resources (-want +got):\n%s", diff)
+	}
+}
+
+func TestRenderManifestDisabled(t *testing.T) {
+	m := manifest.New([]fleet.BundleResource{{Name: "configmap.yaml", Content: configMapYAML}})
+
+	for _, opts := range []fleet.BundleDeploymentOptions{
+		{},
+		{ResourceTemplating: &fleet.ResourceTemplating{Context: &fleet.GenericMap{Data: map[string]interface{}{}}}},
+	} {
+		result, err := RenderManifest(m, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != m {
+			t.Errorf("expected manifest to be unchanged, got %v", result.Resources)
+		}
+	}
+}
+
+func TestRenderManifestErrors(t *testing.T) {
+	cluster := &fleet.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "prod"}}
+
+	for name, content := range map[string]string{
+		"missing key":       "replicas: ${ .ClusterValues.replicas }",
+		"invalid template":  "name: ${ .ClusterName",
+		"removed functions": `home: ${ env "HOME" }`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			m := manifest.New([]fleet.BundleResource{{Name: "deployment.yaml", Content: content}})
+			_, err := RenderManifest(m, options(cluster))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestResourceContext(t *testing.T) {
+	cluster := &fleet.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "prod",
+			Namespace: "fleet-default",
+			Labels:    map[string]string{"region": "eu-west"},
+		},
+		Status: fleet.ClusterStatus{
+			Facts: &fleet.ClusterFacts{KubernetesVersion: "v1.31.2", NodeCount: 3},
+		},
+	}
+	templateContext := Context(cluster, nil)
+
+	tests := []struct {
+		name      string
+		resources []fleet.BundleResource
+		expected  map[string]interface{}
+	}{
+		{
+			name:      "referenced keys",
+			resources: []fleet.BundleResource{{Name: "cm.yaml", Content: configMapYAML}},
+			expected: map[string]interface{}{
+				"ClusterName":   "prod",
+				"ClusterLabels": map[string]interface{}{"region": "eu-west"},
+				"ClusterValues": map[string]interface{}{},
+			},
+		},
+		{
+			name: "facts referenced by name",
+			resources: []fleet.BundleResource{{
+				Name:    "cm.yaml",
+				Content: "version: ${ .ClusterFacts.kubernetesVersion }\n${ with .ClusterLabels }${ $.ClusterFacts.kubernetesVersion }${ end }",
+			}},
+			expected: map[string]interface{}{
+				"ClusterLabels": map[string]interface{}{"region": "eu-west"},
+				"ClusterFacts":  map[string]interface{}{"kubernetesVersion": "v1.31.2"},
+			},
+		},
+		{
+			name: "facts used by with",
+			resources: []fleet.BundleResource{{
+				Name:    "cm.yaml",
+				Content: `${ with .ClusterFacts }${ .kubernetesVersion }${ end }`,
+			}},
+			expected: map[string]interface{}{
+				"ClusterFacts": templateContext["ClusterFacts"],
+			},
+		},
+		{
+			name: "facts used by range",
+			resources: []fleet.BundleResource{{
+				Name:    "cm.yaml",
+				Content: `${ range $k, $v := .ClusterFacts }${ $k }: ${ $v }${ end }`,
+			}},
+			expected: map[string]interface{}{
+				"ClusterFacts": templateContext["ClusterFacts"],
+			},
+		},
+		{
+			name: "facts used by index",
+			resources: []fleet.BundleResource{{
+				Name:    "cm.yaml",
+				Content: `${ index .ClusterFacts "kubernetesVersion" }`,
+			}},
+			expected: map[string]interface{}{
+				"ClusterFacts": templateContext["ClusterFacts"],
+			},
+		},
+		{
+			name: "facts used by a variable",
+			resources: []fleet.BundleResource{{
+				Name:    "cm.yaml",
+				Content: `${ $f := .ClusterFacts }${ $f.kubernetesVersion }`,
+			}},
+			expected: map[string]interface{}{
+				"ClusterFacts": templateContext["ClusterFacts"],
+			},
+		},
+		{
+			name: "facts used by a root variable",
+			resources: []fleet.BundleResource{{
+				Name:    "cm.yaml",
+				Content: `${ with .ClusterLabels }${ toJson $.ClusterFacts }${ end }`,
+			}},
+			expected: map[string]interface{}{
+				"ClusterLabels": map[string]interface{}{"region": "eu-west"},
+				"ClusterFacts":  templateContext["ClusterFacts"],
+			},
+		},
+		{
+			name: "bare facts",
+			resources: []fleet.BundleResource{{
+				Name:    "cm.yaml",
+				Content: `${ toJson .ClusterFacts }`,
+			}},
+			expected: map[string]interface{}{
+				"ClusterFacts": templateContext["ClusterFacts"],
+			},
+		},
+		{
+			name: "root references inside of with",
+			resources: []fleet.BundleResource{{
+				Name:    "cm.yaml",
+				Content: "${ with .ClusterLabels }${ .region }-${ $.ClusterName }${ end }",
+			}},
+			expected: map[string]interface{}{
+				"ClusterName":   "prod",
+				"ClusterLabels": map[string]interface{}{"region": "eu-west"},
+			},
+		},
+		{
+			name: "whole context",
+			resources: []fleet.BundleResource{{
+				Name:    "cm.yaml",
+				Content: "${ toJson . }",
+			}},
+			expected: templateContext,
+		},
+		{
+			name:      "unknown resources",
+			resources: nil,
+			expected:  withoutFacts(templateContext),
+		},
+		{
+			name: "helm charts and fleet.yaml are not rendered",
+			resources: []fleet.BundleResource{
+				{Name: "chart/Chart.yaml", Content: "name: chart"},
+				{Name: "chart/values.yaml", Content: "name: ${ .ClusterName }"},
+				{Name: "templates/cm.yaml", Content: "name: ${ .ClusterName }"},
+				{Name: "fleet.yaml", Content: "name: ${ .ClusterName }"},
+			},
+			expected: map[string]interface{}{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := ResourceContext(test.resources, templateContext)
+			if diff := cmp.Diff(test.expected, got); diff != "" {
+				t.Errorf("unexpected context (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func withoutFacts(templateContext map[string]interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	for k, v := range templateContext {
+		result[k] = v
+	}
+	result["ClusterFacts"] = map[string]interface{}{}
+	return result
+}
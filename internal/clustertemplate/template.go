@@ -0,0 +1,136 @@
+// Package clustertemplate renders templates with the values of a targeted cluster. It is used for helm values
+// templating by the controller and for resource templating by the agent.
+package clustertemplate
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	"github.com/rancher/wrangler/v3/pkg/yaml"
+)
+
+// FuncMap returns a mapping of all of the functions from sprig but removes potentially dangerous operations
+func FuncMap() template.FuncMap {
+	f := sprig.TxtFuncMap()
+	delete(f, "env")
+	delete(f, "expandenv")
+	delete(f, "include")
+	delete(f, "tpl")
+
+	return f
+}
+
+// New returns an empty template, which fails on missing keys.
+//
+// fleet.yaml must be valid yaml, however '{}[]' are YAML control characters and will be interpreted as JSON data
+// structures. This causes issues when parsing the fleet.yaml so the delims for templating are '${ }'.
+func New(name string) *template.Template {
+	return template.New(name).Funcs(FuncMap()).Option("missingkey=error").Delims("${", "}")
+}
+
+// Render parses text as a template and executes it with the template context.
+func Render(name, text string, templateContext map[string]interface{}) ([]byte, error) {
+	tmpl, err := New(name).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, templateContext); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Context returns the template context of the cluster, which contains the keys "ClusterNamespace", "ClusterName",
+// "ClusterLabels", "ClusterAnnotations", "ClusterValues", "ClusterGroupValues" and "ClusterFacts".
+// The cluster groups must be ordered from the root of the hierarchy to the leaves.
+func Context(cluster *fleet.Cluster, groups []*fleet.ClusterGroup) map[string]interface{} {
+	return map[string]interface{}{
+		"ClusterNamespace":   cluster.Namespace,
+		"ClusterName":        cluster.Name,
+		"ClusterLabels":      toDict(Labels(cluster)),
+		"ClusterAnnotations": toDict(yaml.CleanAnnotationsForExport(cluster.Annotations)),
+		"ClusterValues":      values(cluster),
+		"ClusterGroupValues": GroupValues(cluster, groups),
+		"ClusterFacts":       toFactsDict(cluster.Status.Facts),
+	}
+}
+
+// GroupValues merges the template values of the cluster groups, in order, and of the cluster. Later values take
+// precedence, nested maps are merged.
+func GroupValues(cluster *fleet.Cluster, groups []*fleet.ClusterGroup) map[string]interface{} {
+	result := map[string]interface{}{}
+	for _, cg := range groups {
+		if cg.Spec.TemplateValues != nil {
+			mergeValues(result, cg.Spec.TemplateValues.Data)
+		}
+	}
+	mergeValues(result, values(cluster))
+	return result
+}
+
+// mergeValues merges src into dest. Nested maps are copied, so src is never modified by later merges.
+func mergeValues(dest, src map[string]interface{}) {
+	for k, v := range src {
+		srcMap, ok := v.(map[string]interface{})
+		if !ok {
+			dest[k] = v
+			continue
+		}
+		destMap, ok := dest[k].(map[string]interface{})
+		if !ok {
+			destMap = map[string]interface{}{}
+			dest[k] = destMap
+		}
+		mergeValues(destMap, srcMap)
+	}
+}
+
+// Labels returns the labels of the cluster, which are available to templates. Fleet and Rancher labels are kept,
+// other internal labels are removed.
+func Labels(cluster *fleet.Cluster) map[string]string {
+	labels := yaml.CleanAnnotationsForExport(cluster.Labels)
+	for k, v := range cluster.Labels {
+		if strings.HasPrefix(k, "fleet.cattle.io/") || strings.HasPrefix(k, "management.cattle.io/") {
+			labels[k] = v
+		}
+	}
+	return labels
+}
+
+func values(cluster *fleet.Cluster) map[string]interface{} {
+	if cluster.Spec.TemplateValues == nil || cluster.Spec.TemplateValues.Data == nil {
+		return map[string]interface{}{}
+	}
+	return cluster.Spec.TemplateValues.Data
+}
+
+// sprig dictionary functions like "default" and "hasKey" expect map[string]interface{}
+func toDict(values map[string]string) map[string]interface{} {
+	dict := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		dict[k] = v
+	}
+	return dict
+}
+
+// toFactsDict converts the facts to a dictionary with the JSON field names as keys, like ClusterValues.
+func toFactsDict(facts *fleet.ClusterFacts) map[string]interface{} {
+	dict := map[string]interface{}{}
+	if facts == nil {
+		return dict
+	}
+	data, err := json.Marshal(facts)
+	if err != nil {
+		return dict
+	}
+	_ = json.Unmarshal(data, &dict)
+	return dict
+}
@@ -0,0 +1,43 @@
+package clustertemplate
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+func TestGroupValues(t *testing.T) {
+	groupValues := func(name string, values map[string]interface{}) *fleet.ClusterGroup {
+		return &fleet.ClusterGroup{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       fleet.ClusterGroupSpec{TemplateValues: &fleet.GenericMap{Data: values}},
+		}
+	}
+	region := groupValues("eu", map[string]interface{}{
+		"region":  "eu-west",
+		"ingress": map[string]interface{}{"class": "nginx", "replicas": 2},
+	})
+	env := groupValues("eu-prod", map[string]interface{}{
+		"env":     "prod",
+		"ingress": map[string]interface{}{"replicas": 3},
+	})
+	cluster := &fleet.Cluster{Spec: fleet.ClusterSpec{
+		TemplateValues: &fleet.GenericMap{Data: map[string]interface{}{"env": "prod-eu-1"}},
+	}}
+
+	values := GroupValues(cluster, []*fleet.ClusterGroup{region, {}, env})
+	expected := map[string]interface{}{
+		"region":  "eu-west",
+		"env":     "prod-eu-1",
+		"ingress": map[string]interface{}{"class": "nginx", "replicas": 3},
+	}
+	if diff := cmp.Diff(expected, values); diff != "" {
+		t.Errorf("unexpected values (-want +got):\n%s", diff)
+	}
+	if replicas := region.Spec.TemplateValues.Data["ingress"].(map[string]interface{})["replicas"]; replicas != 2 {
+		t.Errorf("expected group values to be unchanged, got replicas %v", replicas)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -16,6 +17,7 @@ import (
 
 type ClusterStatusRunnable struct {
 	config          *rest.Config
+	localConfig     *rest.Config
 	namespace       string
 	checkinInterval string
 	agentInfo       *register.AgentInfo
@@ -42,10 +44,17 @@ func (cs *ClusterStatusRunnable) Start(ctx context.Context) error {
 		return err
 	}
 
+	// the local client discovers the cluster facts
+	local, err := kubernetes.NewForConfig(cs.localConfig)
+	if err != nil {
+		return err
+	}
+
 	go func() {
 		clusterstatus.Ticker(
 			ctx,
 			client,
+			local,
 			cs.namespace,
 			cs.agentInfo.ClusterNamespace,
 			cs.agentInfo.ClusterName,
@@ -0,0 +1,77 @@
+package clusterstatus
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// defaultStorageClassAnnotation marks the default storage class of a cluster.
+const defaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+
+// DiscoverFacts returns the facts of the local cluster, which targets can select.
+func DiscoverFacts(ctx context.Context, local kubernetes.Interface) (*fleet.ClusterFacts, error) {
+	facts := &fleet.ClusterFacts{}
+
+	version, err := local.Discovery().ServerVersion()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server version: %w", err)
+	}
+	facts.KubernetesVersion = version.GitVersion
+	facts.Platform = version.Platform
+
+	groups, err := local.Discovery().ServerGroups()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API groups: %w", err)
+	}
+	for _, group := range groups.Groups {
+		if group.Name != "" {
+			facts.APIGroups = append(facts.APIGroups, group.Name)
+		}
+	}
+
+	nodes, err := local.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	facts.NodeCount = len(nodes.Items)
+	for _, node := range nodes.Items {
+		facts.OperatingSystems = append(facts.OperatingSystems, node.Status.NodeInfo.OperatingSystem)
+		facts.Architectures = append(facts.Architectures, node.Status.NodeInfo.Architecture)
+		if prefix, _, ok := strings.Cut(node.Spec.ProviderID, "://"); ok {
+			facts.ProviderIDPrefixes = append(facts.ProviderIDPrefixes, prefix)
+		}
+	}
+
+	storageClasses, err := local.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list storage classes: %w", err)
+	}
+	for _, sc := range storageClasses.Items {
+		facts.StorageClasses = append(facts.StorageClasses, sc.Name)
+		if sc.Annotations[defaultStorageClassAnnotation] == "true" {
+			facts.DefaultStorageClass = sc.Name
+		}
+	}
+
+	facts.OperatingSystems = sortedUnique(facts.OperatingSystems)
+	facts.Architectures = sortedUnique(facts.Architectures)
+	facts.ProviderIDPrefixes = sortedUnique(facts.ProviderIDPrefixes)
+	facts.APIGroups = sortedUnique(facts.APIGroups)
+	facts.StorageClasses = sortedUnique(facts.StorageClasses)
+
+	return facts, nil
+}
+
+// sortedUnique sorts the values and removes duplicates and empty values, so facts only change if the cluster does.
+func sortedUnique(values []string) []string {
+	values = slices.DeleteFunc(values, func(v string) bool { return v == "" })
+	slices.Sort(values)
+	return slices.Compact(values)
+}
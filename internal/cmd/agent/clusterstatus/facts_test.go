@@ -0,0 +1,63 @@
+package clusterstatus
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func node(name, arch, providerID string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       corev1.NodeSpec{ProviderID: providerID},
+		Status: corev1.NodeStatus{
+			NodeInfo: corev1.NodeSystemInfo{OperatingSystem: "linux", Architecture: arch},
+		},
+	}
+}
+
+var _ = Describe("DiscoverFacts", func() {
+	It("reports the facts of the cluster", func() {
+		local := fake.NewClientset(
+			node("node-1", "amd64", "aws:///eu-west-1a/i-1"),
+			node("node-2", "arm64", "aws:///eu-west-1b/i-2"),
+			node("node-3", "arm64", ""),
+			&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "gp2"}},
+			&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{
+				Name:        "gp3",
+				Annotations: map[string]string{defaultStorageClassAnnotation: "true"},
+			}},
+		)
+		discovery := local.Discovery().(*fakediscovery.FakeDiscovery)
+		discovery.FakedServerVersion = &version.Info{GitVersion: "v1.31.2+k3s1", Platform: "linux/amd64"}
+		discovery.Resources = []*metav1.APIResourceList{
+			{GroupVersion: "v1"},
+			{GroupVersion: "apps/v1"},
+			{GroupVersion: "cert-manager.io/v1"},
+		}
+
+		facts, err := DiscoverFacts(context.Background(), local)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(facts).To(Equal(&fleet.ClusterFacts{
+			KubernetesVersion:   "v1.31.2+k3s1",
+			Platform:            "linux/amd64",
+			NodeCount:           3,
+			OperatingSystems:    []string{"linux"},
+			Architectures:       []string{"amd64", "arm64"},
+			ProviderIDPrefixes:  []string{"aws"},
+			APIGroups:           []string{"apps", "cert-manager.io"},
+			StorageClasses:      []string{"gp2", "gp3"},
+			DefaultStorageClass: "gp3",
+		}))
+	})
+})
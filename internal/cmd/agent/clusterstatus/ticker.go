@@ -3,6 +3,7 @@ package clusterstatus
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
@@ -13,6 +14,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -22,10 +24,14 @@ type handler struct {
 	clusterName      string
 	clusterNamespace string
 	client           client.Client
+	local            kubernetes.Interface
 	reported         fleet.AgentStatus
+	reportedFacts    *fleet.ClusterFacts
 }
 
-func Ticker(ctx context.Context, client client.Client, agentNamespace string, clusterNamespace string, clusterName string, checkinInterval time.Duration) {
+// Ticker periodically reports the agent status to the upstream cluster. If local is not nil, it also reports the
+// facts of the downstream cluster whenever they change.
+func Ticker(ctx context.Context, client client.Client, local kubernetes.Interface, agentNamespace string, clusterNamespace string, clusterName string, checkinInterval time.Duration) {
 	logger := log.FromContext(ctx).WithName("clusterstatus").WithValues("cluster", clusterName, "interval", checkinInterval)
 
 	h := handler{
@@ -33,6 +39,7 @@ func Ticker(ctx context.Context, client client.Client, agentNamespace string, cl
 		clusterName:      clusterName,
 		clusterNamespace: clusterNamespace,
 		client:           client,
+		local:            local,
 	}
 
 	go func() {
@@ -62,7 +69,18 @@ func (h *handler) Update(ctx context.Context) error {
 		Namespace: h.agentNamespace,
 	}
 
-	if equality.Semantic.DeepEqual(h.reported, agentStatus) {
+	var facts *fleet.ClusterFacts
+	if h.local != nil {
+		var err error
+		facts, err = DiscoverFacts(ctx, h.local)
+		if err != nil {
+			// facts are optional, the agent still checks in
+			log.FromContext(ctx).Error(err, "failed to discover cluster facts")
+		}
+	}
+	factsChanged := facts != nil && !equality.Semantic.DeepEqual(h.reportedFacts, facts)
+
+	if equality.Semantic.DeepEqual(h.reported, agentStatus) && !factsChanged {
 		return nil
 	}
 
@@ -78,7 +96,15 @@ func (h *handler) Update(ctx context.Context) error {
 	patch := `[{"op":"add","path":"/status/agent","value":{"lastSeen":"` +
 		agentStatus.LastSeen.Format(time.RFC3339) +
 		`","namespace":"` + agentStatus.Namespace +
-		`"}}]`
+		`"}}`
+	if factsChanged {
+		data, err := json.Marshal(facts)
+		if err != nil {
+			return err
+		}
+		patch += `,{"op":"add","path":"/status/facts","value":` + string(data) + `}`
+	}
+	patch += `]`
 
 	err := h.client.Status().Patch(ctx, cluster, client.RawPatch(types.JSONPatchType, []byte(patch)))
 	if err != nil {
@@ -86,5 +112,8 @@ func (h *handler) Update(ctx context.Context) error {
 	}
 
 	h.reported = agentStatus
+	if factsChanged {
+		h.reportedFacts = facts
+	}
 	return nil
 }
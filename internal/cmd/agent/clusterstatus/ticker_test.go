@@ -95,7 +95,7 @@ var _ = Describe("ClusterStatus Ticker", func() {
 	})
 
 	It("should patch the cluster status after checkinInterval", func() {
-		Ticker(ctx, clt, agentNamespace, clusterNamespace, clusterName, checkinInterval)
+		Ticker(ctx, clt, nil, agentNamespace, clusterNamespace, clusterName, checkinInterval)
 		<-ctx.Done()
 	})
 })
@@ -12,6 +12,7 @@ import (
 	"github.com/rancher/fleet/internal/cmd/agent/deployer/driftdetect"
 	"github.com/rancher/fleet/internal/cmd/agent/deployer/monitor"
 	"github.com/rancher/fleet/internal/experimental"
+	"github.com/rancher/fleet/internal/helmdeployer/sops"
 	"github.com/rancher/fleet/internal/helmvalues"
 	"github.com/rancher/fleet/internal/namespaces"
 	fleetv1 "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
@@ -69,35 +70,40 @@ var DefaultRetry = wait.Backoff{
 func (r *BundleDeploymentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&fleetv1.BundleDeployment{}).
-		WithEventFilter(
-			// we do not trigger for status changes
-			predicate.Or(
-				// Note: These predicates prevent cache
-				// syncPeriod from triggering reconcile, since
-				// cache sync is an Update event.
-				predicate.GenerationChangedPredicate{},
-				predicate.AnnotationChangedPredicate{},
-				predicate.LabelChangedPredicate{},
-				predicate.Funcs{
-					// except for changes to status.Refresh
-					UpdateFunc: func(e event.UpdateEvent) bool {
-						n := e.ObjectNew.(*fleetv1.BundleDeployment)
-						o := e.ObjectOld.(*fleetv1.BundleDeployment)
-						if n == nil || o == nil {
-							return false
-						}
-						return n.Status.SyncGeneration != o.Status.SyncGeneration ||
-							(o.Spec.DownstreamResourcesGeneration != n.Spec.DownstreamResourcesGeneration)
-					},
-					DeleteFunc: func(e event.DeleteEvent) bool {
-						return true
-					},
-				},
-			)).
+		WithEventFilter(BundleDeploymentChangedPredicate()).
 		WithOptions(controller.Options{MaxConcurrentReconciles: r.Workers}).
 		Complete(r)
 }
 
+// BundleDeploymentChangedPredicate filters the bundledeployment events which
+// require a deployment.
+func BundleDeploymentChangedPredicate() predicate.Predicate {
+	// we do not trigger for status changes
+	return predicate.Or(
+		// Note: These predicates prevent cache
+		// syncPeriod from triggering reconcile, since
+		// cache sync is an Update event.
+		predicate.GenerationChangedPredicate{},
+		predicate.AnnotationChangedPredicate{},
+		predicate.LabelChangedPredicate{},
+		predicate.Funcs{
+			// except for changes to status.Refresh
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				n := e.ObjectNew.(*fleetv1.BundleDeployment)
+				o := e.ObjectOld.(*fleetv1.BundleDeployment)
+				if n == nil || o == nil {
+					return false
+				}
+				return n.Status.SyncGeneration != o.Status.SyncGeneration ||
+					(o.Spec.DownstreamResourcesGeneration != n.Spec.DownstreamResourcesGeneration)
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return true
+			},
+		},
+	)
+}
+
 //+kubebuilder:rbac:groups=fleet.cattle.io,resources=bundledeployments,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=fleet.cattle.io,resources=bundledeployments/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=fleet.cattle.io,resources=bundledeployments/finalizers,verbs=update
@@ -167,6 +173,11 @@ func (r *BundleDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		return ctrl.Result{}, err
 	}
 
+	// upstream secrets referenced in valuesFrom changed, upgrade the release to pick them up
+	if bd.Spec.ValuesFromGeneration != bd.Status.ValuesFromGeneration {
+		forceDeploy = true
+	}
+
 	var merr []error
 
 	// helm deploy the bundledeployment
@@ -190,6 +201,7 @@ func (r *BundleDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	} else {
 		logger.V(1).Info("Bundle deployed", "status", status)
 		bd.Status = setCondition(status, nil, monitor.Cond(fleetv1.BundleDeploymentConditionDeployed))
+		bd.Status.ValuesFromGeneration = bd.Spec.ValuesFromGeneration
 	}
 
 	// retrieve the resources from the helm history.
@@ -453,10 +465,19 @@ func (r *BundleDeploymentReconciler) updateStatus(ctx context.Context, orig *fle
 
 // setCondition sets the condition and updates the timestamp, if the condition changed
 func setCondition(newStatus fleetv1.BundleDeploymentStatus, err error, cond monitor.Cond) fleetv1.BundleDeploymentStatus {
-	cond.SetError(&newStatus, "", ignoreConflict(err))
+	cond.SetError(&newStatus, conditionReason(err), ignoreConflict(err))
 	return newStatus
 }
 
+// conditionReason returns the reason for a condition with err, so users can
+// tell errors they need to fix apart. An empty reason defaults to "Error".
+func conditionReason(err error) string {
+	if errors.Is(err, sops.ErrMissingKey) {
+		return fleetv1.BundleDeploymentReasonSopsKeyMissing
+	}
+	return ""
+}
+
 func ignoreConflict(err error) error {
 	if apierrors.IsConflict(err) {
 		return nil
@@ -40,13 +40,13 @@ type DriftReconciler struct {
 	Workers int
 }
 
-// enqueueDelay is used as an artificial delay for enqueuing BundleDeployment reconciliation requests
+// EnqueueDelay is used as an artificial delay for enqueuing BundleDeployment reconciliation requests
 // This allows aggregating multiple consecutive events on deployed resources, reducing the number of BundleDeployment (and Bundle) reconciliations at the cost of introducing a delay in the notification
-const enqueueDelay = 5 * time.Second
+const EnqueueDelay = 5 * time.Second
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *DriftReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	src := source.Channel(r.DriftChan, enqueueRequestHandlerWithDelay(enqueueDelay))
+	src := source.Channel(r.DriftChan, EnqueueRequestHandlerWithDelay(EnqueueDelay))
 	return ctrl.NewControllerManagedBy(mgr).
 		Named("drift-reconciler").
 		WatchesRawSource(src).
@@ -156,10 +156,10 @@ func (r *DriftReconciler) updateStatus(ctx context.Context, logger logr.Logger,
 	return nil
 }
 
-// enqueueRequestHandlerWithDelay implements a TypedEventHandler that introduces a constant delay in the resources being enqueued
+// EnqueueRequestHandlerWithDelay implements a TypedEventHandler that introduces a constant delay in the resources being enqueued
 // Due to how workqueue.TypedDelayingInterface's AddAfter is implemented, successive calls with the same key are aggregated.
 // Only implemented for Generic events, as this is only meant to be used from with source.Channel to receive internal events, not from an informer
-func enqueueRequestHandlerWithDelay(delay time.Duration) handler.TypedEventHandler[*fleetv1.BundleDeployment, reconcile.Request] {
+func EnqueueRequestHandlerWithDelay(delay time.Duration) handler.TypedEventHandler[*fleetv1.BundleDeployment, reconcile.Request] {
 	return &handler.TypedFuncs[*fleetv1.BundleDeployment, reconcile.Request]{
 		GenericFunc: func(ctx context.Context, e event.TypedGenericEvent[*fleetv1.BundleDeployment], w workqueue.TypedRateLimitingInterface[reconcile.Request]) {
 			if e.Object == nil {
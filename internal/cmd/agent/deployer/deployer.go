@@ -11,6 +11,7 @@ import (
 	"github.com/rancher/fleet/internal/bundlereader"
 	"github.com/rancher/fleet/internal/cmd/controller/summary"
 	"github.com/rancher/fleet/internal/helmdeployer"
+	"github.com/rancher/fleet/internal/helmdeployer/imagerewrite"
 	"github.com/rancher/fleet/internal/manifest"
 	"github.com/rancher/fleet/internal/ocistorage"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
@@ -104,6 +105,12 @@ func (d *Deployer) DeployBundle(
 	status.Release = releaseID
 	status.AppliedDeploymentID = bd.Spec.DeploymentID
 
+	images, err := d.rewrittenImages(bd, releaseID)
+	if err != nil {
+		return status, err
+	}
+	status.RewrittenImages = images
+
 	if err := d.setNamespaceLabelsAndAnnotations(ctx, bd, releaseID); err != nil {
 		return fleet.BundleDeploymentStatus{}, err
 	}
@@ -177,6 +184,21 @@ func (d *Deployer) helmdeploy(ctx context.Context, logger logr.Logger, bd *fleet
 	return resourceID, nil
 }
 
+// rewrittenImages returns the image references rewritten in the release,
+// according to the image rewrite options of the bundle deployment.
+func (d *Deployer) rewrittenImages(bd *fleet.BundleDeployment, releaseID string) ([]fleet.RewrittenImage, error) {
+	if bd.Spec.Options.ImageRewrite == nil || len(bd.Spec.Options.ImageRewrite.Rules) == 0 {
+		return nil, nil
+	}
+
+	resources, err := d.helm.Resources(bd.Name, releaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	return imagerewrite.FromObjects(resources.Objects), nil
+}
+
 // setNamespaceLabelsAndAnnotations updates the namespace for the release, applying all labels and annotations to that namespace as configured in the bundle spec.
 func (d *Deployer) setNamespaceLabelsAndAnnotations(ctx context.Context, bd *fleet.BundleDeployment, releaseID string) error {
 	if bd.Spec.Options.NamespaceLabels == nil && bd.Spec.Options.NamespaceAnnotations == nil {
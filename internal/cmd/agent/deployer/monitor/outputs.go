@@ -0,0 +1,91 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+// evaluateOutputs evaluates the outputs over the live objects of the bundle deployment. Outputs, which reference a
+// missing object or evaluate to an empty value, e.g. a load balancer without hostname, are omitted.
+func evaluateOutputs(ctx context.Context, objs []runtime.Object, outputs []fleet.BundleOutput, defaultNamespace string) map[string]string {
+	if len(outputs) == 0 {
+		return nil
+	}
+
+	logger := log.FromContext(ctx)
+	result := map[string]string{}
+	for _, output := range outputs {
+		obj := findObject(objs, output, defaultNamespace)
+		if obj == nil {
+			logger.V(1).Info("Object of output not found", "output", output.Name, "kind", output.Kind, "name", output.ResourceName)
+			continue
+		}
+
+		value, err := evaluateJSONPath(obj, output.JSONPath)
+		if err != nil {
+			logger.V(1).Info("Cannot evaluate output", "output", output.Name, "error", err)
+			continue
+		}
+		if value != "" {
+			result[output.Name] = value
+		}
+	}
+
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+func findObject(objs []runtime.Object, output fleet.BundleOutput, defaultNamespace string) *unstructured.Unstructured {
+	namespace := output.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+
+	for _, obj := range objs {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		if u.GetKind() != output.Kind || u.GetName() != output.ResourceName {
+			continue
+		}
+		if output.APIVersion != "" && u.GetAPIVersion() != output.APIVersion {
+			continue
+		}
+		// cluster-scoped objects have no namespace
+		if u.GetNamespace() != "" && u.GetNamespace() != namespace {
+			continue
+		}
+		return u
+	}
+	return nil
+}
+
+// evaluateJSONPath evaluates the expression over the object. Braces are optional, ".status.x" is treated as
+// "{.status.x}".
+func evaluateJSONPath(obj *unstructured.Unstructured, expression string) (string, error) {
+	if !strings.Contains(expression, "{") {
+		expression = "{" + expression + "}"
+	}
+
+	jp := jsonpath.New("output")
+	if err := jp.Parse(expression); err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	if err := jp.Execute(&b, obj.Object); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,58 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+func Test_evaluateOutputs(t *testing.T) {
+	objs := []runtime.Object{
+		&unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"metadata":   map[string]interface{}{"name": "db", "namespace": "infra"},
+			"spec":       map[string]interface{}{"ports": []interface{}{map[string]interface{}{"port": int64(5432)}}},
+			"status": map[string]interface{}{"loadBalancer": map[string]interface{}{
+				"ingress": []interface{}{map[string]interface{}{"hostname": "db.example.com"}},
+			}},
+		}},
+		&unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"metadata":   map[string]interface{}{"name": "web", "namespace": "infra"},
+			"status":     map[string]interface{}{"loadBalancer": map[string]interface{}{}},
+		}},
+		&unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata":   map[string]interface{}{"name": "db-credentials", "namespace": "other"},
+		}},
+	}
+
+	outputs := evaluateOutputs(context.Background(), objs, []fleet.BundleOutput{
+		{Name: "host", Kind: "Service", ResourceName: "db", JSONPath: "{.status.loadBalancer.ingress[0].hostname}"},
+		{Name: "port", APIVersion: "v1", Kind: "Service", ResourceName: "db", JSONPath: ".spec.ports[0].port"},
+		{Name: "url", Kind: "Service", ResourceName: "db", JSONPath: "postgres://{.status.loadBalancer.ingress[0].hostname}"},
+		{Name: "secret", Kind: "Secret", Namespace: "other", ResourceName: "db-credentials", JSONPath: "{.metadata.name}"},
+		// not evaluated yet or missing
+		{Name: "web", Kind: "Service", ResourceName: "web", JSONPath: "{.status.loadBalancer.ingress[0].hostname}"},
+		{Name: "wrong-namespace", Kind: "Secret", ResourceName: "db-credentials", JSONPath: "{.metadata.name}"},
+		{Name: "wrong-version", APIVersion: "v2", Kind: "Service", ResourceName: "db", JSONPath: "{.metadata.name}"},
+		{Name: "invalid", Kind: "Service", ResourceName: "db", JSONPath: "{.status["},
+	}, "infra")
+
+	assert.Equal(t, map[string]string{
+		"host":   "db.example.com",
+		"port":   "5432",
+		"url":    "postgres://db.example.com",
+		"secret": "db-credentials",
+	}, outputs)
+
+	assert.Nil(t, evaluateOutputs(context.Background(), objs, nil, "infra"))
+}
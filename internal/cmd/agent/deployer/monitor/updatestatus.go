@@ -84,7 +84,7 @@ func ShouldUpdateStatus(bd *fleet.BundleDeployment) bool {
 }
 
 // UpdateStatus sets the status of the bundledeployment based on the resources from the helm release history and the live state.
-// In the status it updates: Ready, NonReadyStatus, IncompleteState, NonReadyStatus, NonModified, ModifiedStatus, Resources and ResourceCounts fields.
+// In the status it updates: Ready, NonReadyStatus, IncompleteState, NonReadyStatus, NonModified, ModifiedStatus, Resources, ResourceCounts and Outputs fields.
 // Additionally it sets the Ready condition either from the NonReadyStatus or the NonModified status field.
 func (m *Monitor) UpdateStatus(ctx context.Context, bd *fleet.BundleDeployment, resources *helmdeployer.Resources) (fleet.BundleDeploymentStatus, error) {
 	logger := log.FromContext(ctx).WithName("update-status")
@@ -190,6 +190,7 @@ func (m *Monitor) updateFromPreviousDeployment(ctx context.Context, bd *fleet.Bu
 	}
 
 	updateFromResources(&bd.Status, allResources, nonReadyResources, modifiedResources)
+	bd.Status.Outputs = evaluateOutputs(ctx, plan.Objects, bd.Spec.Options.Outputs, ns)
 	return nil
 }
 
@@ -17,6 +17,7 @@ import (
 	"github.com/rancher/fleet/internal/config"
 	"github.com/rancher/fleet/internal/helmdeployer"
 	"github.com/rancher/fleet/internal/manifest"
+	"github.com/rancher/fleet/internal/secretstore"
 	"github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 
 	"helm.sh/helm/v4/pkg/cli"
@@ -159,6 +160,7 @@ func start(
 	clusterStatus := &ClusterStatusRunnable{
 		agentInfo:       agentInfo,
 		config:          upstreamConfig,
+		localConfig:     localConfig,
 		checkinInterval: checkinInterval,
 		namespace:       systemNamespace,
 	}
@@ -228,6 +230,8 @@ func newReconciler(
 		setupLog.Error(err, "unable to setup local helm SDK client")
 		return nil, err
 	}
+	// valuesFrom upstream secrets are copied into the cluster namespace by the bundle controller
+	helmDeployer.SetValuesResolver(secretstore.NewResolver(mgr.GetAPIReader(), fleetNamespace))
 
 	// Build the deployer that the bundledeployment reconciler will use
 	deployer := deployer.New(
@@ -67,6 +67,8 @@ type Apply struct {
 	DrivenScan                   bool              `usage:"Use driven scan. Bundles are defined by the user" name:"driven-scan"`
 	DrivenScanSeparator          string            `usage:"Separator to use for bundle folder and options file" name:"driven-scan-sep" default:":"`
 	BundleCreationMaxConcurrency int               `usage:"Maximum number of concurrent bundle creation routines" name:"bundle-creation-max-concurrency" default:"4" env:"FLEET_BUNDLE_CREATION_MAX_CONCURRENCY"`
+	ChangedPathsFile             string            `usage:"Path of file listing the paths changed since the previously applied commit. Only bundles affected by these changes are regenerated, all bundles are regenerated if the file does not exist" name:"changed-paths-file"`
+	ResultsConfigMap             string            `usage:"Name of the config map to store the result of each path in, for the status of the GitRepo" name:"results-configmap"`
 }
 
 func (r *Apply) PersistentPre(_ *cobra.Command, _ []string) error {
@@ -142,6 +144,12 @@ func (a *Apply) run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("adding auth to opts: %w", err)
 	}
 
+	if a.ChangedPathsFile != "" && opts.Output == nil {
+		if err := addChangedPathsToOpts(&opts, a.ChangedPathsFile, os.ReadFile); err != nil {
+			return fmt.Errorf("adding changed paths to opts: %w", err)
+		}
+	}
+
 	switch {
 	case a.File == "-":
 		opts.BundleReader = os.Stdin
@@ -185,10 +193,26 @@ func (a *Apply) run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if a.ResultsConfigMap != "" {
+		opts.Results = &apply.Results{}
+	}
+
 	if opts.DrivenScan {
-		return apply.CreateBundlesDriven(ctx, client, recorder, name, args, opts)
+		err = apply.CreateBundlesDriven(ctx, client, recorder, name, args, opts)
+	} else {
+		err = apply.CreateBundles(ctx, client, recorder, name, args, opts)
+	}
+
+	if opts.Results != nil {
+		if paths := opts.Results.Paths(); len(paths) > 0 {
+			jobName := os.Getenv(apply.JobNameEnvVar)
+			if werr := apply.WriteResults(ctx, client, a.Namespace, a.ResultsConfigMap, jobName, paths); werr != nil {
+				logrus.Warnf("failed to store the results of the paths in config map %s: %v", a.ResultsConfigMap, werr)
+			}
+		}
 	}
-	return apply.CreateBundles(ctx, client, recorder, name, args, opts)
+
+	return err
 }
 
 // addAuthToOpts adds auth if provided as arguments. It will look first for HelmCredentialsByPathFile. If HelmCredentialsByPathFile
@@ -241,6 +265,28 @@ func (a *Apply) addAuthToOpts(opts *apply.Options, readFile readFile, helmBasicH
 	return nil
 }
 
+// addChangedPathsToOpts enables incremental bundle regeneration, if the file listing the changed paths exists. The
+// file does not exist if the changes could not be computed, in which case all bundles are regenerated.
+func addChangedPathsToOpts(opts *apply.Options, file string, readFile readFile) error {
+	data, err := readFile(file)
+	if os.IsNotExist(err) {
+		logrus.Infof("No changed paths found in %s, regenerating all bundles", file)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	opts.Incremental = true
+	for _, path := range strings.Split(string(data), "\n") {
+		if path = strings.TrimSpace(path); path != "" {
+			opts.ChangedPaths = append(opts.ChangedPaths, path)
+		}
+	}
+
+	return nil
+}
+
 func currentCommit() string {
 	cmd := exec.Command("git", "rev-parse", "HEAD") //nolint:noctx // TODO: refactor to use go-git's ResolveRevision
 	buf := &bytes.Buffer{}
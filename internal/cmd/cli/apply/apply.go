@@ -94,19 +94,49 @@ type Options struct {
 	DrivenScanSeparator          string
 	JobNameEnvVar                string
 	BundleCreationMaxConcurrency int
+	// Incremental enables regenerating only the bundles affected by ChangedPaths. Existing bundles, which are not
+	// affected, only get their labels updated.
+	Incremental  bool
+	ChangedPaths []string
+	// Root is the directory the paths are relative to, instead of the current directory. Bundle names are derived
+	// from the relative paths.
+	Root string
+	// Results collects the result of each path, if set. A path which fails does not prevent the bundles of the other
+	// paths from being created.
+	Results *Results
+}
+
+// path returns the path of p, relative to the current directory.
+func (o Options) path(p string) string {
+	if o.Root == "" {
+		return p
+	}
+	return filepath.Join(o.Root, p)
+}
+
+// rel returns the path of p, relative to the root.
+func (o Options) rel(p string) (string, error) {
+	if o.Root == "" {
+		return p, nil
+	}
+	return filepath.Rel(o.Root, p)
 }
 
 type bundleWithOpts struct {
+	// path is the bundle directory, relative to the root
+	path   string
 	bundle *fleet.Bundle
 	scans  []*fleet.ImageScan
 	opts   *Options
+	// orig is only set for existing bundles, which are not regenerated
+	orig *fleet.Bundle
 }
 
-func globDirs(baseDir string) (result []string, err error) {
+func globDirs(baseDir string, opts Options) (result []string, err error) {
 	for strings.HasPrefix(baseDir, "/") {
 		baseDir = baseDir[1:]
 	}
-	paths, err := filepath.Glob(baseDir)
+	paths, err := filepath.Glob(opts.path(baseDir))
 	if err != nil {
 		return nil, err
 	}
@@ -134,6 +164,10 @@ func CreateBundles(pctx context.Context, client client.Client, r record.EventRec
 	}
 
 	maxConcurrency := getEffectiveMaxConcurrency(opts.BundleCreationMaxConcurrency)
+	results := opts.Results
+	if results == nil {
+		results = &Results{}
+	}
 
 	// Using an errgroup to manage concurrency
 	// 1. Goroutines will be launched, honouring the concurrency limit, and eventually block trying to write to `bundlesChan`.
@@ -145,7 +179,7 @@ func CreateBundles(pctx context.Context, client client.Client, r record.EventRec
 	eg.SetLimit(maxConcurrency + 1) // extra goroutine for WalkDir loop
 	eg.Go(func() error {
 		for _, baseDir := range baseDirs {
-			matches, err := globDirs(baseDir)
+			matches, err := globDirs(baseDir, opts)
 			if err != nil {
 				return fmt.Errorf("invalid path glob %s: %w", baseDir, err)
 			}
@@ -165,25 +199,36 @@ func CreateBundles(pctx context.Context, client client.Client, r record.EventRec
 						return nil
 					}
 
+					path, err = opts.rel(path)
+					if err != nil {
+						return err
+					}
+
 					// needed as opts are mutated in this loop
 					opts := opts
 					eg.Go(func() error {
 						if err := setAuthByPath(&opts, path); err != nil {
-							return err
+							results.failed(path, opts.BundleFile, "", err)
+							return nil
 						}
 
-						bundle, scans, err := bundleFromDir(ctx, repoName, path, opts)
-						if err != nil {
-							if errors.Is(err, ErrNoResources) {
-								logrus.Warnf("%s: %v", path, err)
+						b := unchangedBundle(ctx, client, repoName, path, opts)
+						if b == nil {
+							bundle, scans, err := bundleFromDir(ctx, repoName, path, opts)
+							if err != nil {
+								if errors.Is(err, ErrNoResources) {
+									logrus.Warnf("%s: %v", path, err)
+									return nil
+								}
+								results.failed(path, opts.BundleFile, "", err)
 								return nil
 							}
-							return err
+							b = &bundleWithOpts{path: path, bundle: bundle, scans: scans, opts: &opts}
 						}
 						select {
 						case <-ctx.Done():
 							return ctx.Err()
-						case bundlesChan <- &bundleWithOpts{bundle: bundle, scans: scans, opts: &opts}:
+						case bundlesChan <- b:
 						}
 						return nil
 					})
@@ -213,25 +258,24 @@ func CreateBundles(pctx context.Context, client client.Client, r record.EventRec
 	ctx = pctx // context from ErrorGroup is canceled after the first Wait() returns
 
 	if opts.Output == nil {
-		err := pruneBundlesNotFoundInRepo(ctx, client, repoName, opts.Namespace, gitRepoBundlesMap)
-		if err != nil {
+		// the bundles of failed paths are unknown, they must not be pruned
+		if results.hasErrors() {
+			logrus.Warnf("Not pruning bundles of %s, as not all bundles could be generated", repoName)
+		} else if err := pruneBundlesNotFoundInRepo(ctx, client, repoName, opts.Namespace, gitRepoBundlesMap); err != nil {
 			return err
 		}
 	}
 
 	if len(gitRepoBundlesMap) == 0 {
+		if err := results.Err(); err != nil {
+			return err
+		}
 		return fmt.Errorf("no resource found at the following paths to deploy: %v", baseDirs)
 	}
 
-	egWrite, ctx := errgroup.WithContext(pctx)
-	egWrite.SetLimit(maxConcurrency)
-	for _, b := range bundlesToWrite {
-		egWrite.Go(func() error {
-			return writeBundle(ctx, client, r, b.bundle, b.scans, *b.opts)
-		})
-	}
+	writeBundles(pctx, client, r, bundlesToWrite, maxConcurrency, results)
 
-	return egWrite.Wait()
+	return results.Err()
 }
 
 // CreateBundlesDriven creates bundles from the given baseDirs. Those bundles' names will be prefixed with
@@ -249,6 +293,10 @@ func CreateBundlesDriven(pctx context.Context, client client.Client, r record.Ev
 	}
 
 	maxConcurrency := getEffectiveMaxConcurrency(opts.BundleCreationMaxConcurrency)
+	results := opts.Results
+	if results == nil {
+		results = &Results{}
+	}
 
 	// Using an errgroup to manage concurrency
 	// 1. Goroutines will be launched, honouring the concurrency limit, and eventually block trying to write to `bundlesChan`.
@@ -262,27 +310,35 @@ func CreateBundlesDriven(pctx context.Context, client client.Client, r record.Ev
 			opts := opts
 			eg.Go(func() error {
 				var err error
+				path := baseDir
 				baseDir, opts.BundleFile, err = getPathAndFleetYaml(baseDir, opts.DrivenScanSeparator)
 				if err != nil {
-					return err
+					results.failed(path, "", "", err)
+					return nil
 				}
 
 				if err := setAuthByPath(&opts, baseDir); err != nil {
-					return err
+					results.failed(baseDir, opts.BundleFile, "", err)
+					return nil
 				}
 
-				bundle, scans, err := bundleFromDir(ctx, repoName, baseDir, opts)
-				if err != nil {
-					if errors.Is(err, ErrNoResources) {
-						logrus.Warnf("%s: %v", baseDir, err)
+				b := unchangedBundle(ctx, client, repoName, baseDir, opts)
+				if b == nil {
+					bundle, scans, err := bundleFromDir(ctx, repoName, baseDir, opts)
+					if err != nil {
+						if errors.Is(err, ErrNoResources) {
+							logrus.Warnf("%s: %v", baseDir, err)
+							return nil
+						}
+						results.failed(baseDir, opts.BundleFile, "", err)
 						return nil
 					}
-					return err
+					b = &bundleWithOpts{path: baseDir, bundle: bundle, scans: scans, opts: &opts}
 				}
 				select {
 				case <-ctx.Done():
 					return ctx.Err()
-				case bundlesChan <- &bundleWithOpts{bundle: bundle, scans: scans, opts: &opts}:
+				case bundlesChan <- b:
 				}
 				return nil
 			})
@@ -307,25 +363,51 @@ func CreateBundlesDriven(pctx context.Context, client client.Client, r record.Ev
 	ctx = pctx // context from ErrorGroup is canceled after the first Wait() returns
 
 	if opts.Output == nil {
-		err := pruneBundlesNotFoundInRepo(ctx, client, repoName, opts.Namespace, gitRepoBundlesMap)
-		if err != nil {
+		// the bundles of failed paths are unknown, they must not be pruned
+		if results.hasErrors() {
+			logrus.Warnf("Not pruning bundles of %s, as not all bundles could be generated", repoName)
+		} else if err := pruneBundlesNotFoundInRepo(ctx, client, repoName, opts.Namespace, gitRepoBundlesMap); err != nil {
 			return err
 		}
 	}
 
 	if len(gitRepoBundlesMap) == 0 {
+		if err := results.Err(); err != nil {
+			return err
+		}
 		return fmt.Errorf("no resource found at the following paths to deploy: %v", baseDirs)
 	}
 
-	egWrite, ctx := errgroup.WithContext(pctx)
-	egWrite.SetLimit(maxConcurrency)
-	for _, b := range bundlesToWrite {
-		egWrite.Go(func() error {
-			return writeBundle(ctx, client, r, b.bundle, b.scans, *b.opts)
+	writeBundles(pctx, client, r, bundlesToWrite, maxConcurrency, results)
+
+	return results.Err()
+}
+
+// writeBundles writes the bundles, or updates the labels of unchanged bundles, and records the result of their
+// paths. A bundle which fails to be written does not prevent the others from being written.
+func writeBundles(ctx context.Context, client client.Client, r record.EventRecorder, bundles []*bundleWithOpts, maxConcurrency int, results *Results) {
+	var eg errgroup.Group
+	eg.SetLimit(maxConcurrency)
+	for _, b := range bundles {
+		eg.Go(func() error {
+			// the bundle is replaced by the stored one while writing it, e.g. without resources if it is stored
+			// in an OCI registry
+			name, hash := b.bundle.Name, contentHash(b.bundle)
+			var err error
+			if b.orig != nil {
+				err = updateUnchangedBundle(ctx, client, b)
+			} else {
+				err = writeBundle(ctx, client, r, b.bundle, b.scans, *b.opts)
+			}
+			if err != nil {
+				results.failed(b.path, b.opts.BundleFile, name, err)
+				return nil
+			}
+			results.succeeded(b.path, b.opts.BundleFile, name, hash)
+			return nil
 		})
 	}
-
-	return egWrite.Wait()
+	_ = eg.Wait()
 }
 
 // getPathAndFleetYaml returns the path and options file from a given path.
@@ -455,15 +537,7 @@ func newBundle(ctx context.Context, name, baseDir string, opts Options) (*fleet.
 // name: the gitrepo name, passed to 'fleet apply' on the cli
 // basedir: a directory containing a Bundle, as observed by CreateBundles or CreateBundlesDriven
 func bundleFromDir(ctx context.Context, name, baseDir string, opts Options) (*fleet.Bundle, []*fleet.ImageScan, error) {
-	// The bundleID is a valid helm release name, it's used as a default if a release name is not specified in helm options.
-	// It's also used to create the bundle name.
-	bundleID := filepath.Join(name, baseDir)
-	if opts.BundleFile != "" {
-		bundleID = filepath.Join(bundleID, strings.TrimSuffix(opts.BundleFile, filepath.Ext(opts.BundleFile)))
-	}
-	bundleID = names.HelmReleaseName(bundleID)
-
-	bundle, scans, err := newBundle(ctx, bundleID, baseDir, opts)
+	bundle, scans, err := newBundle(ctx, bundleID(name, baseDir, opts.BundleFile), opts.path(baseDir), opts)
 	if err != nil {
 		return nil, nil, err
 	} else if len(bundle.Spec.Resources) == 0 {
@@ -472,6 +546,16 @@ func bundleFromDir(ctx context.Context, name, baseDir string, opts Options) (*fl
 	return bundle, scans, nil
 }
 
+// bundleID returns a valid helm release name, it's used as a default if a release name is not specified in helm
+// options. It's also used as the bundle name, unless the fleet.yaml sets a name.
+func bundleID(name, baseDir, bundleFile string) string {
+	id := filepath.Join(name, baseDir)
+	if bundleFile != "" {
+		id = filepath.Join(id, strings.TrimSuffix(bundleFile, filepath.Ext(bundleFile)))
+	}
+	return names.HelmReleaseName(id)
+}
+
 func writeBundle(ctx context.Context, c client.Client, r record.EventRecorder, bundle *fleet.Bundle, scans []*fleet.ImageScan, opts Options) error {
 	// Early return for "offline" mode, only printing the result to stdout/file
 	if opts.Output != nil {
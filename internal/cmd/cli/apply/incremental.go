@@ -0,0 +1,62 @@
+package apply
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/rancher/fleet/internal/bundlereader"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// unchangedBundle returns the existing bundle for baseDir, if incremental regeneration is enabled and the bundle is
+// not affected by the changed paths. It returns nil if the bundle has to be regenerated, which is always the case if
+// its dependencies cannot be determined or include content from outside the repository.
+func unchangedBundle(ctx context.Context, c client.Reader, repoName, baseDir string, opts Options) *bundleWithOpts {
+	if !opts.Incremental {
+		return nil
+	}
+
+	deps, err := bundlereader.ReadDependencies(opts.path(baseDir), opts.BundleFile)
+	if err != nil {
+		logrus.Debugf("%s: regenerating bundle, failed to read its dependencies: %v", baseDir, err)
+		return nil
+	}
+	changed := make([]string, 0, len(opts.ChangedPaths))
+	for _, path := range opts.ChangedPaths {
+		changed = append(changed, opts.path(path))
+	}
+	if deps.Remote || deps.Changed(changed) {
+		return nil
+	}
+
+	name := deps.Name
+	if name == "" {
+		name = bundleID(repoName, baseDir, opts.BundleFile)
+	}
+	bundle := &fleet.Bundle{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: opts.Namespace, Name: name}, bundle); err != nil {
+		return nil
+	}
+	if bundle.Labels[fleet.RepoLabel] != repoName {
+		return nil
+	}
+
+	logrus.Debugf("%s: bundle %s is not affected by the changed paths, skipping regeneration", baseDir, name)
+	return &bundleWithOpts{path: baseDir, bundle: bundle, orig: bundle.DeepCopy(), opts: &opts}
+}
+
+// updateUnchangedBundle updates the labels, e.g. the commit label, of a bundle which was not regenerated. It also
+// persists overwrites added while pruning bundles.
+func updateUnchangedBundle(ctx context.Context, c client.Client, b *bundleWithOpts) error {
+	if b.bundle.Labels == nil {
+		b.bundle.Labels = map[string]string{}
+	}
+	for k, v := range b.opts.Labels {
+		b.bundle.Labels[k] = v
+	}
+
+	return c.Patch(ctx, b.bundle, client.MergeFrom(b.orig))
+}
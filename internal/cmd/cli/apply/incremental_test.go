@@ -0,0 +1,95 @@
+package apply
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/wrangler/v3/pkg/schemes"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestUnchangedBundle(t *testing.T) {
+	t.Chdir(t.TempDir())
+	for _, dir := range []string{"app", "other"} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(dir+"/cm.yaml", []byte("kind: ConfigMap"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scheme := runtime.NewScheme()
+	utilruntime.Must(fleet.AddToScheme(scheme))
+	existing := &fleet.Bundle{ObjectMeta: metav1.ObjectMeta{
+		Name:      bundleID("repo", "app", ""),
+		Namespace: "fleet-local",
+		Labels:    map[string]string{fleet.RepoLabel: "repo", fleet.CommitLabel: "old"},
+	}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(existing).Build()
+
+	opts := Options{
+		Namespace:    "fleet-local",
+		Labels:       map[string]string{fleet.RepoLabel: "repo", fleet.CommitLabel: "new"},
+		Incremental:  true,
+		ChangedPaths: []string{"other/cm.yaml"},
+	}
+
+	if b := unchangedBundle(context.TODO(), c, "repo", "other", opts); b != nil {
+		t.Errorf("expected changed bundle to be regenerated")
+	}
+	if b := unchangedBundle(context.TODO(), c, "repo", "new", opts); b != nil {
+		t.Errorf("expected bundle which does not exist yet to be generated")
+	}
+
+	b := unchangedBundle(context.TODO(), c, "repo", "app", opts)
+	if b == nil {
+		t.Fatal("expected unchanged bundle not to be regenerated")
+	}
+	if err := updateUnchangedBundle(context.TODO(), c, b); err != nil {
+		t.Fatal(err)
+	}
+	bundle := &fleet.Bundle{}
+	if err := c.Get(context.TODO(), client.ObjectKeyFromObject(existing), bundle); err != nil {
+		t.Fatal(err)
+	}
+	if bundle.Labels[fleet.CommitLabel] != "new" {
+		t.Errorf("expected commit label of unchanged bundle to be updated, got %v", bundle.Labels)
+	}
+
+	opts.Incremental = false
+	if b := unchangedBundle(context.TODO(), c, "repo", "app", opts); b != nil {
+		t.Errorf("expected all bundles to be regenerated without changed paths")
+	}
+}
+
+func TestCreateBundlesRoot(t *testing.T) {
+	if err := schemes.Register(fleet.AddToScheme); err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	if err := os.MkdirAll(root+"/app", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(root+"/app/cm.yaml", []byte("kind: ConfigMap\napiVersion: v1\nmetadata:\n  name: cm"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	out := &bytes.Buffer{}
+	opts := Options{Namespace: "fleet-local", Output: out, Root: root}
+	if err := CreateBundles(context.TODO(), nil, nil, "repo", []string{"/app"}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "name: "+bundleID("repo", "app", "")+"\n") {
+		t.Errorf("expected bundle name relative to the root, got\n%s", out.String())
+	}
+}
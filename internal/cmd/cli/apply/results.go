@@ -0,0 +1,164 @@
+package apply
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/rancher/fleet/internal/manifest"
+	"github.com/rancher/fleet/internal/names"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/sirupsen/logrus"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ResultsJobKey is the key of the name of the job, which stored the results in the results config map.
+	ResultsJobKey = "job"
+	// ResultsPathsKey is the key of the results of the paths in the results config map.
+	ResultsPathsKey = "paths.json"
+)
+
+// ResultsConfigMapName returns the name of the config map, in which fleet apply stores the results of the paths of
+// a gitrepo for the gitjob controller.
+func ResultsConfigMapName(repoName string) string {
+	return names.SafeConcatName(repoName, "results")
+}
+
+type resultKey struct {
+	path    string
+	options string
+}
+
+// Results collects the result of creating the bundle of each path. It is safe for concurrent use. The result of a
+// path replaces the previous one, e.g. when fleet apply is retried after a conflict.
+type Results struct {
+	mu      sync.Mutex
+	results map[resultKey]fleet.GitRepoPathStatus
+	errs    map[resultKey]error
+}
+
+func (r *Results) set(result fleet.GitRepoPathStatus, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.results == nil {
+		r.results = map[resultKey]fleet.GitRepoPathStatus{}
+		r.errs = map[resultKey]error{}
+	}
+	key := resultKey{path: result.Path, options: result.Options}
+	r.results[key] = result
+	if err != nil {
+		r.errs[key] = err
+	} else {
+		delete(r.errs, key)
+	}
+}
+
+// succeeded records the bundle created from the path.
+func (r *Results) succeeded(path, options, bundleName, hash string) {
+	r.set(fleet.GitRepoPathStatus{
+		Path:        path,
+		Options:     options,
+		BundleName:  bundleName,
+		ContentHash: hash,
+	}, nil)
+}
+
+// failed records the error of the path. The bundle name is only known if the bundle was generated.
+func (r *Results) failed(path, options, bundleName string, err error) {
+	r.set(fleet.GitRepoPathStatus{
+		Path:       path,
+		Options:    options,
+		BundleName: bundleName,
+		Error:      true,
+		Message:    err.Error(),
+	}, err)
+}
+
+// Paths returns the results sorted by path.
+func (r *Results) Paths() []fleet.GitRepoPathStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	paths := make([]fleet.GitRepoPathStatus, 0, len(r.results))
+	for _, result := range r.results {
+		paths = append(paths, result)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if paths[i].Path != paths[j].Path {
+			return paths[i].Path < paths[j].Path
+		}
+		return paths[i].Options < paths[j].Options
+	})
+	return paths
+}
+
+// Err returns the errors of the failed paths, prefixed with their path, or nil if no path failed.
+func (r *Results) Err() error {
+	paths := r.Paths()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var errs []error
+	for _, result := range paths {
+		if err, ok := r.errs[resultKey{path: result.Path, options: result.Options}]; ok {
+			errs = append(errs, fmt.Errorf("%s: %w", result.Path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Results) hasErrors() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.errs) > 0
+}
+
+// contentHash returns the SHA256 sum of the resources of the bundle, which is the resourcesSha256Sum of unchanged
+// bundles read from the cluster.
+func contentHash(bundle *fleet.Bundle) string {
+	h, err := manifest.FromBundle(bundle).SHASum()
+	if err != nil {
+		logrus.Debugf("failed to compute the content hash of bundle %s: %v", bundle.Name, err)
+		return ""
+	}
+	return h
+}
+
+// WriteResults stores the results of the paths in the results config map, which is created by the gitjob
+// controller. The name of the job identifies the run of fleet apply the results belong to.
+func WriteResults(ctx context.Context, c client.Client, namespace, name, jobName string, paths []fleet.GitRepoPathStatus) error {
+	data, err := json.Marshal(paths)
+	if err != nil {
+		return err
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, cm); err != nil {
+		return err
+	}
+	cm.Data = map[string]string{
+		ResultsJobKey:   jobName,
+		ResultsPathsKey: string(data),
+	}
+	return c.Update(ctx, cm)
+}
+
+// ReadResults returns the results of the paths stored in the results config map by the job, or nil if the config
+// map holds the results of another job.
+func ReadResults(cm *corev1.ConfigMap, jobName string) ([]fleet.GitRepoPathStatus, error) {
+	if cm.Data[ResultsJobKey] != jobName || cm.Data[ResultsPathsKey] == "" {
+		return nil, nil
+	}
+	var paths []fleet.GitRepoPathStatus
+	if err := json.Unmarshal([]byte(cm.Data[ResultsPathsKey]), &paths); err != nil {
+		return nil, fmt.Errorf("failed to parse results of config map %s: %w", cm.Name, err)
+	}
+	return paths, nil
+}
@@ -0,0 +1,91 @@
+package apply
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/wrangler/v3/pkg/schemes"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCreateBundlesResults(t *testing.T) {
+	if err := schemes.Register(fleet.AddToScheme); err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	for _, dir := range []string{"good", "broken"} {
+		if err := os.MkdirAll(root+"/"+dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(root+"/"+dir+"/cm.yaml", []byte("kind: ConfigMap\napiVersion: v1\nmetadata:\n  name: cm"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(root+"/broken/fleet.yaml", []byte("helm: ["), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	out := &bytes.Buffer{}
+	results := &Results{}
+	opts := Options{Namespace: "fleet-local", Output: out, Root: root, Results: results}
+	err := CreateBundles(context.TODO(), nil, nil, "repo", []string{"good", "broken"}, opts)
+	if err == nil || !strings.HasPrefix(err.Error(), "broken: ") {
+		t.Fatalf("expected error of the broken path, got %v", err)
+	}
+	if !strings.Contains(out.String(), "name: "+bundleID("repo", "good", "")+"\n") {
+		t.Errorf("expected bundle of the good path to be created, got\n%s", out.String())
+	}
+
+	paths := results.Paths()
+	if len(paths) != 2 {
+		t.Fatalf("expected results of both paths, got %v", paths)
+	}
+	broken, good := paths[0], paths[1]
+	if broken.Path != "broken" || !broken.Error || broken.Message == "" || broken.BundleName != "" {
+		t.Errorf("unexpected result of the broken path: %+v", broken)
+	}
+	if good.Path != "good" || good.Error || good.BundleName != bundleID("repo", "good", "") || len(good.ContentHash) != 64 {
+		t.Errorf("unexpected result of the good path: %+v", good)
+	}
+}
+
+func TestWriteResults(t *testing.T) {
+	name := ResultsConfigMapName("repo")
+	c := fake.NewClientBuilder().WithObjects(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "fleet-local"},
+	}).Build()
+
+	paths := []fleet.GitRepoPathStatus{
+		{Path: "app", BundleName: "repo-app", ContentHash: "abc"},
+		{Path: "broken", Error: true, Message: "boom"},
+	}
+	if err := WriteResults(context.TODO(), c, "fleet-local", name, "repo-12345", paths); err != nil {
+		t.Fatal(err)
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := c.Get(context.TODO(), client.ObjectKey{Namespace: "fleet-local", Name: name}, cm); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadResults(cm, "repo-12345")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(got, paths) {
+		t.Errorf("unexpected results: %s", cmp.Diff(paths, got))
+	}
+
+	got, err = ReadResults(cm, "repo-67890")
+	if err != nil || got != nil {
+		t.Errorf("expected results of another job to be ignored, got %v, %v", got, err)
+	}
+}
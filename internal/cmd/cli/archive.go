@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	command "github.com/rancher/fleet/internal/cmd"
+	"github.com/rancher/fleet/pkg/archive"
+)
+
+// NewArchive returns a subcommand to download the content of a GitRepo's archive source, like `gitcloner` clones
+// its git repository.
+func NewArchive() *cobra.Command {
+	return command.Command(&Archive{}, cobra.Command{
+		Use:           "archive [URL] [PATH]",
+		Short:         "Downloads and unpacks an archive, the objects under a prefix of an S3 bucket or an OCI artifact",
+		Args:          cobra.ExactArgs(2),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	})
+}
+
+type Archive struct {
+	Type             string `usage:"Type of the source, http, s3 or oci" default:"http"`
+	Endpoint         string `usage:"Endpoint of the S3-compatible service"`
+	Region           string `usage:"Region of the S3 bucket"`
+	Insecure         bool   `usage:"Connect to the S3 endpoint or the OCI registry over plain HTTP"`
+	Username         string `usage:"User name for basic auth, or access key ID for S3" short:"u"`
+	PasswordFile     string `usage:"Password file for basic auth, or secret access key file for S3"`
+	CABundleFile     string `usage:"CA bundle file" name:"ca-bundle-file"`
+	InsecureSkipTLS  bool   `usage:"Do not verify tls certificates" name:"insecure-skip-tls"`
+	Tag              string `usage:"Tag or digest of the OCI artifact, latest if empty"`
+	ExpectedRevision string `usage:"Revision the OCI artifact must have" name:"expected-revision"`
+	CosignPublicKey  string `usage:"PEM encoded public key the OCI artifact must be signed with by cosign" name:"cosign-public-key"`
+}
+
+func (a *Archive) Run(cmd *cobra.Command, args []string) error {
+	s := &archive.Source{
+		Type:             a.Type,
+		URL:              args[0],
+		Endpoint:         a.Endpoint,
+		Region:           a.Region,
+		Insecure:         a.Insecure,
+		Username:         a.Username,
+		InsecureSkipTLS:  a.InsecureSkipTLS,
+		Tag:              a.Tag,
+		ExpectedRevision: a.ExpectedRevision,
+		CosignPublicKey:  []byte(a.CosignPublicKey),
+	}
+
+	if a.PasswordFile != "" {
+		password, err := os.ReadFile(a.PasswordFile)
+		if err != nil {
+			return fmt.Errorf("failed to read password file: %w", err)
+		}
+		s.Password = string(password)
+	}
+
+	if a.CABundleFile != "" {
+		caBundle, err := os.ReadFile(a.CABundleFile)
+		if err != nil {
+			return fmt.Errorf("failed to read CA bundle file: %w", err)
+		}
+		s.CABundle = caBundle
+	}
+
+	revision, err := s.Download(cmd.Context(), args[1])
+	if err != nil {
+		return err
+	}
+	logrus.Infof("Downloaded revision %s of %s", revision, args[0])
+
+	return nil
+}
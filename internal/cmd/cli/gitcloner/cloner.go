@@ -1,11 +1,14 @@
 package gitcloner
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/protocol/packp/capability"
 	"github.com/go-git/go-git/v5/plumbing/transport"
@@ -17,6 +20,7 @@ import (
 	"github.com/rancher/fleet/internal/cmd/cli/gitcloner/submodule"
 	fleetgithub "github.com/rancher/fleet/internal/github"
 	fleetssh "github.com/rancher/fleet/internal/ssh"
+	fleetgit "github.com/rancher/fleet/pkg/git"
 	giturls "github.com/rancher/fleet/pkg/git-urls"
 )
 
@@ -26,7 +30,9 @@ var (
 	plainClone                                 = git.PlainClone
 	updateSubmodules                           = submodule.UpdateSubmodules
 	readFile                                   = os.ReadFile
+	writeFile                                  = os.WriteFile
 	fileStat                                   = os.Stat
+	fetchLFS                                   = fleetgit.FetchLFSObjects
 	appAuthGetter    fleetgithub.AppAuthGetter = fleetgithub.DefaultAppAuthGetter{}
 )
 
@@ -91,12 +97,29 @@ func cloneBranch(opts *GitCloner, auth transport.AuthMethod, caBundle []byte) er
 		ReferenceName:     plumbing.ReferenceName(opts.Branch),
 		RecurseSubmodules: git.NoRecurseSubmodules,
 		Tags:              git.NoTags,
+		NoCheckout:        len(opts.SparsePaths) > 0,
 	})
 
 	if err != nil {
 		return fmt.Errorf("failed to clone main repo from branch %s: %w, skipping submodule clone", repo(opts), err)
 	}
 
+	if len(opts.SparsePaths) > 0 {
+		head, err := r.Head()
+		if err != nil {
+			return fmt.Errorf("failed to resolve HEAD of %s: %w", repo(opts), err)
+		}
+		if err := sparseCheckout(r, head.Hash(), opts); err != nil {
+			return fmt.Errorf("failed to check out bundle paths of %s: %w", repo(opts), err)
+		}
+	}
+
+	writeChangedPaths(r, opts, auth, caBundle)
+
+	if err := fetchLFSObjects(opts, auth, caBundle); err != nil {
+		return err
+	}
+
 	submoduleUpdateOptions := &git.SubmoduleUpdateOptions{
 		Init:              true,
 		RecurseSubmodules: git.DefaultSubmoduleRecursionDepth,
@@ -120,6 +143,7 @@ func cloneRevision(opts *GitCloner, auth transport.AuthMethod, caBundle []byte)
 		CABundle:          caBundle,
 		RecurseSubmodules: git.NoRecurseSubmodules,
 		Tags:              git.NoTags,
+		NoCheckout:        len(opts.SparsePaths) > 0,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to clone repo from revision %s: %w", repo(opts), err)
@@ -133,10 +157,20 @@ func cloneRevision(opts *GitCloner, auth transport.AuthMethod, caBundle []byte)
 		return fmt.Errorf("failed to get filesystem worktree for %s: %w", repo(opts), err)
 	}
 
-	if err := w.Checkout(&git.CheckoutOptions{Hash: *h}); err != nil {
+	if len(opts.SparsePaths) > 0 {
+		if err := sparseCheckout(r, *h, opts); err != nil {
+			return fmt.Errorf("failed to check out bundle paths of %s: %w", repo(opts), err)
+		}
+	} else if err := w.Checkout(&git.CheckoutOptions{Hash: *h}); err != nil {
 		return fmt.Errorf("failed to checkout in worktree %s: %w", repo(opts), err)
 	}
 
+	writeChangedPaths(r, opts, auth, caBundle)
+
+	if err := fetchLFSObjects(opts, auth, caBundle); err != nil {
+		return err
+	}
+
 	submoduleUpdateOptions := &git.SubmoduleUpdateOptions{
 		Init:              true,
 		RecurseSubmodules: git.DefaultSubmoduleRecursionDepth,
@@ -151,6 +185,71 @@ func cloneRevision(opts *GitCloner, auth transport.AuthMethod, caBundle []byte)
 	return nil
 }
 
+// writeChangedPaths writes the paths changed between the previous commit and the checked out commit to the changed
+// paths file, one per line. If the changes cannot be computed, no file is written and fleet apply falls back to
+// regenerating all bundles.
+func writeChangedPaths(r *git.Repository, opts *GitCloner, auth transport.AuthMethod, caBundle []byte) {
+	if opts.PreviousCommit == "" || opts.ChangedPathsFile == "" {
+		return
+	}
+
+	paths, err := changedPaths(r, opts, auth, caBundle)
+	if err != nil {
+		logrus.Warnf("Failed to compute changed paths since commit %s, all bundles will be regenerated: %v", opts.PreviousCommit, err)
+		return
+	}
+	if err := writeFile(opts.ChangedPathsFile, []byte(strings.Join(paths, "\n")), 0600); err != nil {
+		logrus.Warnf("Failed to write changed paths, all bundles will be regenerated: %v", err)
+	}
+}
+
+// changedPaths returns the sorted paths of files added, modified, deleted or renamed between the previous commit
+// and HEAD. The previous commit is fetched, if the shallow clone does not contain it.
+func changedPaths(r *git.Repository, opts *GitCloner, auth transport.AuthMethod, caBundle []byte) ([]string, error) {
+	if !plumbing.IsHash(opts.PreviousCommit) {
+		return nil, fmt.Errorf("invalid commit %q", opts.PreviousCommit)
+	}
+	prevHash := plumbing.NewHash(opts.PreviousCommit)
+
+	if _, err := r.CommitObject(prevHash); errors.Is(err, plumbing.ErrObjectNotFound) {
+		err := r.Fetch(&git.FetchOptions{
+			RefSpecs:        []config.RefSpec{config.RefSpec(opts.PreviousCommit + ":refs/fleet/previous")},
+			Depth:           1,
+			Auth:            auth,
+			InsecureSkipTLS: opts.InsecureSkipTLS,
+			CABundle:        caBundle,
+			Tags:            git.NoTags,
+		})
+		if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+			return nil, fmt.Errorf("failed to fetch previous commit: %w", err)
+		}
+	}
+
+	head, err := r.Head()
+	if err != nil {
+		return nil, err
+	}
+
+	return fleetgit.DiffCommits(r, prevHash, head.Hash())
+}
+
+// fetchLFSObjects replaces the Git LFS pointer files of the checked out worktree with their objects, using the
+// credentials of the repository.
+func fetchLFSObjects(opts *GitCloner, auth transport.AuthMethod, caBundle []byte) error {
+	if !opts.LFS {
+		return nil
+	}
+	err := fetchLFS(context.Background(), opts.Repo, opts.Path, fleetgit.LFSOptions{
+		Auth:            auth,
+		CABundle:        caBundle,
+		InsecureSkipTLS: opts.InsecureSkipTLS,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch LFS objects of %s: %w", repo(opts), err)
+	}
+	return nil
+}
+
 func getCABundleFromFile(path string) ([]byte, error) {
 	if path == "" {
 		return nil, nil
@@ -209,6 +308,15 @@ func createAuthFromOpts(opts *GitCloner) (transport.AuthMethod, error) {
 		return auth, nil
 	}
 
+	if opts.BearerTokenFile != "" {
+		token, err := readFile(opts.BearerTokenFile)
+		if err != nil {
+			return nil, err
+		}
+
+		return &httpgit.TokenAuth{Token: strings.TrimSpace(string(token))}, nil
+	}
+
 	if opts.PasswordFile != "" {
 		password, err := readFile(opts.PasswordFile)
 		if err != nil {
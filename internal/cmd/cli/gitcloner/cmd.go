@@ -16,11 +16,17 @@ type GitCloner struct {
 	CABundleFile          string
 	Username              string
 	PasswordFile          string
+	BearerTokenFile       string
 	SSHPrivateKeyFile     string
 	InsecureSkipTLS       bool
 	GitHubAppID           int64
 	GitHubAppInstallation int64
 	GitHubAppKeyFile      string
+	PreviousCommit        string
+	ChangedPathsFile      string
+	SparsePaths           []string
+	SparseSeparator       string
+	LFS                   bool
 }
 
 var opts *GitCloner
@@ -43,11 +49,17 @@ func NewCmd(gitCloner CloneGit) *cobra.Command {
 	cmd.Flags().StringVar(&opts.CABundleFile, "ca-bundle-file", "", "CA bundle file")
 	cmd.Flags().StringVarP(&opts.Username, "username", "u", "", "user name for basic auth")
 	cmd.Flags().StringVar(&opts.PasswordFile, "password-file", "", "password file for basic auth")
+	cmd.Flags().StringVar(&opts.BearerTokenFile, "bearer-token-file", "", "bearer token file for token auth")
 	cmd.Flags().StringVar(&opts.SSHPrivateKeyFile, "ssh-private-key-file", "", "ssh private key file path")
 	cmd.Flags().BoolVar(&opts.InsecureSkipTLS, "insecure-skip-tls", false, "do not verify tls certificates")
 	cmd.Flags().Int64Var(&opts.GitHubAppID, "github-app-id", 0, "GitHub App ID")
 	cmd.Flags().Int64Var(&opts.GitHubAppInstallation, "github-app-installation-id", 0, "GitHub App installation ID")
 	cmd.Flags().StringVar(&opts.GitHubAppKeyFile, "github-app-key-file", "", "path to GitHub App private-key PEM")
+	cmd.Flags().StringVar(&opts.PreviousCommit, "previous-commit", "", "previously applied commit, used to compute the changed paths")
+	cmd.Flags().StringVar(&opts.ChangedPathsFile, "changed-paths-file", "", "file to write the paths changed since the previous commit to")
+	cmd.Flags().StringArrayVar(&opts.SparsePaths, "sparse-path", nil, "bundle path to check out, instead of the whole repository, can be repeated")
+	cmd.Flags().StringVar(&opts.SparseSeparator, "sparse-separator", "", "separator between the bundle directories and options files of sparse paths")
+	cmd.Flags().BoolVar(&opts.LFS, "lfs", false, "download Git LFS objects")
 
 	return cmd
 }
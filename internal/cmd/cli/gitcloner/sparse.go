@@ -0,0 +1,258 @@
+package gitcloner
+
+import (
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+
+	v1alpha1 "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+var kustomizationFiles = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// sparseBundle is a bundle directory of the GitRepo and its options file, if it is not a fleet.yaml in the
+// directory.
+type sparseBundle struct {
+	base    string
+	options string
+}
+
+// sparseCheckout checks out the bundle directories of the GitRepo, and the files and directories the fleet.yaml and
+// kustomization files in them reference, instead of the whole tree. Files are referenced by local Helm charts, values
+// files, kustomize directories and kustomize resources. References are resolved from the commit's tree, before the
+// files are checked out. The whole tree is checked out, if a bundle directory is the root of the repository.
+func sparseCheckout(r *git.Repository, hash plumbing.Hash, opts *GitCloner) error {
+	w, err := r.Worktree()
+	if err != nil {
+		return err
+	}
+	commit, err := r.CommitObject(hash)
+	if err != nil {
+		return err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return err
+	}
+
+	var (
+		patterns []string
+		refs     []string
+	)
+	for _, b := range sparseBundles(opts.SparsePaths, opts.SparseSeparator) {
+		dir := globBase(b.base)
+		if dir == "" {
+			logrus.Infof("Bundle path %q is the root of the repository, checking out all files", b.base)
+			return w.Checkout(&git.CheckoutOptions{Hash: hash, Force: true})
+		}
+		refs = append(refs, dir)
+		if b.options != "" && dir == path.Clean(b.base) {
+			// options files are read relative to their bundle directory
+			refs = append(refs, fleetYAMLReferences(tree, path.Join(dir, b.options), dir)...)
+		}
+	}
+	// submodules in the checked out directories are cloned as well
+	refs = append(refs, ".gitmodules")
+
+	for len(refs) > 0 {
+		ref := refs[0]
+		refs = refs[1:]
+
+		n := len(patterns)
+		patterns = addPattern(patterns, tree, ref)
+		if len(patterns) == n {
+			continue
+		}
+		refs = append(refs, treeReferences(tree, patterns[n])...)
+	}
+
+	return w.Checkout(&git.CheckoutOptions{Hash: hash, Force: true, SparseCheckoutDirectories: patterns})
+}
+
+// sparseBundles splits the bundle paths, which are passed like to fleet apply, into the bundle directories and their
+// options files.
+func sparseBundles(paths []string, separator string) []sparseBundle {
+	bundles := make([]sparseBundle, 0, len(paths))
+	for _, p := range paths {
+		b := sparseBundle{base: p}
+		if separator != "" {
+			b.base, b.options, _ = strings.Cut(p, separator)
+		}
+		bundles = append(bundles, b)
+	}
+	return bundles
+}
+
+// globBase returns the directory of the path before the first glob pattern, or an empty string for the root of the
+// repository.
+func globBase(p string) string {
+	var dirs []string
+	for _, elem := range strings.Split(path.Clean(filepath.ToSlash(p)), "/") {
+		if strings.ContainsAny(elem, `*?[\`) {
+			break
+		}
+		dirs = append(dirs, elem)
+	}
+	dir := path.Join(dirs...)
+	if dir == "." || strings.HasPrefix(dir, "../") || dir == ".." {
+		return ""
+	}
+	return dir
+}
+
+// addPattern adds the pattern for the path in the tree to the patterns. The patterns are prefixes, so directories are
+// added with a trailing slash. Paths not in the tree are ignored, e.g. files generated by a helm chart.
+func addPattern(patterns []string, tree *object.Tree, p string) []string {
+	entry, err := tree.FindEntry(p)
+	if err != nil {
+		return patterns
+	}
+	if entry.Mode == filemode.Dir {
+		p += "/"
+	}
+	for _, existing := range patterns {
+		if strings.HasPrefix(p, existing) {
+			return patterns
+		}
+	}
+	return append(patterns, p)
+}
+
+// treeReferences returns the paths, relative to the repository, referenced by the fleet.yaml and kustomization
+// files matching the pattern.
+func treeReferences(tree *object.Tree, pattern string) []string {
+	dir, isDir := strings.CutSuffix(pattern, "/")
+	if !isDir {
+		return fileReferences(tree, pattern)
+	}
+
+	sub, err := tree.Tree(dir)
+	if err != nil {
+		return nil
+	}
+	var refs []string
+	_ = sub.Files().ForEach(func(f *object.File) error {
+		refs = append(refs, fileReferences(tree, path.Join(dir, f.Name))...)
+		return nil
+	})
+	return refs
+}
+
+func fileReferences(tree *object.Tree, name string) []string {
+	switch base := path.Base(name); {
+	case base == "fleet.yaml" || base == "fleet.yml":
+		return fleetYAMLReferences(tree, name, path.Dir(name))
+	case slices.Contains(kustomizationFiles, base):
+		return kustomizationReferences(tree, name, path.Dir(name))
+	default:
+		return nil
+	}
+}
+
+func readTreeFile(tree *object.Tree, name string) ([]byte, error) {
+	f, err := tree.File(name)
+	if err != nil {
+		return nil, err
+	}
+	content, err := f.Contents()
+	return []byte(content), err
+}
+
+// fleetYAMLReferences returns the local Helm charts, values files and kustomize directories of the fleet.yaml file.
+func fleetYAMLReferences(tree *object.Tree, file, dir string) []string {
+	b, err := readTreeFile(tree, file)
+	if err != nil {
+		return nil
+	}
+	var fy v1alpha1.FleetYAML
+	if err := yaml.Unmarshal(b, &fy); err != nil {
+		logrus.Debugf("Failed to parse %s for sparse checkout: %v", file, err)
+		return nil
+	}
+
+	options := []v1alpha1.BundleDeploymentOptions{fy.BundleDeploymentOptions}
+	for _, t := range fy.TargetCustomizations {
+		options = append(options, t.BundleDeploymentOptions)
+	}
+
+	var refs []string
+	for _, o := range options {
+		if o.Helm != nil {
+			if o.Helm.Repo == "" && isLocalPath(o.Helm.Chart) {
+				refs = append(refs, o.Helm.Chart)
+			}
+			refs = append(refs, o.Helm.ValuesFiles...)
+		}
+		if o.Kustomize != nil && o.Kustomize.Dir != "" {
+			refs = append(refs, o.Kustomize.Dir)
+		}
+	}
+
+	return resolveReferences(dir, refs)
+}
+
+// kustomizationReferences returns the local resources, bases, components and patches of the kustomization file.
+func kustomizationReferences(tree *object.Tree, file, dir string) []string {
+	b, err := readTreeFile(tree, file)
+	if err != nil {
+		return nil
+	}
+	var k struct {
+		Resources             []string `json:"resources"`
+		Bases                 []string `json:"bases"`
+		Components            []string `json:"components"`
+		PatchesStrategicMerge []string `json:"patchesStrategicMerge"`
+		Patches               []struct {
+			Path string `json:"path"`
+		} `json:"patches"`
+	}
+	if err := yaml.Unmarshal(b, &k); err != nil {
+		logrus.Debugf("Failed to parse %s for sparse checkout: %v", file, err)
+		return nil
+	}
+
+	refs := slices.Concat(k.Resources, k.Bases, k.Components, k.PatchesStrategicMerge)
+	for _, p := range k.Patches {
+		refs = append(refs, p.Path)
+	}
+
+	var local []string
+	for _, ref := range refs {
+		if isLocalPath(ref) {
+			local = append(local, ref)
+		}
+	}
+	return resolveReferences(dir, local)
+}
+
+// isLocalPath returns false for empty references and remote ones, like URLs, OCI references and go-getter sources.
+func isLocalPath(ref string) bool {
+	if ref == "" || strings.Contains(ref, "://") || strings.Contains(ref, "::") || strings.HasPrefix(ref, "oci:") {
+		return false
+	}
+	return !strings.HasPrefix(ref, "github.com/") && !strings.HasPrefix(ref, "git@")
+}
+
+// resolveReferences returns the references relative to the repository. References outside of it are ignored.
+func resolveReferences(dir string, refs []string) []string {
+	var resolved []string
+	for _, ref := range refs {
+		if path.IsAbs(ref) {
+			continue
+		}
+		p := path.Join(dir, filepath.ToSlash(ref))
+		if p == "." || !filepath.IsLocal(p) {
+			continue
+		}
+		resolved = append(resolved, p)
+	}
+	return resolved
+}
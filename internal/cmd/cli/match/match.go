@@ -67,9 +67,12 @@ func Match(ctx context.Context, opts *Options) error {
 	}
 
 	if opts.Target == "" {
-		m := bm.Match(opts.ClusterName, map[string]map[string]string{
+		cluster := &fleet.Cluster{}
+		cluster.Name = opts.ClusterName
+		cluster.Labels = opts.ClusterLabels
+		m := bm.Match(ctx, matcher.NewCluster(cluster, map[string]map[string]string{
 			opts.ClusterGroup: opts.ClusterGroupLabels,
-		}, opts.ClusterLabels)
+		}))
 		return printMatch(ctx, bundle, m, opts.Output)
 	}
 
@@ -49,6 +49,7 @@ import (
 //   - Agent Connectivity: Clusters with non-ready agents, missing lastSeen timestamps, or stale lastSeen (>24h)
 //   - Broken Ownership Chains: Bundles without GitRepos, BundleDeployments without Bundles
 //   - Generation Mismatches: GitRepos and Bundles where generation != observedGeneration (reconciliation not progressing)
+//   - Failed Paths: GitRepos with paths for which the last sync could not create a bundle
 //
 // # When to Use
 //
@@ -70,7 +71,7 @@ import (
 //
 //   - timestamp: When the snapshot was taken (RFC3339)
 //   - controller: Fleet controller pod status (name, restarts, uptime)
-//   - gitrepos: Array of GitRepo info (generation, observedGeneration, commit, forceSyncGeneration, ready status)
+//   - gitrepos: Array of GitRepo info (generation, observedGeneration, commit, forceSyncGeneration, ready status, failed paths)
 //   - bundles: Array of Bundle info (UID, generation, observedGeneration, commit, resourcesSHA256Sum, finalizers, ready status)
 //   - bundledeployments: Array of BundleDeployment info (UID, generation, forceSyncGeneration, syncGeneration, deploymentIDs, ready status)
 //   - contents: Array of Content info (name, size, finalizers, deletion timestamps)
@@ -111,6 +112,9 @@ import (
 //	# Check for large bundles
 //	fleet monitor | jq '.diagnostics.largeBundles'
 //
+//	# Show which paths of GitRepos failed to create their bundles
+//	fleet monitor | jq '.diagnostics.gitReposWithFailedPaths[] | {name, failedPaths}'
+//
 //	# Check agent connectivity issues
 //	fleet monitor | jq '.diagnostics.clustersWithAgentIssues'
 //
@@ -263,18 +267,19 @@ type ControllerInfo struct {
 }
 
 type GitRepoInfo struct {
-	Namespace           string        `json:"namespace"`
-	Name                string        `json:"name"`
-	Generation          int64         `json:"generation"`
-	ObservedGeneration  int64         `json:"observedGeneration,omitempty"`
-	Commit              string        `json:"commit,omitempty"`
-	PollingCommit       string        `json:"pollingCommit,omitempty"`
-	WebhookCommit       string        `json:"webhookCommit,omitempty"`
-	LastPollingTime     time.Time     `json:"lastPollingTime,omitempty"`
-	PollingInterval     time.Duration `json:"pollingInterval,omitempty"`
-	ForceSyncGeneration int64         `json:"forceSyncGeneration,omitempty"`
-	Ready               bool          `json:"ready"`
-	ReadyMessage        string        `json:"readyMessage,omitempty"`
+	Namespace           string                    `json:"namespace"`
+	Name                string                    `json:"name"`
+	Generation          int64                     `json:"generation"`
+	ObservedGeneration  int64                     `json:"observedGeneration,omitempty"`
+	Commit              string                    `json:"commit,omitempty"`
+	PollingCommit       string                    `json:"pollingCommit,omitempty"`
+	WebhookCommit       string                    `json:"webhookCommit,omitempty"`
+	LastPollingTime     time.Time                 `json:"lastPollingTime,omitempty"`
+	PollingInterval     time.Duration             `json:"pollingInterval,omitempty"`
+	ForceSyncGeneration int64                     `json:"forceSyncGeneration,omitempty"`
+	Ready               bool                      `json:"ready"`
+	ReadyMessage        string                    `json:"readyMessage,omitempty"`
+	FailedPaths         []fleet.GitRepoPathStatus `json:"failedPaths,omitempty"`
 }
 
 type BundleInfo struct {
@@ -387,6 +392,7 @@ type Diagnostics struct {
 	GitReposWithCommitMismatch                  []GitRepoInfo            `json:"gitReposWithCommitMismatch,omitempty"`
 	GitReposWithGenerationMismatch              []GitRepoInfo            `json:"gitReposWithGenerationMismatch,omitempty"`
 	GitReposUnpolled                            []GitRepoInfo            `json:"gitReposUnpolled,omitempty"`
+	GitReposWithFailedPaths                     []GitRepoInfo            `json:"gitReposWithFailedPaths,omitempty"`
 	BundlesWithGenerationMismatch               []BundleInfo             `json:"bundlesWithGenerationMismatch,omitempty"`
 	BundleDeploymentsWithSyncGenerationMismatch []BundleDeploymentInfo   `json:"bundleDeploymentsWithSyncGenerationMismatch,omitempty"`
 	OrphanedSecretsCount                        int                      `json:"orphanedSecretsCount,omitempty"`
@@ -570,6 +576,12 @@ func (m *Monitor) convertGitRepos(gitRepos []fleet.GitRepo) []GitRepoInfo {
 			ForceSyncGeneration: gr.Spec.ForceSyncGeneration,
 		}
 
+		for _, p := range gr.Status.Paths {
+			if p.Error {
+				info.FailedPaths = append(info.FailedPaths, p)
+			}
+		}
+
 		if gr.Spec.PollingInterval == nil || gr.Spec.PollingInterval.Duration == 0 {
 			info.PollingInterval = reconciler.GetPollingIntervalDuration(&gr)
 		} else {
@@ -180,6 +180,7 @@ func (m *Monitor) collectDiagnostics(
 		GitReposWithCommitMismatch:                  m.convertGitRepos(m.detectGitReposWithCommitMismatch(gitRepos)),
 		GitReposWithGenerationMismatch:              m.convertGitRepos(m.detectGitReposWithGenerationMismatch(gitRepos)),
 		GitReposUnpolled:                            m.convertGitRepos(m.detectUnpolledGitRepos(gitRepos)),
+		GitReposWithFailedPaths:                     m.convertGitRepos(m.detectGitReposWithFailedPaths(gitRepos)),
 		BundlesWithGenerationMismatch:               m.convertBundles(m.detectBundlesWithGenerationMismatch(bundles)),
 		BundleDeploymentsWithSyncGenerationMismatch: m.convertBundleDeployments(m.detectBundleDeploymentsWithSyncGenerationMismatch(bundleDeployments)),
 		OrphanedSecretsCount:                        len(orphanedSecrets),
@@ -621,6 +622,22 @@ func (m *Monitor) detectUnpolledGitRepos(gitRepos []fleet.GitRepo) []fleet.GitRe
 	return unpolledGitRepos
 }
 
+// detectGitReposWithFailedPaths detects GitRepos with paths, for which the last sync failed to create a bundle
+func (m *Monitor) detectGitReposWithFailedPaths(gitRepos []fleet.GitRepo) []fleet.GitRepo {
+	var failedGitRepos []fleet.GitRepo
+
+	for _, gr := range gitRepos {
+		for _, p := range gr.Status.Paths {
+			if p.Error {
+				failedGitRepos = append(failedGitRepos, gr)
+				break
+			}
+		}
+	}
+
+	return failedGitRepos
+}
+
 // detectGitReposWithGenerationMismatch detects GitRepos with generation != observedGeneration
 func (m *Monitor) detectGitReposWithGenerationMismatch(gitRepos []fleet.GitRepo) []fleet.GitRepo {
 	var mismatchedGitRepos []fleet.GitRepo
@@ -29,6 +29,7 @@ func App() *cobra.Command {
 		NewTarget(),
 		NewDeploy(),
 		gitcloner.NewCmd(gitcloner.New()),
+		NewArchive(),
 
 		NewMonitor(),
 		NewAnalyze(),
@@ -156,7 +156,7 @@ func (t *Target) Run(cmd *cobra.Command, args []string) error {
 	// create this many deployments if the bundle is new.
 	bundle.Status.MaxNew = len(matchedTargets)
 
-	if err := target.UpdatePartitions(&bundle.Status, matchedTargets); err != nil {
+	if err := target.UpdatePartitions(ctx, &bundle.Status, matchedTargets); err != nil {
 		return err
 	}
 	for _, target := range matchedTargets {
@@ -0,0 +1,41 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/rancher/fleet/internal/cmd/controller/agentmanagement/scheduling"
+	"github.com/rancher/fleet/internal/config"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Delete deletes the agent in the namespace of the downstream cluster, along with its configuration, priority class
+// and pod disruption budget. Missing resources are ignored.
+func Delete(ctx context.Context, kc kubernetes.Interface, namespace string) error {
+	err := kc.CoreV1().Secrets(namespace).Delete(ctx, config.AgentConfigName, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	err = kc.CoreV1().Secrets(namespace).Delete(ctx, config.AgentBootstrapConfigName, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	if err := kc.AppsV1().StatefulSets(namespace).Delete(ctx, config.AgentConfigName, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err := kc.AppsV1().Deployments(namespace).Delete(ctx, config.AgentConfigName, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err := kc.SchedulingV1().PriorityClasses().Delete(ctx, scheduling.FleetAgentPriorityClassName, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err := kc.PolicyV1().PodDisruptionBudgets(namespace).Delete(ctx, scheduling.FleetAgentPodDisruptionBudgetName, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
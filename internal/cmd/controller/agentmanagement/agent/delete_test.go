@@ -0,0 +1,44 @@
+package agent_test
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/rancher/fleet/internal/cmd/controller/agentmanagement/agent"
+	"github.com/rancher/fleet/internal/config"
+)
+
+func TestDelete(t *testing.T) {
+	other := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: namespace}}
+	kc := fake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: config.AgentConfigName, Namespace: namespace}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: config.AgentConfigName, Namespace: namespace}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: config.AgentBootstrapConfigName, Namespace: namespace}},
+		other,
+	)
+
+	if err := agent.Delete(context.TODO(), kc, namespace); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := kc.AppsV1().Deployments(namespace).Get(context.TODO(), config.AgentConfigName, metav1.GetOptions{}); err == nil {
+		t.Error("expected the agent deployment to be deleted")
+	}
+	secrets, err := kc.CoreV1().Secrets(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secrets.Items) != 1 || secrets.Items[0].Name != other.Name {
+		t.Errorf("expected only the agent's secrets to be deleted, got %v", secrets.Items)
+	}
+
+	// missing resources are ignored
+	if err := agent.Delete(context.TODO(), kc, namespace); err != nil {
+		t.Errorf("expected deleting a missing agent to succeed, got %v", err)
+	}
+}
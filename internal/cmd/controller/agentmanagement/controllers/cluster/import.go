@@ -7,7 +7,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
 	"os"
 
 	"github.com/sirupsen/logrus"
@@ -22,6 +21,7 @@ import (
 	"github.com/rancher/fleet/internal/cmd/controller/agentmanagement/scheduling"
 	fleetns "github.com/rancher/fleet/internal/cmd/controller/namespace"
 	"github.com/rancher/fleet/internal/config"
+	"github.com/rancher/fleet/internal/kubeconfig"
 	"github.com/rancher/fleet/internal/names"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	"github.com/rancher/fleet/pkg/durations"
@@ -38,7 +38,6 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/utils/ptr"
 )
 
@@ -221,7 +220,7 @@ func (i *importHandler) OnChange(key string, cluster *fleet.Cluster) (_ *fleet.C
 		return cluster, nil
 	}
 
-	if manageagent.SkipCluster(cluster) {
+	if manageagent.SkipCluster(cluster) || manageagent.PushMode(cluster) {
 		return cluster, nil
 	}
 
@@ -254,26 +253,7 @@ func (i *importHandler) deleteOldAgentBundle(cluster *fleet.Cluster) error {
 }
 
 func (i *importHandler) deleteOldAgent(cluster *fleet.Cluster, kc kubernetes.Interface, namespace string) error {
-	err := kc.CoreV1().Secrets(namespace).Delete(i.ctx, config.AgentConfigName, metav1.DeleteOptions{})
-	if err != nil && !apierrors.IsNotFound(err) {
-		return err
-	}
-
-	err = kc.CoreV1().Secrets(namespace).Delete(i.ctx, config.AgentBootstrapConfigName, metav1.DeleteOptions{})
-	if err != nil && !apierrors.IsNotFound(err) {
-		return err
-	}
-
-	if err := kc.AppsV1().StatefulSets(namespace).Delete(i.ctx, config.AgentConfigName, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
-		return err
-	}
-	if err := kc.AppsV1().Deployments(namespace).Delete(i.ctx, config.AgentConfigName, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
-		return err
-	}
-	if err := kc.SchedulingV1().PriorityClasses().Delete(i.ctx, scheduling.FleetAgentPriorityClassName, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
-		return err
-	}
-	if err := kc.PolicyV1().PodDisruptionBudgets(namespace).Delete(i.ctx, scheduling.FleetAgentPodDisruptionBudgetName, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+	if err := agent.Delete(i.ctx, kc, namespace); err != nil {
 		return err
 	}
 
@@ -287,7 +267,7 @@ func (i *importHandler) deleteOldAgent(cluster *fleet.Cluster, kc kubernetes.Int
 //
 //nolint:gocyclo
 func (i *importHandler) importCluster(cluster *fleet.Cluster, status fleet.ClusterStatus) (fleet.ClusterStatus, error) {
-	if manageagent.SkipCluster(cluster) {
+	if manageagent.SkipCluster(cluster) || manageagent.PushMode(cluster) {
 		return status, nil
 	}
 
@@ -526,38 +506,12 @@ func isLegacyAgentNamespaceSelectedByUser() bool {
 // restConfigFromKubeConfig checks kubeconfig data and tries to connect to server. If server is behind public CA, remove
 // CertificateAuthorityData in kubeconfig file unless strict TLS mode is enabled.
 func (i *importHandler) restConfigFromKubeConfig(data []byte, agentTLSMode string) (*rest.Config, error) {
-	if agentTLSMode != config.AgentTLSModeStrict && agentTLSMode != config.AgentTLSModeSystemStore {
-		return nil, fmt.Errorf(
-			"provided config value for agentTLSMode is none of [%q,%q]",
-			config.AgentTLSModeStrict,
-			config.AgentTLSModeSystemStore,
-		)
-	}
-
-	clientConfig, err := clientcmd.NewClientConfigFromBytes(data)
-	if err != nil {
-		return nil, err
-	}
-
-	raw, err := clientConfig.RawConfig()
+	clientConfig, err := kubeconfig.Load(data, agentTLSMode)
 	if err != nil {
 		return nil, err
 	}
 
-	if agentTLSMode == config.AgentTLSModeSystemStore && raw.Contexts[raw.CurrentContext] != nil {
-		cluster := raw.Contexts[raw.CurrentContext].Cluster
-		if raw.Clusters[cluster] != nil {
-			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, raw.Clusters[cluster].Server, nil)
-			if err == nil {
-				if resp, err := http.DefaultClient.Do(req); err == nil {
-					resp.Body.Close()
-					raw.Clusters[cluster].CertificateAuthorityData = nil
-				}
-			}
-		}
-	}
-
-	return clientcmd.NewDefaultClientConfig(raw, &clientcmd.ConfigOverrides{}).ClientConfig()
+	return clientConfig.ClientConfig()
 }
 
 func (i *importHandler) checkForConfigChange(cfg *config.Config, cluster *fleet.Cluster, secret *corev1.Secret) error {
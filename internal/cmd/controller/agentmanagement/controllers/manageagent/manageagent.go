@@ -116,7 +116,7 @@ func Register(ctx context.Context,
 }
 
 func (h *handler) onClusterStatusChange(cluster *fleet.Cluster, status fleet.ClusterStatus) (fleet.ClusterStatus, error) {
-	if SkipCluster(cluster) {
+	if SkipCluster(cluster) || PushMode(cluster) {
 		return status, nil
 	}
 
@@ -264,6 +264,10 @@ func (h *handler) resolveNS(namespace, _ string, obj runtime.Object) ([]relatedr
 			//nolint:nilerr // Intentionally ignoring error - "not found" is expected and should trigger reconciliation
 			return []relatedresource.Key{{Name: namespace}}, nil
 		}
+		if PushMode(cluster) {
+			// the agent bundle of a cluster in push mode is removed by OnNamespace
+			return []relatedresource.Key{{Name: namespace}}, nil
+		}
 	}
 	return nil, nil
 }
@@ -294,7 +298,7 @@ func (h *handler) OnNamespace(key string, namespace *corev1.Namespace) (*corev1.
 	var objs []runtime.Object
 
 	for _, cluster := range clusters {
-		if SkipCluster(cluster) {
+		if SkipCluster(cluster) || PushMode(cluster) {
 			continue
 		}
 		logrus.Infof("Update agent bundle for cluster %s/%s", cluster.Namespace, cluster.Name)
@@ -414,3 +418,9 @@ func SkipCluster(cluster *fleet.Cluster) bool {
 	}
 	return false
 }
+
+// PushMode checks if bundles are deployed to the cluster by the fleet
+// controller, in which case no agent is deployed to the cluster.
+func PushMode(cluster *fleet.Cluster) bool {
+	return cluster != nil && cluster.Spec.DeploymentMode == fleet.DeploymentModePush
+}
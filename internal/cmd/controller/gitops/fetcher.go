@@ -0,0 +1,136 @@
+package gitops
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	clog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	command "github.com/rancher/fleet/internal/cmd"
+	"github.com/rancher/fleet/internal/cmd/controller/gitops/reconciler"
+	fcreconciler "github.com/rancher/fleet/internal/cmd/controller/reconciler"
+	"github.com/rancher/fleet/internal/gitcredentials"
+	"github.com/rancher/fleet/internal/ssh"
+	"github.com/rancher/fleet/pkg/git"
+	"github.com/rancher/fleet/pkg/version"
+)
+
+// GitFetcher runs the git fetcher service, which syncs GitRepos in-process, if the gitjob controller runs with
+// --git-fetcher. Each replica syncs the GitRepos of a share of the repository URLs.
+type GitFetcher struct {
+	command.DebugConfig
+	Namespace         string `usage:"namespace to watch" default:"cattle-fleet-system" env:"NAMESPACE"`
+	MetricsAddr       string `name:"metrics-bind-address" default:":8081" usage:"The address the metric endpoint binds to."`
+	DisableMetrics    bool   `name:"disable-metrics" usage:"Disable the metrics server."`
+	ShardID           string `usage:"only manage resources labeled with a specific shard ID" name:"shard-id"`
+	CacheDir          string `name:"cache-dir" default:"/var/cache/fleet/git" usage:"Directory of the cached bare clones of the repositories."`
+	Replicas          int    `default:"1" env:"REPLICAS" usage:"Number of git fetcher replicas."`
+	Replica           int    `env:"REPLICA_INDEX" usage:"Index of this git fetcher replica."`
+	SkipHostKeyChecks bool   `name:"insecure-skip-host-key-checks" usage:"Enable SSH connections to succeed even without matching known_hosts entries. Enabling this will expose SSH operations to man-in-the-middle attacks."`
+}
+
+func FetcherApp(zo *zap.Options) *cobra.Command {
+	zopts = zo
+	return command.Command(&GitFetcher{}, cobra.Command{
+		Version: version.FriendlyVersion(),
+		Use:     "gitfetcher",
+	})
+}
+
+// HelpFunc hides the global flag from the help output
+func (c *GitFetcher) HelpFunc(cmd *cobra.Command, strings []string) {
+	cmd.Parent().HelpFunc()(cmd, strings)
+}
+
+func (g *GitFetcher) PersistentPre(_ *cobra.Command, _ []string) error {
+	if err := g.SetupDebug(); err != nil {
+		return fmt.Errorf("failed to setup debug logging: %w", err)
+	}
+	zopts = g.OverrideZapOpts(zopts)
+
+	return nil
+}
+
+func (g *GitFetcher) Run(cmd *cobra.Command, args []string) error {
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(zopts)))
+	ctx := clog.IntoContext(cmd.Context(), ctrl.Log.WithName("gitfetcher"))
+
+	if g.Replica < 0 || g.Replica >= max(g.Replicas, 1) {
+		return fmt.Errorf("invalid replica index %d for %d replicas", g.Replica, g.Replicas)
+	}
+
+	metricsOpts := metricsserver.Options{BindAddress: g.MetricsAddr}
+	if g.DisableMetrics {
+		metricsOpts.BindAddress = "0"
+	}
+
+	cfg := ctrl.GetConfigOrDie()
+	// replicas sync distinct GitRepos, there is no leader
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+		Scheme:  scheme,
+		Metrics: metricsOpts,
+	})
+	if err != nil {
+		return err
+	}
+
+	var workers int
+	if d := os.Getenv("GITREPO_RECONCILER_WORKERS"); d != "" {
+		w, err := strconv.Atoi(d)
+		if err != nil {
+			setupLog.Error(err, "failed to parse GITREPO_RECONCILER_WORKERS", "value", d)
+		}
+		workers = w
+	}
+
+	if err := os.MkdirAll(g.CacheDir, 0700); err != nil {
+		return err
+	}
+
+	gitFetcher := &git.Fetch{
+		KnownHosts:  ssh.KnownHosts{EnforceHostKeyChecks: !g.SkipHostKeyChecks},
+		Credentials: gitcredentials.NewClientCache(mgr.GetClient(), mgr.GetAPIReader()),
+	}
+	fetcherReconciler := &reconciler.GitFetcherReconciler{
+		Client:     mgr.GetClient(),
+		Scheme:     mgr.GetScheme(),
+		Recorder:   mgr.GetEventRecorderFor("fleet-gitfetcher"),
+		GitFetcher: gitFetcher,
+		Cache:      &git.Cache{Dir: g.CacheDir},
+		ClientFor:  reconciler.ImpersonatingClientFor(cfg, mgr.GetScheme()),
+		ShardID:    g.ShardID,
+		Workers:    workers,
+		Replicas:   g.Replicas,
+		Replica:    g.Replica,
+	}
+
+	configReconciler := &fcreconciler.ConfigReconciler{
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		SystemNamespace: g.Namespace,
+		ShardID:         g.ShardID,
+	}
+
+	if err := fcreconciler.Load(ctx, mgr.GetAPIReader(), g.Namespace); err != nil {
+		setupLog.Error(err, "failed to load config")
+		return err
+	}
+
+	setupLog.Info("starting config controller")
+	if err := configReconciler.SetupWithManager(mgr); err != nil {
+		return err
+	}
+
+	setupLog.Info("starting git fetcher", "replica", g.Replica, "replicas", g.Replicas)
+	if err := fetcherReconciler.SetupWithManager(mgr); err != nil {
+		return err
+	}
+
+	return mgr.Start(ctx)
+}
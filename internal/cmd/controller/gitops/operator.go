@@ -27,6 +27,7 @@ import (
 	"github.com/rancher/fleet/internal/cmd/controller/gitops/reconciler"
 	fcreconciler "github.com/rancher/fleet/internal/cmd/controller/reconciler"
 	"github.com/rancher/fleet/internal/config"
+	"github.com/rancher/fleet/internal/gitcredentials"
 	"github.com/rancher/fleet/internal/metrics"
 	"github.com/rancher/fleet/internal/ssh"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
@@ -59,6 +60,7 @@ type GitOperator struct {
 	ShardID              string `usage:"only manage resources labeled with a specific shard ID" name:"shard-id"`
 	ShardNodeSelector    string `usage:"node selector to apply to jobs based on the shard ID, if any" name:"shard-node-selector"`
 	SkipHostKeyChecks    bool   `name:"insecure-skip-host-key-checks" usage:"Enable SSH connections to succeed even without matching known_hosts entries. Enabling this will expose SSH operations to man-in-the-middle attacks."`
+	GitFetcher           bool   `name:"git-fetcher" usage:"Request syncs from the git fetcher service instead of creating a job per sync."`
 }
 
 func App(zo *zap.Options) *cobra.Command {
@@ -141,6 +143,7 @@ func (g *GitOperator) Run(cmd *cobra.Command, args []string) error {
 	}
 
 	kh := ssh.KnownHosts{EnforceHostKeyChecks: !g.SkipHostKeyChecks}
+	credentials := gitcredentials.NewClientCache(mgr.GetClient(), mgr.GetAPIReader())
 
 	// Add an indexer for the Gitrepo name label as that will make accesses in the cache
 	// faster
@@ -162,11 +165,13 @@ func (g *GitOperator) Run(cmd *cobra.Command, args []string) error {
 		Workers:         workers,
 		ShardID:         g.ShardID,
 		JobNodeSelector: g.ShardNodeSelector,
-		GitFetcher:      &git.Fetch{KnownHosts: kh},
+		GitFetcher:      &git.Fetch{KnownHosts: kh, Credentials: credentials},
 		Clock:           reconciler.RealClock{},
 		Recorder:        mgr.GetEventRecorderFor(fmt.Sprintf("fleet-gitops%s", shardIDSuffix)),
 		SystemNamespace: namespace,
 		KnownHosts:      kh,
+		Credentials:     credentials,
+		Fetcher:         g.GitFetcher,
 	}
 
 	statusReconciler := &reconciler.StatusReconciler{
@@ -176,6 +181,14 @@ func (g *GitOperator) Run(cmd *cobra.Command, args []string) error {
 		Workers: workers,
 	}
 
+	generatorReconciler := &reconciler.GitRepoGeneratorReconciler{
+		Client:      mgr.GetClient(),
+		Scheme:      mgr.GetScheme(),
+		ShardID:     g.ShardID,
+		Workers:     workers,
+		Credentials: credentials,
+	}
+
 	configReconciler := &fcreconciler.ConfigReconciler{
 		Client:          mgr.GetClient(),
 		Scheme:          mgr.GetScheme(),
@@ -190,7 +203,7 @@ func (g *GitOperator) Run(cmd *cobra.Command, args []string) error {
 
 	group, ctx := errgroup.WithContext(ctx)
 	group.Go(func() error {
-		return startWebhook(ctx, namespace, g.Listen, mgr.GetClient(), mgr.GetCache())
+		return startWebhook(ctx, namespace, g.Listen, mgr.GetClient(), mgr.GetCache(), &git.Fetch{KnownHosts: kh, Credentials: credentials})
 	})
 	group.Go(func() error {
 		setupLog.Info("starting config controller")
@@ -208,6 +221,11 @@ func (g *GitOperator) Run(cmd *cobra.Command, args []string) error {
 			return err
 		}
 
+		setupLog.Info("starting gitrepo generator controller")
+		if err = generatorReconciler.SetupWithManager(mgr); err != nil {
+			return err
+		}
+
 		return mgr.Start(ctx)
 	})
 
@@ -232,9 +250,9 @@ func (g *GitOperator) setupMetrics() metricsserver.Options {
 	return metricServerOpts
 }
 
-func startWebhook(ctx context.Context, namespace string, addr string, client client.Client, cacheClient cache.Cache) error {
+func startWebhook(ctx context.Context, namespace string, addr string, client client.Client, cacheClient cache.Cache, fetcher webhook.TagFetcher) error {
 	setupLog.Info("Setting up webhook listener")
-	handler, err := webhook.HandleHooks(ctx, namespace, client, cacheClient)
+	handler, err := webhook.HandleHooks(ctx, namespace, client, cacheClient, fetcher)
 	if err != nil {
 		return fmt.Errorf("webhook handler can't be created: %w", err)
 	}
@@ -0,0 +1,406 @@
+package reconciler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/rancher/fleet/internal/bundlereader"
+	fleetapply "github.com/rancher/fleet/internal/cmd/cli/apply"
+	"github.com/rancher/fleet/internal/names"
+	v1alpha1 "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/archive"
+	"github.com/rancher/fleet/pkg/cert"
+	fleetevent "github.com/rancher/fleet/pkg/event"
+	"github.com/rancher/fleet/pkg/git"
+	"github.com/rancher/fleet/pkg/sharding"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// GitCheckouter checks out a commit of a GitRepo from a cache of its repository.
+type GitCheckouter interface {
+	Checkout(ctx context.Context, gitrepo *v1alpha1.GitRepo, client client.Client, cache *git.Cache, commit, dst string) error
+}
+
+// GitFetcherReconciler syncs the GitRepos, for which the gitjob controller requested a sync in their status. It
+// checks out the requested commit from a persistent cache of bare clones and creates the bundles in-process,
+// impersonating the service account a git job would run as.
+// Replicas of the git fetcher each sync the GitRepos of a share of the repository URLs, so each repository is only
+// cached by one replica.
+type GitFetcherReconciler struct {
+	client.Client
+	Scheme     *runtime.Scheme
+	Recorder   record.EventRecorder
+	GitFetcher GitCheckouter
+	Cache      *git.Cache
+	// Workspace is the directory commits are checked out into, the operating system's temporary directory if empty.
+	Workspace string
+	// ClientFor returns a client impersonating the service account.
+	ClientFor func(namespace, serviceAccount string) (client.Client, error)
+	ShardID   string
+	Workers   int
+	// Replicas is the number of git fetcher replicas and Replica the index of this one.
+	Replicas int
+	Replica  int
+
+	// synced are the IDs of the last requests synced per GitRepo, to avoid syncing twice when the cache still
+	// contains the GitRepo before the result was reported.
+	synced sync.Map
+}
+
+// ImpersonatingClientFor returns a function creating clients from the config, which impersonate service accounts.
+func ImpersonatingClientFor(cfg *rest.Config, scheme *runtime.Scheme) func(namespace, serviceAccount string) (client.Client, error) {
+	return func(namespace, serviceAccount string) (client.Client, error) {
+		impersonated := rest.CopyConfig(cfg)
+		impersonated.Impersonate = rest.ImpersonationConfig{
+			UserName: fmt.Sprintf("system:serviceaccount:%s:%s", namespace, serviceAccount),
+		}
+		return client.New(impersonated, client.Options{Scheme: scheme})
+	}
+}
+
+func (r *GitFetcherReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("gitfetcher").
+		For(&v1alpha1.GitRepo{},
+			builder.WithPredicates(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+				gitrepo, ok := obj.(*v1alpha1.GitRepo)
+				return ok && r.assigned(gitrepo) && fetcherSyncPending(gitrepo)
+			})),
+		).
+		WithEventFilter(sharding.FilterByShardID(r.ShardID)).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.Workers}).
+		Complete(r)
+}
+
+// Reconcile syncs the commit requested in the status of the GitRepo and reports the result in its status, from
+// which the gitjob controller sets the status of the GitRepo.
+func (r *GitFetcherReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithName("gitfetcher")
+	gitrepo := &v1alpha1.GitRepo{}
+	if err := r.Get(ctx, req.NamespacedName, gitrepo); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !gitrepo.DeletionTimestamp.IsZero() || !r.assigned(gitrepo) || !fetcherSyncPending(gitrepo) {
+		return ctrl.Result{}, nil
+	}
+
+	request := *gitrepo.Status.FetcherRequest
+	if id, ok := r.synced.Load(req.NamespacedName); ok && id == request.ID {
+		return ctrl.Result{}, nil
+	}
+
+	logger = logger.WithValues("request", request.ID, "commit", request.Commit)
+	ctx = log.IntoContext(ctx, logger)
+
+	if err := r.setResult(ctx, gitrepo, &v1alpha1.GitFetcherResult{ID: request.ID, Status: status.InProgressStatus.String()}); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	logger.V(1).Info("Syncing GitRepo")
+	result := &v1alpha1.GitFetcherResult{ID: request.ID, Status: status.CurrentStatus.String()}
+	results := &fleetapply.Results{}
+	if err := r.sync(ctx, gitrepo, request, results); err != nil {
+		logger.Error(err, "Failed to sync GitRepo")
+		r.Recorder.Event(gitrepo, fleetevent.Warning, "FailedToSync", err.Error())
+		result.Status = status.FailedStatus.String()
+		result.Message = err.Error()
+	}
+	result.Paths = results.Paths()
+
+	if err := r.setResult(ctx, gitrepo, result); err != nil {
+		return ctrl.Result{}, err
+	}
+	r.synced.Store(req.NamespacedName, request.ID)
+
+	return ctrl.Result{}, nil
+}
+
+// setResult patches the result of the git fetcher into the status of the GitRepo, without touching the fields owned
+// by the gitjob controller.
+func (r *GitFetcherReconciler) setResult(ctx context.Context, gitrepo *v1alpha1.GitRepo, result *v1alpha1.GitFetcherResult) error {
+	orig := gitrepo.DeepCopy()
+	gitrepo.Status.FetcherResult = result
+	return r.Status().Patch(ctx, gitrepo, client.MergeFrom(orig))
+}
+
+// sync checks out the requested commit and creates the bundles from it, like fleet apply does in a git job. The
+// result of each path is collected in results.
+func (r *GitFetcherReconciler) sync(ctx context.Context, gitrepo *v1alpha1.GitRepo, request v1alpha1.GitFetcherRequest, results *fleetapply.Results) error {
+	workspace, err := os.MkdirTemp(r.Workspace, "gitfetcher-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workspace)
+
+	root := filepath.Join(workspace, "source")
+	if err := r.checkout(ctx, gitrepo, request, root); err != nil {
+		return err
+	}
+
+	opts, err := r.applyOptions(ctx, gitrepo, request, workspace)
+	if err != nil {
+		return err
+	}
+	opts.Root = root
+	opts.Results = results
+
+	if request.PreviousCommit != "" {
+		paths, err := r.Cache.ChangedPaths(git.CacheScope(gitrepo), gitrepo.Spec.Repo, request.PreviousCommit, request.Commit)
+		if err != nil {
+			log.FromContext(ctx).Info("Failed to compute changed paths, all bundles will be regenerated", "previousCommit", request.PreviousCommit, "error", err)
+		} else {
+			opts.Incremental = true
+			opts.ChangedPaths = paths
+		}
+	}
+
+	paths, _, err := bundlePaths(gitrepo)
+	if err != nil {
+		return err
+	}
+
+	c, err := r.ClientFor(gitrepo.Namespace, names.SafeConcatName("git", gitrepo.Name))
+	if err != nil {
+		return err
+	}
+
+	retries, err := fleetapply.GetOnConflictRetries()
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed parsing env variable, using defaults", "name", fleetapply.FleetApplyConflictRetriesEnv)
+	}
+	for range retries {
+		if opts.DrivenScan {
+			err = fleetapply.CreateBundlesDriven(ctx, c, r.Recorder, gitrepo.Name, paths, opts)
+		} else {
+			err = fleetapply.CreateBundles(ctx, c, r.Recorder, gitrepo.Name, paths, opts)
+		}
+		if !apierrors.IsConflict(err) {
+			break
+		}
+	}
+
+	return err
+}
+
+// checkout checks out the requested commit, or downloads the content of an archive source, into dst. Additional
+// sources are checked out into the subdirectories they are mounted at.
+func (r *GitFetcherReconciler) checkout(ctx context.Context, gitrepo *v1alpha1.GitRepo, request v1alpha1.GitFetcherRequest, dst string) error {
+	commit := request.Commit
+	if len(gitrepo.Spec.Sources) > 0 {
+		commit = request.RepoCommit
+	}
+
+	if err := r.checkoutRepo(ctx, gitrepo, commit, dst); err != nil {
+		return err
+	}
+
+	return r.checkoutSources(ctx, gitrepo, request, dst)
+}
+
+func (r *GitFetcherReconciler) checkoutRepo(ctx context.Context, gitrepo *v1alpha1.GitRepo, commit, dst string) error {
+	if gitrepo.Spec.Archive == nil {
+		return r.GitFetcher.Checkout(ctx, gitrepo, r.Client, r.Cache, commit, dst)
+	}
+
+	s, err := archive.NewSource(ctx, gitrepo, r.Client)
+	if err != nil {
+		return err
+	}
+	if gitrepo.Spec.Archive.Type == v1alpha1.ArchiveTypeOCI {
+		s.Tag, s.TagSemver = ociTag(gitrepo), ""
+		s.ExpectedRevision = commit
+	}
+	revision, err := s.Download(ctx, dst)
+	if err != nil {
+		return err
+	}
+	if revision != commit {
+		// the content changed since it was polled, the next poll requests a sync of the new revision
+		log.FromContext(ctx).V(1).Info("Downloaded newer revision of archive", "revision", revision)
+	}
+
+	return nil
+}
+
+// checkoutSources checks out the additional sources of the gitrepo at the requested commits and moves their paths
+// to the subdirectories of the repository named after them.
+func (r *GitFetcherReconciler) checkoutSources(ctx context.Context, gitrepo *v1alpha1.GitRepo, request v1alpha1.GitFetcherRequest, dst string) error {
+	if err := ValidateSources(gitrepo.Spec.Sources); err != nil {
+		return err
+	}
+
+	for _, source := range gitrepo.Spec.Sources {
+		commit := sourceCommit(request.Sources, source.Name)
+		if commit == "" {
+			return fmt.Errorf("no commit of source %q was requested", source.Name)
+		}
+
+		tmp, err := os.MkdirTemp(filepath.Dir(dst), "source-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+
+		if err := r.GitFetcher.Checkout(ctx, sourceGitRepo(gitrepo, source, commit), r.Client, r.Cache, commit, tmp); err != nil {
+			return fmt.Errorf("failed to check out source %q: %w", source.Name, err)
+		}
+
+		target := filepath.Join(dst, source.Name)
+		if err := os.RemoveAll(target); err != nil {
+			return err
+		}
+		if err := os.Rename(filepath.Join(tmp, filepath.FromSlash(source.Path)), target); err != nil {
+			return fmt.Errorf("failed to mount path %q of source %q: %w", source.Path, source.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// applyOptions returns the options fleet apply is called with in a git job for the GitRepo. Files, like the
+// targets file, are written to the workspace.
+func (r *GitFetcherReconciler) applyOptions(ctx context.Context, gitrepo *v1alpha1.GitRepo, request v1alpha1.GitFetcherRequest, workspace string) (fleetapply.Options, error) {
+	configMap, err := newTargetsConfigMap(gitrepo)
+	if err != nil {
+		return fleetapply.Options{}, err
+	}
+	targetsFile := filepath.Join(workspace, "targets.yaml")
+	if err := os.WriteFile(targetsFile, configMap.BinaryData["targets.yaml"], 0600); err != nil {
+		return fleetapply.Options{}, err
+	}
+
+	_, drivenScanSeparator, err := bundlePaths(gitrepo)
+	if err != nil {
+		return fleetapply.Options{}, err
+	}
+
+	bundleLabels := labels.Merge(gitrepo.Labels, map[string]string{
+		v1alpha1.RepoLabel:   gitrepo.Name,
+		v1alpha1.CommitLabel: request.Commit,
+	})
+
+	maxConcurrency, err := fleetapply.GetBundleCreationMaxConcurrency()
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed parsing env variable, using defaults", "name", fleetapply.BundleCreationMaxConcurrencyEnv)
+	}
+
+	opts := fleetapply.Options{
+		Namespace:                    gitrepo.Namespace,
+		TargetsFile:                  targetsFile,
+		ServiceAccount:               gitrepo.Spec.ServiceAccount,
+		TargetNamespace:              gitrepo.Spec.TargetNamespace,
+		Paused:                       gitrepo.Spec.Paused,
+		Labels:                       bundleLabels,
+		SyncGeneration:               gitrepo.Spec.ForceSyncGeneration,
+		KeepResources:                gitrepo.Spec.KeepResources,
+		DeleteNamespace:              gitrepo.Spec.DeleteNamespace,
+		OCIRegistrySecret:            gitrepo.Spec.OCIRegistrySecret,
+		DrivenScan:                   len(gitrepo.Spec.Bundles) > 0,
+		DrivenScanSeparator:          drivenScanSeparator,
+		BundleCreationMaxConcurrency: maxConcurrency,
+	}
+	if gitrepo.Spec.CorrectDrift != nil && gitrepo.Spec.CorrectDrift.Enabled {
+		opts.CorrectDrift = true
+		opts.CorrectDriftForce = gitrepo.Spec.CorrectDrift.Force
+		opts.CorrectDriftKeepFailHistory = gitrepo.Spec.CorrectDrift.KeepFailHistory
+	}
+
+	if err := r.addHelmAuth(ctx, gitrepo, &opts); err != nil {
+		return fleetapply.Options{}, err
+	}
+
+	return opts, nil
+}
+
+// addHelmAuth adds the credentials from the Helm secrets of the GitRepo to the options, falling back to
+// Rancher-configured CA bundles, like the volumes of a git job do.
+func (r *GitFetcherReconciler) addHelmAuth(ctx context.Context, gitrepo *v1alpha1.GitRepo, opts *fleetapply.Options) error {
+	secretName := gitrepo.Spec.HelmSecretName
+	if gitrepo.Spec.HelmSecretNameForPaths != "" {
+		secretName = gitrepo.Spec.HelmSecretNameForPaths
+	}
+
+	secret := &corev1.Secret{}
+	if secretName != "" {
+		err := r.Get(ctx, types.NamespacedName{Namespace: gitrepo.Namespace, Name: secretName}, secret)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	if gitrepo.Spec.HelmSecretNameForPaths != "" {
+		var authByPath map[string]bundlereader.Auth
+		if err := yaml.NewYAMLToJSONDecoder(bytes.NewBuffer(secret.Data["secrets-path.yaml"])).Decode(&authByPath); err != nil {
+			return fmt.Errorf("failed to read Helm credentials by path: %w", err)
+		}
+		opts.AuthByPath = authByPath
+		return nil
+	}
+
+	if gitrepo.Spec.HelmSecretName != "" {
+		opts.Auth = bundlereader.Auth{
+			Username:      string(secret.Data["username"]),
+			Password:      string(secret.Data["password"]),
+			CABundle:      secret.Data["cacerts"],
+			SSHPrivateKey: secret.Data["ssh-privatekey"],
+		}
+		// in case of errors parsing the values, they are considered false as those values are security related
+		opts.Auth.InsecureSkipVerify, _ = strconv.ParseBool(string(secret.Data["insecureSkipVerify"]))
+		opts.Auth.BasicHTTP, _ = strconv.ParseBool(string(secret.Data["basicHTTP"]))
+	}
+
+	if len(opts.Auth.CABundle) == 0 {
+		cab, err := cert.GetRancherCABundle(ctx, r.Client)
+		if err != nil {
+			return err
+		}
+		opts.Auth.CABundle = cab
+	}
+	if gitrepo.Spec.HelmSecretName != "" || len(opts.Auth.CABundle) > 0 {
+		opts.HelmRepoURLRegex = gitrepo.Spec.HelmRepoURLRegex
+	}
+
+	return nil
+}
+
+// assigned returns true if the GitRepo's repository is synced by this replica.
+func (r *GitFetcherReconciler) assigned(gitrepo *v1alpha1.GitRepo) bool {
+	return fetcherReplica(gitrepo.Spec.Repo, r.Replicas) == r.Replica
+}
+
+// fetcherReplica returns the index of the git fetcher replica, which syncs the repository.
+func fetcherReplica(repo string, replicas int) int {
+	if replicas <= 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(repo))
+	return int(h.Sum32() % uint32(replicas)) //nolint:gosec // replicas is positive
+}
+
+// fetcherSyncPending returns true if the gitjob controller requested a sync, which the git fetcher did not finish
+// yet. Syncs in progress are pending, too, so they are restarted if a git fetcher replica restarts.
+func fetcherSyncPending(gitrepo *v1alpha1.GitRepo) bool {
+	return gitrepo.Status.FetcherRequest != nil && gitrepo.Status.FetcherRequest.Commit != "" && !fetcherSyncDone(gitrepo)
+}
@@ -0,0 +1,337 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/rancher/fleet/internal/gitcredentials"
+	fleetgithub "github.com/rancher/fleet/internal/github"
+	"github.com/rancher/fleet/internal/gitorg"
+	"github.com/rancher/fleet/internal/names"
+	v1alpha1 "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/durations"
+	"github.com/rancher/fleet/pkg/sharding"
+	"github.com/rancher/wrangler/v3/pkg/condition"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const defaultFleetYAMLPath = "fleet.yaml"
+
+// GitRepoGeneratorReconciler scans Git organizations for repositories and creates a GitRepo for each repository
+// selected by a GitRepoGenerator. The created GitRepos are reconciled like any other GitRepo, which includes the
+// GitRepoRestrictions of their namespace.
+type GitRepoGeneratorReconciler struct {
+	client.Client
+	Scheme  *runtime.Scheme
+	Workers int
+	ShardID string
+	// Credentials mints tokens for client secrets configuring a credential provider.
+	Credentials *gitcredentials.Cache
+}
+
+func (r *GitRepoGeneratorReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		// scans are scheduled by requeueing, status updates must not trigger them
+		For(&v1alpha1.GitRepoGenerator{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		WithEventFilter(sharding.FilterByShardID(r.ShardID)).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.Workers}).
+		Named("GitRepoGenerator").
+		Complete(r)
+}
+
+// Reconcile scans the organization of the GitRepoGenerator, if its interval passed or its spec changed, and
+// creates, updates and deletes its GitRepos. GitRepos are owned by the GitRepoGenerator and garbage collected with
+// it.
+func (r *GitRepoGeneratorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithName("gitrepo-generator")
+
+	gen := &v1alpha1.GitRepoGenerator{}
+	if err := r.Get(ctx, req.NamespacedName, gen); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !gen.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	interval := durations.DefaultGeneratorInterval
+	if gen.Spec.Interval != nil && gen.Spec.Interval.Duration > 0 {
+		interval = gen.Spec.Interval.Duration
+	}
+	if gen.Status.ObservedGeneration == gen.Generation {
+		if next := gen.Status.LastScanTime.Add(interval); time.Now().Before(next) {
+			return ctrl.Result{RequeueAfter: time.Until(next)}, nil
+		}
+	}
+
+	repos, err := r.scan(ctx, gen)
+	if err == nil {
+		err = r.syncGitRepos(ctx, gen, repos)
+	}
+	if err != nil {
+		logger.Error(err, "Failed to generate GitRepos")
+	} else {
+		logger.V(1).Info("Generated GitRepos", "count", len(gen.Status.Repositories))
+		gen.Status.LastScanTime = metav1.Now()
+		gen.Status.ObservedGeneration = gen.Generation
+	}
+	condition.Cond(v1alpha1.GitRepoGeneratorScannedCondition).SetError(&gen.Status, "", err)
+
+	if updateErr := r.updateStatus(ctx, req.NamespacedName, gen.Status); updateErr != nil {
+		return ctrl.Result{}, errors.Join(err, updateErr)
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: interval}, nil
+}
+
+// scan returns the repositories of the organization selected by the filters of the generator, which contain the
+// fleet.yaml file.
+func (r *GitRepoGeneratorReconciler) scan(ctx context.Context, gen *v1alpha1.GitRepoGenerator) ([]gitorg.Repository, error) {
+	filters, err := compileRepoFilters(gen.Spec.Filters)
+	if err != nil {
+		return nil, err
+	}
+	token, err := r.apiToken(ctx, gen)
+	if err != nil {
+		return nil, err
+	}
+	scanner, err := gitorg.New(gen.Spec.Provider, gitorg.Options{
+		URL:          gen.Spec.URL,
+		Organization: gen.Spec.Organization,
+		Token:        token,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	all, err := scanner.Repositories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories of %s: %w", gen.Spec.Organization, err)
+	}
+
+	path := gen.Spec.FleetYAMLPath
+	if path == "" {
+		path = defaultFleetYAMLPath
+	}
+	var repos []gitorg.Repository
+	for _, repo := range all {
+		if repo.Archived || !matchesRepoFilters(repo, filters) {
+			continue
+		}
+		ref := gitRepoBranch(gen, repo)
+		if ref == "" {
+			ref = repo.DefaultBranch
+		}
+		if ref == "" {
+			// empty repository
+			continue
+		}
+		found, err := scanner.HasFile(ctx, repo, ref, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up %s in repository %s: %w", path, repo.FullName, err)
+		}
+		if found {
+			repos = append(repos, repo)
+		}
+	}
+	return repos, nil
+}
+
+// apiToken returns the token for the API of the provider from the client secret of the generator.
+func (r *GitRepoGeneratorReconciler) apiToken(ctx context.Context, gen *v1alpha1.GitRepoGenerator) (string, error) {
+	if gen.Spec.ClientSecretName == "" {
+		return "", nil
+	}
+	var secret corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Namespace: gen.Namespace, Name: gen.Spec.ClientSecretName}, &secret); err != nil {
+		return "", err
+	}
+
+	switch {
+	case gitcredentials.HasProvider(&secret):
+		if r.Credentials == nil {
+			return "", fmt.Errorf("credential providers are not enabled, cannot use secret %s/%s", secret.Namespace, secret.Name)
+		}
+		token, err := r.Credentials.Token(ctx, &secret)
+		if err != nil {
+			return "", fmt.Errorf("failed to get token from credential provider of secret %s/%s: %w", secret.Namespace, secret.Name, err)
+		}
+		return token.Password, nil
+	case fleetgithub.HasGitHubAppKeys(&secret):
+		auth, err := fleetgithub.GetGithubAppAuthFromSecret(&secret, fleetgithub.DefaultAppAuthGetter{})
+		if err != nil {
+			return "", err
+		}
+		return auth.Password, nil
+	case secret.Type == corev1.SecretTypeBasicAuth:
+		return string(secret.Data[corev1.BasicAuthPasswordKey]), nil
+	default:
+		return "", fmt.Errorf("secret %s/%s is neither a basic-auth secret nor holds the keys of a GitHub App", secret.Namespace, secret.Name)
+	}
+}
+
+// syncGitRepos creates or updates the GitRepos of the repositories and deletes the GitRepos of the generator for
+// other repositories. It records the repositories in the status of the generator.
+func (r *GitRepoGeneratorReconciler) syncGitRepos(ctx context.Context, gen *v1alpha1.GitRepoGenerator, repos []gitorg.Repository) error {
+	statuses := make([]v1alpha1.GitRepoGeneratorRepository, 0, len(repos))
+	for _, repo := range repos {
+		gitrepo := &v1alpha1.GitRepo{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: gen.Namespace,
+				Name:      generatedGitRepoName(gen, repo),
+			},
+		}
+		_, err := controllerutil.CreateOrUpdate(ctx, r.Client, gitrepo, func() error {
+			if gitrepo.ResourceVersion != "" && !metav1.IsControlledBy(gitrepo, gen) {
+				return fmt.Errorf("GitRepo %s/%s for repository %s exists and is not owned by the generator", gitrepo.Namespace, gitrepo.Name, repo.FullName)
+			}
+			r.applyTemplate(gen, repo, gitrepo)
+			return controllerutil.SetControllerReference(gen, gitrepo, r.Scheme)
+		})
+		if err != nil {
+			return err
+		}
+		statuses = append(statuses, v1alpha1.GitRepoGeneratorRepository{
+			Name:        repo.FullName,
+			Repo:        gitrepo.Spec.Repo,
+			GitRepoName: gitrepo.Name,
+		})
+	}
+
+	var gitrepos v1alpha1.GitRepoList
+	if err := r.List(ctx, &gitrepos, client.InNamespace(gen.Namespace), client.MatchingLabels{v1alpha1.GitRepoGeneratorLabel: gen.Name}); err != nil {
+		return err
+	}
+	for _, gitrepo := range gitrepos.Items {
+		if !metav1.IsControlledBy(&gitrepo, gen) || slices.ContainsFunc(statuses, func(s v1alpha1.GitRepoGeneratorRepository) bool {
+			return s.GitRepoName == gitrepo.Name
+		}) {
+			continue
+		}
+		if err := r.Delete(ctx, &gitrepo); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	gen.Status.Repositories = statuses
+	gen.Status.GitRepoCount = len(statuses)
+	return nil
+}
+
+// applyTemplate sets the labels, annotations and spec of the GitRepo of the repository from the template of the
+// generator. Labels and annotations set by others are kept.
+func (r *GitRepoGeneratorReconciler) applyTemplate(gen *v1alpha1.GitRepoGenerator, repo gitorg.Repository, gitrepo *v1alpha1.GitRepo) {
+	if gitrepo.Labels == nil {
+		gitrepo.Labels = map[string]string{}
+	}
+	if shard, ok := gen.Labels[sharding.ShardingRefLabel]; ok {
+		gitrepo.Labels[sharding.ShardingRefLabel] = shard
+	}
+	for k, v := range gen.Spec.Template.Labels {
+		gitrepo.Labels[k] = v
+	}
+	gitrepo.Labels[v1alpha1.GitRepoGeneratorLabel] = gen.Name
+
+	if len(gen.Spec.Template.Annotations) > 0 && gitrepo.Annotations == nil {
+		gitrepo.Annotations = map[string]string{}
+	}
+	for k, v := range gen.Spec.Template.Annotations {
+		gitrepo.Annotations[k] = v
+	}
+
+	spec := gen.Spec.Template.Spec.DeepCopy()
+	spec.Repo = repo.CloneURL
+	if gen.Spec.SSH {
+		spec.Repo = repo.SSHURL
+	}
+	spec.Branch = gitRepoBranch(gen, repo)
+	gitrepo.Spec = *spec
+}
+
+// gitRepoBranch returns the branch the GitRepo of the repository follows, or an empty string if the template selects
+// a revision or tags instead.
+func gitRepoBranch(gen *v1alpha1.GitRepoGenerator, repo gitorg.Repository) string {
+	spec := gen.Spec.Template.Spec
+	switch {
+	case spec.Branch != "":
+		return spec.Branch
+	case spec.Revision != "" || spec.TagSemver != "":
+		return ""
+	default:
+		return repo.DefaultBranch
+	}
+}
+
+// generatedGitRepoName returns a name for the GitRepo of the repository, which is unique for the generator.
+func generatedGitRepoName(gen *v1alpha1.GitRepoGenerator, repo gitorg.Repository) string {
+	return names.HelmReleaseName(gen.Name + "-" + repo.FullName)
+}
+
+func (r *GitRepoGeneratorReconciler) updateStatus(ctx context.Context, key types.NamespacedName, status v1alpha1.GitRepoGeneratorStatus) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		t := &v1alpha1.GitRepoGenerator{}
+		if err := r.Get(ctx, key, t); err != nil {
+			return err
+		}
+		t.Status = status
+		return r.Status().Update(ctx, t)
+	})
+}
+
+type repoFilter struct {
+	name   *regexp.Regexp
+	topics []string
+}
+
+func compileRepoFilters(filters []v1alpha1.GitRepoGeneratorFilter) ([]repoFilter, error) {
+	compiled := make([]repoFilter, 0, len(filters))
+	for _, f := range filters {
+		c := repoFilter{topics: f.Topics}
+		if f.NamePattern != "" {
+			re, err := regexp.Compile(f.NamePattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid name pattern %q: %w", f.NamePattern, err)
+			}
+			c.name = re
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+// matchesRepoFilters returns true if the repository matches any of the filters, or if there are no filters.
+func matchesRepoFilters(repo gitorg.Repository, filters []repoFilter) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	for _, f := range filters {
+		if f.name != nil && !f.name.MatchString(repo.Name) {
+			continue
+		}
+		if slices.ContainsFunc(f.topics, func(topic string) bool { return !slices.Contains(repo.Topics, topic) }) {
+			continue
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package reconciler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	fleetv1 "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// fakeGitHubOrg serves the repositories of the "example" organization. Only repositories in withFleetYAML contain a
+// fleet.yaml on their default branch.
+func fakeGitHubOrg(t *testing.T, repos []map[string]any, withFleetYAML ...string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /orgs/example/repos", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer api-token" {
+			t.Errorf("unexpected authorization header %q", r.Header.Get("Authorization"))
+		}
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = w.Write([]byte(`[]`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(repos)
+	})
+	mux.HandleFunc("GET /repos/example/{name}/contents/fleet.yaml", func(w http.ResponseWriter, r *http.Request) {
+		for _, name := range withFleetYAML {
+			if r.PathValue("name") == name {
+				_, _ = w.Write([]byte(`{}`))
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	return httptest.NewServer(mux)
+}
+
+func githubRepo(name string, topics ...string) map[string]any {
+	return map[string]any{
+		"name":           name,
+		"full_name":      "example/" + name,
+		"clone_url":      "https://github.com/example/" + name + ".git",
+		"ssh_url":        "git@github.com:example/" + name + ".git",
+		"default_branch": "main",
+		"topics":         topics,
+	}
+}
+
+func TestGitRepoGeneratorReconcile(t *testing.T) {
+	srv := fakeGitHubOrg(t, []map[string]any{
+		githubRepo("app-frontend", "fleet"),
+		githubRepo("app-backend", "fleet"),
+		githubRepo("app-docs"),
+		githubRepo("infra", "fleet"),
+	}, "app-frontend", "app-docs", "infra")
+	defer srv.Close()
+
+	scheme := runtime.NewScheme()
+	utilruntime.Must(corev1.AddToScheme(scheme))
+	utilruntime.Must(fleetv1.AddToScheme(scheme))
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "fleet-default"},
+		Type:       corev1.SecretTypeBasicAuth,
+		Data:       map[string][]byte{corev1.BasicAuthPasswordKey: []byte("api-token")},
+	}
+	gen := &fleetv1.GitRepoGenerator{
+		ObjectMeta: metav1.ObjectMeta{Name: "apps", Namespace: "fleet-default", Generation: 1, UID: "gen-uid"},
+		Spec: fleetv1.GitRepoGeneratorSpec{
+			Provider:         fleetv1.GitRepoGeneratorProviderGitHub,
+			URL:              srv.URL,
+			Organization:     "example",
+			ClientSecretName: "api",
+			Filters: []fleetv1.GitRepoGeneratorFilter{
+				{NamePattern: "^app-", Topics: []string{"fleet"}},
+			},
+			Template: fleetv1.GitRepoGeneratorTemplate{
+				Labels: map[string]string{"team": "apps"},
+				Spec:   fleetv1.GitRepoSpec{Paths: []string{"deploy"}},
+			},
+		},
+	}
+	// GitRepo of a repository, which no longer contains a fleet.yaml
+	stale := &fleetv1.GitRepo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stale",
+			Namespace: "fleet-default",
+			Labels:    map[string]string{fleetv1.GitRepoGeneratorLabel: "apps"},
+		},
+	}
+	utilruntime.Must(ctrl.SetControllerReference(gen, stale, scheme))
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(secret, gen, stale).
+		WithStatusSubresource(&fleetv1.GitRepoGenerator{}).
+		Build()
+	r := GitRepoGeneratorReconciler{Client: c, Scheme: scheme}
+
+	key := types.NamespacedName{Namespace: "fleet-default", Name: "apps"}
+	res, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RequeueAfter == 0 {
+		t.Error("expected the next scan to be scheduled")
+	}
+
+	var gitrepos fleetv1.GitRepoList
+	if err := c.List(context.Background(), &gitrepos, client.InNamespace("fleet-default")); err != nil {
+		t.Fatal(err)
+	}
+	if len(gitrepos.Items) != 1 {
+		t.Fatalf("expected a single GitRepo, got %d", len(gitrepos.Items))
+	}
+	gitrepo := gitrepos.Items[0]
+	expectedSpec := fleetv1.GitRepoSpec{
+		Repo:   "https://github.com/example/app-frontend.git",
+		Branch: "main",
+		Paths:  []string{"deploy"},
+	}
+	if !cmp.Equal(gitrepo.Spec, expectedSpec) {
+		t.Errorf("unexpected GitRepo spec: %s", cmp.Diff(expectedSpec, gitrepo.Spec))
+	}
+	if gitrepo.Labels["team"] != "apps" || gitrepo.Labels[fleetv1.GitRepoGeneratorLabel] != "apps" {
+		t.Errorf("unexpected GitRepo labels: %v", gitrepo.Labels)
+	}
+	if !metav1.IsControlledBy(&gitrepo, gen) {
+		t.Error("expected GitRepo to be owned by the generator")
+	}
+
+	if err := c.Get(context.Background(), key, gen); err != nil {
+		t.Fatal(err)
+	}
+	expectedRepos := []fleetv1.GitRepoGeneratorRepository{{
+		Name:        "example/app-frontend",
+		Repo:        "https://github.com/example/app-frontend.git",
+		GitRepoName: gitrepo.Name,
+	}}
+	if !cmp.Equal(gen.Status.Repositories, expectedRepos) || gen.Status.GitRepoCount != 1 {
+		t.Errorf("unexpected status: %s", cmp.Diff(expectedRepos, gen.Status.Repositories))
+	}
+	if gen.Status.ObservedGeneration != 1 || gen.Status.LastScanTime.IsZero() {
+		t.Errorf("expected scan to be recorded in status, got %+v", gen.Status)
+	}
+
+	// A GitRepo not created by the generator is not taken over.
+	gitrepo.OwnerReferences = nil
+	if err := c.Update(context.Background(), &gitrepo); err != nil {
+		t.Fatal(err)
+	}
+	gen.Generation = 2
+	if err := c.Update(context.Background(), gen); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err == nil {
+		t.Error("expected error for GitRepo not owned by the generator")
+	}
+}
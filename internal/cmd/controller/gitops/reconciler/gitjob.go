@@ -13,6 +13,7 @@ import (
 	"github.com/go-logr/logr"
 	fleetapply "github.com/rancher/fleet/internal/cmd/cli/apply"
 	"github.com/rancher/fleet/internal/config"
+	"github.com/rancher/fleet/internal/gitcredentials"
 	fleetgithub "github.com/rancher/fleet/internal/github"
 	"github.com/rancher/fleet/internal/names"
 	"github.com/rancher/fleet/internal/ocistorage"
@@ -39,12 +40,17 @@ const (
 	bundleCAVolumeName        = "additional-ca"
 	bundleCAFile              = "additional-ca.crt"
 	gitCredentialVolumeName   = "git-credential" // #nosec G101 this is not a credential
+	gitTokenVolumeName        = "git-token"      // #nosec G101 this is not a credential
 	ociRegistryAuthVolumeName = "oci-auth"
 	gitClonerVolumeName       = "git-cloner"
 	emptyDirVolumeName        = "git-cloner-empty-dir"
 
 	fleetHomeDir = "/fleet-home"
 
+	// changedPathsFile is written by the git cloner into the cloned repository's .git directory, which fleet apply
+	// does not scan for bundles.
+	changedPathsFile = ".git/fleet-changed-paths"
+
 	bundleOptionsSeparatorChars = ":,|?<>"
 )
 
@@ -54,7 +60,9 @@ type helmSecretOptions struct {
 	BasicHTTP       bool
 }
 
-func (r *GitJobReconciler) createJobAndResources(ctx context.Context, gitrepo *v1alpha1.GitRepo, logger logr.Logger) error {
+// createJobAndResources creates the git job and the resources it needs. If previousCommit is set, the job only
+// regenerates the bundles affected by the changes since that commit.
+func (r *GitJobReconciler) createJobAndResources(ctx context.Context, gitrepo *v1alpha1.GitRepo, logger logr.Logger, previousCommit string) error {
 	logger.V(1).Info("Creating Git job resources")
 
 	if err := r.createJobRBAC(ctx, gitrepo); err != nil {
@@ -63,10 +71,13 @@ func (r *GitJobReconciler) createJobAndResources(ctx context.Context, gitrepo *v
 	if err := r.createTargetsConfigMap(ctx, gitrepo); err != nil {
 		return fmt.Errorf("failed to create targets config map for git job: %w", err)
 	}
+	if err := r.createResultsConfigMap(ctx, gitrepo); err != nil {
+		return fmt.Errorf("failed to create results config map for git job: %w", err)
+	}
 	if _, err := r.createCABundleSecret(ctx, gitrepo, caBundleName(gitrepo)); err != nil {
 		return fmt.Errorf("failed to create cabundle secret for git job: %w", err)
 	}
-	if err := r.createJob(ctx, gitrepo); err != nil {
+	if err := r.createJob(ctx, gitrepo, previousCommit); err != nil {
 		return fmt.Errorf("error creating git job: %w", err)
 	}
 
@@ -91,6 +102,26 @@ func (r *GitJobReconciler) createTargetsConfigMap(ctx context.Context, gitrepo *
 	return err
 }
 
+// createResultsConfigMap creates the config map, in which fleet apply stores the result of each path. The job is
+// only allowed to update it, so it is created before the job. An existing config map, which is not controlled by the
+// gitrepo, is not adopted, as the results are used for the status of the gitrepo.
+func (r *GitJobReconciler) createResultsConfigMap(ctx context.Context, gitrepo *v1alpha1.GitRepo) error {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fleetapply.ResultsConfigMapName(gitrepo.Name),
+			Namespace: gitrepo.Namespace,
+		},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, configMap, func() error {
+		if configMap.ResourceVersion != "" && !metav1.IsControlledBy(configMap, gitrepo) {
+			return fmt.Errorf("config map %s/%s exists and is not controlled by the gitrepo", configMap.Namespace, configMap.Name)
+		}
+		return controllerutil.SetControllerReference(gitrepo, configMap, r.Scheme)
+	})
+
+	return err
+}
+
 // createCABundleSecret creates a CA bundle secret, if the provided source contains data.
 // That provided source may be the CABundle field of the provided gitrepo (if the provided name matches the CA bundle
 // name expected for the gitrepo, and that CABundle field is non-empty), or Rancher-configured secrets in all other cases.
@@ -163,8 +194,38 @@ func (r *GitJobReconciler) createCABundleSecret(ctx context.Context, gitrepo *v1
 	return true, nil
 }
 
-func (r *GitJobReconciler) createJob(ctx context.Context, gitRepo *v1alpha1.GitRepo) error {
-	job, err := r.newGitJob(ctx, gitRepo)
+// createTokenSecret mints a token with the credential provider configured by the client secret and stores it in a
+// secret owned by the gitrepo. That secret is mounted into the git cloner, instead of the client secret.
+func (r *GitJobReconciler) createTokenSecret(ctx context.Context, obj *v1alpha1.GitRepo, clientSecret *corev1.Secret) (*gitcredentials.Token, error) {
+	if r.Credentials == nil {
+		return nil, fmt.Errorf("credential providers are not enabled, cannot use secret %s/%s", clientSecret.Namespace, clientSecret.Name)
+	}
+	token, err := r.Credentials.Token(ctx, clientSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token from credential provider of secret %s/%s: %w", clientSecret.Namespace, clientSecret.Name, err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: obj.Namespace,
+			Name:      tokenSecretName(obj),
+		},
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		secret.Data = map[string][]byte{
+			corev1.BasicAuthPasswordKey: []byte(token.Password),
+		}
+		return controllerutil.SetControllerReference(obj, secret, r.Scheme)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token secret for git job: %w", err)
+	}
+
+	return token, nil
+}
+
+func (r *GitJobReconciler) createJob(ctx context.Context, gitRepo *v1alpha1.GitRepo, previousCommit string) error {
+	job, err := r.newGitJob(ctx, gitRepo, previousCommit)
 	if err != nil {
 		return err
 	}
@@ -174,7 +235,7 @@ func (r *GitJobReconciler) createJob(ctx context.Context, gitRepo *v1alpha1.GitR
 	return r.Create(ctx, job)
 }
 
-func (r *GitJobReconciler) newGitJob(ctx context.Context, obj *v1alpha1.GitRepo) (*batchv1.Job, error) {
+func (r *GitJobReconciler) newGitJob(ctx context.Context, obj *v1alpha1.GitRepo, previousCommit string) (*batchv1.Job, error) {
 	jobSpec, err := r.newJobSpec(ctx, obj)
 	if err != nil {
 		return nil, err
@@ -217,14 +278,31 @@ func (r *GitJobReconciler) newGitJob(ctx context.Context, obj *v1alpha1.GitRepo)
 		})
 	}
 
-	knownHostsData, err := r.KnownHosts.Get(ctx, r.Client, obj.Namespace, obj.Spec.ClientSecretName)
-	if err != nil {
-		return nil, err
-	}
+	var (
+		knownHostsData string
+		initContainer  corev1.Container
+	)
+	if obj.Spec.Archive != nil {
+		initContainer, err = r.newArchiveDownloader(ctx, obj)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		knownHostsData, err = r.KnownHosts.Get(ctx, r.Client, obj.Namespace, obj.Spec.ClientSecretName)
+		if err != nil {
+			return nil, err
+		}
 
-	initContainer, err := r.newGitCloner(ctx, obj, knownHostsData)
-	if err != nil {
-		return nil, err
+		initContainer, err = r.newGitCloner(ctx, obj, knownHostsData)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if previousCommit != "" {
+		initContainer.Args = append(initContainer.Args,
+			"--previous-commit", previousCommit,
+			"--changed-paths-file", "/workspace/"+changedPathsFile,
+		)
 	}
 
 	job.Spec.Template.Spec.InitContainers = []corev1.Container{initContainer}
@@ -256,6 +334,17 @@ func (r *GitJobReconciler) newGitJob(ctx context.Context, obj *v1alpha1.GitRepo)
 		})
 	}
 
+	if slices.ContainsFunc(initContainer.VolumeMounts, func(m corev1.VolumeMount) bool { return m.Name == gitTokenVolumeName }) {
+		job.Spec.Template.Spec.Volumes = append(job.Spec.Template.Spec.Volumes, corev1.Volume{
+			Name: gitTokenVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: tokenSecretName(obj),
+				},
+			},
+		})
+	}
+
 	if obj.Spec.ClientSecretName != "" {
 		job.Spec.Template.Spec.Volumes = append(job.Spec.Template.Spec.Volumes,
 			corev1.Volume{
@@ -312,43 +401,28 @@ func (r *GitJobReconciler) newGitJob(ctx context.Context, obj *v1alpha1.GitRepo)
 				Value: obj.Status.Commit,
 			},
 		)
+		// flags have to be passed before the bundle name and paths
+		if args := job.Spec.Template.Spec.Containers[i].Args; previousCommit != "" && slices.Contains(args, "--") {
+			job.Spec.Template.Spec.Containers[i].Args = slices.Insert(args, slices.Index(args, "--"),
+				"--changed-paths-file", "/workspace/source/"+changedPathsFile)
+		}
 		job.Spec.Template.Spec.Containers[i].Env = append(job.Spec.Template.Spec.Containers[i].Env, proxyEnvVars()...)
 	}
 
+	// sources are mounted into the repository, after the volume it is cloned into
+	if err := r.addSources(ctx, obj, job); err != nil {
+		return nil, err
+	}
+
 	return job, nil
 }
 
 func (r *GitJobReconciler) newJobSpec(ctx context.Context, gitrepo *v1alpha1.GitRepo) (*batchv1.JobSpec, error) {
 	var CACertsFilePathOverride string
 
-	paths := gitrepo.Spec.Paths
-	if len(paths) == 0 {
-		paths = []string{"."}
-	}
-
-	drivenScanSeparator := ""
-	var err error
-	if len(gitrepo.Spec.Bundles) > 0 {
-		paths = []string{}
-		// use driven scan instead
-		// We calculate a separator because we will continue using the
-		// same call format for "fleet apply."
-		// The bundle definitions + options file (fleet.yaml)
-		// will be passed at the end, in the same way we pass the bundle
-		// directories for the classic fleet scan, but since we need to
-		// pass 2 strings, we will separate them with
-		// the calculated separator.
-		drivenScanSeparator, err = getDrivenScanSeparator(*gitrepo)
-		if err != nil {
-			return nil, err
-		}
-		for _, b := range gitrepo.Spec.Bundles {
-			path := b.Base
-			if b.Options != "" {
-				path = path + drivenScanSeparator + b.Options
-			}
-			paths = append(paths, path)
-		}
+	paths, drivenScanSeparator, err := bundlePaths(gitrepo)
+	if err != nil {
+		return nil, err
 	}
 
 	// compute configmap, needed because its name contains a hash
@@ -515,6 +589,9 @@ func (r *GitJobReconciler) newGitCloner(
 
 	branch, rev := obj.Spec.Branch, obj.Spec.Revision
 	switch {
+	case obj.Spec.TagSemver != "" && repoCommit(obj) != "":
+		// clone the commit of the selected tag, the tag could have been moved since
+		args = append(args, "--revision", repoCommit(obj))
 	case branch != "":
 		args = append(args, "--branch", branch)
 	case rev != "":
@@ -556,7 +633,23 @@ func (r *GitJobReconciler) newGitCloner(
 			})
 			args = append(args, "--ssh-private-key-file", "/gitjob/ssh/"+corev1.SSHAuthPrivateKey)
 		default:
-			if fleetgithub.HasGitHubAppKeys(&secret) {
+			switch {
+			case gitcredentials.HasProvider(&secret):
+				token, err := r.createTokenSecret(ctx, obj, &secret)
+				if err != nil {
+					return corev1.Container{}, err
+				}
+				volumeMounts = append(volumeMounts, corev1.VolumeMount{
+					Name:      gitTokenVolumeName,
+					MountPath: "/gitjob/token",
+				})
+				if token.Bearer {
+					args = append(args, "--bearer-token-file", "/gitjob/token/"+corev1.BasicAuthPasswordKey)
+				} else {
+					args = append(args, "--username", token.Username)
+					args = append(args, "--password-file", "/gitjob/token/"+corev1.BasicAuthPasswordKey)
+				}
+			case fleetgithub.HasGitHubAppKeys(&secret):
 				volumeMounts = append(volumeMounts, corev1.VolumeMount{
 					Name:      gitCredentialVolumeName,
 					MountPath: "/gitjob/githubapp",
@@ -566,7 +659,7 @@ func (r *GitJobReconciler) newGitCloner(
 					"--github-app-installation-id", string(secret.Data[fleetgithub.GithubAppInstallationIDKey]),
 					"--github-app-key-file", "/gitjob/githubapp/"+fleetgithub.GithubAppPrivateKeyKey,
 				)
-			} else {
+			default:
 				return corev1.Container{}, fmt.Errorf("missing Github App keys in secret %s/%s", secret.Namespace, secret.Name)
 			}
 		}
@@ -576,6 +669,22 @@ func (r *GitJobReconciler) newGitCloner(
 		args = append(args, "--insecure-skip-tls")
 	}
 
+	if obj.Spec.SparseCheckout {
+		paths, separator, err := bundlePaths(obj)
+		if err != nil {
+			return corev1.Container{}, err
+		}
+		for _, p := range paths {
+			args = append(args, "--sparse-path", p)
+		}
+		if separator != "" {
+			args = append(args, "--sparse-separator", separator)
+		}
+	}
+	if obj.Spec.LFS {
+		args = append(args, "--lfs")
+	}
+
 	var CABundleSecret corev1.Secret
 	err = r.Get(ctx, types.NamespacedName{
 		Namespace: obj.Namespace,
@@ -609,6 +718,179 @@ func (r *GitJobReconciler) newGitCloner(
 		env = append(env, corev1.EnvVar{Name: ssh.KnownHostsEnvVar, Value: knownHosts})
 	}
 
+	return r.initContainer(args, volumeMounts, env), nil
+}
+
+// addSources adds a git cloner for each additional source of the gitrepo to the job. Each source is cloned into its
+// own volume, which is mounted into the repository at the name of the source. Sources are cloned at the commits
+// resolved by polling, or follow their branch until then.
+func (r *GitJobReconciler) addSources(ctx context.Context, obj *v1alpha1.GitRepo, job *batchv1.Job) error {
+	if err := ValidateSources(obj.Spec.Sources); err != nil {
+		return err
+	}
+
+	podSpec := &job.Spec.Template.Spec
+	for i, source := range obj.Spec.Sources {
+		volumeName := fmt.Sprintf("source-%d", i)
+		credentialVolumeName := fmt.Sprintf("source-credential-%d", i)
+		tokenVolumeName := fmt.Sprintf("source-token-%d", i)
+
+		gitrepo := sourceGitRepo(obj, source, sourceCommit(obj.Status.Sources, source.Name))
+		knownHosts, err := r.KnownHosts.Get(ctx, r.Client, obj.Namespace, source.ClientSecretName)
+		if err != nil {
+			return err
+		}
+		cloner, err := r.newGitCloner(ctx, gitrepo, knownHosts)
+		if err != nil {
+			return fmt.Errorf("failed to create git cloner for source %q: %w", source.Name, err)
+		}
+		cloner.Name = fmt.Sprintf("gitcloner-source-%d", i)
+
+		hasCredentials, hasToken := false, false
+		for j := range cloner.VolumeMounts {
+			switch cloner.VolumeMounts[j].Name {
+			case gitClonerVolumeName:
+				cloner.VolumeMounts[j].Name = volumeName
+			case gitCredentialVolumeName:
+				cloner.VolumeMounts[j].Name = credentialVolumeName
+				hasCredentials = true
+			case gitTokenVolumeName:
+				cloner.VolumeMounts[j].Name = tokenVolumeName
+				hasToken = true
+			}
+		}
+		podSpec.InitContainers = append(podSpec.InitContainers, cloner)
+
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: volumeName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		})
+		if hasCredentials {
+			secretName := source.ClientSecretName
+			if secretName == "" {
+				secretName = config.DefaultGitCredentialsSecretName
+			}
+			podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+				Name: credentialVolumeName,
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{
+						SecretName: secretName,
+					},
+				},
+			})
+		}
+		if hasToken {
+			podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+				Name: tokenVolumeName,
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{
+						SecretName: tokenSecretName(gitrepo),
+					},
+				},
+			})
+		}
+
+		for j := range podSpec.Containers {
+			podSpec.Containers[j].VolumeMounts = append(podSpec.Containers[j].VolumeMounts, corev1.VolumeMount{
+				Name:      volumeName,
+				MountPath: "/workspace/source/" + source.Name,
+				SubPath:   source.Path,
+				ReadOnly:  true,
+			})
+		}
+	}
+
+	return nil
+}
+
+// newArchiveDownloader returns the init container, which downloads the content of a gitrepo's archive source into
+// the same volume the git cloner clones into.
+func (r *GitJobReconciler) newArchiveDownloader(ctx context.Context, obj *v1alpha1.GitRepo) (corev1.Container, error) {
+	archive := obj.Spec.Archive
+	args := []string{"fleet", "archive", obj.Spec.Repo, "/workspace", "--type", archive.Type}
+	volumeMounts := []corev1.VolumeMount{
+		{
+			Name:      gitClonerVolumeName,
+			MountPath: "/workspace",
+		},
+		{
+			Name:      emptyDirVolumeName,
+			MountPath: "/tmp",
+		},
+	}
+
+	if archive.Endpoint != "" {
+		args = append(args, "--endpoint", archive.Endpoint)
+	}
+	if archive.Region != "" {
+		args = append(args, "--region", archive.Region)
+	}
+	if archive.Insecure {
+		args = append(args, "--insecure")
+	}
+	if archive.Type == v1alpha1.ArchiveTypeOCI {
+		if tag := ociTag(obj); tag != "" {
+			args = append(args, "--tag", tag)
+		}
+		// pull the artifact which was polled, even if the tag was moved since
+		if commit := repoCommit(obj); commit != "" {
+			args = append(args, "--expected-revision", commit)
+		}
+		if archive.CosignPublicKey != "" {
+			args = append(args, "--cosign-public-key", archive.CosignPublicKey)
+		}
+	}
+	if obj.Spec.InsecureSkipTLSverify {
+		args = append(args, "--insecure-skip-tls")
+	}
+
+	if obj.Spec.ClientSecretName != "" {
+		var secret corev1.Secret
+		if err := r.Get(ctx, types.NamespacedName{Namespace: obj.Namespace, Name: obj.Spec.ClientSecretName}, &secret); err != nil {
+			return corev1.Container{}, err
+		}
+		if secret.Type != corev1.SecretTypeBasicAuth {
+			return corev1.Container{}, fmt.Errorf("secret %s/%s for archive sources must be of type %s", secret.Namespace, secret.Name, corev1.SecretTypeBasicAuth)
+		}
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      gitCredentialVolumeName,
+			MountPath: "/gitjob/credentials",
+		})
+		args = append(args, "--username", string(secret.Data[corev1.BasicAuthUsernameKey]))
+		args = append(args, "--password-file", "/gitjob/credentials/"+corev1.BasicAuthPasswordKey)
+	}
+
+	var CABundleSecret corev1.Secret
+	err := r.Get(ctx, types.NamespacedName{
+		Namespace: obj.Namespace,
+		Name:      caBundleName(obj),
+	}, &CABundleSecret)
+	if client.IgnoreNotFound(err) != nil {
+		return corev1.Container{}, err
+	}
+	if !apierrors.IsNotFound(err) {
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      bundleCAVolumeName,
+			MountPath: "/gitjob/cabundle",
+		})
+		args = append(args, "--ca-bundle-file", "/gitjob/cabundle/"+bundleCAFile)
+	}
+
+	return r.initContainer(args, volumeMounts, proxyEnvVars()), nil
+}
+
+// ociTag returns the tag of the OCI artifact to pull, which is the tag selected by the semver constraint or the
+// revision.
+func ociTag(obj *v1alpha1.GitRepo) string {
+	if obj.Spec.TagSemver != "" {
+		return obj.Status.Tag
+	}
+	return obj.Spec.Revision
+}
+
+func (r *GitJobReconciler) initContainer(args []string, volumeMounts []corev1.VolumeMount, env []corev1.EnvVar) corev1.Container {
 	return corev1.Container{
 		Command:      []string{"log.sh"},
 		Args:         args,
@@ -626,7 +908,7 @@ func (r *GitJobReconciler) newGitCloner(
 				Type: corev1.SeccompProfileTypeRuntimeDefault,
 			},
 		},
-	}, nil
+	}
 }
 
 // readIntEnvVar reads an integer from an environment variable using the provided getter function.
@@ -669,6 +951,7 @@ func argsAndEnvs(
 		fmt.Sprintf("--sync-generation=%d", gitrepo.Spec.ForceSyncGeneration),
 		fmt.Sprintf("--paused=%v", gitrepo.Spec.Paused),
 		"--target-namespace", gitrepo.Spec.TargetNamespace,
+		"--results-configmap", fleetapply.ResultsConfigMapName(gitrepo.Name),
 	)
 
 	if gitrepo.Spec.KeepResources {
@@ -799,6 +1082,42 @@ func argsAndEnvs(
 	return append(args, "--", gitrepo.Name), env
 }
 
+// bundlePaths returns the paths passed to fleet apply. If the GitRepo defines bundles, the paths consist of the
+// base and options file of each bundle, joined by the returned separator, for the driven scan.
+func bundlePaths(gitrepo *v1alpha1.GitRepo) ([]string, string, error) {
+	paths := gitrepo.Spec.Paths
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	drivenScanSeparator := ""
+	var err error
+	if len(gitrepo.Spec.Bundles) > 0 {
+		paths = []string{}
+		// use driven scan instead
+		// We calculate a separator because we will continue using the
+		// same call format for "fleet apply."
+		// The bundle definitions + options file (fleet.yaml)
+		// will be passed at the end, in the same way we pass the bundle
+		// directories for the classic fleet scan, but since we need to
+		// pass 2 strings, we will separate them with
+		// the calculated separator.
+		drivenScanSeparator, err = getDrivenScanSeparator(*gitrepo)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, b := range gitrepo.Spec.Bundles {
+			path := b.Base
+			if b.Options != "" {
+				path = path + drivenScanSeparator + b.Options
+			}
+			paths = append(paths, path)
+		}
+	}
+
+	return paths, drivenScanSeparator, nil
+}
+
 // volumes builds sets of volumes and their volume mounts for default folders and the targets config map.
 func volumes(targetsConfigName string) ([]corev1.Volume, []corev1.VolumeMount) {
 	const (
@@ -998,6 +1317,12 @@ func jobName(obj *v1alpha1.GitRepo) string {
 	return names.SafeConcatName(obj.Name, names.Hex(obj.Spec.Repo+obj.Status.Commit, 5))
 }
 
+// tokenSecretName returns the name of the secret holding the token minted for cloning the repository of the gitrepo,
+// which may be one of its additional sources.
+func tokenSecretName(obj *v1alpha1.GitRepo) string {
+	return names.SafeConcatName(obj.Name, "git-token", names.Hex(obj.Spec.Repo, 5))
+}
+
 func caBundleName(obj *v1alpha1.GitRepo) string {
 	return fmt.Sprintf("%s-cabundle", obj.Name)
 }
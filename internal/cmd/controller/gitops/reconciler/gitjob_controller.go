@@ -12,13 +12,16 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/reugn/go-quartz/quartz"
 
+	fleetapply "github.com/rancher/fleet/internal/cmd/cli/apply"
 	"github.com/rancher/fleet/internal/cmd/controller/finalize"
 	"github.com/rancher/fleet/internal/cmd/controller/imagescan"
 	ctrlquartz "github.com/rancher/fleet/internal/cmd/controller/quartz"
 	"github.com/rancher/fleet/internal/cmd/controller/reconciler"
 	"github.com/rancher/fleet/internal/config"
+	"github.com/rancher/fleet/internal/gitcredentials"
 	"github.com/rancher/fleet/internal/metrics"
 	v1alpha1 "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/archive"
 	"github.com/rancher/fleet/pkg/durations"
 	fleetevent "github.com/rancher/fleet/pkg/event"
 	"github.com/rancher/fleet/pkg/sharding"
@@ -100,6 +103,7 @@ var (
 
 type GitFetcher interface {
 	LatestCommit(ctx context.Context, gitrepo *v1alpha1.GitRepo, client client.Client) (string, error)
+	LatestTag(ctx context.Context, gitrepo *v1alpha1.GitRepo, client client.Client) (string, string, error)
 }
 
 // TimeGetter interface is used to mock the time.Now() call in unit tests
@@ -132,6 +136,11 @@ type GitJobReconciler struct {
 	Recorder        record.EventRecorder
 	SystemNamespace string
 	KnownHosts      KnownHostsGetter
+	// Credentials mints tokens for client secrets configuring a credential provider.
+	Credentials *gitcredentials.Cache
+	// Fetcher enables requesting syncs from the git fetcher service in the GitRepo status, instead of creating a
+	// job per sync.
+	Fetcher bool
 }
 
 func (r *GitJobReconciler) SetupWithManager(mgr ctrl.Manager) error {
@@ -145,6 +154,7 @@ func (r *GitJobReconciler) SetupWithManager(mgr ctrl.Manager) error {
 					predicate.AnnotationChangedPredicate{},
 					predicate.LabelChangedPredicate{},
 					commitChangedPredicate(),
+					fetcherResultChangedPredicate(),
 				),
 			),
 		).
@@ -264,8 +274,44 @@ func monitorLatestCommit(obj metav1.Object, fetch func() (string, error)) (strin
 	return commit, nil
 }
 
+// latestCommit returns the latest commit of the gitrepo. If the gitrepo follows tags by a semver constraint, it
+// also returns the selected tag. If it has additional sources, the commit combines the commits of all sources.
+func latestCommit(ctx context.Context, fetcher GitFetcher, gitrepo *v1alpha1.GitRepo, c client.Client) (latestRevision, error) {
+	var rev latestRevision
+	commit, err := monitorLatestCommit(gitrepo, func() (string, error) {
+		var (
+			commit string
+			err    error
+		)
+		switch {
+		case gitrepo.Spec.Archive != nil:
+			rev.tag, commit, err = archive.Latest(ctx, gitrepo, c)
+		case gitrepo.Spec.TagSemver != "":
+			rev.tag, commit, err = fetcher.LatestTag(ctx, gitrepo, c)
+		default:
+			commit, err = fetcher.LatestCommit(ctx, gitrepo, c)
+		}
+		if err != nil || len(gitrepo.Spec.Sources) == 0 {
+			return commit, err
+		}
+
+		rev.repoCommit = commit
+		rev.sources, err = latestSourceCommits(ctx, fetcher, gitrepo, c)
+		if err != nil {
+			return "", err
+		}
+		return combinedRevision(commit, rev.sources), nil
+	})
+	rev.commit = commit
+	return rev, err
+}
+
 // manageGitJob is responsible for creating, updating and deleting the GitJob and setting the GitRepo's status accordingly
 func (r *GitJobReconciler) manageGitJob(ctx context.Context, logger logr.Logger, gitrepo *v1alpha1.GitRepo, oldCommit string) (ctrl.Result, error) {
+	if r.Fetcher {
+		return r.manageFetcherSync(ctx, logger, gitrepo, oldCommit)
+	}
+
 	if err := r.deletePreviousJob(ctx, logger, *gitrepo, oldCommit); err != nil {
 		return ctrl.Result{}, err
 	}
@@ -283,28 +329,16 @@ func (r *GitJobReconciler) manageGitJob(ctx context.Context, logger logr.Logger,
 	}
 
 	if apierrors.IsNotFound(err) {
-		if gitrepo.Spec.DisablePolling {
-			commit, err := monitorLatestCommit(gitrepo, func() (string, error) {
-				return r.GitFetcher.LatestCommit(ctx, gitrepo, r.Client)
-			})
-			condition.Cond(gitPollingCondition).SetError(&gitrepo.Status, "", err)
-			if err == nil && commit != "" {
-				gitrepo.Status.Commit = commit
-			}
-			if err != nil {
-				r.Recorder.Event(gitrepo, fleetevent.Warning, "Failed", err.Error())
-			} else if oldCommit != gitrepo.Status.Commit {
-				r.Recorder.Event(gitrepo, fleetevent.Normal, "GotNewCommit", gitrepo.Status.Commit)
-			}
-		}
+		r.updateCommitIfPollingDisabled(ctx, gitrepo, oldCommit)
 
 		if r.shouldCreateJob(gitrepo, oldCommit) {
+			previousCommit := incrementalBaseCommit(gitrepo, oldCommit)
 			r.updateGenerationValuesIfNeeded(gitrepo)
 			if err := r.validateExternalSecretExist(ctx, gitrepo); err != nil {
 				r.Recorder.Event(gitrepo, fleetevent.Warning, "FailedValidatingSecret", err.Error())
 				return ctrl.Result{}, fmt.Errorf("error validating external secrets: %w", err)
 			}
-			if err := r.createJobAndResources(ctx, gitrepo, logger); err != nil {
+			if err := r.createJobAndResources(ctx, gitrepo, logger, previousCommit); err != nil {
 				gitjobsCreatedFailure.Inc(gitrepo)
 				return ctrl.Result{}, err
 			}
@@ -331,6 +365,65 @@ func (r *GitJobReconciler) manageGitJob(ctx context.Context, logger logr.Logger,
 	return ctrl.Result{}, nil
 }
 
+// manageFetcherSync requests a sync from the git fetcher, if the conditions to create a job are met, and sets the
+// GitRepo's status from the result of the requested sync.
+func (r *GitJobReconciler) manageFetcherSync(ctx context.Context, logger logr.Logger, gitrepo *v1alpha1.GitRepo, oldCommit string) (ctrl.Result, error) {
+	request := gitrepo.Status.FetcherRequest
+	if request == nil || fetcherSyncDone(gitrepo) {
+		r.updateCommitIfPollingDisabled(ctx, gitrepo, oldCommit)
+	}
+
+	if gitrepo.Status.Commit != "" && (request == nil || r.shouldCreateJob(gitrepo, oldCommit)) {
+		previousCommit := incrementalBaseCommit(gitrepo, oldCommit)
+		r.updateGenerationValuesIfNeeded(gitrepo)
+		if err := r.validateExternalSecretExist(ctx, gitrepo); err != nil {
+			r.Recorder.Event(gitrepo, fleetevent.Warning, "FailedValidatingSecret", err.Error())
+			return ctrl.Result{}, fmt.Errorf("error validating external secrets: %w", err)
+		}
+		// the git fetcher impersonates the service account of the job
+		if err := r.createJobRBAC(ctx, gitrepo); err != nil {
+			gitjobsCreatedFailure.Inc(gitrepo)
+			return ctrl.Result{}, fmt.Errorf("failed to create RBAC resources for git fetcher: %w", err)
+		}
+
+		gitrepo.Status.FetcherRequest = &v1alpha1.GitFetcherRequest{
+			ID:             fetcherRequestID(gitrepo),
+			Commit:         gitrepo.Status.Commit,
+			PreviousCommit: previousCommit,
+			RepoCommit:     gitrepo.Status.RepoCommit,
+			Sources:        gitrepo.Status.Sources,
+		}
+		logger.V(1).Info("Requesting sync from git fetcher", "request", gitrepo.Status.FetcherRequest.ID)
+		r.Recorder.Event(gitrepo, fleetevent.Normal, "Requested", "Sync was requested from git fetcher")
+		gitjobsCreatedSuccess.Inc(gitrepo)
+	}
+
+	gitrepo.Status.ObservedGeneration = gitrepo.Generation
+
+	setStatusFromFetcher(gitrepo)
+
+	return ctrl.Result{}, nil
+}
+
+// updateCommitIfPollingDisabled sets the latest commit, if polling is disabled for the GitRepo.
+func (r *GitJobReconciler) updateCommitIfPollingDisabled(ctx context.Context, gitrepo *v1alpha1.GitRepo, oldCommit string) {
+	if !gitrepo.Spec.DisablePolling {
+		return
+	}
+
+	rev, err := latestCommit(ctx, r.GitFetcher, gitrepo, r.Client)
+	condition.Cond(gitPollingCondition).SetError(&gitrepo.Status, "", err)
+	if err == nil && rev.commit != "" {
+		gitrepo.Status.Commit = rev.commit
+		rev.setStatus(&gitrepo.Status)
+	}
+	if err != nil {
+		r.Recorder.Event(gitrepo, fleetevent.Warning, "Failed", err.Error())
+	} else if oldCommit != gitrepo.Status.Commit {
+		r.Recorder.Event(gitrepo, fleetevent.Normal, "GotNewCommit", gitrepo.Status.Commit)
+	}
+}
+
 func (r *GitJobReconciler) deletePreviousJob(ctx context.Context, logger logr.Logger, gitrepo v1alpha1.GitRepo, oldCommit string) error {
 	if oldCommit == "" || oldCommit == gitrepo.Status.Commit {
 		return nil
@@ -440,6 +533,30 @@ func (r *GitJobReconciler) shouldCreateJob(gitrepo *v1alpha1.GitRepo, oldCommit
 	return false
 }
 
+// incrementalBaseCommit returns the commit the new job can compute the changed paths from, to only regenerate
+// affected bundles. This is only safe if the job for the old commit succeeded and nothing but the commit changed
+// since, otherwise an empty string is returned and all bundles are regenerated.
+func incrementalBaseCommit(gitrepo *v1alpha1.GitRepo, oldCommit string) string {
+	if gitrepo.Spec.Archive != nil {
+		// archives have no history to compute the changed paths from
+		return ""
+	}
+	if len(gitrepo.Spec.Sources) > 0 {
+		// the combined revision is not a commit of the repository
+		return ""
+	}
+	if oldCommit == "" || oldCommit == gitrepo.Status.Commit {
+		return ""
+	}
+	if gitrepo.Status.GitJobStatus != status.CurrentStatus.String() {
+		return ""
+	}
+	if gitrepo.Spec.ForceSyncGeneration != gitrepo.Status.UpdateGeneration || generationChanged(gitrepo) {
+		return ""
+	}
+	return oldCommit
+}
+
 func (r *GitJobReconciler) updateGenerationValuesIfNeeded(gitrepo *v1alpha1.GitRepo) {
 	if gitrepo.Spec.ForceSyncGeneration != gitrepo.Status.UpdateGeneration {
 		gitrepo.Status.UpdateGeneration = gitrepo.Spec.ForceSyncGeneration
@@ -713,8 +830,6 @@ func setStatusFromGitjob(ctx context.Context, c client.Client, gitRepo *v1alpha1
 		}
 	}
 
-	gitRepo.Status.GitJobStatus = result.Status.String()
-
 	for _, con := range result.Conditions {
 		if con.Type.String() == "Ready" {
 			continue
@@ -724,17 +839,82 @@ func setStatusFromGitjob(ctx context.Context, c client.Client, gitRepo *v1alpha1
 		condition.Cond(con.Type.String()).Reason(gitRepo, con.Reason)
 	}
 
+	commit := ""
+	if result.Status == status.CurrentStatus && strings.Contains(result.Message, "Job Completed") {
+		commit = job.Annotations["commit"]
+	}
+	if commit != "" || result.Status == status.FailedStatus {
+		if err := setPathsFromGitjob(ctx, c, gitRepo, job); err != nil {
+			return err
+		}
+	}
+	setSyncStatus(gitRepo, result.Status, filterFleetCLIJobOutput(terminationMessage), commit)
+
+	return nil
+}
+
+// setPathsFromGitjob sets the results of the paths, which fleet apply stored for the finished job, in the gitRepo.
+// The results of the previous job are kept, if the job failed before fleet apply created bundles. Config maps, which
+// are not controlled by the gitRepo, are ignored.
+func setPathsFromGitjob(ctx context.Context, c client.Client, gitRepo *v1alpha1.GitRepo, job *batchv1.Job) error {
+	configMap := &corev1.ConfigMap{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: gitRepo.Namespace, Name: fleetapply.ResultsConfigMapName(gitRepo.Name)}, configMap)
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !metav1.IsControlledBy(configMap, gitRepo) {
+		return nil
+	}
+
+	paths, err := fleetapply.ReadResults(configMap, job.Name)
+	if err != nil {
+		return err
+	}
+	if len(paths) > 0 {
+		gitRepo.Status.Paths = paths
+	}
+	return nil
+}
+
+// setStatusFromFetcher sets the status fields relative to the sync requested from the git fetcher in the gitRepo.
+// The sync is in progress until the git fetcher reports the result of the current request.
+func setStatusFromFetcher(gitRepo *v1alpha1.GitRepo) {
+	request := gitRepo.Status.FetcherRequest
+	if request == nil {
+		return
+	}
+
+	result := status.InProgressStatus
+	message := ""
+	if res := gitRepo.Status.FetcherResult; res != nil && res.ID == request.ID {
+		result = status.Status(res.Status)
+		message = res.Message
+		if len(res.Paths) > 0 {
+			gitRepo.Status.Paths = res.Paths
+		}
+	}
+
+	setSyncStatus(gitRepo, result, message, request.Commit)
+}
+
+// setSyncStatus sets the status fields relative to the result of a sync, by a job or the git fetcher, in the
+// gitRepo. The commit is only set if the sync succeeded.
+func setSyncStatus(gitRepo *v1alpha1.GitRepo, result status.Status, errorMessage, commit string) {
+	gitRepo.Status.GitJobStatus = result.String()
+
 	// status.Compute() possible results are
 	//   - InProgress
 	//   - Current
 	//   - Failed
 	//   - Terminating
-	switch result.Status {
+	switch result {
 	case status.FailedStatus:
-		kstatus.SetError(gitRepo, filterFleetCLIJobOutput(terminationMessage))
+		kstatus.SetError(gitRepo, errorMessage)
 	case status.CurrentStatus:
-		if strings.Contains(result.Message, "Job Completed") {
-			gitRepo.Status.Commit = job.Annotations["commit"]
+		if commit != "" {
+			gitRepo.Status.Commit = commit
 		}
 		kstatus.SetActive(gitRepo)
 	case status.InProgressStatus:
@@ -745,8 +925,18 @@ func setStatusFromGitjob(ctx context.Context, c client.Client, gitRepo *v1alpha1
 		// that case
 		kstatus.SetActive(gitRepo)
 	}
+}
 
-	return nil
+// fetcherSyncDone returns true if the git fetcher finished the current request of the GitRepo.
+func fetcherSyncDone(gitRepo *v1alpha1.GitRepo) bool {
+	request, result := gitRepo.Status.FetcherRequest, gitRepo.Status.FetcherResult
+	return request != nil && result != nil && result.ID == request.ID && result.Status != status.InProgressStatus.String()
+}
+
+// fetcherRequestID identifies a sync by the commit, generation and force sync generation of the GitRepo, which are
+// the values a job name and its spec depend on.
+func fetcherRequestID(gitRepo *v1alpha1.GitRepo) string {
+	return fmt.Sprintf("%s-%d-%d", jobName(gitRepo), gitRepo.Generation, gitRepo.Status.UpdateGeneration)
 }
 
 // updateErrorStatus sets the condition in the status and tries to update the resource
@@ -777,9 +967,11 @@ func updateStatus(ctx context.Context, c client.Client, req types.NamespacedName
 		t.Status.Commit = status.Commit
 		t.Status.GitJobStatus = status.GitJobStatus
 		t.Status.PollingCommit = status.PollingCommit
+		t.Status.Tag = status.Tag
 		t.Status.LastPollingTime = status.LastPollingTime
 		t.Status.ObservedGeneration = status.ObservedGeneration
 		t.Status.UpdateGeneration = status.UpdateGeneration
+		t.Status.FetcherRequest = status.FetcherRequest
 
 		// only keep the Ready condition from live status, it's calculated by the status reconciler
 		conds := []genericcondition.GenericCondition{}
@@ -4,6 +4,7 @@ package reconciler
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -16,6 +17,7 @@ import (
 	fleetapply "github.com/rancher/fleet/internal/cmd/cli/apply"
 	"github.com/rancher/fleet/internal/cmd/controller/finalize"
 	"github.com/rancher/fleet/internal/config"
+	"github.com/rancher/fleet/internal/gitcredentials"
 	"github.com/rancher/fleet/internal/mocks"
 	"github.com/rancher/fleet/internal/ssh"
 	fleetv1 "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
@@ -32,6 +34,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -1524,7 +1527,7 @@ func TestNewJob(t *testing.T) {
 				KnownHosts:      ssh.KnownHosts{EnforceHostKeyChecks: test.strictHostKeyChecks},
 			}
 
-			job, err := r.newGitJob(ctx, test.gitrepo)
+			job, err := r.newGitJob(ctx, test.gitrepo, "")
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -2144,7 +2147,7 @@ func TestGenerateJob_EnvVars(t *testing.T) {
 					strict: test.strictSSHHostKeyChecks,
 				},
 			}
-			job, err := r.newGitJob(ctx, test.gitrepo)
+			job, err := r.newGitJob(ctx, test.gitrepo, "")
 			if err != nil {
 				t.Errorf("unexpected error: %v", err)
 			}
@@ -2290,6 +2293,279 @@ func TestGitClonerSSH(t *testing.T) {
 	}
 }
 
+func TestGitClonerSparseCheckout(t *testing.T) {
+	r := GitJobReconciler{
+		Client:     fake.NewFakeClient(),
+		Image:      "test",
+		KnownHosts: mockKnownHostsGetter{},
+	}
+	gitrepo := &fleetv1.GitRepo{
+		Spec: fleetv1.GitRepoSpec{
+			Repo:           "https://github.com/example/monorepo",
+			Branch:         "main",
+			SparseCheckout: true,
+			LFS:            true,
+			Bundles: []fleetv1.BundlePath{
+				{Base: "deploy/app"},
+				{Base: "deploy/web", Options: "prod.yaml"},
+			},
+		},
+	}
+
+	cont, err := r.newGitCloner(context.TODO(), gitrepo, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"fleet", "gitcloner", "https://github.com/example/monorepo", "/workspace", "--branch", "main",
+		"--sparse-path", "deploy/app", "--sparse-path", "deploy/web:prod.yaml", "--sparse-separator", ":",
+		"--lfs",
+	}
+	if !cmp.Equal(cont.Args, expected) {
+		t.Errorf("unexpected args: %s", cmp.Diff(expected, cont.Args))
+	}
+}
+
+func TestGitClonerCredentialProvider(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(corev1.AddToScheme(scheme))
+	utilruntime.Must(fleetv1.AddToScheme(scheme))
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "gitlab-token", Namespace: "default"},
+		Data: map[string][]byte{
+			gitcredentials.ProviderKey:             []byte(gitcredentials.ProviderGitLab),
+			gitcredentials.GitLabURLKey:            []byte("https://gitlab.example.com"),
+			gitcredentials.GitLabProjectKey:        []byte("example/config"),
+			gitcredentials.GitLabTokenKey:          []byte("glpat-token"),
+			gitcredentials.GitLabTokenExpiresAtKey: []byte("2999-01-01"),
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+	r := GitJobReconciler{
+		Client:      c,
+		Scheme:      scheme,
+		Image:       "test",
+		KnownHosts:  mockKnownHostsGetter{},
+		Credentials: gitcredentials.NewCache(gitcredentials.ClientOptions(c, c)),
+	}
+
+	gitrepo := &fleetv1.GitRepo{
+		ObjectMeta: metav1.ObjectMeta{Name: "gitrepo", Namespace: "default"},
+		Spec: fleetv1.GitRepoSpec{
+			Repo:             "https://gitlab.example.com/example/config",
+			Branch:           "main",
+			ClientSecretName: "gitlab-token",
+		},
+	}
+
+	cont, err := r.newGitCloner(context.TODO(), gitrepo, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"fleet", "gitcloner", "https://gitlab.example.com/example/config", "/workspace", "--branch", "main",
+		"--username", "oauth2", "--password-file", "/gitjob/token/password",
+	}
+	if !cmp.Equal(cont.Args, expected) {
+		t.Errorf("unexpected args: %s", cmp.Diff(expected, cont.Args))
+	}
+	if !slices.ContainsFunc(cont.VolumeMounts, func(m corev1.VolumeMount) bool { return m.Name == gitTokenVolumeName }) {
+		t.Errorf("expected token to be mounted, got %v", cont.VolumeMounts)
+	}
+
+	var token corev1.Secret
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: tokenSecretName(gitrepo)}, &token); err != nil {
+		t.Fatal(err)
+	}
+	if string(token.Data[corev1.BasicAuthPasswordKey]) != "glpat-token" {
+		t.Errorf("unexpected token secret %v", token.Data)
+	}
+
+	r.Credentials = nil
+	if _, err := r.newGitCloner(context.TODO(), gitrepo, ""); err == nil {
+		t.Errorf("expected error without credential providers")
+	}
+}
+
+func TestArchiveDownloader(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(corev1.AddToScheme(scheme))
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "s3-credentials", Namespace: "default"},
+		Type:       corev1.SecretTypeBasicAuth,
+		Data: map[string][]byte{
+			corev1.BasicAuthUsernameKey: []byte("access"),
+			corev1.BasicAuthPasswordKey: []byte("secret"),
+		},
+	}
+	r := GitJobReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build(),
+		Image:  "test",
+	}
+
+	gitrepo := &fleetv1.GitRepo{
+		ObjectMeta: metav1.ObjectMeta{Name: "gitrepo", Namespace: "default"},
+		Spec: fleetv1.GitRepoSpec{
+			Repo:             "s3://config/prod/",
+			ClientSecretName: "s3-credentials",
+			Archive: &fleetv1.ArchiveSource{
+				Type:     fleetv1.ArchiveTypeS3,
+				Endpoint: "minio:9000",
+				Insecure: true,
+			},
+		},
+	}
+
+	cont, err := r.newArchiveDownloader(context.TODO(), gitrepo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"fleet", "archive", "s3://config/prod/", "/workspace", "--type", "s3",
+		"--endpoint", "minio:9000", "--insecure",
+		"--username", "access", "--password-file", "/gitjob/credentials/password",
+	}
+	if !cmp.Equal(cont.Args, expected) {
+		t.Errorf("unexpected args: %s", cmp.Diff(expected, cont.Args))
+	}
+	if !slices.ContainsFunc(cont.VolumeMounts, func(m corev1.VolumeMount) bool { return m.Name == gitCredentialVolumeName }) {
+		t.Errorf("expected credentials to be mounted, got %v", cont.VolumeMounts)
+	}
+
+	oci := &fleetv1.GitRepo{
+		ObjectMeta: metav1.ObjectMeta{Name: "gitrepo", Namespace: "default"},
+		Spec: fleetv1.GitRepoSpec{
+			Repo:      "oci://ghcr.io/example/config",
+			TagSemver: "^1.0.0",
+			Archive:   &fleetv1.ArchiveSource{Type: fleetv1.ArchiveTypeOCI, CosignPublicKey: "key"},
+		},
+		Status: fleetv1.GitRepoStatus{Commit: "0123456789", Tag: "v1.2.0"},
+	}
+	cont, err = r.newArchiveDownloader(context.TODO(), oci)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []string{
+		"fleet", "archive", "oci://ghcr.io/example/config", "/workspace", "--type", "oci",
+		"--tag", "v1.2.0", "--expected-revision", "0123456789", "--cosign-public-key", "key",
+	}
+	if !cmp.Equal(cont.Args, expected) {
+		t.Errorf("unexpected args: %s", cmp.Diff(expected, cont.Args))
+	}
+
+	secret.Type = corev1.SecretTypeSSHAuth
+	r.Client = fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+	if _, err := r.newArchiveDownloader(context.TODO(), gitrepo); err == nil {
+		t.Errorf("expected error for SSH secret")
+	}
+}
+
+func TestAddSources(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(corev1.AddToScheme(scheme))
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "overlays-credentials", Namespace: "default"},
+		Type:       corev1.SecretTypeBasicAuth,
+		Data: map[string][]byte{
+			corev1.BasicAuthUsernameKey: []byte("user"),
+			corev1.BasicAuthPasswordKey: []byte("pass"),
+		},
+	}
+	r := GitJobReconciler{
+		Client:     fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build(),
+		Image:      "test",
+		KnownHosts: mockKnownHostsGetter{},
+	}
+
+	gitrepo := &fleetv1.GitRepo{
+		ObjectMeta: metav1.ObjectMeta{Name: "gitrepo", Namespace: "default"},
+		Spec: fleetv1.GitRepoSpec{
+			Repo: "https://github.com/example/base",
+			Sources: []fleetv1.GitRepoSource{
+				{Name: "overlays", Repo: "https://github.com/example/overlays", Branch: "main", Path: "prod", ClientSecretName: "overlays-credentials"},
+				{Name: "values", Repo: "https://github.com/example/values"},
+			},
+		},
+		Status: fleetv1.GitRepoStatus{
+			Sources: []fleetv1.GitRepoSourceStatus{{Name: "overlays", Commit: "abc"}},
+		},
+	}
+	job := &batchv1.Job{}
+	job.Spec.Template.Spec.Containers = []corev1.Container{{Name: "fleet"}}
+
+	if err := r.addSources(context.TODO(), gitrepo, job); err != nil {
+		t.Fatal(err)
+	}
+
+	podSpec := job.Spec.Template.Spec
+	if len(podSpec.InitContainers) != 2 {
+		t.Fatalf("expected a git cloner per source, got %v", podSpec.InitContainers)
+	}
+	expected := []string{
+		"fleet", "gitcloner", "https://github.com/example/overlays", "/workspace", "--revision", "abc",
+		"--username", "user", "--password-file", "/gitjob/credentials/password",
+	}
+	if !cmp.Equal(podSpec.InitContainers[0].Args, expected) {
+		t.Errorf("unexpected args: %s", cmp.Diff(expected, podSpec.InitContainers[0].Args))
+	}
+	expected = []string{"fleet", "gitcloner", "https://github.com/example/values", "/workspace", "--branch", "master"}
+	if !cmp.Equal(podSpec.InitContainers[1].Args, expected) {
+		t.Errorf("unexpected args: %s", cmp.Diff(expected, podSpec.InitContainers[1].Args))
+	}
+	if podSpec.InitContainers[0].Name == podSpec.InitContainers[1].Name {
+		t.Errorf("expected unique init container names, got %q", podSpec.InitContainers[0].Name)
+	}
+
+	expectedMounts := []corev1.VolumeMount{
+		{Name: "source-0", MountPath: "/workspace"},
+		{Name: emptyDirVolumeName, MountPath: "/tmp"},
+		{Name: "source-credential-0", MountPath: "/gitjob/credentials"},
+	}
+	if !cmp.Equal(podSpec.InitContainers[0].VolumeMounts, expectedMounts) {
+		t.Errorf("unexpected volume mounts: %s", cmp.Diff(expectedMounts, podSpec.InitContainers[0].VolumeMounts))
+	}
+	if !slices.ContainsFunc(podSpec.Volumes, func(v corev1.Volume) bool {
+		return v.Name == "source-credential-0" && v.Secret != nil && v.Secret.SecretName == "overlays-credentials"
+	}) {
+		t.Errorf("expected credentials of the source to be mounted, got %v", podSpec.Volumes)
+	}
+
+	expectedMounts = []corev1.VolumeMount{
+		{Name: "source-0", MountPath: "/workspace/source/overlays", SubPath: "prod", ReadOnly: true},
+		{Name: "source-1", MountPath: "/workspace/source/values", ReadOnly: true},
+	}
+	if !cmp.Equal(podSpec.Containers[0].VolumeMounts, expectedMounts) {
+		t.Errorf("unexpected volume mounts: %s", cmp.Diff(expectedMounts, podSpec.Containers[0].VolumeMounts))
+	}
+
+	gitrepo.Spec.Sources[1].Name = "overlays"
+	if err := r.addSources(context.TODO(), gitrepo, &batchv1.Job{}); err == nil {
+		t.Errorf("expected error for duplicate source names")
+	}
+	gitrepo.Spec.Sources[1] = fleetv1.GitRepoSource{Name: "values", Repo: "https://github.com/example/values", Path: "../secrets"}
+	if err := r.addSources(context.TODO(), gitrepo, &batchv1.Job{}); err == nil {
+		t.Errorf("expected error for path outside of the source")
+	}
+}
+
+func TestCombinedRevision(t *testing.T) {
+	sources := []fleetv1.GitRepoSourceStatus{{Name: "overlays", Commit: "abc"}, {Name: "values", Commit: "def"}}
+	rev := combinedRevision("123", sources)
+	if len(rev) != 40 {
+		t.Errorf("expected revision as long as a commit hash, got %q", rev)
+	}
+	if rev != combinedRevision("123", sources) {
+		t.Errorf("expected revision to be stable")
+	}
+	if rev == combinedRevision("456", sources) {
+		t.Errorf("expected revision to change with the commit of the repository")
+	}
+	changed := []fleetv1.GitRepoSourceStatus{{Name: "overlays", Commit: "abc"}, {Name: "values", Commit: "ghi"}}
+	if rev == combinedRevision("123", changed) {
+		t.Errorf("expected revision to change with the commit of a source")
+	}
+}
+
 func TestDrivenScanSeparator(t *testing.T) {
 	tests := map[string]struct {
 		bundles        []fleetv1.BundlePath
@@ -2423,6 +2699,61 @@ func TestDrivenScanSeparator(t *testing.T) {
 	}
 }
 
+func TestIncrementalBaseCommit(t *testing.T) {
+	gitrepo := func(mutate func(*fleetv1.GitRepo)) *fleetv1.GitRepo {
+		g := &fleetv1.GitRepo{
+			ObjectMeta: metav1.ObjectMeta{Generation: 2},
+			Status: fleetv1.GitRepoStatus{
+				ObservedGeneration: 2,
+				Commit:             "new",
+				GitJobStatus:       "Current",
+			},
+		}
+		if mutate != nil {
+			mutate(g)
+		}
+		return g
+	}
+
+	tests := map[string]struct {
+		gitrepo   *fleetv1.GitRepo
+		oldCommit string
+		expected  string
+	}{
+		"only the commit changed": {
+			gitrepo:   gitrepo(nil),
+			oldCommit: "old",
+			expected:  "old",
+		},
+		"first job": {
+			gitrepo: gitrepo(nil),
+		},
+		"previous job failed": {
+			gitrepo:   gitrepo(func(g *fleetv1.GitRepo) { g.Status.GitJobStatus = "Failed" }),
+			oldCommit: "old",
+		},
+		"spec changed": {
+			gitrepo:   gitrepo(func(g *fleetv1.GitRepo) { g.Generation = 3 }),
+			oldCommit: "old",
+		},
+		"force update": {
+			gitrepo:   gitrepo(func(g *fleetv1.GitRepo) { g.Spec.ForceSyncGeneration = 1 }),
+			oldCommit: "old",
+		},
+		"archive source": {
+			gitrepo:   gitrepo(func(g *fleetv1.GitRepo) { g.Spec.Archive = &fleetv1.ArchiveSource{Type: fleetv1.ArchiveTypeHTTP} }),
+			oldCommit: "old",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := incrementalBaseCommit(test.gitrepo, test.oldCommit); got != test.expected {
+				t.Errorf("expected base commit %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
+
 func TestFilterFleetApplyJobOutput(t *testing.T) {
 	tests := map[string]struct {
 		input          string
@@ -2529,3 +2860,169 @@ func getFleetControllerDeployment(tolerations []corev1.Toleration) *appsv1.Deplo
 		},
 	}
 }
+
+func TestSetStatusFromFetcher(t *testing.T) {
+	gitrepo := &fleetv1.GitRepo{
+		ObjectMeta: metav1.ObjectMeta{Name: "gitrepo", Namespace: "default"},
+		Status: fleetv1.GitRepoStatus{
+			Commit:         "old",
+			FetcherRequest: &fleetv1.GitFetcherRequest{ID: "gitrepo-1-0", Commit: "new"},
+			FetcherResult:  &fleetv1.GitFetcherResult{ID: "gitrepo-0-0", Status: "Current"},
+		},
+	}
+
+	setStatusFromFetcher(gitrepo)
+	if gitrepo.Status.GitJobStatus != "InProgress" || gitrepo.Status.Commit != "old" {
+		t.Errorf("expected sync of a previous request to be ignored, got %q, commit %q", gitrepo.Status.GitJobStatus, gitrepo.Status.Commit)
+	}
+	if !fetcherSyncPending(gitrepo) {
+		t.Errorf("expected sync to be pending")
+	}
+
+	gitrepo.Status.FetcherResult = &fleetv1.GitFetcherResult{ID: "gitrepo-1-0", Status: "Failed", Message: "boom"}
+	setStatusFromFetcher(gitrepo)
+	cond, ok := getCondition(gitrepo, "Stalled")
+	if gitrepo.Status.GitJobStatus != "Failed" || gitrepo.Status.Commit != "old" || !ok || cond.Message != "boom" {
+		t.Errorf("expected failed sync, got %q, commit %q, condition %v", gitrepo.Status.GitJobStatus, gitrepo.Status.Commit, cond)
+	}
+
+	gitrepo.Status.FetcherResult.Status = "Current"
+	gitrepo.Status.FetcherResult.Paths = []fleetv1.GitRepoPathStatus{{Path: "app", BundleName: "gitrepo-app"}}
+	setStatusFromFetcher(gitrepo)
+	if gitrepo.Status.GitJobStatus != "Current" || gitrepo.Status.Commit != "new" {
+		t.Errorf("expected commit of the synced request, got %q, commit %q", gitrepo.Status.GitJobStatus, gitrepo.Status.Commit)
+	}
+	if !cmp.Equal(gitrepo.Status.Paths, gitrepo.Status.FetcherResult.Paths) {
+		t.Errorf("expected results of the paths of the synced request, got %v", gitrepo.Status.Paths)
+	}
+	if fetcherSyncPending(gitrepo) {
+		t.Errorf("expected sync not to be pending")
+	}
+}
+
+func TestSetStatusFromGitjobPaths(t *testing.T) {
+	gitrepo := &fleetv1.GitRepo{
+		ObjectMeta: metav1.ObjectMeta{Name: "gitrepo", Namespace: "default", UID: "gitrepo-uid"},
+		Status: fleetv1.GitRepoStatus{
+			Paths: []fleetv1.GitRepoPathStatus{{Path: "old"}},
+		},
+	}
+	job := &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "gitrepo-12345",
+			Namespace:   "default",
+			Annotations: map[string]string{"commit": "new"},
+		},
+		Status: batchv1.JobStatus{
+			Succeeded:  1,
+			Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}},
+		},
+	}
+	paths := []fleetv1.GitRepoPathStatus{
+		{Path: "app", BundleName: "gitrepo-app", ContentHash: "abc"},
+		{Path: "broken", Error: true, Message: "boom"},
+	}
+	data, err := json.Marshal(paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: fleetapply.ResultsConfigMapName(gitrepo.Name), Namespace: "default"},
+		Data:       map[string]string{fleetapply.ResultsJobKey: job.Name, fleetapply.ResultsPathsKey: string(data)},
+	}
+	c := fake.NewClientBuilder().WithObjects(configMap).Build()
+
+	if err := setStatusFromGitjob(context.TODO(), c, gitrepo, job); err != nil {
+		t.Fatal(err)
+	}
+	if len(gitrepo.Status.Paths) != 1 || gitrepo.Status.Paths[0].Path != "old" {
+		t.Errorf("expected results of a config map not controlled by the gitrepo to be ignored, got paths %v", gitrepo.Status.Paths)
+	}
+
+	configMap.Data[fleetapply.ResultsJobKey] = "gitrepo-00000"
+	configMap.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: "fleet.cattle.io/v1alpha1",
+		Kind:       "GitRepo",
+		Name:       gitrepo.Name,
+		UID:        gitrepo.UID,
+		Controller: ptr.To(true),
+	}}
+	if err := c.Update(context.TODO(), configMap); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := setStatusFromGitjob(context.TODO(), c, gitrepo, job); err != nil {
+		t.Fatal(err)
+	}
+	if gitrepo.Status.Commit != "new" || len(gitrepo.Status.Paths) != 1 || gitrepo.Status.Paths[0].Path != "old" {
+		t.Errorf("expected results of another job to be ignored, got commit %q, paths %v", gitrepo.Status.Commit, gitrepo.Status.Paths)
+	}
+
+	configMap.Data[fleetapply.ResultsJobKey] = job.Name
+	if err := c.Update(context.TODO(), configMap); err != nil {
+		t.Fatal(err)
+	}
+	if err := setStatusFromGitjob(context.TODO(), c, gitrepo, job); err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(gitrepo.Status.Paths, paths) {
+		t.Errorf("unexpected results of the paths: %s", cmp.Diff(paths, gitrepo.Status.Paths))
+	}
+}
+
+func TestCreateResultsConfigMap(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(corev1.AddToScheme(scheme))
+	utilruntime.Must(fleetv1.AddToScheme(scheme))
+	gitrepo := &fleetv1.GitRepo{ObjectMeta: metav1.ObjectMeta{Name: "gitrepo", Namespace: "default", UID: "gitrepo-uid"}}
+	name := fleetapply.ResultsConfigMapName(gitrepo.Name)
+
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	r := GitJobReconciler{Client: c, Scheme: scheme}
+	if err := r.createResultsConfigMap(context.TODO(), gitrepo); err != nil {
+		t.Fatal(err)
+	}
+	configMap := &corev1.ConfigMap{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: name}, configMap); err != nil {
+		t.Fatal(err)
+	}
+	if !metav1.IsControlledBy(configMap, gitrepo) {
+		t.Errorf("expected config map to be controlled by the gitrepo, got owners %v", configMap.OwnerReferences)
+	}
+	// the existing config map is kept
+	if err := r.createResultsConfigMap(context.TODO(), gitrepo); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, owners := range [][]metav1.OwnerReference{
+		nil,
+		{{APIVersion: "v1", Kind: "Secret", Name: "other", UID: "other-uid", Controller: ptr.To(true)}},
+	} {
+		existing := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", OwnerReferences: owners},
+			Data:       map[string]string{fleetapply.ResultsJobKey: "gitrepo-00000"},
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(existing).Build()
+		r := GitJobReconciler{Client: c, Scheme: scheme}
+		err := r.createResultsConfigMap(context.TODO(), gitrepo)
+		if err == nil || !strings.Contains(err.Error(), "is not controlled by the gitrepo") {
+			t.Errorf("expected config map with owners %v not to be adopted, got %v", owners, err)
+		}
+	}
+}
+
+func TestFetcherReplica(t *testing.T) {
+	if r := fetcherReplica("https://github.com/rancher/fleet-examples", 1); r != 0 {
+		t.Errorf("expected single replica to sync all repositories, got %d", r)
+	}
+	for _, repo := range []string{"https://github.com/rancher/fleet-examples", "git@github.com:rancher/fleet.git"} {
+		r := fetcherReplica(repo, 3)
+		if r < 0 || r >= 3 {
+			t.Errorf("replica %d out of range for %s", r, repo)
+		}
+		if r != fetcherReplica(repo, 3) {
+			t.Errorf("expected stable replica for %s", repo)
+		}
+	}
+}
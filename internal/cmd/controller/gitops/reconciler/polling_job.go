@@ -93,15 +93,16 @@ func (j *gitPollingJob) pollGitRepo(ctx context.Context) error {
 		return j.updateErrorStatus(ctx, gitrepo, pollingTimestamp, origErr)
 	}
 
-	commit, err := monitorLatestCommit(gitrepo, func() (string, error) {
-		return j.gitFetcher.LatestCommit(ctx, gitrepo, j.client)
-	})
+	rev, err := latestCommit(ctx, j.gitFetcher, gitrepo, j.client)
 	if err != nil {
 		return fail(err)
 	}
 
-	if commit != gitrepo.Status.Commit {
-		j.recorder.Event(gitrepo, fleetevent.Normal, "GotNewCommit", commit)
+	if rev.commit != gitrepo.Status.Commit {
+		j.recorder.Event(gitrepo, fleetevent.Normal, "GotNewCommit", rev.commit)
+	}
+	if rev.tag != gitrepo.Status.Tag {
+		j.recorder.Event(gitrepo, fleetevent.Normal, "GotNewTag", rev.tag)
 	}
 
 	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
@@ -111,7 +112,8 @@ func (j *gitPollingJob) pollGitRepo(ctx context.Context) error {
 		}
 
 		t.Status.LastPollingTime = metav1.Time{Time: pollingTimestamp}
-		t.Status.PollingCommit = commit
+		t.Status.PollingCommit = rev.commit
+		rev.setStatus(&t.Status)
 
 		condition.Cond(gitPollingCondition).SetError(&t.Status, "", nil)
 
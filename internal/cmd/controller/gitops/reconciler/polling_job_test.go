@@ -67,6 +67,75 @@ func TestPollGitRepo(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "New tag found",
+			gitrepo: &v1alpha1.GitRepo{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+				Spec:       v1alpha1.GitRepoSpec{Repo: repoURL, TagSemver: ">=1.4.0 <2.0.0"},
+				Status:     v1alpha1.GitRepoStatus{Commit: "old-commit", Tag: "v1.4.0"},
+			},
+			setupMocks: func(c *mocks.MockK8sClient, sw *mocks.MockStatusWriter, gf *gitmocks.MockGitFetcher, r *record.FakeRecorder) {
+				nsName := types.NamespacedName{Name: name, Namespace: namespace}
+				c.EXPECT().Get(gomock.Any(), nsName, gomock.Any()).Times(2).DoAndReturn(func(_ context.Context, _ types.NamespacedName, obj *v1alpha1.GitRepo, _ ...client.GetOption) error {
+					obj.Name = name
+					obj.Namespace = namespace
+					obj.Spec.Repo = repoURL
+					obj.Spec.TagSemver = ">=1.4.0 <2.0.0"
+					obj.Status.Commit = "old-commit"
+					obj.Status.Tag = "v1.4.0"
+					return nil
+				})
+				gf.EXPECT().LatestTag(gomock.Any(), gomock.Any(), gomock.Any()).Return("v1.5.0", "new-commit", nil)
+				c.EXPECT().Status().Return(sw)
+			},
+			expectedEvents: []string{"Normal GotNewCommit new-commit", "Normal GotNewTag v1.5.0"},
+			validateGitRepo: func(t *testing.T, gr *v1alpha1.GitRepo) {
+				t.Helper()
+				if gr.Status.PollingCommit != "new-commit" || gr.Status.Tag != "v1.5.0" {
+					t.Errorf("expected PollingCommit 'new-commit' for tag 'v1.5.0', got %s for tag %s", gr.Status.PollingCommit, gr.Status.Tag)
+				}
+			},
+		},
+		{
+			name: "New commit of a source found",
+			gitrepo: &v1alpha1.GitRepo{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+				Spec: v1alpha1.GitRepoSpec{Repo: repoURL, Branch: branch, Sources: []v1alpha1.GitRepoSource{
+					{Name: "overlays", Repo: "https://github.com/rancher/overlays"},
+				}},
+				Status: v1alpha1.GitRepoStatus{Commit: "old-commit"},
+			},
+			setupMocks: func(c *mocks.MockK8sClient, sw *mocks.MockStatusWriter, gf *gitmocks.MockGitFetcher, r *record.FakeRecorder) {
+				nsName := types.NamespacedName{Name: name, Namespace: namespace}
+				c.EXPECT().Get(gomock.Any(), nsName, gomock.Any()).Times(2).DoAndReturn(func(_ context.Context, _ types.NamespacedName, obj *v1alpha1.GitRepo, _ ...client.GetOption) error {
+					obj.Name = name
+					obj.Namespace = namespace
+					obj.Spec.Repo = repoURL
+					obj.Spec.Branch = branch
+					obj.Spec.Sources = []v1alpha1.GitRepoSource{{Name: "overlays", Repo: "https://github.com/rancher/overlays"}}
+					obj.Status.Commit = "old-commit"
+					return nil
+				})
+				gf.EXPECT().LatestCommit(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, gitrepo *v1alpha1.GitRepo, _ client.Client) (string, error) {
+					if gitrepo.Spec.Repo == repoURL {
+						return "repo-commit", nil
+					}
+					return "source-commit", nil
+				}).Times(2)
+				c.EXPECT().Status().Return(sw)
+			},
+			expectedEvents: []string{"Normal GotNewCommit " + combinedRevision("repo-commit", []v1alpha1.GitRepoSourceStatus{{Name: "overlays", Commit: "source-commit"}})},
+			validateGitRepo: func(t *testing.T, gr *v1alpha1.GitRepo) {
+				t.Helper()
+				sources := []v1alpha1.GitRepoSourceStatus{{Name: "overlays", Commit: "source-commit"}}
+				if gr.Status.PollingCommit != combinedRevision("repo-commit", sources) {
+					t.Errorf("expected PollingCommit to combine the commits of all sources, got %s", gr.Status.PollingCommit)
+				}
+				if gr.Status.RepoCommit != "repo-commit" || len(gr.Status.Sources) != 1 || gr.Status.Sources[0] != sources[0] {
+					t.Errorf("expected commits of the sources in the status, got %s, %v", gr.Status.RepoCommit, gr.Status.Sources)
+				}
+			},
+		},
 		{
 			name: "No new commit",
 			gitrepo: &v1alpha1.GitRepo{
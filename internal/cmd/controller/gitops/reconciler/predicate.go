@@ -48,3 +48,20 @@ func commitChangedPredicate() predicate.Predicate {
 		},
 	}
 }
+
+// fetcherResultChangedPredicate triggers a reconcile when the git fetcher reports the result of a sync.
+func fetcherResultChangedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldGitRepo, ok := e.ObjectOld.(*v1alpha1.GitRepo)
+			if !ok {
+				return true
+			}
+			newGitRepo, ok := e.ObjectNew.(*v1alpha1.GitRepo)
+			if !ok {
+				return true
+			}
+			return !reflect.DeepEqual(oldGitRepo.Status.FetcherResult, newGitRepo.Status.FetcherResult)
+		},
+	}
+}
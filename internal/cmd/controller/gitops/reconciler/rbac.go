@@ -5,6 +5,7 @@ package reconciler
 import (
 	"context"
 
+	fleetapply "github.com/rancher/fleet/internal/cmd/cli/apply"
 	"github.com/rancher/fleet/internal/names"
 
 	v1alpha1 "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
@@ -77,6 +78,12 @@ func (r *GitJobReconciler) createOrUpdateRole(ctx context.Context, gitRepo *v1al
 				APIGroups: []string{""},
 				Resources: []string{"events"},
 			},
+			{
+				Verbs:         []string{"get", "update"},
+				APIGroups:     []string{""},
+				Resources:     []string{"configmaps"},
+				ResourceNames: []string{fleetapply.ResultsConfigMapName(gitRepo.Name)},
+			},
 		}
 		return nil
 	}); err != nil {
@@ -4,8 +4,8 @@ import (
 	"context"
 	"fmt"
 	"regexp"
-	"sort"
 
+	"github.com/rancher/fleet/internal/cmd/controller/restrictions"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -14,17 +14,17 @@ import (
 // AuthorizeAndAssignDefaults applies restrictions and mutates the passed in
 // GitRepo if it passes the restrictions
 func AuthorizeAndAssignDefaults(ctx context.Context, c client.Client, gitrepo *fleet.GitRepo) error {
-	restrictions := &fleet.GitRepoRestrictionList{}
-	err := c.List(ctx, restrictions, client.InNamespace(gitrepo.Namespace))
+	restrictionList := &fleet.GitRepoRestrictionList{}
+	err := c.List(ctx, restrictionList, client.InNamespace(gitrepo.Namespace))
 	if err != nil {
 		return err
 	}
 
-	if len(restrictions.Items) == 0 {
+	if len(restrictionList.Items) == 0 {
 		return nil
 	}
 
-	restriction := aggregate(restrictions.Items)
+	restriction := restrictions.Aggregate(restrictionList.Items)
 
 	if len(restriction.AllowedTargetNamespaces) > 0 && gitrepo.Spec.TargetNamespace == "" {
 		return fmt.Errorf("empty targetNamespace denied, because allowedTargetNamespaces restriction is present")
@@ -54,34 +54,43 @@ func AuthorizeAndAssignDefaults(ctx context.Context, c client.Client, gitrepo *f
 		return fmt.Errorf("disallowed clientSecretName %s: %w", gitrepo.Spec.ClientSecretName, err)
 	}
 
+	// sources are cloned like the repo, with their own client secret
+	sourceClientSecretNames := make([]string, len(gitrepo.Spec.Sources))
+	for i, source := range gitrepo.Spec.Sources {
+		if _, err := isAllowedByRegex(source.Repo, "", restriction.AllowedRepoPatterns); err != nil {
+			return fmt.Errorf("disallowed repo %s of source %s: %w", source.Repo, source.Name, err)
+		}
+		sourceClientSecretNames[i], err = isAllowed(source.ClientSecretName,
+			restriction.DefaultClientSecretName,
+			restriction.AllowedClientSecretNames)
+		if err != nil {
+			return fmt.Errorf("disallowed clientSecretName %s of source %s: %w", source.ClientSecretName, source.Name, err)
+		}
+	}
+
+	targets := []fleet.BundleTargetRestriction{restrictions.DefaultTarget}
+	if len(gitrepo.Spec.Targets) > 0 {
+		targets = make([]fleet.BundleTargetRestriction, 0, len(gitrepo.Spec.Targets))
+		for _, target := range gitrepo.Spec.Targets {
+			targets = append(targets, targetRestriction(target))
+		}
+	}
+	if err := restrictions.CheckTargets(&restriction, targets); err != nil {
+		return fmt.Errorf("disallowed targets: %w", err)
+	}
+
 	// set the defaults back to the GitRepo
 	gitrepo.Spec.TargetNamespace = targetNamespace
 	gitrepo.Spec.ServiceAccount = serviceAccount
 	gitrepo.Spec.Repo = repo
 	gitrepo.Spec.ClientSecretName = clientSecretName
+	for i := range gitrepo.Spec.Sources {
+		gitrepo.Spec.Sources[i].ClientSecretName = sourceClientSecretNames[i]
+	}
 
 	return nil
 }
 
-func aggregate(restrictions []fleet.GitRepoRestriction) (result fleet.GitRepoRestriction) {
-	sort.Slice(restrictions, func(i, j int) bool {
-		return restrictions[i].Name < restrictions[j].Name
-	})
-	for _, restriction := range restrictions {
-		if result.DefaultServiceAccount == "" {
-			result.DefaultServiceAccount = restriction.DefaultServiceAccount
-		}
-		if result.DefaultClientSecretName == "" {
-			result.DefaultClientSecretName = restriction.DefaultClientSecretName
-		}
-		result.AllowedServiceAccounts = append(result.AllowedServiceAccounts, restriction.AllowedServiceAccounts...)
-		result.AllowedClientSecretNames = append(result.AllowedClientSecretNames, restriction.AllowedClientSecretNames...)
-		result.AllowedRepoPatterns = append(result.AllowedRepoPatterns, restriction.AllowedRepoPatterns...)
-		result.AllowedTargetNamespaces = append(result.AllowedTargetNamespaces, restriction.AllowedTargetNamespaces...)
-	}
-	return
-}
-
 func isAllowed(currentValue, defaultValue string, allowedValues []string) (string, error) {
 	if currentValue == "" {
 		return defaultValue, nil
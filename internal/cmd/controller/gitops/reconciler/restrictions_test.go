@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	crclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/rancher/fleet/internal/cmd/controller/gitops/reconciler"
@@ -137,6 +138,52 @@ func TestAuthorizeAndAssignDefaults(t *testing.T) {
 			},
 			expectedErr: "disallowed clientSecretName.*",
 		},
+		{
+			name: "deny disallowed source repo pattern",
+			inputGr: fleet.GitRepo{
+				Spec: fleet.GitRepoSpec{
+					Repo:    "baz",
+					Sources: []fleet.GitRepoSource{{Name: "values", Repo: "bar"}},
+				},
+			},
+			restrictions: &fleet.GitRepoRestrictionList{
+				Items: []fleet.GitRepoRestriction{
+					{
+						AllowedRepoPatterns: []string{"baz"},
+					},
+				},
+			},
+			expectedGr: fleet.GitRepo{
+				Spec: fleet.GitRepoSpec{
+					Repo:    "baz",
+					Sources: []fleet.GitRepoSource{{Name: "values", Repo: "bar"}},
+				},
+			},
+			expectedErr: "disallowed repo bar of source values.*",
+		},
+		{
+			name: "deny disallowed source client secret name",
+			inputGr: fleet.GitRepo{
+				Spec: fleet.GitRepoSpec{
+					ClientSecretName: "foo",
+					Sources:          []fleet.GitRepoSource{{Name: "values", ClientSecretName: "not-foo"}},
+				},
+			},
+			restrictions: &fleet.GitRepoRestrictionList{
+				Items: []fleet.GitRepoRestriction{
+					{
+						AllowedClientSecretNames: []string{"foo"},
+					},
+				},
+			},
+			expectedGr: fleet.GitRepo{
+				Spec: fleet.GitRepoSpec{
+					ClientSecretName: "foo",
+					Sources:          []fleet.GitRepoSource{{Name: "values", ClientSecretName: "not-foo"}},
+				},
+			},
+			expectedErr: "disallowed clientSecretName not-foo of source values.*",
+		},
 		{
 			name: "pass when no restrictions nor disallowed values exist",
 			inputGr: fleet.GitRepo{
@@ -180,12 +227,77 @@ func TestAuthorizeAndAssignDefaults(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "deny targets outside of allowed cluster groups",
+			inputGr: fleet.GitRepo{
+				Spec: fleet.GitRepoSpec{
+					Targets: []fleet.GitTarget{
+						{Name: "tenant", ClusterGroup: "tenant-a"},
+						{Name: "all", ClusterSelector: &metav1.LabelSelector{}},
+					},
+				},
+			},
+			restrictions: &fleet.GitRepoRestrictionList{
+				Items: []fleet.GitRepoRestriction{
+					{
+						AllowedClusterGroups: []string{"tenant-a"},
+					},
+				},
+			},
+			expectedGr: fleet.GitRepo{
+				Spec: fleet.GitRepoSpec{
+					Targets: []fleet.GitTarget{
+						{Name: "tenant", ClusterGroup: "tenant-a"},
+						{Name: "all", ClusterSelector: &metav1.LabelSelector{}},
+					},
+				},
+			},
+			expectedErr: `disallowed targets: target "all" is not restricted to allowedClusterGroups \[tenant-a\]`,
+		},
+		{
+			name:    "deny default target when it is not in allowed cluster groups",
+			inputGr: fleet.GitRepo{},
+			restrictions: &fleet.GitRepoRestrictionList{
+				Items: []fleet.GitRepoRestriction{
+					{
+						AllowedClusterGroups: []string{"tenant-a"},
+					},
+				},
+			},
+			expectedGr:  fleet.GitRepo{},
+			expectedErr: `disallowed targets: target "default"`,
+		},
+		{
+			name: "pass targets matching allowed cluster selectors",
+			inputGr: fleet.GitRepo{
+				Spec: fleet.GitRepoSpec{
+					Targets: []fleet.GitTarget{
+						{ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "a", "env": "dev"}}},
+					},
+				},
+			},
+			restrictions: &fleet.GitRepoRestrictionList{
+				Items: []fleet.GitRepoRestriction{
+					{
+						AllowedClusterSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"tenant": "a"}}},
+					},
+				},
+			},
+			expectedGr: fleet.GitRepo{
+				Spec: fleet.GitRepoSpec{
+					Targets: []fleet.GitTarget{
+						{ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "a", "env": "dev"}}},
+					},
+				},
+			},
+		},
 		{
 			name: "pass and mutate repo with defaults when restrictions exist and the GitRepo matches allowed values",
 			inputGr: fleet.GitRepo{
 				Spec: fleet.GitRepoSpec{
 					Repo:            "http://foo.bar/baz",
 					TargetNamespace: "tns",
+					Sources:         []fleet.GitRepoSource{{Name: "values", Repo: "http://foo.bar/values"}},
 				},
 			},
 			restrictions: &fleet.GitRepoRestrictionList{
@@ -206,6 +318,7 @@ func TestAuthorizeAndAssignDefaults(t *testing.T) {
 					Repo:             "http://foo.bar/baz",
 					ServiceAccount:   "dsacc",
 					TargetNamespace:  "tns",
+					Sources:          []fleet.GitRepoSource{{Name: "values", Repo: "http://foo.bar/values", ClientSecretName: "dcsn"}},
 				},
 			},
 		},
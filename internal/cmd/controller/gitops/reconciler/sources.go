@@ -0,0 +1,116 @@
+package reconciler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+
+	v1alpha1 "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// latestRevision is the latest revision of a gitrepo, as resolved by polling.
+type latestRevision struct {
+	// tag is the tag selected by the semver constraint of the gitrepo.
+	tag string
+	// commit is the latest commit, or the revision combining the commits of all sources.
+	commit string
+	// repoCommit and sources are the commits of the repository and its additional sources, if it has any.
+	repoCommit string
+	sources    []v1alpha1.GitRepoSourceStatus
+}
+
+// setStatus sets the tag and the commits of the sources in the status. The commit is not set, as it is either the
+// polling commit or the commit, if polling is disabled.
+func (rev latestRevision) setStatus(status *v1alpha1.GitRepoStatus) {
+	status.Tag = rev.tag
+	status.RepoCommit = rev.repoCommit
+	status.Sources = rev.sources
+}
+
+// latestSourceCommits returns the latest commits of the additional sources of the gitrepo.
+func latestSourceCommits(ctx context.Context, fetcher GitFetcher, gitrepo *v1alpha1.GitRepo, c client.Client) ([]v1alpha1.GitRepoSourceStatus, error) {
+	sources := make([]v1alpha1.GitRepoSourceStatus, 0, len(gitrepo.Spec.Sources))
+	for _, source := range gitrepo.Spec.Sources {
+		commit, err := fetcher.LatestCommit(ctx, sourceGitRepo(gitrepo, source, ""), c)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get latest commit of source %q: %w", source.Name, err)
+		}
+		sources = append(sources, v1alpha1.GitRepoSourceStatus{Name: source.Name, Commit: commit})
+	}
+	return sources, nil
+}
+
+// combinedRevision returns a revision, as long as a commit hash, which changes with the commit of the repository or
+// of any source. It is used as the commit of a gitrepo with additional sources, so a change in any of them triggers
+// a new sync.
+func combinedRevision(commit string, sources []v1alpha1.GitRepoSourceStatus) string {
+	h := sha256.New()
+	h.Write([]byte(commit))
+	for _, source := range sources {
+		fmt.Fprintf(h, "\n%s=%s", source.Name, source.Commit)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:40]
+}
+
+// repoCommit returns the commit of the gitrepo's repository, which differs from the commit in the status if the
+// gitrepo has additional sources.
+func repoCommit(gitrepo *v1alpha1.GitRepo) string {
+	if len(gitrepo.Spec.Sources) > 0 {
+		return gitrepo.Status.RepoCommit
+	}
+	return gitrepo.Status.Commit
+}
+
+// sourceCommit returns the commit of the source, which was resolved by polling, or an empty string if the source
+// was not polled yet.
+func sourceCommit(sources []v1alpha1.GitRepoSourceStatus, name string) string {
+	for _, s := range sources {
+		if s.Name == name {
+			return s.Commit
+		}
+	}
+	return ""
+}
+
+// sourceGitRepo returns a copy of the gitrepo for an additional source, to poll and clone it like a repository. The
+// source shares the CA bundle, TLS and LFS settings of the gitrepo. If commit is set, it is checked out instead of the
+// branch the source follows.
+func sourceGitRepo(gitrepo *v1alpha1.GitRepo, source v1alpha1.GitRepoSource, commit string) *v1alpha1.GitRepo {
+	obj := gitrepo.DeepCopy()
+	obj.Spec.Repo = source.Repo
+	obj.Spec.Branch = source.Branch
+	obj.Spec.Revision = source.Revision
+	obj.Spec.ClientSecretName = source.ClientSecretName
+	obj.Spec.TagSemver = ""
+	obj.Spec.SparseCheckout = false
+	obj.Spec.Archive = nil
+	obj.Spec.Sources = nil
+	obj.Status = v1alpha1.GitRepoStatus{}
+	if commit != "" {
+		obj.Spec.Branch = ""
+		obj.Spec.Revision = commit
+	}
+	return obj
+}
+
+// ValidateSources checks that the sources can be mounted into the repository.
+func ValidateSources(sources []v1alpha1.GitRepoSource) error {
+	names := map[string]bool{}
+	for _, source := range sources {
+		if names[source.Name] {
+			return fmt.Errorf("duplicate source %q", source.Name)
+		}
+		names[source.Name] = true
+		if source.Name == "." || source.Name == ".." || source.Name == ".git" {
+			return fmt.Errorf("invalid source name %q", source.Name)
+		}
+		if source.Path != "" && !filepath.IsLocal(source.Path) {
+			return fmt.Errorf("invalid path %q of source %q, it must be relative to the repository", source.Path, source.Name)
+		}
+	}
+	return nil
+}
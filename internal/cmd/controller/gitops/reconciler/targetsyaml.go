@@ -23,8 +23,12 @@ func newTargetsConfigMap(repo *fleet.GitRepo) (*corev1.ConfigMap, error) {
 			ClusterSelector:      target.ClusterSelector,
 			ClusterGroup:         target.ClusterGroup,
 			ClusterGroupSelector: target.ClusterGroupSelector,
+			ClusterFactSelector:  target.ClusterFactSelector,
+			ClusterExpression:    target.ClusterExpression,
+			MaxClusters:          target.MaxClusters,
+			SpreadByLabel:        target.SpreadByLabel,
 		})
-		spec.TargetRestrictions = append(spec.TargetRestrictions, fleet.BundleTargetRestriction(target))
+		spec.TargetRestrictions = append(spec.TargetRestrictions, targetRestriction(target))
 	}
 	data, err := json.Marshal(spec)
 	if err != nil {
@@ -43,6 +47,20 @@ func newTargetsConfigMap(repo *fleet.GitRepo) (*corev1.ConfigMap, error) {
 	}, nil
 }
 
+// targetRestriction returns the cluster matchers of a GitTarget. The limits on the number of clusters are not
+// restrictions, they only apply to the BundleTarget.
+func targetRestriction(target fleet.GitTarget) fleet.BundleTargetRestriction {
+	return fleet.BundleTargetRestriction{
+		Name:                 target.Name,
+		ClusterName:          target.ClusterName,
+		ClusterSelector:      target.ClusterSelector,
+		ClusterGroup:         target.ClusterGroup,
+		ClusterGroupSelector: target.ClusterGroupSelector,
+		ClusterFactSelector:  target.ClusterFactSelector,
+		ClusterExpression:    target.ClusterExpression,
+	}
+}
+
 func targetsOrDefault(targets []fleet.GitTarget) []fleet.GitTarget {
 	if len(targets) == 0 {
 		return []fleet.GitTarget{
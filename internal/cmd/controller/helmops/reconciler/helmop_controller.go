@@ -124,7 +124,7 @@ func (r *HelmOpReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return ctrl.Result{}, err
 	}
 
-	if err := validate(*helmop); err != nil {
+	if err := Validate(*helmop); err != nil {
 		if delErr := r.deletePollingJob(*helmop); delErr != nil {
 			err = errutil.NewAggregate([]error{err, delErr})
 		}
@@ -542,13 +542,13 @@ func jobKey(h fleet.HelmOp) *quartz.JobKey {
 	return quartz.NewJobKey(string(h.UID))
 }
 
-// validate checks combinations of Chart, Repo and Version fields in h's Helm options.
+// Validate checks combinations of Chart, Repo and Version fields in h's Helm options.
 // It returns an error if those options are nil, or if they don't fall under any of these categories,
 // as per https://helm.sh/docs/helm/helm_install/ :
 // * tarball URL in Chart, empty Repo, empty Version
 // * OCI reference in the Repo field, empty Chart, optional Version
 // * non-empty Repo URL, non-empty Chart name, optional Version
-func validate(h fleet.HelmOp) error {
+func Validate(h fleet.HelmOp) error {
 	if h.Spec.Helm == nil {
 		return fmt.Errorf("helm options are empty in the HelmOp's spec")
 	}
@@ -26,6 +26,7 @@ import (
 	"golang.org/x/sync/semaphore"
 
 	"github.com/rancher/fleet/internal/cmd/controller/imagescan/update"
+	"github.com/rancher/fleet/internal/gitcredentials"
 	fleetgithub "github.com/rancher/fleet/internal/github"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	"github.com/rancher/fleet/pkg/durations"
@@ -54,8 +55,9 @@ var (
 var _ quartz.Job = &GitCommitJob{}
 
 type GitCommitJob struct {
-	sem    *semaphore.Weighted
-	client client.Client
+	sem         *semaphore.Weighted
+	client      client.Client
+	credentials *gitcredentials.Cache
 
 	namespace string
 	name      string
@@ -69,10 +71,11 @@ func GitCommitKey(namespace string, name string) *quartz.JobKey {
 	return quartz.NewJobKey(gitCommitDescription(namespace, name))
 }
 
-func NewGitCommitJob(c client.Client, namespace string, name string) *GitCommitJob {
+func NewGitCommitJob(c client.Client, credentials *gitcredentials.Cache, namespace string, name string) *GitCommitJob {
 	return &GitCommitJob{
-		sem:    semaphore.NewWeighted(1),
-		client: c,
+		sem:         semaphore.NewWeighted(1),
+		client:      c,
+		credentials: credentials,
 
 		namespace: namespace,
 		name:      name,
@@ -160,7 +163,7 @@ func (j *GitCommitJob) cloneAndReplace(ctx context.Context) {
 	}
 	defer os.RemoveAll(tmp)
 
-	auth, err := readAuth(ctx, logger, j.client, gitrepo)
+	auth, err := readAuth(ctx, logger, j.client, j.credentials, gitrepo)
 	if err != nil {
 		err = j.updateErrorStatus(ctx, gitrepo, err)
 		logger.V(1).Info("Cannot create temp dir to clone repo", "error", err)
@@ -272,7 +275,7 @@ func shouldSync(gitrepo *fleet.GitRepo) bool {
 	return true
 }
 
-func readAuth(ctx context.Context, logger logr.Logger, c client.Client, gitrepo *fleet.GitRepo) (transport.AuthMethod, error) {
+func readAuth(ctx context.Context, logger logr.Logger, c client.Client, credentials *gitcredentials.Cache, gitrepo *fleet.GitRepo) (transport.AuthMethod, error) {
 	if gitrepo.Spec.ClientSecretName == "" {
 		return nil, errors.New("requires git secret for write access")
 	}
@@ -305,6 +308,16 @@ func readAuth(ctx context.Context, logger logr.Logger, c client.Client, gitrepo
 		}
 		return publicKey, nil
 	default:
+		if gitcredentials.HasProvider(secret) {
+			if credentials == nil {
+				return nil, fmt.Errorf("credential providers are not enabled, cannot use secret %s/%s", secret.Namespace, secret.Name)
+			}
+			token, err := credentials.Token(ctx, secret)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get token from credential provider of secret %s/%s: %w", secret.Namespace, secret.Name, err)
+			}
+			return token.AuthMethod(), nil
+		}
 		auth, err := fleetgithub.GetGithubAppAuthFromSecret(secret, fleetgithub.DefaultAppAuthGetter{})
 		if err != nil {
 			return nil, err
@@ -8,12 +8,17 @@ import (
 	"github.com/reugn/go-quartz/quartz"
 
 	"github.com/rancher/fleet/internal/cmd"
+	"github.com/rancher/fleet/internal/cmd/controller/push"
 	"github.com/rancher/fleet/internal/cmd/controller/reconciler"
 	"github.com/rancher/fleet/internal/cmd/controller/target"
+	"github.com/rancher/fleet/internal/cmd/controller/webhook"
 	"github.com/rancher/fleet/internal/config"
 	"github.com/rancher/fleet/internal/experimental"
+	"github.com/rancher/fleet/internal/gitcredentials"
 	"github.com/rancher/fleet/internal/manifest"
 	"github.com/rancher/fleet/internal/metrics"
+	"github.com/rancher/fleet/internal/ocistorage"
+	"github.com/rancher/fleet/internal/secretstore"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 
 	"k8s.io/apimachinery/pkg/runtime"
@@ -25,6 +30,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	ctrlwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
 )
 
 var (
@@ -47,6 +53,8 @@ func start(
 	bindAddresses BindAddresses,
 	disableMetrics bool,
 	shardID string,
+	webhookPort int,
+	capiNamespaces []string,
 ) error {
 	setupLog.Info("listening for changes on local cluster",
 		"disableMetrics", disableMetrics,
@@ -65,10 +73,19 @@ func start(
 		leaderElectionSuffix = fmt.Sprintf("-%s", shardID)
 	}
 
+	var webhookServer ctrlwebhook.Server
+	if webhookPort != 0 {
+		webhookServer = ctrlwebhook.NewServer(ctrlwebhook.Options{
+			Port:    webhookPort,
+			CertDir: webhook.CertDir,
+		})
+	}
+
 	mgr, err := ctrl.NewManager(config, ctrl.Options{
 		Scheme:                 scheme,
 		Metrics:                metricServerOptions,
 		HealthProbeBindAddress: bindAddresses.HealthProbe,
+		WebhookServer:          webhookServer,
 
 		LeaderElection:          leaderElection,
 		LeaderElectionID:        fmt.Sprintf("fleet-controller-leader-election-shard%s", leaderElectionSuffix),
@@ -159,6 +176,22 @@ func start(
 		return err
 	}
 
+	// bundledeployments of clusters in push mode are deployed by the
+	// controller, instead of an agent
+	if err = (&push.BundleDeploymentReconciler{
+		Client:          mgr.GetClient(),
+		Reader:          mgr.GetAPIReader(),
+		Scheme:          mgr.GetScheme(),
+		SystemNamespace: systemNamespace,
+		ShardID:         shardID,
+
+		Workers:        workersOpts.Push,
+		ClusterWorkers: workersOpts.PushCluster,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "PushBundleDeployment")
+		return err
+	}
+
 	// imagescan controller
 	if err = (&reconciler.ImageScanReconciler{
 		Client: mgr.GetClient(),
@@ -168,6 +201,8 @@ func start(
 		ShardID:   shardID,
 
 		Workers: workersOpts.ImageScan,
+
+		Credentials: gitcredentials.NewClientCache(mgr.GetClient(), mgr.GetAPIReader()),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ImageScan")
 		return err
@@ -209,6 +244,38 @@ func start(
 		return err
 	}
 
+	// OCI storage registries are shared by all shards, only the unsharded controller reconciles them
+	if shardID == "" && ocistorage.OCIIsEnabled() {
+		if err = (&reconciler.OCIStorageReconciler{
+			Client:   mgr.GetClient(),
+			Scheme:   mgr.GetScheme(),
+			Recorder: mgr.GetEventRecorderFor("fleet-ocistorage-ctrl"),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "OCIStorage")
+			return err
+		}
+	}
+
+	// Cluster API clusters are registered by the unsharded controller, the
+	// fleet clusters are assigned to shards like manually created ones
+	if shardID == "" && len(capiNamespaces) > 0 {
+		if err = (&reconciler.CAPIClusterReconciler{
+			Client:     mgr.GetClient(),
+			Scheme:     mgr.GetScheme(),
+			Namespaces: capiNamespaces,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "CAPICluster")
+			return err
+		}
+	}
+
+	if webhookPort != 0 {
+		if err := webhook.Setup(ctx, mgr, systemNamespace); err != nil {
+			setupLog.Error(err, "unable to set up admission webhook")
+			return err
+		}
+	}
+
 	//+kubebuilder:scaffold:builder
 
 	if err := reconciler.Load(ctx, mgr.GetAPIReader(), systemNamespace); err != nil {
@@ -260,7 +327,8 @@ func AddContentNameLabelIndexer(ctx context.Context, mgr manager.Manager) error
 	)
 }
 
-// AddBundleDownstreamResourceIndexer indexes Bundles by their DownstreamResources (secrets and configmaps).
+// AddBundleDownstreamResourceIndexer indexes Bundles by their DownstreamResources (secrets and configmaps) and
+// the upstream secrets referenced in their valuesFrom options.
 // This allows querying which bundles reference a specific secret or configmap, enabling reconciliation
 // when those resources change.
 func AddBundleDownstreamResourceIndexer(ctx context.Context, mgr manager.Manager) error {
@@ -283,6 +351,19 @@ func AddBundleDownstreamResourceIndexer(ctx context.Context, mgr manager.Manager
 					resources = append(resources, fmt.Sprintf("%s/%s", lowerKind, dr.Name))
 				}
 			}
+
+			var valuesFrom []fleet.ValuesFrom
+			if bundle.Spec.Helm != nil {
+				valuesFrom = append(valuesFrom, bundle.Spec.Helm.ValuesFrom...)
+			}
+			for _, target := range bundle.Spec.Targets {
+				if target.Helm != nil {
+					valuesFrom = append(valuesFrom, target.Helm.ValuesFrom...)
+				}
+			}
+			for _, name := range secretstore.SecretNames(valuesFrom) {
+				resources = append(resources, fmt.Sprintf("secret/%s", name))
+			}
 			return resources
 		},
 	)
@@ -61,6 +61,9 @@ func Merge(base, custom fleet.BundleDeploymentOptions) fleet.BundleDeploymentOpt
 		if custom.Helm.ValuesFrom != nil {
 			result.Helm.ValuesFrom = append(result.Helm.ValuesFrom, custom.Helm.ValuesFrom...)
 		}
+		if custom.Helm.SopsValuesFiles != nil {
+			result.Helm.SopsValuesFiles = append(result.Helm.SopsValuesFiles, custom.Helm.SopsValuesFiles...)
+		}
 		if custom.Helm.Repo != "" {
 			result.Helm.Repo = custom.Helm.Repo
 		}
@@ -107,6 +110,25 @@ func Merge(base, custom fleet.BundleDeploymentOptions) fleet.BundleDeploymentOpt
 	if custom.CorrectDrift != nil {
 		result.CorrectDrift = custom.CorrectDrift
 	}
+	if custom.Sops != nil {
+		result.Sops = custom.Sops.DeepCopy()
+	}
+	if custom.ResourceTemplating != nil {
+		result.ResourceTemplating = custom.ResourceTemplating.DeepCopy()
+	}
+	if custom.Outputs != nil {
+		// custom outputs are evaluated last, as the last output with a name wins
+		result.Outputs = append(result.Outputs, custom.Outputs...)
+	}
+	if custom.ImageRewrite != nil {
+		// custom rules are evaluated first, as the first matching rule wins
+		merged := custom.ImageRewrite.DeepCopy()
+		if result.ImageRewrite != nil {
+			merged.Rules = append(merged.Rules, result.ImageRewrite.Rules...)
+			merged.Paths = append(merged.Paths, result.ImageRewrite.Paths...)
+		}
+		result.ImageRewrite = merged
+	}
 
 	return result
 }
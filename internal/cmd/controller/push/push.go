@@ -0,0 +1,237 @@
+// Package push deploys bundles to clusters without an agent. For clusters in push mode, the fleet controller runs the
+// agent's reconcilers against the API server of the cluster, using the kubeconfig from the cluster's KubeConfigSecret.
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	agentcontroller "github.com/rancher/fleet/internal/cmd/agent/controller"
+	"github.com/rancher/fleet/internal/config"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/sharding"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// defaultNamespace is the namespace to use for resources that don't specify a namespace, like on the agent
+	defaultNamespace = "default"
+	// agentScope is empty, like for agents deployed by fleet
+	agentScope = ""
+
+	// clusterBusyRequeueDelay is the delay before a bundledeployment is
+	// reconciled again, when all workers of its cluster are busy
+	clusterBusyRequeueDelay = 2 * time.Second
+
+	// workerRetryDelay is the initial delay before creating the worker of
+	// a cluster is retried, it doubles with each failure up to
+	// workerMaxRetryDelay
+	workerRetryDelay    = 5 * time.Second
+	workerMaxRetryDelay = 5 * time.Minute
+)
+
+// remoteScheme is the scheme of the clients for the clusters, like the agent's local scheme
+var remoteScheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(remoteScheme))
+}
+
+// BundleDeploymentReconciler deploys the bundledeployments of clusters in
+// push mode. It creates a worker for each cluster, which runs the agent's
+// bundledeployment and drift reconcilers against the cluster's API server and
+// reports the cluster status in place of the agent.
+type BundleDeploymentReconciler struct {
+	client.Client
+	Reader client.Reader
+	Scheme *runtime.Scheme
+
+	// SystemNamespace is the agent namespace on the clusters, unless the
+	// cluster sets an agent namespace.
+	SystemNamespace string
+	ShardID         string
+
+	// Workers is the number of bundledeployments reconciled concurrently,
+	// across all clusters.
+	Workers int
+	// ClusterWorkers is the number of bundledeployments reconciled
+	// concurrently for a single cluster.
+	ClusterWorkers int
+
+	workers   *workers
+	driftChan chan event.TypedGenericEvent[*fleet.BundleDeployment]
+}
+
+// SetupWithManager sets up the bundledeployment and drift controllers with the Manager.
+func (r *BundleDeploymentReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.workers = newWorkers()
+	r.driftChan = make(chan event.TypedGenericEvent[*fleet.BundleDeployment])
+	if err := mgr.Add(r.workers); err != nil {
+		return err
+	}
+
+	if err := ctrl.NewControllerManagedBy(mgr).
+		Named("push-bundledeployment").
+		For(&fleet.BundleDeployment{}, builder.WithPredicates(agentcontroller.BundleDeploymentChangedPredicate())).
+		Watches(
+			// redeploy when a cluster is switched to push mode or its kubeconfig secret changes
+			&fleet.Cluster{},
+			handler.EnqueueRequestsFromMapFunc(r.mapClusterToBundleDeployments),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		WithEventFilter(sharding.FilterByShardID(r.ShardID)).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.Workers}).
+		Complete(r); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("push-drift").
+		WatchesRawSource(source.Channel(r.driftChan, agentcontroller.EnqueueRequestHandlerWithDelay(agentcontroller.EnqueueDelay))).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.Workers}).
+		Complete(reconcile.Func(r.reconcileDrift))
+}
+
+//+kubebuilder:rbac:groups=fleet.cattle.io,resources=bundledeployments,verbs=get;list;watch
+//+kubebuilder:rbac:groups=fleet.cattle.io,resources=bundledeployments/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=fleet.cattle.io,resources=clusters,verbs=get;list;watch
+//+kubebuilder:rbac:groups=fleet.cattle.io,resources=clusters/status,verbs=patch
+
+// Reconcile deploys a bundledeployment of a cluster in push mode to the
+// cluster. Bundledeployments of other clusters are ignored.
+func (r *BundleDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithName("push").WithValues("namespace", req.Namespace)
+	ctx = log.IntoContext(ctx, logger)
+
+	w, err := r.ensureWorker(ctx, req.Namespace)
+	if errors.Is(err, errClusterBusy) {
+		return ctrl.Result{RequeueAfter: clusterBusyRequeueDelay}, nil
+	}
+	var backoff *backoffError
+	if errors.As(err, &backoff) {
+		return ctrl.Result{RequeueAfter: max(time.Until(backoff.retryAt), time.Second)}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if w == nil {
+		return ctrl.Result{}, nil
+	}
+
+	return w.run(ctx, w.bundleDeployments, req)
+}
+
+// reconcileDrift corrects drift of resources deployed by a worker. Drift is
+// only detected by existing workers.
+func (r *BundleDeploymentReconciler) reconcileDrift(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	w := r.workers.get(req.Namespace)
+	if w == nil {
+		return ctrl.Result{}, nil
+	}
+	return w.run(ctx, w.drift, req)
+}
+
+// ensureWorker returns the worker for the cluster of the namespace, if the
+// cluster is in push mode. The worker is replaced when the kubeconfig changes
+// and stopped when the cluster leaves push mode. An agent on the cluster is
+// deleted before the worker is started.
+func (r *BundleDeploymentReconciler) ensureWorker(ctx context.Context, namespace string) (*worker, error) {
+	cluster, err := r.clusterForNamespace(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if cluster == nil || cluster.Spec.DeploymentMode != fleet.DeploymentModePush || cluster.Spec.KubeConfigSecret == "" {
+		r.workers.stop(namespace)
+		return nil, nil
+	}
+
+	// the kubeconfig secret is used with the controller's permissions, it
+	// has to be in the cluster's namespace
+	if ns := cluster.Spec.KubeConfigSecretNamespace; ns != "" && ns != cluster.Namespace {
+		r.workers.stop(namespace)
+		return nil, reconcile.TerminalError(fmt.Errorf("kubeconfig secret namespace %q is not supported in push mode, the secret has to be in the namespace of the cluster", ns))
+	}
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: cluster.Namespace, Name: cluster.Spec.KubeConfigSecret}, secret); err != nil {
+		return nil, err
+	}
+
+	key := string(cluster.UID) + "/" + secret.ResourceVersion + "/" + cluster.Spec.AgentNamespace
+	return r.workers.ensure(namespace, key, func(parent context.Context) (*worker, error) {
+		logger := log.FromContext(ctx).WithValues("cluster", cluster.Name, "clusterNamespace", cluster.Namespace)
+		logger.Info("Starting push mode worker for cluster")
+		return newWorker(parent, logger, workerOptions{
+			upstream:        r.Client,
+			upstreamReader:  r.Reader,
+			systemNamespace: r.SystemNamespace,
+			workers:         r.ClusterWorkers,
+			driftChan:       r.driftChan,
+		}, key, cluster, secret.Data[config.KubeConfigSecretValueKey])
+	})
+}
+
+// clusterForNamespace returns the cluster of a cluster namespace, which
+// contains the cluster's bundledeployments.
+func (r *BundleDeploymentReconciler) clusterForNamespace(ctx context.Context, namespace string) (*fleet.Cluster, error) {
+	ns := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	name := ns.Annotations[fleet.ClusterAnnotation]
+	clusterNamespace := ns.Annotations[fleet.ClusterNamespaceAnnotation]
+	if name == "" || clusterNamespace == "" {
+		return nil, nil
+	}
+
+	cluster := &fleet.Cluster{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: clusterNamespace, Name: name}, cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if cluster.Status.Namespace != namespace {
+		return nil, nil
+	}
+	return cluster, nil
+}
+
+// mapClusterToBundleDeployments enqueues the bundledeployments of a cluster,
+// so they are deployed by the agent or a worker when the deployment mode
+// changes.
+func (r *BundleDeploymentReconciler) mapClusterToBundleDeployments(ctx context.Context, obj client.Object) []reconcile.Request {
+	cluster, ok := obj.(*fleet.Cluster)
+	if !ok || cluster.Status.Namespace == "" {
+		return nil
+	}
+
+	bds := &fleet.BundleDeploymentList{}
+	if err := r.List(ctx, bds, client.InNamespace(cluster.Status.Namespace)); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list bundledeployments of cluster", "cluster", cluster.Name, "namespace", cluster.Namespace)
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(bds.Items))
+	for _, bd := range bds.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: bd.Namespace, Name: bd.Name}})
+	}
+	return requests
+}
@@ -0,0 +1,215 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func newTestWorker(ctx context.Context, key string, size int) *worker {
+	ctx, cancel := context.WithCancel(ctx)
+	return &worker{key: key, ctx: ctx, cancel: cancel, sem: make(chan struct{}, size)}
+}
+
+func TestWorkers(t *testing.T) {
+	ws := newWorkers()
+	created := 0
+	create := func(key string) func(ctx context.Context) (*worker, error) {
+		return func(ctx context.Context) (*worker, error) {
+			created++
+			return newTestWorker(ctx, key, 1), nil
+		}
+	}
+
+	w1, err := ws.ensure("ns", "v1", create("v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w, _ := ws.ensure("ns", "v1", create("v1")); w != w1 || created != 1 {
+		t.Fatalf("expected the existing worker to be reused, created %d workers", created)
+	}
+
+	// a new kubeconfig replaces the worker
+	w2, err := ws.ensure("ns", "v2", create("v2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w2 == w1 || w1.ctx.Err() == nil {
+		t.Fatal("expected the old worker to be stopped and replaced")
+	}
+	if ws.get("ns") != w2 {
+		t.Fatal("expected the new worker to be returned")
+	}
+
+	ws.stop("ns")
+	if w2.ctx.Err() == nil || ws.get("ns") != nil {
+		t.Fatal("expected the worker to be stopped and removed")
+	}
+
+	// all workers are stopped with the manager
+	w3, _ := ws.ensure("other", "v1", create("v1"))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := ws.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if w3.ctx.Err() == nil {
+		t.Fatal("expected the worker to be stopped with the manager")
+	}
+}
+
+func TestWorkersCreate(t *testing.T) {
+	ws := newWorkers()
+
+	// other clusters are not blocked while a worker is created
+	_, err := ws.ensure("ns", "v1", func(ctx context.Context) (*worker, error) {
+		if ws.get("other") != nil {
+			t.Error("expected no worker for the other cluster")
+		}
+		if _, err := ws.ensure("ns", "v1", nil); !errors.Is(err, errClusterBusy) {
+			t.Errorf("expected the cluster to be busy, got %v", err)
+		}
+		return nil, errors.New("cluster unreachable")
+	})
+	if err == nil || err.Error() != "cluster unreachable" {
+		t.Fatalf("expected the error of the worker, got %v", err)
+	}
+
+	// creating the worker is backed off after a failure
+	_, err = ws.ensure("ns", "v1", func(context.Context) (*worker, error) {
+		t.Fatal("expected the worker not to be created")
+		return nil, nil
+	})
+	var backoff *backoffError
+	if !errors.As(err, &backoff) || time.Until(backoff.retryAt) <= 0 {
+		t.Fatalf("expected a backoff error, got %v", err)
+	}
+
+	// but not when the kubeconfig changes
+	w, err := ws.ensure("ns", "v2", func(ctx context.Context) (*worker, error) {
+		return newTestWorker(ctx, "v2", 1), nil
+	})
+	if err != nil || w == nil {
+		t.Fatalf("expected a worker, got %v", err)
+	}
+
+	// a worker is discarded, if the cluster left push mode while it was created
+	_, err = ws.ensure("stopped", "v1", func(ctx context.Context) (*worker, error) {
+		ws.stop("stopped")
+		w = newTestWorker(ctx, "v1", 1)
+		return w, nil
+	})
+	if !errors.Is(err, errClusterBusy) || ws.get("stopped") != nil || w.ctx.Err() == nil {
+		t.Fatalf("expected the worker to be discarded, got %v", err)
+	}
+}
+
+func TestWorkerBackoff(t *testing.T) {
+	if d := workerBackoff(1); d != workerRetryDelay {
+		t.Errorf("expected %s, got %s", workerRetryDelay, d)
+	}
+	if d := workerBackoff(2); d != 2*workerRetryDelay {
+		t.Errorf("expected %s, got %s", 2*workerRetryDelay, d)
+	}
+	if d := workerBackoff(100); d != workerMaxRetryDelay {
+		t.Errorf("expected %s, got %s", workerMaxRetryDelay, d)
+	}
+}
+
+func TestWorkerRun(t *testing.T) {
+	w := newTestWorker(context.Background(), "v1", 1)
+	calls := 0
+	r := reconcile.Func(func(ctx context.Context, _ ctrl.Request) (ctrl.Result, error) {
+		calls++
+		if ctx.Err() != nil {
+			t.Error("expected the worker's context")
+		}
+		return ctrl.Result{}, nil
+	})
+
+	if res, err := w.run(context.Background(), r, ctrl.Request{}); err != nil || !res.IsZero() || calls != 1 {
+		t.Fatalf("expected the reconciler to run, got %v, %v", res, err)
+	}
+
+	// the cluster's reconciles are busy
+	w.sem <- struct{}{}
+	res, err := w.run(context.Background(), r, ctrl.Request{})
+	if err != nil || res.RequeueAfter != clusterBusyRequeueDelay || calls != 1 {
+		t.Fatalf("expected the request to be requeued, got %v, %v", res, err)
+	}
+}
+
+func TestEnsureWorkerStopsWorkerOutsidePushMode(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(fleet.AddToScheme(scheme))
+
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name: "cluster-fleet-default-c1",
+		Annotations: map[string]string{
+			fleet.ClusterAnnotation:          "c1",
+			fleet.ClusterNamespaceAnnotation: "fleet-default",
+		},
+	}}
+	cluster := &fleet.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "c1"},
+		Spec:       fleet.ClusterSpec{KubeConfigSecret: "c1-kubeconfig"},
+		Status:     fleet.ClusterStatus{Namespace: "cluster-fleet-default-c1"},
+	}
+	bd := &fleet.BundleDeployment{ObjectMeta: metav1.ObjectMeta{Namespace: ns.Name, Name: "bd"}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(ns, cluster, bd).Build()
+	r := &BundleDeploymentReconciler{Client: c, Reader: c, Scheme: scheme, workers: newWorkers()}
+	ctx := context.Background()
+
+	existing, _ := r.workers.ensure(ns.Name, "v1", func(ctx context.Context) (*worker, error) {
+		return newTestWorker(ctx, "v1", 1), nil
+	})
+
+	w, err := r.ensureWorker(ctx, ns.Name)
+	if err != nil || w != nil {
+		t.Fatalf("expected no worker for a cluster with an agent, got %v, %v", w, err)
+	}
+	if existing.ctx.Err() == nil || r.workers.get(ns.Name) != nil {
+		t.Fatal("expected the worker to be stopped when the cluster leaves push mode")
+	}
+
+	cluster.Spec.DeploymentMode = fleet.DeploymentModePush
+	if err := c.Update(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.ensureWorker(ctx, ns.Name); err == nil {
+		t.Fatal("expected an error for a missing kubeconfig secret")
+	}
+
+	// kubeconfig secrets in other namespaces are not used
+	cluster.Spec.KubeConfigSecretNamespace = "kube-system"
+	if err := c.Update(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.ensureWorker(ctx, ns.Name); !errors.Is(err, reconcile.TerminalError(nil)) {
+		t.Fatalf("expected a terminal error for a kubeconfig secret in another namespace, got %v", err)
+	}
+
+	// bundledeployments outside of cluster namespaces are ignored
+	if w, err := r.ensureWorker(ctx, "default"); err != nil || w != nil {
+		t.Fatalf("expected no worker, got %v, %v", w, err)
+	}
+
+	// bundledeployments are redeployed when the cluster changes
+	got := r.mapClusterToBundleDeployments(ctx, cluster)
+	if len(got) != 1 || got[0].Namespace != ns.Name || got[0].Name != "bd" {
+		t.Fatalf("expected a request for the bundledeployment, got %v", got)
+	}
+}
@@ -0,0 +1,348 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/rancher/fleet/internal/cmd/agent/clusterstatus"
+	agentcontroller "github.com/rancher/fleet/internal/cmd/agent/controller"
+	"github.com/rancher/fleet/internal/cmd/agent/deployer"
+	"github.com/rancher/fleet/internal/cmd/agent/deployer/cleanup"
+	"github.com/rancher/fleet/internal/cmd/agent/deployer/desiredset"
+	"github.com/rancher/fleet/internal/cmd/agent/deployer/driftdetect"
+	"github.com/rancher/fleet/internal/cmd/agent/deployer/monitor"
+	"github.com/rancher/fleet/internal/cmd/agent/trigger"
+	"github.com/rancher/fleet/internal/cmd/controller/agentmanagement/agent"
+	"github.com/rancher/fleet/internal/config"
+	"github.com/rancher/fleet/internal/helmdeployer"
+	"github.com/rancher/fleet/internal/kubeconfig"
+	"github.com/rancher/fleet/internal/manifest"
+	"github.com/rancher/fleet/internal/secretstore"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/durations"
+
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// worker deploys the bundledeployments of a single cluster, with the same
+// reconcilers the agent uses. Instead of the local cluster, they use the
+// kubeconfig of the cluster.
+type worker struct {
+	// key identifies the cluster and the version of its kubeconfig, the
+	// worker is replaced when it changes
+	key string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// sem limits the number of concurrent reconciles for the cluster
+	sem chan struct{}
+
+	bundleDeployments reconcile.Reconciler
+	drift             reconcile.Reconciler
+}
+
+// run calls the reconciler with the worker's context, so its goroutines,
+// e.g. drift detection and garbage collection, are stopped with the worker.
+// If all workers of the cluster are busy, the request is requeued instead of
+// blocking a worker of the controller.
+func (w *worker) run(ctx context.Context, r reconcile.Reconciler, req ctrl.Request) (ctrl.Result, error) {
+	select {
+	case w.sem <- struct{}{}:
+	default:
+		return ctrl.Result{RequeueAfter: clusterBusyRequeueDelay}, nil
+	}
+	defer func() { <-w.sem }()
+
+	return r.Reconcile(log.IntoContext(w.ctx, log.FromContext(ctx)), req)
+}
+
+type workerOptions struct {
+	upstream        client.Client
+	upstreamReader  client.Reader
+	systemNamespace string
+	workers         int
+	driftChan       chan event.TypedGenericEvent[*fleet.BundleDeployment]
+}
+
+func newWorker(parent context.Context, logger logr.Logger, opts workerOptions, key string, cluster *fleet.Cluster, data []byte) (*worker, error) {
+	cfg := config.Get()
+
+	// the kubeconfig is provided by users, it must not run commands or read
+	// files in the controller's pod
+	clientConfig, err := kubeconfig.Load(data, cfg.AgentTLSMode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	restConfig.Timeout = durations.RestConfigTimeout
+
+	// the client is not cached, a cache would start informers on the
+	// cluster for every kind of deployed resource
+	remote, err := client.New(restConfig, client.Options{Scheme: remoteScheme})
+	if err != nil {
+		return nil, err
+	}
+	remoteDynamic, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	remoteKubernetes, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	ds, err := desiredset.New(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	getter, err := helmdeployer.NewKubeConfigGetter(clientConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(log.IntoContext(parent, logger))
+
+	agentNamespace := opts.systemNamespace
+	if cluster.Spec.AgentNamespace != "" {
+		agentNamespace = cluster.Spec.AgentNamespace
+	}
+
+	// hand over from an agent, e.g. when the cluster was in agent mode
+	// before, so bundledeployments are not deployed twice
+	for _, ns := range slices.Compact([]string{agentNamespace, cluster.Status.Agent.Namespace}) {
+		if ns == "" {
+			continue
+		}
+		if err := agent.Delete(ctx, remoteKubernetes, ns); err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to delete agent in namespace %s: %w", ns, err)
+		}
+	}
+
+	helmDeployer := helmdeployer.New(agentNamespace, defaultNamespace, defaultNamespace, agentScope)
+	if err := helmDeployer.Setup(ctx, remote, getter); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to set up helm: %w", err)
+	}
+	// valuesFrom upstream secrets are copied into the cluster namespace by the bundle controller
+	helmDeployer.SetValuesResolver(secretstore.NewResolver(opts.upstreamReader, cluster.Status.Namespace))
+
+	d := deployer.New(remote, opts.upstreamReader, manifest.NewLookup(), helmDeployer)
+	m := monitor.New(remote, ds, helmDeployer, defaultNamespace, agentScope)
+	dd := driftdetect.New(
+		trigger.New(ctx, remoteDynamic, remote.RESTMapper()),
+		ds,
+		defaultNamespace,
+		defaultNamespace,
+		agentScope,
+		opts.driftChan,
+	)
+
+	w := &worker{
+		key:    key,
+		ctx:    ctx,
+		cancel: cancel,
+		sem:    make(chan struct{}, max(opts.workers, 1)),
+		bundleDeployments: &agentcontroller.BundleDeploymentReconciler{
+			Client:      opts.upstream,
+			Reader:      opts.upstreamReader,
+			Scheme:      opts.upstream.Scheme(),
+			LocalClient: remote,
+
+			Deployer:    d,
+			Monitor:     m,
+			DriftDetect: dd,
+			Cleanup: cleanup.New(
+				opts.upstream,
+				remote.RESTMapper(),
+				remoteDynamic,
+				helmDeployer,
+				cluster.Status.Namespace,
+				defaultNamespace,
+				cfg.GarbageCollectionInterval.Duration,
+			),
+
+			DefaultNamespace: defaultNamespace,
+			AgentScope:       agentScope,
+		},
+		drift: &agentcontroller.DriftReconciler{
+			Client: opts.upstream,
+			Scheme: opts.upstream.Scheme(),
+
+			Deployer:    d,
+			Monitor:     m,
+			DriftDetect: dd,
+		},
+	}
+
+	// report the cluster status, like the agent does
+	clusterstatus.Ticker(ctx, opts.upstream, remoteKubernetes, agentNamespace, cluster.Namespace, cluster.Name, cfg.AgentCheckinInterval.Duration)
+
+	return w, nil
+}
+
+// errClusterBusy is returned while the worker of a cluster is created.
+var errClusterBusy = errors.New("worker of cluster is being created")
+
+// backoffError is returned while creating the worker of a cluster is backed
+// off after a failure.
+type backoffError struct {
+	retryAt time.Time
+	err     error
+}
+
+func (e *backoffError) Error() string {
+	return fmt.Sprintf("creating worker of cluster failed, retrying at %s: %v", e.retryAt.Format(time.RFC3339), e.err)
+}
+
+func (e *backoffError) Unwrap() error {
+	return e.err
+}
+
+// failure records failed attempts to create the worker of a cluster.
+type failure struct {
+	key     string
+	count   int
+	retryAt time.Time
+	err     error
+}
+
+// workers holds the workers of the push mode clusters, by the cluster
+// namespace of their bundledeployments.
+type workers struct {
+	sync.Mutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	byNS   map[string]*worker
+	// pending holds a token for each namespace whose worker is being
+	// created. Workers are created without holding the lock, as it can
+	// take long for unreachable clusters.
+	pending  map[string]*struct{}
+	failures map[string]*failure
+}
+
+func newWorkers() *workers {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &workers{
+		ctx:      ctx,
+		cancel:   cancel,
+		byNS:     map[string]*worker{},
+		pending:  map[string]*struct{}{},
+		failures: map[string]*failure{},
+	}
+}
+
+// Start implements manager.Runnable. It stops all workers when the manager
+// stops.
+func (ws *workers) Start(ctx context.Context) error {
+	<-ctx.Done()
+	ws.cancel()
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Workers are
+// created by the reconcilers, which only run on the leader, but need to be
+// stopped on all replicas.
+func (ws *workers) NeedLeaderElection() bool {
+	return false
+}
+
+// get returns the worker for the namespace, if it exists.
+func (ws *workers) get(namespace string) *worker {
+	ws.Lock()
+	defer ws.Unlock()
+	return ws.byNS[namespace]
+}
+
+// ensure returns the worker for the namespace with the key, replacing a
+// worker with a different key. It returns errClusterBusy while the worker is
+// created by another call and a backoffError after creating it failed.
+func (ws *workers) ensure(namespace, key string, create func(ctx context.Context) (*worker, error)) (*worker, error) {
+	ws.Lock()
+	if w, ok := ws.byNS[namespace]; ok && w.key == key {
+		ws.Unlock()
+		return w, nil
+	}
+	if _, ok := ws.pending[namespace]; ok {
+		ws.Unlock()
+		return nil, errClusterBusy
+	}
+	if f, ok := ws.failures[namespace]; ok && f.key == key && time.Now().Before(f.retryAt) {
+		ws.Unlock()
+		return nil, &backoffError{retryAt: f.retryAt, err: f.err}
+	}
+	if w, ok := ws.byNS[namespace]; ok {
+		w.cancel()
+		delete(ws.byNS, namespace)
+	}
+	token := &struct{}{}
+	ws.pending[namespace] = token
+	ws.Unlock()
+
+	w, err := create(ws.ctx)
+
+	ws.Lock()
+	defer ws.Unlock()
+	if ws.pending[namespace] != token {
+		// the worker was stopped while it was created
+		if w != nil {
+			w.cancel()
+		}
+		return nil, errClusterBusy
+	}
+	delete(ws.pending, namespace)
+
+	if err != nil {
+		f, ok := ws.failures[namespace]
+		if !ok || f.key != key {
+			f = &failure{key: key}
+			ws.failures[namespace] = f
+		}
+		f.count++
+		f.err = err
+		f.retryAt = time.Now().Add(workerBackoff(f.count))
+		return nil, err
+	}
+	delete(ws.failures, namespace)
+	ws.byNS[namespace] = w
+	return w, nil
+}
+
+// stop stops the worker for the namespace, if it exists.
+func (ws *workers) stop(namespace string) {
+	ws.Lock()
+	defer ws.Unlock()
+
+	if w, ok := ws.byNS[namespace]; ok {
+		w.cancel()
+		delete(ws.byNS, namespace)
+	}
+	delete(ws.pending, namespace)
+	delete(ws.failures, namespace)
+}
+
+// workerBackoff returns the delay before creating a worker is retried, after
+// it failed count times.
+func workerBackoff(count int) time.Duration {
+	d := workerRetryDelay
+	for i := 1; i < count && d < workerMaxRetryDelay; i++ {
+		d *= 2
+	}
+	return min(d, workerMaxRetryDelay)
+}
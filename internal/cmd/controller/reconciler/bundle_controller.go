@@ -4,6 +4,8 @@ package reconciler
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"maps"
@@ -17,6 +19,7 @@ import (
 	"github.com/rancher/fleet/internal/cmd/agent/deployer/kv"
 	fleetutil "github.com/rancher/fleet/internal/cmd/controller/errorutil"
 	"github.com/rancher/fleet/internal/cmd/controller/finalize"
+	"github.com/rancher/fleet/internal/cmd/controller/restrictions"
 	"github.com/rancher/fleet/internal/cmd/controller/summary"
 	"github.com/rancher/fleet/internal/cmd/controller/target"
 	"github.com/rancher/fleet/internal/config"
@@ -25,12 +28,15 @@ import (
 	"github.com/rancher/fleet/internal/manifest"
 	"github.com/rancher/fleet/internal/metrics"
 	"github.com/rancher/fleet/internal/ocistorage"
+	"github.com/rancher/fleet/internal/secretstore"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	fleetevent "github.com/rancher/fleet/pkg/event"
 	"github.com/rancher/fleet/pkg/sharding"
+	"github.com/rancher/wrangler/v3/pkg/condition"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	errutil "k8s.io/apimachinery/pkg/util/errors"
@@ -101,35 +107,31 @@ func (r *BundleReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			&fleet.BundleDeployment{}, handler.EnqueueRequestsFromMapFunc(BundleDeploymentMapFunc(r)),
 			builder.WithPredicates(bundleDeploymentStatusChangedPredicate()),
 		).
+		Watches(
+			// Fan out from bundledeployment to the bundles depending on it,
+			// they use the outputs of the bundledeployment in templates.
+			&fleet.BundleDeployment{}, handler.EnqueueRequestsFromMapFunc(r.dependentBundlesMapFunc),
+			builder.WithPredicates(bundleDeploymentOutputsChangedPredicate()),
+		).
 		Watches(
 			// Fan out from cluster to bundle, this is useful for targeting and templating.
 			&fleet.Cluster{},
 			handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, a client.Object) []ctrl.Request {
-				cluster := a.(*fleet.Cluster)
-				bundlesToRefresh, _, err := r.Query.BundlesForCluster(ctx, cluster)
-				if err != nil {
-					return nil
-				}
-				requests := []ctrl.Request{}
-				for _, bundle := range bundlesToRefresh {
-					if !sharding.ShouldProcess(bundle, r.ShardID) {
-						continue
-					}
-					requests = append(requests, ctrl.Request{
-						NamespacedName: types.NamespacedName{
-							Namespace: bundle.Namespace,
-							Name:      bundle.Name,
-						},
-					})
-				}
-
-				return requests
+				return r.bundleRequestsForCluster(ctx, a.(*fleet.Cluster))
 			}),
 			builder.WithPredicates(clusterChangedPredicate()),
 		).
+		Watches(
+			// Fan out from cluster group to bundle, the cluster group's image
+			// rewrite rules and template values are part of the
+			// bundledeployment options.
+			&fleet.ClusterGroup{},
+			handler.EnqueueRequestsFromMapFunc(r.clusterGroupMapFunc),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
 		Watches(
 			// Fan out from secret to bundle, reconcile bundles when a secret
-			// referenced in DownstreamResources changes.
+			// referenced in DownstreamResources or valuesFrom changes.
 			&corev1.Secret{},
 			handler.EnqueueRequestsFromMapFunc(r.downstreamResourceMapFunc("Secret")),
 			builder.WithPredicates(dataChangedPredicate()),
@@ -141,6 +143,12 @@ func (r *BundleReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			handler.EnqueueRequestsFromMapFunc(r.downstreamResourceMapFunc("ConfigMap")),
 			builder.WithPredicates(dataChangedPredicate()),
 		).
+		Watches(
+			// Fan out from GitRepoRestriction to the bundles in its namespace,
+			// which are checked against the restrictions.
+			&fleet.GitRepoRestriction{},
+			handler.EnqueueRequestsFromMapFunc(r.restrictionMapFunc),
+		).
 		WithEventFilter(sharding.FilterByShardID(r.ShardID)).
 		WithOptions(controller.Options{MaxConcurrentReconciles: r.Workers}).
 		Complete(r)
@@ -188,6 +196,10 @@ func (r *BundleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return r.computeResult(ctx, logger, bundleOrig, bundle, "failed to remove display name label", err)
 	}
 
+	if err := r.authorizeBundle(ctx, bundle); err != nil {
+		return r.computeResult(ctx, logger, bundleOrig, bundle, "denied by GitRepoRestriction", err)
+	}
+
 	logger.V(1).Info(
 		"Reconciling bundle, checking targets, calculating changes, building objects",
 		"generation",
@@ -293,7 +305,7 @@ func (r *BundleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	}
 
 	// this will add the defaults for a new bundledeployment. It propagates stagedOptions to options.
-	if err := target.UpdatePartitions(&bundle.Status, matchedTargets); err != nil {
+	if err := target.UpdatePartitions(ctx, &bundle.Status, matchedTargets); err != nil {
 		err = fmt.Errorf("failed to update partitions: %w", err)
 
 		return ctrl.Result{}, r.updateErrorStatus(ctx, bundleOrig, bundle, err)
@@ -350,6 +362,14 @@ func (r *BundleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		// Changes in the values hash trigger a bundle deployment reconcile.
 		bd.Spec.ValuesHash = valuesHash
 
+		// Changes to the upstream secrets referenced in valuesFrom make the agent upgrade the release. The
+		// generation is derived from their data, so it stays the same as long as the data does.
+		valuesFromGeneration, err := r.valuesFromGeneration(ctx, bundle.Namespace, bd)
+		if err != nil {
+			return r.computeResult(ctx, logger, bundleOrig, bundle, "failed to read valuesFrom secrets", err)
+		}
+		bd.Spec.ValuesFromGeneration = valuesFromGeneration
+
 		// When content resources are stored in etcd, we need to keep track of the content resource so they
 		// are properly gargabe-collected by the content controller.
 		if !contentsInOCI && !contentsInHelmChart {
@@ -403,6 +423,10 @@ func (r *BundleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		if err := r.handleContentAccessSecrets(ctx, bundle, bd); err != nil {
 			return r.computeResult(ctx, logger, bundleOrig, bundle, "failed to clone secrets downstream", err)
 		}
+
+		if err := r.handleValuesFromSecrets(ctx, bundle, bd); err != nil {
+			return r.computeResult(ctx, logger, bundleOrig, bundle, "failed to clone valuesFrom secrets downstream", err)
+		}
 	}
 
 	// the targets configuration may have changed, leaving behind some BundleDeployments that are no longer needed
@@ -817,6 +841,60 @@ func (r *BundleReconciler) handleDownstreamObjects(ctx context.Context, bundle *
 	return nil
 }
 
+// handleValuesFromSecrets copies the upstream secrets referenced in the valuesFrom options of bd from the bundle
+// namespace to the cluster namespace of bd, where the agent reads them when rendering the release. Secret values are
+// never copied into bd itself.
+func (r *BundleReconciler) handleValuesFromSecrets(ctx context.Context, bundle *fleet.Bundle, bd *fleet.BundleDeployment) error {
+	if bd.Spec.Options.Helm == nil {
+		return nil
+	}
+
+	for _, name := range secretstore.SecretNames(bd.Spec.Options.Helm.ValuesFrom) {
+		if _, err := r.cloneSecret(ctx, bundle.Namespace, name, "", bd); err != nil {
+			return fmt.Errorf(
+				"%w: failed to copy valuesFrom secret %s/%s to downstream cluster namespace: %w",
+				fleetutil.ErrRetryable,
+				bundle.Namespace,
+				name,
+				err,
+			)
+		}
+	}
+
+	return nil
+}
+
+// valuesFromGeneration returns a generation for the upstream secrets referenced in the valuesFrom options of bd,
+// derived from a hash of their data. It is 0 if no upstream secrets are referenced.
+func (r *BundleReconciler) valuesFromGeneration(ctx context.Context, namespace string, bd *fleet.BundleDeployment) (int64, error) {
+	if bd.Spec.Options.Helm == nil {
+		return 0, nil
+	}
+	names := secretstore.SecretNames(bd.Spec.Options.Helm.ValuesFrom)
+	if len(names) == 0 {
+		return 0, nil
+	}
+
+	h := sha256.New()
+	for _, name := range names {
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, secret); err != nil {
+			return 0, fmt.Errorf("%w: failed to get valuesFrom secret %s/%s: %w", fleetutil.ErrRetryable, namespace, name, err)
+		}
+		fmt.Fprintf(h, "%s\x00%s\x00", name, secret.Type)
+		for _, k := range slices.Sorted(maps.Keys(secret.Data)) {
+			fmt.Fprintf(h, "%s\x00%d\x00", k, len(secret.Data[k]))
+			h.Write(secret.Data[k])
+		}
+		for _, k := range slices.Sorted(maps.Keys(secret.StringData)) {
+			fmt.Fprintf(h, "%s\x00%d\x00%s", k, len(secret.StringData[k]), secret.StringData[k])
+		}
+	}
+
+	// keep the generation positive, the agent only compares it for equality
+	return int64(binary.BigEndian.Uint64(h.Sum(nil)) >> 1), nil
+}
+
 // updateStatus patches the status of the bundle and collects metrics upon a successful update of
 // the bundle status. It returns nil if the status update is successful, otherwise it returns an
 // error.
@@ -964,6 +1042,112 @@ func batchDeleteBundleDeployments(ctx context.Context, c client.Client, list []f
 	return errors.Join(errs...)
 }
 
+// bundleRequestsForCluster returns reconcile requests for all bundles targeting the cluster.
+func (r *BundleReconciler) bundleRequestsForCluster(ctx context.Context, cluster *fleet.Cluster) []ctrl.Request {
+	bundlesToRefresh, _, err := r.Query.BundlesForCluster(ctx, cluster)
+	if err != nil {
+		return nil
+	}
+	requests := []ctrl.Request{}
+	for _, bundle := range bundlesToRefresh {
+		if !sharding.ShouldProcess(bundle, r.ShardID) {
+			continue
+		}
+		requests = append(requests, ctrl.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: bundle.Namespace,
+				Name:      bundle.Name,
+			},
+		})
+	}
+
+	return requests
+}
+
+// clusterGroupMapFunc returns reconcile requests for all bundles targeting a
+// cluster of the cluster group or of its child groups.
+func (r *BundleReconciler) clusterGroupMapFunc(ctx context.Context, a client.Object) []ctrl.Request {
+	cg := a.(*fleet.ClusterGroup)
+
+	clusters, err := target.ClusterGroupMembers(ctx, r.Client, cg)
+	if err != nil {
+		return nil
+	}
+
+	seen := map[types.NamespacedName]struct{}{}
+	requests := []ctrl.Request{}
+	for _, cluster := range clusters {
+		for _, req := range r.bundleRequestsForCluster(ctx, &cluster) {
+			if _, ok := seen[req.NamespacedName]; ok {
+				continue
+			}
+			seen[req.NamespacedName] = struct{}{}
+			requests = append(requests, req)
+		}
+	}
+
+	return requests
+}
+
+// restrictionMapFunc returns reconcile requests for all bundles in the namespace of the GitRepoRestriction.
+func (r *BundleReconciler) restrictionMapFunc(ctx context.Context, a client.Object) []ctrl.Request {
+	bundles := &fleet.BundleList{}
+	if err := r.List(ctx, bundles, client.InNamespace(a.GetNamespace())); err != nil {
+		return nil
+	}
+
+	requests := []ctrl.Request{}
+	for _, bundle := range bundles.Items {
+		if !sharding.ShouldProcess(&bundle, r.ShardID) {
+			continue
+		}
+		requests = append(requests, ctrl.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: bundle.Namespace,
+				Name:      bundle.Name,
+			},
+		})
+	}
+
+	return requests
+}
+
+// authorizeBundle checks the bundle against the GitRepoRestrictions of its
+// namespace, so bundles created by `fleet apply` are restricted like the
+// bundles of GitRepos. The result is stored in the Accepted condition, which
+// is only set on bundles in namespaces with restrictions.
+// The bundle deployments of a denied bundle are deleted, which uninstalls its
+// workload from the clusters, e.g. when a restriction is tightened after the
+// bundle was deployed.
+func (r *BundleReconciler) authorizeBundle(ctx context.Context, bundle *fleet.Bundle) error {
+	restriction, err := restrictions.Get(ctx, r.Client, bundle.Namespace)
+	if err != nil {
+		return fmt.Errorf("%w, failed to list GitRepoRestrictions: %w", fleetutil.ErrRetryable, err)
+	}
+
+	accepted := condition.Cond(fleet.BundleAcceptedCondition)
+	if restriction == nil {
+		if accepted.GetStatus(&bundle.Status) != "" {
+			SetCondition(fleet.BundleAcceptedCondition, &bundle.Status, nil)
+		}
+		return nil
+	}
+
+	err = restrictions.CheckBundle(restriction, r.RESTMapper(), bundle)
+	if err != nil {
+		list, listErr := r.listBundleDeploymentsForBundle(ctx, bundle)
+		if listErr != nil {
+			return fmt.Errorf("%w, failed to list bundle deployments of denied bundle: %w", fleetutil.ErrRetryable, listErr)
+		}
+		if err := batchDeleteBundleDeployments(ctx, r.Client, list); err != nil {
+			return fmt.Errorf("%w, failed to delete bundle deployments of denied bundle: %w", fleetutil.ErrRetryable, err)
+		}
+		err = fmt.Errorf("%w; the bundle is not deployed and its bundle deployments are deleted", err)
+	}
+	SetCondition(fleet.BundleAcceptedCondition, &bundle.Status, err)
+	return err
+}
+
 // dataChangedPredicate filters Secret and ConfigMap events to only trigger reconciliation
 // when Data or BinaryData fields have changed.
 func dataChangedPredicate() predicate.Funcs {
@@ -997,6 +1181,67 @@ func dataChangedPredicate() predicate.Funcs {
 	}
 }
 
+// bundleDeploymentOutputsChangedPredicate filters bundledeployment events, which change the outputs reported by the agent.
+func bundleDeploymentOutputsChangedPredicate() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			bd, ok := e.Object.(*fleet.BundleDeployment)
+			return ok && len(bd.Status.Outputs) > 0
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			n, nOK := e.ObjectNew.(*fleet.BundleDeployment)
+			o, oOK := e.ObjectOld.(*fleet.BundleDeployment)
+			if !nOK || !oOK {
+				return false
+			}
+			return !maps.Equal(n.Status.Outputs, o.Status.Outputs)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			bd, ok := e.Object.(*fleet.BundleDeployment)
+			return ok && len(bd.Status.Outputs) > 0
+		},
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+	}
+}
+
+// dependentBundlesMapFunc returns reconcile requests for the bundles, which
+// depend on the bundle of the bundledeployment.
+func (r *BundleReconciler) dependentBundlesMapFunc(ctx context.Context, a client.Object) []ctrl.Request {
+	bd, ok := a.(*fleet.BundleDeployment)
+	if !ok || !sharding.ShouldProcess(bd, r.ShardID) {
+		return nil
+	}
+
+	ns, name := target.BundleFromDeployment(bd.Labels)
+	if ns == "" || name == "" {
+		return nil
+	}
+
+	bundles := &fleet.BundleList{}
+	if err := r.List(ctx, bundles, client.InNamespace(ns)); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list bundles for bundledeployment outputs", "namespace", ns)
+		return nil
+	}
+
+	requests := []ctrl.Request{}
+	for _, bundle := range bundles.Items {
+		for _, depend := range bundle.Spec.DependsOn {
+			selector, err := target.DependencySelector(depend, bundle.Namespace)
+			if err != nil || selector == nil {
+				continue
+			}
+			if selector.Matches(labels.Set(bd.Labels)) {
+				requests = append(requests, ctrl.Request{
+					NamespacedName: types.NamespacedName{Namespace: bundle.Namespace, Name: bundle.Name},
+				})
+				break
+			}
+		}
+	}
+
+	return requests
+}
+
 // clusterChangedPredicate filters cluster events that relate to bundldeployment creation.
 func clusterChangedPredicate() predicate.Funcs {
 	return predicate.Funcs{
@@ -1029,6 +1274,10 @@ func clusterChangedPredicate() predicate.Funcs {
 			if n.Status.Agent.Namespace != o.Status.Agent.Namespace {
 				return true
 			}
+			// facts are used for templating and targeting
+			if !reflect.DeepEqual(n.Status.Facts, o.Status.Facts) {
+				return true
+			}
 
 			if n.Status.Scheduled != o.Status.Scheduled {
 				return true
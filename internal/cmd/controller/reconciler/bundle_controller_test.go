@@ -30,8 +30,10 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -118,6 +120,7 @@ func TestReconcile_HelmValuesLoadError(t *testing.T) {
 
 	mockClient := mocks.NewMockK8sClient(mockCtrl)
 	expectGetWithFinalizer(mockClient, bundle)
+	expectNoRestrictions(mockClient)
 
 	mockClient.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.AssignableToTypeOf(&corev1.Secret{}), gomock.Any()).
 		Return(errors.New("something went wrong"))
@@ -174,6 +177,7 @@ func TestReconcile_HelmVersionResolutionError(t *testing.T) {
 
 	mockClient := mocks.NewMockK8sClient(mockCtrl)
 	expectGetWithFinalizer(mockClient, bundle)
+	expectNoRestrictions(mockClient)
 
 	expectedErrorMsg := "chart version cannot be deployed; check HelmOp status for more details:"
 
@@ -218,6 +222,7 @@ func TestReconcile_TargetsBuildingError(t *testing.T) {
 
 	mockClient := mocks.NewMockK8sClient(mockCtrl)
 	expectGetWithFinalizer(mockClient, bundle)
+	expectNoRestrictions(mockClient)
 
 	expectedErrorMsg := "targeting error: something went wrong"
 
@@ -271,6 +276,7 @@ func TestReconcile_StatusResetFromTargetsError(t *testing.T) {
 
 	mockClient := mocks.NewMockK8sClient(mockCtrl)
 	expectGetWithFinalizer(mockClient, bundle)
+	expectNoRestrictions(mockClient)
 
 	expectedErrorMsg := "failed to reset bundle status from targets: invalid maxUnavailable"
 
@@ -359,6 +365,7 @@ func TestReconcile_ManifestStorageError(t *testing.T) {
 
 			mockClient := mocks.NewMockK8sClient(mockCtrl)
 			expectGetWithFinalizer(mockClient, bundle)
+			expectNoRestrictions(mockClient)
 
 			if c.expectedStatusPatchErrMsg != "" {
 				statusClient := mocks.NewMockSubResourceWriter(mockCtrl)
@@ -448,6 +455,7 @@ func TestReconcile_OptionsSecretCreateUpdateError(t *testing.T) {
 
 			mockClient := mocks.NewMockK8sClient(mockCtrl)
 			expectGetWithFinalizer(mockClient, bundle)
+			expectNoRestrictions(mockClient)
 
 			c.secretCalls(mockClient)
 
@@ -529,6 +537,7 @@ func TestReconcile_OptionsSecretDeletionError(t *testing.T) {
 
 	mockClient := mocks.NewMockK8sClient(mockCtrl)
 	expectGetWithFinalizer(mockClient, bundle)
+	expectNoRestrictions(mockClient)
 
 	mockClient.EXPECT().Delete(gomock.Any(), gomock.AssignableToTypeOf(&corev1.Secret{}), gomock.Any()).
 		Return(errors.New("something went wrong"))
@@ -627,6 +636,7 @@ func TestReconcile_OCIReferenceSecretResolutionError(t *testing.T) {
 
 			mockClient := mocks.NewMockK8sClient(mockCtrl)
 			expectGetWithFinalizer(mockClient, bundle)
+			expectNoRestrictions(mockClient)
 
 			// OCI reference secret
 			mockClient.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.AssignableToTypeOf(&corev1.Secret{}), gomock.Any()).
@@ -789,6 +799,7 @@ func TestReconcile_DownstreamObjectsHandlingError(t *testing.T) {
 
 			mockClient := mocks.NewMockK8sClient(mockCtrl)
 			expectGetWithFinalizer(mockClient, bundle)
+			expectNoRestrictions(mockClient)
 
 			// Options secret: deletion attempt in case it exists, as the bundle deployment's values hash is empty
 			mockClient.EXPECT().Delete(gomock.Any(), gomock.AssignableToTypeOf(&corev1.Secret{}), gomock.Any()).
@@ -883,6 +894,7 @@ func TestReconcile_AccessSecretsHandlingError(t *testing.T) {
 
 	mockClient := mocks.NewMockK8sClient(mockCtrl)
 	expectGetWithFinalizer(mockClient, bundle)
+	expectNoRestrictions(mockClient)
 
 	mockClient.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.AssignableToTypeOf(&fleetv1.BundleDeployment{}), gomock.Any()).
 		Return(nil)
@@ -1109,6 +1121,10 @@ func expectGetWithFinalizer(mockCli *mocks.MockK8sClient, bundle fleetv1.Bundle)
 	)
 }
 
+func expectNoRestrictions(mockCli *mocks.MockK8sClient) {
+	mockCli.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&fleetv1.GitRepoRestrictionList{}), gomock.Any()).Return(nil)
+}
+
 func TestReconcile_DownstreamResourcesGeneration_Increment(t *testing.T) {
 	envVar := "EXPERIMENTAL_COPY_RESOURCES_DOWNSTREAM"
 	bkp := os.Getenv(envVar)
@@ -1347,6 +1363,7 @@ func TestReconcile_DownstreamResourcesGeneration_Increment(t *testing.T) {
 
 			mockClient := mocks.NewMockK8sClient(mockCtrl)
 			expectGetWithFinalizer(mockClient, bundle)
+			expectNoRestrictions(mockClient)
 
 			// Options secret deletion (no values to store)
 			mockClient.EXPECT().Delete(gomock.Any(), gomock.AssignableToTypeOf(&corev1.Secret{}), gomock.Any()).
@@ -1471,6 +1488,7 @@ func TestReconcile_DownstreamResources_FeatureDisabled(t *testing.T) {
 
 	mockClient := mocks.NewMockK8sClient(mockCtrl)
 	expectGetWithFinalizer(mockClient, bundle)
+	expectNoRestrictions(mockClient)
 
 	// Options secret deletion
 	mockClient.EXPECT().Delete(gomock.Any(), gomock.AssignableToTypeOf(&corev1.Secret{}), gomock.Any()).
@@ -1551,3 +1569,175 @@ func TestReconcile_DownstreamResources_FeatureDisabled(t *testing.T) {
 		t.Errorf("unexpected error: %v", err)
 	}
 }
+
+func TestReconcile_ValuesFromGenerationIsStable(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	scheme := runtime.NewScheme()
+	utilruntime.Must(corev1.AddToScheme(scheme))
+	utilruntime.Must(fleetv1.AddToScheme(scheme))
+
+	bundle := &fleetv1.Bundle{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test-bundle",
+			Namespace:  "default",
+			Finalizers: []string{finalize.BundleFinalizer},
+		},
+		Spec: fleetv1.BundleSpec{
+			BundleDeploymentOptions: fleetv1.BundleDeploymentOptions{
+				Helm: &fleetv1.HelmOptions{
+					ValuesFrom: []fleetv1.ValuesFrom{{
+						UpstreamSecretKeyRef: &fleetv1.UpstreamSecretKeySelector{
+							LocalObjectReference: fleetv1.LocalObjectReference{Name: "values"},
+							Key:                  "values.yaml",
+						},
+					}},
+				},
+			},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "values", Namespace: "default"},
+		Data:       map[string][]byte{"values.yaml": []byte("replicas: 1")},
+	}
+	cluster := &fleetv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "fleet-default"},
+		Status:     fleetv1.ClusterStatus{Namespace: "cluster-ns"},
+	}
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(bundle, secret).
+		WithStatusSubresource(&fleetv1.Bundle{}).
+		Build()
+
+	targetBuilderMock := mocks.NewMockTargetBuilder(mockCtrl)
+	targetBuilderMock.EXPECT().Targets(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, b *fleetv1.Bundle, _ string) ([]*target.Target, error) {
+			bd := &fleetv1.BundleDeployment{}
+			err := c.Get(ctx, types.NamespacedName{Namespace: "cluster-ns", Name: "test-bd"}, bd)
+			if k8serrors.IsNotFound(err) {
+				bd = &fleetv1.BundleDeployment{ObjectMeta: metav1.ObjectMeta{Name: "test-bd", Namespace: "cluster-ns"}}
+			} else if err != nil {
+				return nil, err
+			}
+			bd.Spec.DeploymentID = "test-deployment"
+			bd.Spec.Options = b.Spec.BundleDeploymentOptions
+			return []*target.Target{{
+				Bundle:       b,
+				Cluster:      cluster,
+				Deployment:   bd,
+				DeploymentID: "test-deployment",
+			}}, nil
+		},
+	).AnyTimes()
+	storeMock := mocks.NewMockStore(mockCtrl)
+	storeMock.EXPECT().Store(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	r := reconciler.BundleReconciler{
+		Client:   c,
+		Scheme:   scheme,
+		Recorder: record.NewFakeRecorder(10),
+		Builder:  targetBuilderMock,
+		Store:    storeMock,
+	}
+
+	generation := func() int64 {
+		t.Helper()
+		if _, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Name: bundle.Name, Namespace: bundle.Namespace}}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		bd := &fleetv1.BundleDeployment{}
+		if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "cluster-ns", Name: "test-bd"}, bd); err != nil {
+			t.Fatal(err)
+		}
+		return bd.Spec.ValuesFromGeneration
+	}
+
+	first := generation()
+	if first == 0 {
+		t.Fatal("expected a valuesFrom generation")
+	}
+	if second := generation(); second != first {
+		t.Errorf("expected the generation to stay %d, got %d", first, second)
+	}
+
+	secret.Data["values.yaml"] = []byte("replicas: 2")
+	if err := c.Update(context.TODO(), secret); err != nil {
+		t.Fatal(err)
+	}
+	if changed := generation(); changed == first {
+		t.Error("expected the generation to change with the secret data")
+	}
+}
+
+func TestReconcile_DeniedBundleDeletesBundleDeployments(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	scheme := runtime.NewScheme()
+	utilruntime.Must(corev1.AddToScheme(scheme))
+	utilruntime.Must(fleetv1.AddToScheme(scheme))
+
+	bundle := &fleetv1.Bundle{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test-bundle",
+			Namespace:  "default",
+			Finalizers: []string{finalize.BundleFinalizer},
+		},
+	}
+	restriction := &fleetv1.GitRepoRestriction{
+		ObjectMeta:           metav1.ObjectMeta{Name: "restriction", Namespace: "default"},
+		AllowedClusterGroups: []string{"tenant-a"},
+	}
+	bd := &fleetv1.BundleDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-bd",
+			Namespace: "cluster-ns",
+			Labels: map[string]string{
+				fleetv1.BundleLabel:          bundle.Name,
+				fleetv1.BundleNamespaceLabel: bundle.Namespace,
+			},
+		},
+	}
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(bundle, restriction, bd).
+		WithStatusSubresource(&fleetv1.Bundle{}).
+		Build()
+
+	// the builder and store must not be called for denied bundles
+	r := reconciler.BundleReconciler{
+		Client:   c,
+		Scheme:   scheme,
+		Recorder: record.NewFakeRecorder(10),
+		Builder:  mocks.NewMockTargetBuilder(mockCtrl),
+		Store:    mocks.NewMockStore(mockCtrl),
+	}
+
+	_, _ = r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Name: bundle.Name, Namespace: bundle.Namespace}})
+
+	err := c.Get(context.TODO(), client.ObjectKeyFromObject(bd), &fleetv1.BundleDeployment{})
+	if !k8serrors.IsNotFound(err) {
+		t.Errorf("expected the bundle deployment of the denied bundle to be deleted, got %v", err)
+	}
+
+	updated := &fleetv1.Bundle{}
+	if err := c.Get(context.TODO(), client.ObjectKeyFromObject(bundle), updated); err != nil {
+		t.Fatal(err)
+	}
+	var accepted *genericcondition.GenericCondition
+	for i, cond := range updated.Status.Conditions {
+		if cond.Type == fleetv1.BundleAcceptedCondition {
+			accepted = &updated.Status.Conditions[i]
+		}
+	}
+	if accepted == nil || accepted.Status != corev1.ConditionFalse {
+		t.Fatalf("expected a false Accepted condition, got %v", accepted)
+	}
+	if !strings.Contains(accepted.Message, "its bundle deployments are deleted") {
+		t.Errorf("expected the condition to report the deletion, got %q", accepted.Message)
+	}
+}
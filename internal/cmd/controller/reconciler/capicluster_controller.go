@@ -0,0 +1,267 @@
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// CAPIClusterLabelsAnnotation lists the keys of the labels, which were
+	// copied from the Cluster API cluster and its ClusterClass. They are
+	// removed from the fleet cluster when they are removed from the source.
+	CAPIClusterLabelsAnnotation = "fleet.cattle.io/capi-cluster-labels"
+
+	// capiKubeconfigSuffix is the suffix of the secret, in which Cluster API
+	// stores the admin kubeconfig of a cluster under the "value" key.
+	capiKubeconfigSuffix = "-kubeconfig"
+)
+
+var (
+	CAPIClusterGVK      = schema.GroupVersionKind{Group: "cluster.x-k8s.io", Version: "v1beta1", Kind: "Cluster"}
+	CAPIClusterClassGVK = schema.GroupVersionKind{Group: "cluster.x-k8s.io", Version: "v1beta1", Kind: "ClusterClass"}
+)
+
+// CAPIClusterReconciler registers Cluster API clusters as fleet clusters.
+// The fleet cluster has the same name and namespace as the Cluster API
+// cluster, which owns it. Its kubeconfig secret is only set once the control
+// plane is ready, so the import handler doesn't try to deploy the agent to a
+// cluster that is still being provisioned.
+type CAPIClusterReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// Namespaces in which Cluster API clusters are registered
+	Namespaces []string
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CAPIClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	inNamespaces := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return slices.Contains(r.Namespaces, obj.GetNamespace())
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("capi-cluster").
+		For(newUnstructured(CAPIClusterGVK), builder.WithPredicates(inNamespaces)).
+		Owns(&fleet.Cluster{}).
+		Watches(
+			&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(func(_ context.Context, obj client.Object) []reconcile.Request {
+				name, ok := strings.CutSuffix(obj.GetName(), capiKubeconfigSuffix)
+				if !ok {
+					return nil
+				}
+				return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: name}}}
+			}),
+			builder.WithPredicates(inNamespaces, predicate.NewPredicateFuncs(func(obj client.Object) bool {
+				return strings.HasSuffix(obj.GetName(), capiKubeconfigSuffix)
+			})),
+		).
+		Watches(
+			newUnstructured(CAPIClusterClassGVK),
+			handler.EnqueueRequestsFromMapFunc(r.mapClusterClassToClusters),
+			builder.WithPredicates(predicate.LabelChangedPredicate{}),
+		).
+		Complete(r)
+}
+
+// Reconcile creates or updates the fleet cluster of a Cluster API cluster and
+// deletes it once the Cluster API cluster is being deleted.
+func (r *CAPIClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithName("capi-cluster")
+
+	capiCluster := newUnstructured(CAPIClusterGVK)
+	err := r.Get(ctx, req.NamespacedName, capiCluster)
+	if client.IgnoreNotFound(err) != nil {
+		return ctrl.Result{}, err
+	}
+	if apierrors.IsNotFound(err) || !capiCluster.GetDeletionTimestamp().IsZero() {
+		return ctrl.Result{}, r.deleteCluster(ctx, req.NamespacedName)
+	}
+
+	clusterClassLabels, err := r.clusterClassLabels(ctx, capiCluster)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	cluster := &fleet.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: req.Namespace, Name: req.Name}}
+	err = r.Get(ctx, req.NamespacedName, cluster)
+	if client.IgnoreNotFound(err) != nil {
+		return ctrl.Result{}, err
+	}
+	if err == nil && !metav1.IsControlledBy(cluster, capiCluster) {
+		logger.Info("Fleet cluster already exists and is not managed by the Cluster API cluster, skipping", "cluster", req.NamespacedName)
+		return ctrl.Result{}, nil
+	}
+
+	var kubeconfigReady bool
+	if controlPlaneReady(capiCluster) {
+		secret := &corev1.Secret{}
+		err := r.Get(ctx, types.NamespacedName{Namespace: req.Namespace, Name: req.Name + capiKubeconfigSuffix}, secret)
+		if client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+		kubeconfigReady = err == nil
+	}
+
+	op, err := controllerutil.CreateOrUpdate(ctx, r.Client, cluster, func() error {
+		setCAPILabels(cluster, mergeLabels(clusterClassLabels, capiCluster.GetLabels()))
+		// once the agent is deployed, the kubeconfig secret stays set, e.g.
+		// while the control plane is upgraded
+		if kubeconfigReady && cluster.Spec.KubeConfigSecret == "" {
+			cluster.Spec.KubeConfigSecret = req.Name + capiKubeconfigSuffix
+		}
+		return controllerutil.SetControllerReference(capiCluster, cluster, r.Scheme)
+	})
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if op != controllerutil.OperationResultNone {
+		logger.V(1).Info("Reconciled fleet cluster for Cluster API cluster", "cluster", req.NamespacedName, "operation", op, "controlPlaneReady", kubeconfigReady)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// deleteCluster deletes the fleet cluster, if it is owned by the Cluster API
+// cluster of the same name.
+func (r *CAPIClusterReconciler) deleteCluster(ctx context.Context, key types.NamespacedName) error {
+	cluster := &fleet.Cluster{}
+	if err := r.Get(ctx, key, cluster); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	owner := metav1.GetControllerOf(cluster)
+	if owner == nil || owner.Kind != CAPIClusterGVK.Kind || owner.Name != key.Name ||
+		!strings.HasPrefix(owner.APIVersion, CAPIClusterGVK.Group+"/") {
+		return nil
+	}
+	if !cluster.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	log.FromContext(ctx).WithName("capi-cluster").Info("Deleting fleet cluster of deleted Cluster API cluster", "cluster", key)
+	return client.IgnoreNotFound(r.Delete(ctx, cluster))
+}
+
+// clusterClassLabels returns the labels of the ClusterClass the Cluster API
+// cluster is created from, if any.
+func (r *CAPIClusterReconciler) clusterClassLabels(ctx context.Context, capiCluster *unstructured.Unstructured) (map[string]string, error) {
+	name, namespace := clusterClassRef(capiCluster)
+	if name == "" {
+		return nil, nil
+	}
+	clusterClass := newUnstructured(CAPIClusterClassGVK)
+	if err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, clusterClass); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get ClusterClass %s/%s: %w", namespace, name, err)
+	}
+	return clusterClass.GetLabels(), nil
+}
+
+// mapClusterClassToClusters enqueues the Cluster API clusters created from a
+// ClusterClass, so label changes are copied to their fleet clusters.
+func (r *CAPIClusterReconciler) mapClusterClassToClusters(ctx context.Context, obj client.Object) []reconcile.Request {
+	var requests []reconcile.Request
+	for _, ns := range r.Namespaces {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(CAPIClusterGVK.GroupVersion().WithKind(CAPIClusterGVK.Kind + "List"))
+		if err := r.List(ctx, list, client.InNamespace(ns)); err != nil {
+			log.FromContext(ctx).WithName("capi-cluster").Error(err, "Failed to list Cluster API clusters", "namespace", ns)
+			continue
+		}
+		for i := range list.Items {
+			name, namespace := clusterClassRef(&list.Items[i])
+			if name == obj.GetName() && namespace == obj.GetNamespace() {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+					Namespace: list.Items[i].GetNamespace(),
+					Name:      list.Items[i].GetName(),
+				}})
+			}
+		}
+	}
+	return requests
+}
+
+// clusterClassRef returns the name and namespace of the ClusterClass of a
+// Cluster API cluster with a managed topology. The ClusterClass is in the
+// namespace of the cluster, unless the topology sets classNamespace.
+func clusterClassRef(capiCluster *unstructured.Unstructured) (string, string) {
+	name, _, _ := unstructured.NestedString(capiCluster.Object, "spec", "topology", "class")
+	namespace, _, _ := unstructured.NestedString(capiCluster.Object, "spec", "topology", "classNamespace")
+	if namespace == "" {
+		namespace = capiCluster.GetNamespace()
+	}
+	return name, namespace
+}
+
+// controlPlaneReady returns true if the control plane of the Cluster API
+// cluster can be reached. Both the v1beta1 field and the v1beta2
+// initialization status are supported.
+func controlPlaneReady(capiCluster *unstructured.Unstructured) bool {
+	if ready, _, _ := unstructured.NestedBool(capiCluster.Object, "status", "controlPlaneReady"); ready {
+		return true
+	}
+	initialized, _, _ := unstructured.NestedBool(capiCluster.Object, "status", "initialization", "controlPlaneInitialized")
+	return initialized
+}
+
+// setCAPILabels sets the labels copied from Cluster API on the fleet cluster
+// and removes the ones which were copied before, but no longer exist. Labels
+// added to the fleet cluster by other means are kept.
+func setCAPILabels(cluster *fleet.Cluster, copied map[string]string) {
+	labels := cluster.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for _, key := range strings.Split(cluster.Annotations[CAPIClusterLabelsAnnotation], ",") {
+		if _, ok := copied[key]; !ok {
+			delete(labels, key)
+		}
+	}
+	maps.Copy(labels, copied)
+	cluster.SetLabels(labels)
+
+	annotations := cluster.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[CAPIClusterLabelsAnnotation] = strings.Join(slices.Sorted(maps.Keys(copied)), ",")
+	cluster.SetAnnotations(annotations)
+}
+
+// mergeLabels merges label maps, later maps take precedence.
+func mergeLabels(labels ...map[string]string) map[string]string {
+	result := map[string]string{}
+	for _, l := range labels {
+		maps.Copy(result, l)
+	}
+	return result
+}
+
+func newUnstructured(gvk schema.GroupVersionKind) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(gvk)
+	return u
+}
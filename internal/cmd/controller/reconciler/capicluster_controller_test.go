@@ -0,0 +1,153 @@
+package reconciler_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rancher/fleet/internal/cmd/controller/reconciler"
+	fleetv1 "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newCAPIObject(kind, name string, labels map[string]string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(reconciler.CAPIClusterGVK.GroupVersion().WithKind(kind))
+	u.SetNamespace("capi")
+	u.SetName(name)
+	u.SetUID(types.UID(name + "-uid"))
+	u.SetLabels(labels)
+	return u
+}
+
+func TestCAPIClusterReconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(fleetv1.AddToScheme(scheme))
+
+	capiCluster := newCAPIObject("Cluster", "c1", map[string]string{"env": "dev"})
+	if err := unstructured.SetNestedField(capiCluster.Object, "cc", "spec", "topology", "class"); err != nil {
+		t.Fatal(err)
+	}
+	clusterClass := newCAPIObject("ClusterClass", "cc", map[string]string{"env": "base", "provider": "aws"})
+	unmanaged := &fleetv1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "capi", Name: "c2", Labels: map[string]string{"a": "b"}}}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(capiCluster, clusterClass, unmanaged, newCAPIObject("Cluster", "c2", map[string]string{"env": "dev"})).
+		Build()
+	r := &reconciler.CAPIClusterReconciler{Client: c, Scheme: scheme, Namespaces: []string{"capi"}}
+	ctx := context.Background()
+
+	reconcile := func(name string) *fleetv1.Cluster {
+		t.Helper()
+		key := types.NamespacedName{Namespace: "capi", Name: name}
+		if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+			t.Fatalf("reconcile failed: %v", err)
+		}
+		cluster := &fleetv1.Cluster{}
+		if err := c.Get(ctx, key, cluster); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			t.Fatal(err)
+		}
+		return cluster
+	}
+	updateCAPICluster := func(mutate func(u *unstructured.Unstructured)) {
+		t.Helper()
+		if err := c.Get(ctx, client.ObjectKeyFromObject(capiCluster), capiCluster); err != nil {
+			t.Fatal(err)
+		}
+		mutate(capiCluster)
+		if err := c.Update(ctx, capiCluster); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cluster := reconcile("c1")
+	if cluster == nil {
+		t.Fatal("expected fleet cluster to be created")
+	}
+	if cluster.Labels["env"] != "dev" || cluster.Labels["provider"] != "aws" {
+		t.Errorf("expected labels of Cluster API cluster and ClusterClass, got %v", cluster.Labels)
+	}
+	if !metav1.IsControlledBy(cluster, capiCluster) {
+		t.Errorf("expected fleet cluster to be owned by Cluster API cluster, got %v", cluster.OwnerReferences)
+	}
+	if cluster.Spec.KubeConfigSecret != "" {
+		t.Errorf("expected no kubeconfig secret before the control plane is ready, got %q", cluster.Spec.KubeConfigSecret)
+	}
+
+	updateCAPICluster(func(u *unstructured.Unstructured) {
+		_ = unstructured.SetNestedField(u.Object, true, "status", "controlPlaneReady")
+	})
+	if cluster := reconcile("c1"); cluster.Spec.KubeConfigSecret != "" {
+		t.Errorf("expected no kubeconfig secret before the secret exists, got %q", cluster.Spec.KubeConfigSecret)
+	}
+
+	if err := c.Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "capi", Name: "c1-kubeconfig"},
+		Data:       map[string][]byte{"value": []byte("kubeconfig")},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	cluster = reconcile("c1")
+	if cluster.Spec.KubeConfigSecret != "c1-kubeconfig" {
+		t.Errorf("expected kubeconfig secret c1-kubeconfig, got %q", cluster.Spec.KubeConfigSecret)
+	}
+
+	// labels added to the fleet cluster are kept, removed labels fall back to the ClusterClass
+	cluster.Labels["team"] = "a"
+	if err := c.Update(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+	updateCAPICluster(func(u *unstructured.Unstructured) {
+		u.SetLabels(map[string]string{"region": "eu"})
+	})
+	cluster = reconcile("c1")
+	for k, v := range map[string]string{"env": "base", "provider": "aws", "region": "eu", "team": "a"} {
+		if cluster.Labels[k] != v {
+			t.Errorf("expected label %s=%s, got %v", k, v, cluster.Labels)
+		}
+	}
+
+	updateCAPICluster(func(u *unstructured.Unstructured) {
+		u.SetLabels(nil)
+		unstructured.RemoveNestedField(u.Object, "spec", "topology")
+	})
+	cluster = reconcile("c1")
+	for _, k := range []string{"env", "provider", "region"} {
+		if _, ok := cluster.Labels[k]; ok {
+			t.Errorf("expected label %s to be removed, got %v", k, cluster.Labels)
+		}
+	}
+
+	// existing fleet clusters are not taken over
+	if cluster := reconcile("c2"); len(cluster.OwnerReferences) != 0 || cluster.Labels["env"] != "" {
+		t.Errorf("expected unmanaged fleet cluster to be unchanged, got %v", cluster)
+	}
+
+	if err := c.Delete(ctx, capiCluster); err != nil {
+		t.Fatal(err)
+	}
+	if cluster := reconcile("c1"); cluster != nil {
+		t.Error("expected fleet cluster to be deleted with the Cluster API cluster")
+	}
+
+	if err := c.Delete(ctx, newCAPIObject("Cluster", "c2", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if cluster := reconcile("c2"); cluster == nil {
+		t.Error("expected unmanaged fleet cluster not to be deleted")
+	}
+}
@@ -6,12 +6,12 @@ import (
 	"context"
 	"fmt"
 	"reflect"
-	"sort"
 	"strings"
 	"time"
 
 	fleetutil "github.com/rancher/fleet/internal/cmd/controller/errorutil"
 	"github.com/rancher/fleet/internal/cmd/controller/summary"
+	"github.com/rancher/fleet/internal/cmd/controller/target"
 	"github.com/rancher/fleet/internal/metrics"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	"github.com/rancher/fleet/pkg/sharding"
@@ -24,6 +24,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	errutil "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -49,7 +50,7 @@ const MaxReportedNonReadyClusters = 10
 func (r *ClusterGroupReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&fleet.ClusterGroup{}, builder.WithPredicates(
-			// only trigger on status changes, create
+			// only trigger on spec changes, status changes, create
 			predicate.Funcs{
 				CreateFunc: func(e event.CreateEvent) bool {
 					return true
@@ -60,10 +61,28 @@ func (r *ClusterGroupReconciler) SetupWithManager(mgr ctrl.Manager) error {
 					if n == nil || o == nil {
 						return false
 					}
-					return !reflect.DeepEqual(n.Status, o.Status)
+					return n.Generation != o.Generation || !reflect.DeepEqual(n.Status, o.Status)
 				},
 			},
 		)).
+		Watches(
+			// Fan out from cluster group to its ancestors, their status
+			// includes the clusters of their child groups. If the parent
+			// changes, the former ancestors are enqueued as well.
+			&fleet.ClusterGroup{},
+			handler.Funcs{
+				CreateFunc: func(ctx context.Context, e event.CreateEvent, q workqueue.TypedRateLimitingInterface[ctrl.Request]) {
+					r.enqueueAncestors(ctx, q, e.Object)
+				},
+				UpdateFunc: func(ctx context.Context, e event.UpdateEvent, q workqueue.TypedRateLimitingInterface[ctrl.Request]) {
+					r.enqueueAncestors(ctx, q, e.ObjectOld, e.ObjectNew)
+				},
+				DeleteFunc: func(ctx context.Context, e event.DeleteEvent, q workqueue.TypedRateLimitingInterface[ctrl.Request]) {
+					r.enqueueAncestors(ctx, q, e.Object)
+				},
+			},
+			builder.WithPredicates(clusterGroupMembershipChangedPredicate()),
+		).
 		Watches(
 			// Fan out from cluster to clustergroup
 			&fleet.Cluster{},
@@ -91,18 +110,17 @@ func (r *ClusterGroupReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	}
 	logger.V(1).Info("Reconciling clustergroup, updating summary and display status field", "oldDisplay", group.Status.Display)
 
-	clusters := &fleet.ClusterList{}
-	if group.Spec.Selector != nil {
-		selector, err := metav1.LabelSelectorAsSelector(group.Spec.Selector)
-		if err != nil {
-			logger.Error(err, "Failed to parse selector", "selector", group.Spec.Selector)
-			return ctrl.Result{}, r.updateErrorStatus(ctx, req.NamespacedName, group.Status, err)
-		}
+	groups, err := r.clusterGroupsByName(ctx, req.Namespace)
+	if err != nil {
+		return ctrl.Result{}, r.updateErrorStatus(ctx, req.NamespacedName, group.Status, err)
+	}
+	// a missing parent or a cycle is reported, but does not prevent the status update
+	_, parentErr := target.ClusterGroupAncestors(group, groups)
 
-		err = r.List(ctx, clusters, client.InNamespace(req.Namespace), client.MatchingLabelsSelector{Selector: selector})
-		if err != nil {
-			return ctrl.Result{}, r.updateErrorStatus(ctx, req.NamespacedName, group.Status, err)
-		}
+	clusters, err := target.ClusterGroupMembers(ctx, r.Client, group)
+	if err != nil {
+		logger.Error(err, "Failed to list clusters", "selector", group.Spec.Selector)
+		return ctrl.Result{}, r.updateErrorStatus(ctx, req.NamespacedName, group.Status, err)
 	}
 
 	// update summary
@@ -112,11 +130,7 @@ func (r *ClusterGroupReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	group.Status.NonReadyClusterCount = 0
 	group.Status.NonReadyClusters = nil
 
-	sort.Slice(clusters.Items, func(i, j int) bool {
-		return clusters.Items[i].Name < clusters.Items[j].Name
-	})
-
-	for _, cluster := range clusters.Items {
+	for _, cluster := range clusters {
 		summary.IncrementResourceCounts(&group.Status.ResourceCounts, cluster.Status.ResourceCounts)
 		summary.Increment(&group.Status.Summary, cluster.Status.Summary)
 		group.Status.ClusterCount++
@@ -146,7 +160,7 @@ func (r *ClusterGroupReconciler) Reconcile(ctx context.Context, req ctrl.Request
 
 	group.Status.Display.State = string(state)
 
-	r.setCondition(&group.Status, nil)
+	r.setCondition(&group.Status, parentErr)
 
 	err = r.updateStatus(ctx, req.NamespacedName, group.Status)
 	if err != nil {
@@ -189,6 +203,72 @@ func (r *ClusterGroupReconciler) updateStatus(ctx context.Context, req types.Nam
 	})
 }
 
+func (r *ClusterGroupReconciler) clusterGroupsByName(ctx context.Context, namespace string) (map[string]*fleet.ClusterGroup, error) {
+	cgs := &fleet.ClusterGroupList{}
+	if err := r.List(ctx, cgs, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	groups := make(map[string]*fleet.ClusterGroup, len(cgs.Items))
+	for i := range cgs.Items {
+		groups[cgs.Items[i].Name] = &cgs.Items[i]
+	}
+	return groups, nil
+}
+
+// ancestorRequests returns reconcile requests for the ancestors of the cluster group.
+func ancestorRequests(group *fleet.ClusterGroup, groups map[string]*fleet.ClusterGroup) []ctrl.Request {
+	// requests are returned for the ancestors found before a missing parent or a cycle
+	ancestors, _ := target.ClusterGroupAncestors(group, groups)
+	requests := make([]ctrl.Request, 0, len(ancestors))
+	for _, ancestor := range ancestors {
+		requests = append(requests, ctrl.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: ancestor.Namespace,
+				Name:      ancestor.Name,
+			},
+		})
+	}
+	return requests
+}
+
+// clusterGroupMembershipChangedPredicate passes updates of cluster groups, which change the clusters of their
+// ancestors.
+func clusterGroupMembershipChangedPredicate() predicate.Funcs {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			n, nOK := e.ObjectNew.(*fleet.ClusterGroup)
+			o, oOK := e.ObjectOld.(*fleet.ClusterGroup)
+			if !nOK || !oOK {
+				return false
+			}
+			return n.Spec.Parent != o.Spec.Parent || !equality.Semantic.DeepEqual(n.Spec.Selector, o.Spec.Selector)
+		},
+	}
+}
+
+// enqueueAncestors enqueues the ancestors of the cluster groups. For updates, these are the ancestors of the old and
+// of the new object.
+func (r *ClusterGroupReconciler) enqueueAncestors(ctx context.Context, q workqueue.TypedRateLimitingInterface[ctrl.Request], objs ...client.Object) {
+	var groups map[string]*fleet.ClusterGroup
+	for _, obj := range objs {
+		group, ok := obj.(*fleet.ClusterGroup)
+		if !ok || group.Spec.Parent == "" {
+			continue
+		}
+		if groups == nil {
+			var err error
+			groups, err = r.clusterGroupsByName(ctx, group.Namespace)
+			if err != nil {
+				log.FromContext(ctx).WithName("clustergroup-parent-handler").Error(err, "Failed to list cluster groups in namespace", "namespace", group.Namespace)
+				return
+			}
+		}
+		for _, req := range ancestorRequests(group, groups) {
+			q.Add(req)
+		}
+	}
+}
+
 func (r *ClusterGroupReconciler) mapClusterToClusterGroup(ctx context.Context, a client.Object) []ctrl.Request {
 	ns := a.GetNamespace()
 	logger := log.FromContext(ctx).WithName("clustergroup-cluster-handler").WithValues("namespace", ns)
@@ -207,6 +287,11 @@ func (r *ClusterGroupReconciler) mapClusterToClusterGroup(ctx context.Context, a
 	}
 	logger.Info("Cluster changed, enqueue matching cluster groups", "name", cluster.GetName())
 
+	groups := make(map[string]*fleet.ClusterGroup, len(cgs.Items))
+	for i := range cgs.Items {
+		groups[cgs.Items[i].Name] = &cgs.Items[i]
+	}
+
 	requests := []ctrl.Request{}
 	for _, cg := range cgs.Items {
 		if cg.Spec.Selector == nil {
@@ -227,6 +312,8 @@ func (r *ClusterGroupReconciler) mapClusterToClusterGroup(ctx context.Context, a
 					Name:      cg.Name,
 				},
 			})
+			// the cluster is also a member of all ancestors
+			requests = append(requests, ancestorRequests(&cg, groups)...)
 			// only need to enqueue this cluster group once, no need to check cluster count
 			continue
 		}
@@ -245,6 +332,7 @@ func (r *ClusterGroupReconciler) mapClusterToClusterGroup(ctx context.Context, a
 					Name:      cg.Name,
 				},
 			})
+			requests = append(requests, ancestorRequests(&cg, groups)...)
 		}
 	}
 
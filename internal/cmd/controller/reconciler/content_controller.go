@@ -38,8 +38,12 @@ func (r *ContentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		For(&fleet.Content{}, // using For with CreateFunc only to reconcile Contents to check for finalizers
 			builder.WithPredicates(
 				predicate.Funcs{
-					CreateFunc:  func(e event.CreateEvent) bool { return true },
-					UpdateFunc:  func(e event.UpdateEvent) bool { return false },
+					CreateFunc: func(e event.CreateEvent) bool { return true },
+					UpdateFunc: func(e event.UpdateEvent) bool {
+						// Chunks are referenced by their owners, reconcile them to update the reference count
+						return isChunk(e.ObjectNew) &&
+							len(e.ObjectNew.GetOwnerReferences()) != len(e.ObjectOld.GetOwnerReferences())
+					},
 					DeleteFunc:  func(e event.DeleteEvent) bool { return false },
 					GenericFunc: func(e event.GenericEvent) bool { return false },
 				},
@@ -86,6 +90,11 @@ func (r *ContentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	if isChunk(content) {
+		// Chunks are garbage collected by Kubernetes once all owning Content resources are deleted
+		return ctrl.Result{}, r.updateReferenceCount(ctx, content, len(content.OwnerReferences))
+	}
+
 	finalizersDeleted, err := removeFinalizers(ctx, r.Client, content)
 	if err != nil {
 		return ctrl.Result{}, err
@@ -113,17 +122,30 @@ func (r *ContentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return ctrl.Result{}, r.Delete(ctx, content)
 	}
 
-	if content.Status.ReferenceCount != newReferenceCount {
-		logger.V(1).Info("Updating Content reference count", "oldCount", content.Status.ReferenceCount, "newCount", newReferenceCount)
-		orig := content.DeepCopy()
-		content.Status.ReferenceCount = newReferenceCount
-		if err := r.Status().Patch(ctx, content, client.MergeFrom(orig)); err != nil {
-			logger.Error(err, "Failed to update Content reference count status")
-			return ctrl.Result{}, err
-		}
+	return ctrl.Result{}, r.updateReferenceCount(ctx, content, newReferenceCount)
+}
+
+// updateReferenceCount patches the reference count in the status of the Content resource, if it changed.
+func (r *ContentReconciler) updateReferenceCount(ctx context.Context, content *fleet.Content, count int) error {
+	if content.Status.ReferenceCount == count {
+		return nil
+	}
+
+	logger := log.FromContext(ctx)
+	logger.V(1).Info("Updating Content reference count", "oldCount", content.Status.ReferenceCount, "newCount", count)
+	orig := content.DeepCopy()
+	content.Status.ReferenceCount = count
+	if err := r.Status().Patch(ctx, content, client.MergeFrom(orig)); err != nil {
+		logger.Error(err, "Failed to update Content reference count status")
+		return err
 	}
 
-	return ctrl.Result{}, nil
+	return nil
+}
+
+// isChunk returns true if the Content resource stores a chunk of a larger manifest.
+func isChunk(obj client.Object) bool {
+	return obj.GetLabels()[fleet.ContentChunkLabel] == "true"
 }
 
 // mapBundleDeploymentToContent maps a BundleDeployment to its associated Content resource.
@@ -297,6 +297,36 @@ var _ = Describe("ContentReconciler", func() {
 			})
 		})
 
+		Context("when Content is a chunk", func() {
+			BeforeEach(func() {
+				content = &fleet.Content{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "c-chunk",
+						Labels: map[string]string{fleet.ContentChunkLabel: "true"},
+						OwnerReferences: []metav1.OwnerReference{
+							{APIVersion: "fleet.cattle.io/v1alpha1", Kind: "Content", Name: "s-1", UID: "1"},
+							{APIVersion: "fleet.cattle.io/v1alpha1", Kind: "Content", Name: "s-2", UID: "2"},
+						},
+					},
+					Status: fleet.ContentStatus{ReferenceCount: 1},
+				}
+
+				cl = fake.NewClientBuilder().WithScheme(sch).
+					WithObjects(content).
+					WithStatusSubresource(&fleet.Content{}).
+					Build()
+			})
+
+			It("counts the owning Content resources as references and does not delete the chunk", func() {
+				_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKey{Name: content.Name}})
+				Expect(err).ToNot(HaveOccurred())
+
+				got := &fleet.Content{}
+				Expect(cl.Get(ctx, client.ObjectKey{Name: content.Name}, got)).To(Succeed())
+				Expect(got.Status.ReferenceCount).To(Equal(2))
+			})
+		})
+
 		Context("when Content has finalizers and active BundleDeployments", func() {
 			BeforeEach(func() {
 				content = &fleet.Content{
@@ -35,11 +35,7 @@ type CronDurationJob struct {
 // Internally, it assigns Location = Local if no location was specified in the schedule,
 // and from that point onward, any time-related calculations are performed using this location.
 func newCronDurationJob(ctx context.Context, schedule *fleet.Schedule, scheduler quartz.Scheduler, c client.Client) (*CronDurationJob, error) {
-	locationStr := schedule.Spec.Location
-	if locationStr == "" {
-		locationStr = "Local"
-	}
-	location, err := time.LoadLocation(locationStr)
+	location, err := scheduleLocation(schedule)
 	if err != nil {
 		return nil, err
 	}
@@ -75,6 +71,28 @@ func newCronDurationJob(ctx context.Context, schedule *fleet.Schedule, scheduler
 	}, nil
 }
 
+// ValidateSchedule returns an error if the location, the cron expression, the duration or the cluster criteria of the
+// schedule are invalid. It runs the checks of newCronDurationJob, which don't need access to the clusters.
+func ValidateSchedule(schedule *fleet.Schedule) error {
+	location, err := scheduleLocation(schedule)
+	if err != nil {
+		return err
+	}
+	if err := checkScheduleAndDuration(schedule, location); err != nil {
+		return err
+	}
+	_, err = matcher.NewScheduleMatch(schedule)
+	return err
+}
+
+func scheduleLocation(schedule *fleet.Schedule) (*time.Location, error) {
+	locationStr := schedule.Spec.Location
+	if locationStr == "" {
+		locationStr = "Local"
+	}
+	return time.LoadLocation(locationStr)
+}
+
 // Execute implements the quartz.Job interface function to run a scheduled job.
 func (c *CronDurationJob) Execute(ctx context.Context) error {
 	if c.Started {
@@ -262,7 +280,7 @@ func (c *CronDurationJob) executeStop(ctx context.Context) error {
 }
 
 // matchingClusters returns the list of clusters that match the given Schedule at this moment.
-func matchingClusters(ctx context.Context, matcher *matcher.ScheduleMatch, c client.Client, namespace string) ([]string, error) {
+func matchingClusters(ctx context.Context, scheduleMatch *matcher.ScheduleMatch, c client.Client, namespace string) ([]string, error) {
 	clusters := &fleet.ClusterList{}
 	if err := c.List(ctx, clusters, client.InNamespace(namespace)); err != nil {
 		return nil, fmt.Errorf("%w, listing clusters: %w", fleetutil.ErrRetryable, err)
@@ -274,7 +292,7 @@ func matchingClusters(ctx context.Context, matcher *matcher.ScheduleMatch, c cli
 			return nil, fmt.Errorf("%w, getting cluster groups from clusters: %w", fleetutil.ErrRetryable, err)
 		}
 
-		if matcher.MatchCluster(cluster.Name, target.ClusterGroupsToLabelMap(cgs), cluster.Labels) {
+		if scheduleMatch.MatchCluster(ctx, matcher.NewCluster(&cluster, target.ClusterGroupsToLabelMap(cgs))) {
 			clusterNames = append(clusterNames, cluster.Name)
 		}
 	}
@@ -8,6 +8,7 @@ import (
 	"github.com/reugn/go-quartz/quartz"
 
 	"github.com/rancher/fleet/internal/cmd/controller/imagescan"
+	"github.com/rancher/fleet/internal/gitcredentials"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	"github.com/rancher/fleet/pkg/sharding"
 
@@ -29,6 +30,9 @@ type ImageScanReconciler struct {
 	ShardID   string
 
 	Workers int
+
+	// Credentials mints tokens for client secrets configuring a credential provider.
+	Credentials *gitcredentials.Cache
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -108,7 +112,7 @@ func (r *ImageScanReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	_ = r.Scheduler.DeleteJob(gitCommitKey)
 	err = r.Scheduler.ScheduleJob(
 		quartz.NewJobDetail(
-			imagescan.NewGitCommitJob(r.Client, gitrepo.Namespace, gitrepo.Name),
+			imagescan.NewGitCommitJob(r.Client, r.Credentials, gitrepo.Namespace, gitrepo.Name),
 			gitCommitKey),
 		quartz.NewSimpleTrigger(interval.Duration),
 	)
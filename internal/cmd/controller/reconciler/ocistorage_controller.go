@@ -0,0 +1,223 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rancher/fleet/internal/config"
+	"github.com/rancher/fleet/internal/metrics"
+	"github.com/rancher/fleet/internal/ocistorage"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	fleetevent "github.com/rancher/fleet/pkg/event"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	defaultOCIStorageGCInterval = time.Hour
+	defaultOCIStorageRetention  = 24 * time.Hour
+)
+
+// OCIStorageReconciler periodically reconciles the OCI registries configured in OCI storage secrets against the
+// bundles stored in them. It deletes artifacts no longer referenced by any bundle after the retention period,
+// verifies that referenced artifacts can be pulled and reports the registry health.
+// Internal secrets, which are copied per bundle, are not reconciled.
+type OCIStorageReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// unreferenced records when artifacts were first found unreferenced, by storage secret and artifact id. The
+	// retention period starts then, as artifacts are not updated when the bundle referencing them changes. It is
+	// not persisted, after a restart the retention period starts again.
+	mu           sync.Mutex
+	unreferenced map[types.NamespacedName]map[string]time.Time
+}
+
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=fleet.cattle.io,resources=bundles,verbs=get;list;watch
+//+kubebuilder:rbac:groups=fleet.cattle.io,resources=bundles/status,verbs=get;update;patch
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *OCIStorageReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("ocistorage").
+		For(&corev1.Secret{}, builder.WithPredicates(
+			predicate.NewPredicateFuncs(isOCIStorageSecret),
+			predicate.ResourceVersionChangedPredicate{},
+		)).
+		Complete(r)
+}
+
+func isOCIStorageSecret(obj client.Object) bool {
+	secret, ok := obj.(*corev1.Secret)
+	if !ok {
+		return false
+	}
+	return secret.Type == fleet.SecretTypeOCIStorage && secret.Labels[fleet.InternalSecretLabel] == ""
+}
+
+// Reconcile checks the OCI registry of the storage secret and requeues itself after the configured GC interval.
+func (r *OCIStorageReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithName("ocistorage")
+	ctx = log.IntoContext(ctx, logger)
+
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, req.NamespacedName, secret); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			metrics.OCIStorageCollector.Delete(req.Name, req.Namespace)
+			r.setUnreferenced(req.NamespacedName, nil)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !isOCIStorageSecret(secret) {
+		r.setUnreferenced(req.NamespacedName, nil)
+		return ctrl.Result{}, nil
+	}
+
+	interval, retention := defaultOCIStorageGCInterval, defaultOCIStorageRetention
+	if cfg := config.Get(); cfg != nil {
+		if cfg.OCIStorageGCInterval.Duration > 0 {
+			interval = cfg.OCIStorageGCInterval.Duration
+		}
+		if cfg.OCIStorageRetention.Duration > 0 {
+			retention = cfg.OCIStorageRetention.Duration
+		}
+	}
+
+	opts, err := ocistorage.ReadOptsFromSecret(ctx, r.Client, req.NamespacedName)
+	if err != nil {
+		r.Recorder.Event(secret, fleetevent.Warning, "InvalidOCIStorageSecret", err.Error())
+		return ctrl.Result{RequeueAfter: interval}, nil
+	}
+
+	health := &metrics.OCIStorageHealth{ObjectMeta: secret.ObjectMeta, Reference: opts.Reference, Healthy: true}
+	defer metrics.OCIStorageCollector.Collect(ctx, health)
+
+	bundles := &fleet.BundleList{}
+	if err := r.List(ctx, bundles); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	oci := ocistorage.NewOCIWrapper()
+	referenced := sets.New[string]()
+	var errs []error
+	for i := range bundles.Items {
+		bundle := &bundles.Items[i]
+		if bundle.Spec.ContentsID == "" {
+			continue
+		}
+		referenced.Insert(bundle.Spec.ContentsID)
+
+		// the bundle's secret contains the reference and the agent credentials used to pull the artifact
+		bundleOpts, err := ocistorage.ReadOptsFromSecret(ctx, r.Client, client.ObjectKey{Namespace: bundle.Namespace, Name: bundle.Spec.ContentsID})
+		if err != nil {
+			logger.V(1).Info("Skipping bundle, failed to read its OCI storage secret", "bundle", bundle.Name, "namespace", bundle.Namespace, "error", err)
+			continue
+		}
+		if bundleOpts.Reference != opts.Reference {
+			continue
+		}
+
+		checkErr := oci.CheckManifest(ctx, bundleOpts, bundle.Spec.ContentsID)
+		if checkErr != nil {
+			health.Healthy = false
+			health.Missing++
+			checkErr = fmt.Errorf("failed to pull OCI artifact %q: %w", bundle.Spec.ContentsID, checkErr)
+		}
+		if err := r.setOCIStorageCondition(ctx, bundle, checkErr); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	artifacts, err := oci.ListArtifacts(ctx, opts)
+	if err != nil {
+		health.Healthy = false
+		r.Recorder.Event(secret, fleetevent.Warning, "OCIStorageUnhealthy", err.Error())
+		return ctrl.Result{RequeueAfter: interval}, errors.Join(errs...)
+	}
+	health.Artifacts = len(artifacts)
+
+	now := time.Now()
+	previous := r.getUnreferenced(req.NamespacedName)
+	unreferenced := map[string]time.Time{}
+	for _, artifact := range artifacts {
+		if referenced.Has(artifact.ID) {
+			continue
+		}
+		health.Unreferenced++
+
+		// artifacts are pushed before the bundle is updated, the retention period also protects those
+		since, ok := previous[artifact.ID]
+		if !ok {
+			since = now
+		}
+		if now.Sub(since) < retention {
+			unreferenced[artifact.ID] = since
+			continue
+		}
+		if err := oci.DeleteManifest(ctx, opts, artifact.ID); err != nil {
+			r.Recorder.Event(secret, fleetevent.Warning, "FailedToDeleteOCIArtifact", fmt.Sprintf("deleting OCI artifact %q: %v", artifact.ID, err.Error()))
+			unreferenced[artifact.ID] = since
+			continue
+		}
+		logger.Info("Deleted orphaned OCI artifact", "id", artifact.ID, "created", artifact.Created, "unreferencedSince", since)
+		health.Deleted++
+		health.Unreferenced--
+	}
+	r.setUnreferenced(req.NamespacedName, unreferenced)
+
+	return ctrl.Result{RequeueAfter: interval}, errors.Join(errs...)
+}
+
+func (r *OCIStorageReconciler) getUnreferenced(key types.NamespacedName) map[string]time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.unreferenced[key]
+}
+
+// setUnreferenced replaces the unreferenced artifacts of the storage secret, artifacts which are referenced again or
+// deleted are forgotten.
+func (r *OCIStorageReconciler) setUnreferenced(key types.NamespacedName, unreferenced map[string]time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(unreferenced) == 0 {
+		delete(r.unreferenced, key)
+		return
+	}
+	if r.unreferenced == nil {
+		r.unreferenced = map[types.NamespacedName]map[string]time.Time{}
+	}
+	r.unreferenced[key] = unreferenced
+}
+
+// setOCIStorageCondition sets the OCIStorage condition of the bundle, it only updates the status if the condition
+// changed. The status is also updated by the bundle reconciler, the update is retried on conflicts.
+func (r *OCIStorageReconciler) setOCIStorageCondition(ctx context.Context, bundle *fleet.Bundle, err error) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		t := &fleet.Bundle{}
+		if err := r.Get(ctx, client.ObjectKeyFromObject(bundle), t); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		orig := t.DeepCopy()
+		SetCondition(fleet.BundleConditionOCIStorage, &t.Status, err)
+		if equality.Semantic.DeepEqual(orig.Status, t.Status) {
+			return nil
+		}
+		return r.Status().Update(ctx, t)
+	})
+}
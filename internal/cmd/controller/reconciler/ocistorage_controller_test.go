@@ -0,0 +1,151 @@
+package reconciler_test
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/registry"
+
+	"github.com/rancher/fleet/internal/cmd/controller/reconciler"
+	"github.com/rancher/fleet/internal/config"
+	"github.com/rancher/fleet/internal/manifest"
+	"github.com/rancher/fleet/internal/ocistorage"
+	fleetv1 "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestOCIStorageReconcile(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		deleted     []string
+		blobsPulled bool
+		pushed      bool
+	)
+	reg := registry.New(registry.Logger(log.New(io.Discard, "", 0)))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		if r.Method == http.MethodDelete && strings.Contains(r.URL.Path, "/manifests/") {
+			deleted = append(deleted, strings.Split(r.URL.Path, "/")[3])
+		}
+		if pushed && r.Method == http.MethodGet && strings.Contains(r.URL.Path, "/blobs/") {
+			blobsPulled = true
+		}
+		mu.Unlock()
+		reg.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	reference := strings.TrimPrefix(srv.URL, "http://") + "/fleet"
+	opts := ocistorage.OCIOpts{Reference: reference, BasicHTTP: true}
+	m := manifest.New([]fleetv1.BundleResource{{Name: "cm.yaml", Content: "kind: ConfigMap"}})
+	for _, id := range []string{"s-used", "s-orphan"} {
+		if err := ocistorage.NewOCIWrapper().PushManifest(context.TODO(), opts, id, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mu.Lock()
+	pushed = true
+	mu.Unlock()
+
+	storageSecret := func(name string, internal bool) *corev1.Secret {
+		s := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "fleet-default"},
+			Type:       fleetv1.SecretTypeOCIStorage,
+			Data: map[string][]byte{
+				ocistorage.OCISecretReference: []byte(reference),
+				ocistorage.OCISecretBasicHTTP: []byte("true"),
+			},
+		}
+		if internal {
+			s.Labels = map[string]string{fleetv1.InternalSecretLabel: "true"}
+		}
+		return s
+	}
+	bundle := func(name, contentsID string) *fleetv1.Bundle {
+		return &fleetv1.Bundle{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "fleet-default"},
+			Spec:       fleetv1.BundleSpec{ContentsID: contentsID},
+		}
+	}
+
+	scheme := runtime.NewScheme()
+	utilruntime.Must(corev1.AddToScheme(scheme))
+	utilruntime.Must(fleetv1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&fleetv1.Bundle{}).
+		WithObjects(
+			storageSecret("ocistorage", false),
+			storageSecret("s-used", true),
+			storageSecret("s-gone", true),
+			bundle("used", "s-used"),
+			bundle("gone", "s-gone"),
+		).
+		Build()
+
+	config.Set(&config.Config{OCIStorageRetention: metav1.Duration{Duration: time.Nanosecond}})
+	defer config.Set(config.DefaultConfig())
+
+	r := reconciler.OCIStorageReconciler{
+		Client:   c,
+		Scheme:   scheme,
+		Recorder: record.NewFakeRecorder(10),
+	}
+	res, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "fleet-default", Name: "ocistorage"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RequeueAfter != time.Hour {
+		t.Errorf("expected requeue after the default interval, got %v", res.RequeueAfter)
+	}
+
+	// the retention period starts when the artifact is found unreferenced
+	if len(deleted) != 0 {
+		t.Errorf("expected no artifact to be deleted before the retention period, got %v", deleted)
+	}
+	if _, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "fleet-default", Name: "ocistorage"}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 1 || deleted[0] != "s-orphan" {
+		t.Errorf("expected only the orphaned artifact to be deleted, got %v", deleted)
+	}
+	if blobsPulled {
+		t.Error("expected artifacts to be checked without pulling their layers")
+	}
+
+	expected := map[string]corev1.ConditionStatus{"used": corev1.ConditionTrue, "gone": corev1.ConditionFalse}
+	for name, status := range expected {
+		b := &fleetv1.Bundle{}
+		if err := c.Get(context.TODO(), client.ObjectKey{Namespace: "fleet-default", Name: name}, b); err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, cond := range b.Status.Conditions {
+			if cond.Type == fleetv1.BundleConditionOCIStorage {
+				found = true
+				if cond.Status != status {
+					t.Errorf("expected OCIStorage condition of bundle %s to be %s, got %s: %s", name, status, cond.Status, cond.Message)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("expected OCIStorage condition on bundle %s", name)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+// Package restrictions checks GitRepos and bundles against the GitRepoRestrictions of their namespace.
+package restrictions
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/rancher/fleet/internal/content"
+	"github.com/rancher/fleet/internal/fleetyaml"
+	"github.com/rancher/fleet/internal/helmdeployer/sops"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/wrangler/v3/pkg/yaml"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultTarget is the target of GitRepos and bundles without targets.
+var DefaultTarget = fleet.BundleTargetRestriction{Name: "default", ClusterGroup: "default"}
+
+// Get returns the aggregated GitRepoRestrictions of the namespace, or nil if there are none.
+func Get(ctx context.Context, c client.Reader, namespace string) (*fleet.GitRepoRestriction, error) {
+	restrictions := &fleet.GitRepoRestrictionList{}
+	if err := c.List(ctx, restrictions, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	if len(restrictions.Items) == 0 {
+		return nil, nil
+	}
+	restriction := Aggregate(restrictions.Items)
+	return &restriction, nil
+}
+
+// Aggregate merges the restrictions. Allow lists are joined, the first default by name wins and the smallest
+// maximum bundle size is used.
+func Aggregate(restrictions []fleet.GitRepoRestriction) (result fleet.GitRepoRestriction) {
+	sort.Slice(restrictions, func(i, j int) bool {
+		return restrictions[i].Name < restrictions[j].Name
+	})
+	for _, restriction := range restrictions {
+		if result.DefaultServiceAccount == "" {
+			result.DefaultServiceAccount = restriction.DefaultServiceAccount
+		}
+		if result.DefaultClientSecretName == "" {
+			result.DefaultClientSecretName = restriction.DefaultClientSecretName
+		}
+		result.AllowedServiceAccounts = append(result.AllowedServiceAccounts, restriction.AllowedServiceAccounts...)
+		result.AllowedClientSecretNames = append(result.AllowedClientSecretNames, restriction.AllowedClientSecretNames...)
+		result.AllowedRepoPatterns = append(result.AllowedRepoPatterns, restriction.AllowedRepoPatterns...)
+		result.AllowedTargetNamespaces = append(result.AllowedTargetNamespaces, restriction.AllowedTargetNamespaces...)
+		result.AllowedClusterGroups = append(result.AllowedClusterGroups, restriction.AllowedClusterGroups...)
+		result.AllowedClusterSelectors = append(result.AllowedClusterSelectors, restriction.AllowedClusterSelectors...)
+		result.AllowedResourceKinds = append(result.AllowedResourceKinds, restriction.AllowedResourceKinds...)
+		result.DeniedResourceKinds = append(result.DeniedResourceKinds, restriction.DeniedResourceKinds...)
+		result.DenyClusterScopedResources = result.DenyClusterScopedResources || restriction.DenyClusterScopedResources
+		if restriction.MaxBundleSize != nil && (result.MaxBundleSize == nil || restriction.MaxBundleSize.Cmp(*result.MaxBundleSize) < 0) {
+			size := restriction.MaxBundleSize.DeepCopy()
+			result.MaxBundleSize = &size
+		}
+	}
+	return
+}
+
+// CheckTargets returns an error if a target is not restricted to the allowed cluster groups or cluster selectors.
+// Target criteria are combined, so a target is allowed if its clusterGroup is allowed, or if its clusterSelector
+// contains all requirements of an allowed selector, regardless of its other criteria.
+func CheckTargets(restriction *fleet.GitRepoRestriction, targets []fleet.BundleTargetRestriction) error {
+	if len(restriction.AllowedClusterGroups) == 0 && len(restriction.AllowedClusterSelectors) == 0 {
+		return nil
+	}
+
+	for _, target := range targets {
+		if target.ClusterGroup != "" && slices.Contains(restriction.AllowedClusterGroups, target.ClusterGroup) {
+			continue
+		}
+		allowed, err := allowedBySelector(target.ClusterSelector, restriction.AllowedClusterSelectors)
+		if err != nil {
+			return fmt.Errorf("target %q: %w", target.Name, err)
+		}
+		if !allowed {
+			return fmt.Errorf("target %q is not restricted to allowedClusterGroups %v or allowedClusterSelectors", target.Name, restriction.AllowedClusterGroups)
+		}
+	}
+	return nil
+}
+
+func allowedBySelector(clusterSelector *metav1.LabelSelector, allowedSelectors []metav1.LabelSelector) (bool, error) {
+	if clusterSelector == nil {
+		return false, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(clusterSelector)
+	if err != nil {
+		return false, err
+	}
+	have, _ := selector.Requirements()
+
+	for _, allowedSelector := range allowedSelectors {
+		allowed, err := metav1.LabelSelectorAsSelector(&allowedSelector)
+		if err != nil {
+			return false, fmt.Errorf("invalid allowedClusterSelector: %w", err)
+		}
+		required, _ := allowed.Requirements()
+		if containsAll(have, required) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func containsAll(have, required labels.Requirements) bool {
+	for _, r := range required {
+		if !slices.ContainsFunc(have, r.Equal) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckBundle returns an error if the bundle's targets, resource kinds or size are not allowed by the restriction.
+// Bundles created from GitRepos are checked against their target restrictions, which contain the GitRepo's targets.
+// The mapper is used to find cluster-scoped resources.
+//
+// Resource kinds and sizes can only be checked for resources stored in the bundle. While they are restricted,
+// bundles whose resources are rendered or downloaded on the agent, like helm charts, kustomizations, HelmOps and
+// bundles stored in an OCI registry, are rejected.
+func CheckBundle(restriction *fleet.GitRepoRestriction, mapper meta.RESTMapper, bundle *fleet.Bundle) error {
+	if err := CheckTargets(restriction, bundleTargets(bundle)); err != nil {
+		return err
+	}
+
+	if restriction.MaxBundleSize != nil {
+		if reason := downloadedOnAgent(bundle); reason != "" {
+			return fmt.Errorf("bundle size cannot be checked against maxBundleSize, %s", reason)
+		}
+		var size int64
+		for _, resource := range bundle.Spec.Resources {
+			size += int64(len(resource.Content))
+		}
+		if size > restriction.MaxBundleSize.Value() {
+			return fmt.Errorf("bundle size %s exceeds maxBundleSize %s",
+				resource.NewQuantity(size, resource.BinarySI).String(), restriction.MaxBundleSize.String())
+		}
+	}
+
+	if !restrictsResources(restriction) {
+		return nil
+	}
+	if reason := downloadedOnAgent(bundle); reason != "" {
+		return fmt.Errorf("bundle resources cannot be checked against resource restrictions, %s", reason)
+	}
+	if reason := renderedOnAgent(bundle); reason != "" {
+		return fmt.Errorf("bundle resources cannot be checked against resource restrictions, %s", reason)
+	}
+	return checkResources(restriction, mapper, bundle.Spec.Resources)
+}
+
+func restrictsResources(restriction *fleet.GitRepoRestriction) bool {
+	return len(restriction.AllowedResourceKinds) > 0 || len(restriction.DeniedResourceKinds) > 0 || restriction.DenyClusterScopedResources
+}
+
+// downloadedOnAgent returns why the bundle's resources are not stored in the bundle, or an empty string.
+func downloadedOnAgent(bundle *fleet.Bundle) string {
+	if bundle.Spec.ContentsID != "" {
+		return "its resources are stored in an OCI registry"
+	}
+	if bundle.Spec.HelmOpOptions != nil {
+		return "it is created by a HelmOp"
+	}
+	for _, opts := range bundleOptions(bundle) {
+		if opts.Helm != nil && (opts.Helm.Repo != "" || opts.Helm.Chart != "") {
+			return "it deploys a helm chart, which is downloaded by the agent"
+		}
+	}
+	return ""
+}
+
+// renderedOnAgent returns why the bundle's resources are only known after rendering or decryption, or an empty
+// string.
+func renderedOnAgent(bundle *fleet.Bundle) string {
+	for _, opts := range bundleOptions(bundle) {
+		if opts.ResourceTemplating != nil && opts.ResourceTemplating.Enabled {
+			return "it enables resource templating, which is rendered by the agent"
+		}
+	}
+	for _, resource := range bundle.Spec.Resources {
+		if strings.HasPrefix(resource.Name, "templates/") {
+			return "it contains helm templates, which are rendered by the agent"
+		}
+		switch path.Base(resource.Name) {
+		case "Chart.yaml":
+			return "it contains a helm chart, whose templates are rendered by the agent"
+		case "kustomization.yaml", "kustomization.yml", "Kustomization":
+			return "it contains a kustomization, which is built by the agent"
+		}
+		data, err := content.Decode(resource.Content, resource.Encoding)
+		if err == nil && sops.IsEncrypted(resource.Name, data) {
+			return "it contains SOPS encrypted files, which are decrypted by the agent"
+		}
+	}
+	return ""
+}
+
+func bundleOptions(bundle *fleet.Bundle) []fleet.BundleDeploymentOptions {
+	opts := []fleet.BundleDeploymentOptions{bundle.Spec.BundleDeploymentOptions}
+	for _, target := range bundle.Spec.Targets {
+		opts = append(opts, target.BundleDeploymentOptions)
+	}
+	return opts
+}
+
+func bundleTargets(bundle *fleet.Bundle) []fleet.BundleTargetRestriction {
+	if len(bundle.Spec.TargetRestrictions) > 0 {
+		return bundle.Spec.TargetRestrictions
+	}
+	if len(bundle.Spec.Targets) == 0 {
+		return []fleet.BundleTargetRestriction{DefaultTarget}
+	}
+
+	targets := make([]fleet.BundleTargetRestriction, 0, len(bundle.Spec.Targets))
+	for _, target := range bundle.Spec.Targets {
+		targets = append(targets, fleet.BundleTargetRestriction{
+			Name:                 target.Name,
+			ClusterName:          target.ClusterName,
+			ClusterSelector:      target.ClusterSelector,
+			ClusterGroup:         target.ClusterGroup,
+			ClusterGroupSelector: target.ClusterGroupSelector,
+			ClusterFactSelector:  target.ClusterFactSelector,
+			ClusterExpression:    target.ClusterExpression,
+		})
+	}
+	return targets
+}
+
+// checkResources checks the kinds of the plain YAML resources. Bundles containing helm charts, kustomizations,
+// templates or encrypted files are rejected before, their resources cannot be parsed before rendering.
+func checkResources(restriction *fleet.GitRepoRestriction, mapper meta.RESTMapper, resources []fleet.BundleResource) error {
+	for _, resource := range resources {
+		if !isPlainYAML(resource.Name) {
+			continue
+		}
+		data, err := content.Decode(resource.Content, resource.Encoding)
+		if err != nil {
+			return err
+		}
+		objs, err := yaml.ToObjects(bytes.NewBuffer(data))
+		if err != nil {
+			// not a Kubernetes manifest, e.g. a values file
+			continue
+		}
+		for _, obj := range objs {
+			gvk := obj.GetObjectKind().GroupVersionKind()
+			if gvk.Kind == "" {
+				continue
+			}
+			if err := checkKind(restriction, mapper, gvk); err != nil {
+				return fmt.Errorf("resource %s: %w", resource.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func isPlainYAML(name string) bool {
+	switch path.Ext(name) {
+	case ".yaml", ".yml", ".json":
+	default:
+		return false
+	}
+	return !fleetyaml.IsFleetYaml(path.Base(name))
+}
+
+func checkKind(restriction *fleet.GitRepoRestriction, mapper meta.RESTMapper, gvk schema.GroupVersionKind) error {
+	gk := gvk.GroupKind()
+	if matchesKind(restriction.DeniedResourceKinds, gk) {
+		return fmt.Errorf("kind %s is denied", gk)
+	}
+	if len(restriction.AllowedResourceKinds) > 0 && !matchesKind(restriction.AllowedResourceKinds, gk) {
+		return fmt.Errorf("kind %s not in allowedResourceKinds %v", gk, restriction.AllowedResourceKinds)
+	}
+	if !restriction.DenyClusterScopedResources {
+		return nil
+	}
+
+	mapping, err := mapper.RESTMapping(gk, gvk.Version)
+	if err != nil {
+		return fmt.Errorf("cannot determine scope of kind %s, cluster-scoped resources are denied: %w", gk, err)
+	}
+	if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+		return fmt.Errorf("cluster-scoped kind %s is denied", gk)
+	}
+	return nil
+}
+
+// matchesKind returns true if the group kind is in the list, either as "Kind" or as "Kind.group".
+func matchesKind(kinds []string, gk schema.GroupKind) bool {
+	for _, kind := range kinds {
+		if kind == gk.Kind || kind == gk.String() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,277 @@
+package restrictions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+func TestAggregate(t *testing.T) {
+	small := resource.MustParse("1Mi")
+	large := resource.MustParse("10Mi")
+
+	result := Aggregate([]fleet.GitRepoRestriction{
+		{
+			ObjectMeta:           metav1.ObjectMeta{Name: "b"},
+			AllowedClusterGroups: []string{"tenant-b"},
+			MaxBundleSize:        &small,
+		},
+		{
+			ObjectMeta:                 metav1.ObjectMeta{Name: "a"},
+			AllowedClusterGroups:       []string{"tenant-a"},
+			DeniedResourceKinds:        []string{"Secret"},
+			DenyClusterScopedResources: true,
+			MaxBundleSize:              &large,
+		},
+	})
+
+	assert.Equal(t, []string{"tenant-a", "tenant-b"}, result.AllowedClusterGroups)
+	assert.Equal(t, []string{"Secret"}, result.DeniedResourceKinds)
+	assert.True(t, result.DenyClusterScopedResources)
+	assert.Equal(t, "1Mi", result.MaxBundleSize.String())
+}
+
+func TestCheckTargets(t *testing.T) {
+	restriction := &fleet.GitRepoRestriction{
+		AllowedClusterGroups: []string{"tenant-a"},
+		AllowedClusterSelectors: []metav1.LabelSelector{
+			{MatchLabels: map[string]string{"tenant": "a"}},
+		},
+	}
+
+	cases := []struct {
+		name        string
+		target      fleet.BundleTargetRestriction
+		expectedErr string
+	}{
+		{
+			name:   "allowed cluster group",
+			target: fleet.BundleTargetRestriction{ClusterGroup: "tenant-a", ClusterName: "any"},
+		},
+		{
+			name: "allowed cluster selector",
+			target: fleet.BundleTargetRestriction{ClusterSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"tenant": "a", "env": "prod"},
+			}},
+		},
+		{
+			name:        "other cluster group",
+			target:      fleet.BundleTargetRestriction{Name: "t", ClusterGroup: "tenant-b"},
+			expectedErr: `target "t" is not restricted to allowedClusterGroups \[tenant-a\]`,
+		},
+		{
+			name: "cluster selector without allowed requirements",
+			target: fleet.BundleTargetRestriction{Name: "t", ClusterSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"env": "prod"},
+			}},
+			expectedErr: `target "t" is not restricted`,
+		},
+		{
+			name:        "cluster name",
+			target:      fleet.BundleTargetRestriction{Name: "t", ClusterName: "local"},
+			expectedErr: `target "t" is not restricted`,
+		},
+		{
+			name:        "cluster expression",
+			target:      fleet.BundleTargetRestriction{Name: "t", ClusterExpression: "true"},
+			expectedErr: `target "t" is not restricted`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := CheckTargets(restriction, []fleet.BundleTargetRestriction{c.target})
+			if c.expectedErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Regexp(t, c.expectedErr, err.Error())
+		})
+	}
+
+	require.NoError(t, CheckTargets(&fleet.GitRepoRestriction{}, []fleet.BundleTargetRestriction{{ClusterName: "local"}}))
+}
+
+func TestCheckBundle(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}, meta.RESTScopeRoot)
+
+	configMap := fleet.BundleResource{Name: "cm.yaml", Content: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\n"}
+	clusterRole := fleet.BundleResource{Name: "role.yaml", Content: "apiVersion: rbac.authorization.k8s.io/v1\nkind: ClusterRole\nmetadata:\n  name: role\n"}
+	unknown := fleet.BundleResource{Name: "crd.yaml", Content: "apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: w\n"}
+	chart := []fleet.BundleResource{
+		{Name: "chart/Chart.yaml", Content: "name: chart\n"},
+		{Name: "chart/templates/role.yaml", Content: clusterRole.Content},
+	}
+	maxSize := resource.MustParse("10")
+
+	cases := []struct {
+		name        string
+		restriction fleet.GitRepoRestriction
+		bundle      fleet.BundleSpec
+		expectedErr string
+	}{
+		{
+			name:        "denied kind",
+			restriction: fleet.GitRepoRestriction{DeniedResourceKinds: []string{"ConfigMap"}},
+			bundle:      fleet.BundleSpec{Resources: []fleet.BundleResource{configMap}},
+			expectedErr: "resource cm.yaml: kind ConfigMap is denied",
+		},
+		{
+			name:        "kind not allowed",
+			restriction: fleet.GitRepoRestriction{AllowedResourceKinds: []string{"ConfigMap"}},
+			bundle:      fleet.BundleSpec{Resources: []fleet.BundleResource{configMap, clusterRole}},
+			expectedErr: "resource role.yaml: kind ClusterRole.rbac.authorization.k8s.io not in allowedResourceKinds",
+		},
+		{
+			name:        "allowed kind with group",
+			restriction: fleet.GitRepoRestriction{AllowedResourceKinds: []string{"ConfigMap", "ClusterRole.rbac.authorization.k8s.io"}},
+			bundle:      fleet.BundleSpec{Resources: []fleet.BundleResource{configMap, clusterRole}},
+		},
+		{
+			name:        "cluster-scoped resource",
+			restriction: fleet.GitRepoRestriction{DenyClusterScopedResources: true},
+			bundle:      fleet.BundleSpec{Resources: []fleet.BundleResource{configMap, clusterRole}},
+			expectedErr: "cluster-scoped kind ClusterRole.rbac.authorization.k8s.io is denied",
+		},
+		{
+			name:        "unknown kind with cluster-scoped resources denied",
+			restriction: fleet.GitRepoRestriction{DenyClusterScopedResources: true},
+			bundle:      fleet.BundleSpec{Resources: []fleet.BundleResource{unknown}},
+			expectedErr: "cannot determine scope of kind Widget.example.com",
+		},
+		{
+			name:        "helm charts are rejected",
+			restriction: fleet.GitRepoRestriction{DenyClusterScopedResources: true},
+			bundle:      fleet.BundleSpec{Resources: chart},
+			expectedErr: "it contains a helm chart",
+		},
+		{
+			name:        "helm templates are rejected",
+			restriction: fleet.GitRepoRestriction{DeniedResourceKinds: []string{"ClusterRole"}},
+			bundle:      fleet.BundleSpec{Resources: []fleet.BundleResource{{Name: "templates/role.yaml", Content: clusterRole.Content}}},
+			expectedErr: "it contains helm templates",
+		},
+		{
+			name:        "helm charts are accepted without resource restrictions",
+			restriction: fleet.GitRepoRestriction{AllowedClusterGroups: []string{"default"}},
+			bundle:      fleet.BundleSpec{Resources: chart},
+		},
+		{
+			name:        "kustomizations are rejected",
+			restriction: fleet.GitRepoRestriction{AllowedResourceKinds: []string{"ConfigMap"}},
+			bundle: fleet.BundleSpec{Resources: []fleet.BundleResource{
+				configMap,
+				{Name: "overlay/kustomization.yaml", Content: "resources:\n- role.yaml\n"},
+			}},
+			expectedErr: "it contains a kustomization",
+		},
+		{
+			name:        "resource templating is rejected",
+			restriction: fleet.GitRepoRestriction{DeniedResourceKinds: []string{"ClusterRole"}},
+			bundle: fleet.BundleSpec{
+				Resources: []fleet.BundleResource{{
+					Name:    "role.yaml",
+					Content: "apiVersion: rbac.authorization.k8s.io/v1\nkind: ${ \"ClusterRole\" }\nmetadata:\n  name: role\n",
+				}},
+				Targets: []fleet.BundleTarget{{
+					ClusterGroup:            "default",
+					BundleDeploymentOptions: fleet.BundleDeploymentOptions{ResourceTemplating: &fleet.ResourceTemplating{Enabled: true}},
+				}},
+			},
+			expectedErr: "it enables resource templating",
+		},
+		{
+			name:        "SOPS encrypted files are rejected",
+			restriction: fleet.GitRepoRestriction{AllowedResourceKinds: []string{"ConfigMap"}},
+			bundle: fleet.BundleSpec{Resources: []fleet.BundleResource{
+				configMap,
+				{
+					Name:    "role.sops.yaml",
+					Content: "apiVersion: ENC[AES256_GCM,data:abc=,iv:abc=,tag:abc=,type:str]\nkind: ENC[AES256_GCM,data:abc=,iv:abc=,tag:abc=,type:str]\nsops:\n  mac: ENC[AES256_GCM,data:abc=,iv:abc=,tag:abc=,type:str]\n  lastmodified: \"2025-01-01T00:00:00Z\"\n",
+				},
+			}},
+			expectedErr: "it contains SOPS encrypted files",
+		},
+		{
+			name:        "SOPS encrypted files are accepted without resource restrictions",
+			restriction: fleet.GitRepoRestriction{AllowedClusterGroups: []string{"default"}},
+			bundle: fleet.BundleSpec{Resources: []fleet.BundleResource{{
+				Name:    "secret.yaml",
+				Content: "data: ENC[AES256_GCM,data:abc=,iv:abc=,tag:abc=,type:str]\nsops:\n  mac: ENC[AES256_GCM,data:abc=,iv:abc=,tag:abc=,type:str]\n  lastmodified: \"2025-01-01T00:00:00Z\"\n",
+			}}},
+		},
+		{
+			name:        "remote helm charts of targets are rejected",
+			restriction: fleet.GitRepoRestriction{AllowedResourceKinds: []string{"ConfigMap"}},
+			bundle: fleet.BundleSpec{
+				Targets: []fleet.BundleTarget{{
+					ClusterGroup:            "default",
+					BundleDeploymentOptions: fleet.BundleDeploymentOptions{Helm: &fleet.HelmOptions{Chart: "oci://example.com/chart"}},
+				}},
+			},
+			expectedErr: "it deploys a helm chart",
+		},
+		{
+			name:        "HelmOps are rejected",
+			restriction: fleet.GitRepoRestriction{DeniedResourceKinds: []string{"ClusterRole"}},
+			bundle:      fleet.BundleSpec{HelmOpOptions: &fleet.BundleHelmOptions{}},
+			expectedErr: "it is created by a HelmOp",
+		},
+		{
+			name:        "OCI bundles are rejected with maxBundleSize",
+			restriction: fleet.GitRepoRestriction{MaxBundleSize: &maxSize},
+			bundle:      fleet.BundleSpec{ContentsID: "s-123"},
+			expectedErr: "bundle size cannot be checked against maxBundleSize, its resources are stored in an OCI registry",
+		},
+		{
+			name:        "bundle too large",
+			restriction: fleet.GitRepoRestriction{MaxBundleSize: &maxSize},
+			bundle:      fleet.BundleSpec{Resources: []fleet.BundleResource{configMap}},
+			expectedErr: "bundle size 52 exceeds maxBundleSize 10",
+		},
+		{
+			name:        "default target",
+			restriction: fleet.GitRepoRestriction{AllowedClusterGroups: []string{"tenant-a"}},
+			bundle:      fleet.BundleSpec{},
+			expectedErr: `target "default" is not restricted`,
+		},
+		{
+			name:        "target restrictions are checked instead of targets",
+			restriction: fleet.GitRepoRestriction{AllowedClusterGroups: []string{"tenant-a"}},
+			bundle: fleet.BundleSpec{
+				Targets:            []fleet.BundleTarget{{Name: "customization", ClusterName: "other"}},
+				TargetRestrictions: []fleet.BundleTargetRestriction{{Name: "gitrepo", ClusterGroup: "tenant-a"}},
+			},
+		},
+		{
+			name:        "targets of bundles without target restrictions",
+			restriction: fleet.GitRepoRestriction{AllowedClusterGroups: []string{"tenant-a"}},
+			bundle: fleet.BundleSpec{
+				Targets: []fleet.BundleTarget{{Name: "a", ClusterGroup: "tenant-a"}, {Name: "b", ClusterGroup: "tenant-b"}},
+			},
+			expectedErr: `target "b" is not restricted`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := CheckBundle(&c.restriction, mapper, &fleet.Bundle{Spec: c.bundle})
+			if c.expectedErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), c.expectedErr)
+		})
+	}
+}
@@ -26,11 +26,13 @@ import (
 
 type FleetController struct {
 	command.DebugConfig
-	Kubeconfig           string `usage:"Kubeconfig file"`
-	Namespace            string `usage:"namespace to watch" default:"cattle-fleet-system" env:"NAMESPACE"`
-	DisableMetrics       bool   `usage:"disable metrics" name:"disable-metrics"`
-	ShardID              string `usage:"only manage resources labeled with a specific shard ID" name:"shard-id"`
-	EnableLeaderElection bool   `name:"leader-elect" default:"true" usage:"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager."`
+	Kubeconfig           string   `usage:"Kubeconfig file"`
+	Namespace            string   `usage:"namespace to watch" default:"cattle-fleet-system" env:"NAMESPACE"`
+	DisableMetrics       bool     `usage:"disable metrics" name:"disable-metrics"`
+	ShardID              string   `usage:"only manage resources labeled with a specific shard ID" name:"shard-id"`
+	EnableLeaderElection bool     `name:"leader-elect" default:"true" usage:"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager."`
+	WebhookPort          int      `usage:"serve the admission webhook on this port, 0 disables the webhook" name:"webhook-port"`
+	CAPINamespaces       []string `usage:"register the Cluster API clusters in these namespaces as fleet clusters" name:"capi-namespaces"`
 }
 
 type ControllerReconcilerWorkers struct {
@@ -41,6 +43,8 @@ type ControllerReconcilerWorkers struct {
 	ImageScan        int
 	Schedule         int
 	Content          int
+	Push             int
+	PushCluster      int
 }
 
 type BindAddresses struct {
@@ -143,6 +147,22 @@ func (f *FleetController) Run(cmd *cobra.Command, args []string) error {
 		workersOpts.Content = w
 	}
 
+	if d := os.Getenv("PUSH_RECONCILER_WORKERS"); d != "" {
+		w, err := strconv.Atoi(d)
+		if err != nil {
+			setupLog.Error(err, "failed to parse PUSH_RECONCILER_WORKERS", "value", d)
+		}
+		workersOpts.Push = w
+	}
+
+	if d := os.Getenv("PUSH_CLUSTER_WORKERS"); d != "" {
+		w, err := strconv.Atoi(d)
+		if err != nil {
+			setupLog.Error(err, "failed to parse PUSH_CLUSTER_WORKERS", "value", d)
+		}
+		workersOpts.PushCluster = w
+	}
+
 	go func() {
 		log.Println(http.ListenAndServe("localhost:6060", nil)) //nolint:gosec // Debugging only
 	}()
@@ -156,6 +176,8 @@ func (f *FleetController) Run(cmd *cobra.Command, args []string) error {
 		bindAddresses,
 		f.DisableMetrics,
 		f.ShardID,
+		f.WebhookPort,
+		f.CAPINamespaces,
 	); err != nil {
 		return err
 	}
@@ -177,6 +199,7 @@ func App() *cobra.Command {
 		cleanup.App(),
 		agentmanagement.App(),
 		gitops.App(zopts),
+		gitops.FetcherApp(zopts),
 		helmops.App(zopts),
 	)
 	return root
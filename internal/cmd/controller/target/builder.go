@@ -6,21 +6,20 @@ import (
 	"context"
 	"fmt"
 	"maps"
+	"slices"
 	"sort"
 	"strings"
-	"text/template"
 
-	"github.com/Masterminds/sprig/v3"
 	"github.com/go-logr/logr"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 
+	"github.com/rancher/fleet/internal/clustertemplate"
 	"github.com/rancher/fleet/internal/cmd/controller/options"
+	"github.com/rancher/fleet/internal/cmd/controller/restrictions"
 	"github.com/rancher/fleet/internal/cmd/controller/target/matcher"
 	"github.com/rancher/fleet/internal/helmvalues"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 
-	"github.com/rancher/wrangler/v3/pkg/yaml"
-
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -43,6 +42,8 @@ func New(client client.Client, reader client.Reader) *Manager {
 //
 // The returned target structs contain merged BundleDeploymentOptions, which
 // includes the "TargetCustomizations" from fleet.yaml.
+// For BundleTargets with maxClusters, only the chosen clusters are returned
+// and the choice is recorded in the bundle's status.
 // Finally all existing bundledeployments are added to the targets.
 func (m *Manager) Targets(ctx context.Context, bundle *fleet.Bundle, manifestID string) ([]*Target, error) {
 	logger := log.FromContext(ctx).WithName("targets")
@@ -56,7 +57,13 @@ func (m *Manager) Targets(ctx context.Context, bundle *fleet.Bundle, manifestID
 	if err != nil {
 		return nil, err
 	}
+	restriction, err := restrictions.Get(ctx, m.client, bundle.Namespace)
+	if err != nil {
+		return nil, err
+	}
 	var targets []*Target
+	// limited contains the matching targets of BundleTargets with maxClusters
+	limited := map[*fleet.BundleTarget][]*Target{}
 	for _, namespace := range namespaces {
 		clusters := &fleet.ClusterList{}
 		err := m.client.List(ctx, clusters, client.InNamespace(namespace))
@@ -70,13 +77,19 @@ func (m *Manager) Targets(ctx context.Context, bundle *fleet.Bundle, manifestID
 				return nil, err
 			}
 
-			target := bm.Match(cluster.Name, ClusterGroupsToLabelMap(clusterGroups), cluster.Labels)
+			matchGroups := clusterGroups
+			if restriction != nil && len(restriction.AllowedClusterGroups) > 0 {
+				matchGroups = restrictClusterGroups(&cluster, clusterGroups, restriction.AllowedClusterGroups)
+			}
+
+			matchCluster := matcher.NewCluster(&cluster, ClusterGroupsToLabelMap(matchGroups))
+			target := bm.Match(ctx, matchCluster)
 			if target == nil {
 				continue
 			}
 			// check if there is any matching targetCustomization that should be applied
 			targetOpts := target.BundleDeploymentOptions
-			targetCustomized := bm.MatchTargetCustomizations(cluster.Name, ClusterGroupsToLabelMap(clusterGroups), cluster.Labels)
+			targetCustomized := bm.MatchTargetCustomizations(ctx, matchCluster)
 			if targetCustomized != nil {
 				if targetCustomized.DoNotDeploy {
 					logger.V(1).Info("BundleDeployment creation for Bundle was skipped because doNotDeploy is set to true.")
@@ -86,26 +99,49 @@ func (m *Manager) Targets(ctx context.Context, bundle *fleet.Bundle, manifestID
 			}
 
 			opts := options.Merge(bundle.Spec.BundleDeploymentOptions, targetOpts)
-			err = preprocessHelmValues(logger, &opts, &cluster)
+			mergeImageRewrites(&opts, &cluster, clusterGroups)
+			templateContext, err := m.templateContext(ctx, bundle, &cluster, clusterGroups)
+			if err != nil {
+				return nil, err
+			}
+			err = preprocessHelmValues(logger, &opts, &cluster, templateContext)
 			if err != nil {
 				return nil, fmt.Errorf("cluster %s in namespace %s: %w", cluster.Name, cluster.Namespace, err)
 			}
+			setResourceTemplateContext(&opts, bundle, templateContext)
 
 			deploymentID, err := options.DeploymentID(manifestID, opts)
 			if err != nil {
 				return nil, err
 			}
 
-			targets = append(targets, &Target{
+			t := &Target{
 				ClusterGroups: clusterGroups,
 				Cluster:       &cluster,
 				Bundle:        bundle,
 				Options:       opts,
 				DeploymentID:  deploymentID,
-			})
+			}
+			if target.MaxClusters != nil {
+				limited[target] = append(limited[target], t)
+				continue
+			}
+			targets = append(targets, t)
 		}
 	}
 
+	var selections []fleet.TargetSelection
+	for i := range bundle.Spec.Targets {
+		bundleTarget := &bundle.Spec.Targets[i]
+		if bundleTarget.MaxClusters == nil {
+			continue
+		}
+		chosen := chooseClusters(bundle, bundleTarget, limited[bundleTarget])
+		targets = append(targets, chosen...)
+		selections = append(selections, targetSelection(bundleTarget, len(limited[bundleTarget]), chosen))
+	}
+	bundle.Status.TargetSelections = selections
+
 	sort.Slice(targets, func(i, j int) bool {
 		return targets[i].Cluster.Name < targets[j].Cluster.Name
 	})
@@ -190,15 +226,72 @@ func (m *Manager) getNamespacesForBundle(ctx context.Context, bundle *fleet.Bund
 	return nses.List(), nil
 }
 
-func preprocessHelmValues(logger logr.Logger, opts *fleet.BundleDeploymentOptions, cluster *fleet.Cluster) (err error) {
-	clusterLabels := yaml.CleanAnnotationsForExport(cluster.Labels)
-	clusterAnnotations := yaml.CleanAnnotationsForExport(cluster.Annotations)
+// mergeImageRewrites prepends the image rewrite rules of the cluster and its
+// cluster groups to the rules of the bundle. As the first matching rule is
+// applied, cluster rules take precedence over cluster group rules, which take
+// precedence over the bundle's own rules.
+func mergeImageRewrites(opts *fleet.BundleDeploymentOptions, cluster *fleet.Cluster, clusterGroups []*fleet.ClusterGroup) {
+	rewrites := []*fleet.ImageRewrite{cluster.Spec.ImageRewrite}
 
-	for k, v := range cluster.Labels {
-		if strings.HasPrefix(k, "fleet.cattle.io/") || strings.HasPrefix(k, "management.cattle.io/") {
-			clusterLabels[k] = v
+	groups := slices.Clone(clusterGroups)
+	slices.SortFunc(groups, func(a, b *fleet.ClusterGroup) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	for _, cg := range groups {
+		rewrites = append(rewrites, cg.Spec.ImageRewrite)
+	}
+
+	merged := &fleet.ImageRewrite{}
+	for _, r := range append(rewrites, opts.ImageRewrite) {
+		if r == nil {
+			continue
 		}
+		merged.Rules = append(merged.Rules, r.Rules...)
+		merged.Paths = append(merged.Paths, r.Paths...)
+	}
+
+	if len(merged.Rules) == 0 && len(merged.Paths) == 0 {
+		return
+	}
+	opts.ImageRewrite = merged.DeepCopy()
+}
+
+// templateContext returns the template context of the cluster. In addition to the cluster values, it contains the
+// outputs of the bundles the bundle depends on as "BundleOutputs".
+func (m *Manager) templateContext(ctx context.Context, bundle *fleet.Bundle, cluster *fleet.Cluster, clusterGroups []*fleet.ClusterGroup) (map[string]interface{}, error) {
+	templateContext := clustertemplate.Context(cluster, clusterGroups)
+
+	outputs, err := m.bundleOutputs(ctx, bundle, cluster)
+	if err != nil {
+		return nil, err
+	}
+	templateContext["BundleOutputs"] = outputs
+
+	return templateContext, nil
+}
+
+// setResourceTemplateContext passes the template context of the cluster to the agent, if resource templating is
+// enabled. Resources are rendered by the agent, as they are not part of the bundle deployment.
+// Only the keys referenced by the resources are passed, as the context is part of the deployment ID and any change
+// redeploys the bundle.
+func setResourceTemplateContext(opts *fleet.BundleDeploymentOptions, bundle *fleet.Bundle, templateContext map[string]interface{}) {
+	if opts.ResourceTemplating == nil || !opts.ResourceTemplating.Enabled {
+		return
 	}
+	resources := bundle.Spec.Resources
+	if resources == nil {
+		resources = []fleet.BundleResource{}
+	}
+	if bundle.Spec.ContentsID != "" {
+		// the resources are stored in an OCI registry and cannot be inspected
+		resources = nil
+	}
+	opts.ResourceTemplating = opts.ResourceTemplating.DeepCopy()
+	opts.ResourceTemplating.Context = &fleet.GenericMap{Data: clustertemplate.ResourceContext(resources, templateContext)}
+}
+
+func preprocessHelmValues(logger logr.Logger, opts *fleet.BundleDeploymentOptions, cluster *fleet.Cluster, templateContext map[string]interface{}) (err error) {
+	clusterLabels := clustertemplate.Labels(cluster)
 	if len(clusterLabels) == 0 {
 		return nil
 	}
@@ -226,25 +319,12 @@ func preprocessHelmValues(logger logr.Logger, opts *fleet.BundleDeploymentOption
 	}
 
 	if !opts.Helm.DisablePreProcess {
-		templateValues := map[string]interface{}{}
-		if cluster.Spec.TemplateValues != nil {
-			templateValues = cluster.Spec.TemplateValues.Data
-		}
-
-		values := map[string]interface{}{
-			"ClusterNamespace":   cluster.Namespace,
-			"ClusterName":        cluster.Name,
-			"ClusterLabels":      toDict(clusterLabels),
-			"ClusterAnnotations": toDict(clusterAnnotations),
-			"ClusterValues":      templateValues,
-		}
-
-		opts.Helm.Values.Data, err = processTemplateValues(opts.Helm.Values.Data, values)
+		opts.Helm.Values.Data, err = processTemplateValues(opts.Helm.Values.Data, templateContext)
 		if err != nil {
 			return err
 		}
 
-		templatedData, err := processTemplateValuesData(opts.Helm.TemplateValues, values)
+		templatedData, err := processTemplateValuesData(opts.Helm.TemplateValues, templateContext)
 		if err != nil {
 			return err
 		}
@@ -258,15 +338,6 @@ func preprocessHelmValues(logger logr.Logger, opts *fleet.BundleDeploymentOption
 
 }
 
-// sprig dictionary functions like "default" and "hasKey" expect map[string]interface{}
-func toDict(values map[string]string) map[string]interface{} {
-	dict := make(map[string]interface{}, len(values))
-	for k, v := range values {
-		dict[k] = v
-	}
-	return dict
-}
-
 func processLabelValues(logger logr.Logger, valuesMap map[string]interface{}, clusterLabels map[string]string, recursionDepth int) error {
 	if recursionDepth > maxTemplateRecursionDepth {
 		return fmt.Errorf("maximum recursion depth of %v exceeded for cluster label prefix processing, too many nested values", maxTemplateRecursionDepth)
@@ -307,27 +378,11 @@ func processLabelValues(logger logr.Logger, valuesMap map[string]interface{}, cl
 	return nil
 }
 
-// tplFuncMap returns a mapping of all of the functions from sprig but removes potentially dangerous operations
-func tplFuncMap() template.FuncMap {
-	f := sprig.TxtFuncMap()
-	delete(f, "env")
-	delete(f, "expandenv")
-	delete(f, "include")
-	delete(f, "tpl")
-
-	return f
-}
-
 func processTemplateValuesData(helmTemplateData map[string]string, templateContext map[string]interface{}) (map[string]interface{}, error) {
 	renderedValues := make(map[string]interface{}, len(helmTemplateData))
 
 	for k, v := range helmTemplateData {
-		// fleet.yaml must be valid yaml, however '{}[]' are YAML control
-		// characters and will be interpreted as JSON data structures. This
-		// causes issues when parsing the fleet.yaml so we change the delims
-		// for templating to '${ }'
-		tmpl := template.New("values").Funcs(tplFuncMap()).Option("missingkey=error").Delims("${", "}")
-		tmpl, err := tmpl.Parse(v)
+		tmpl, err := clustertemplate.New("values").Parse(v)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse helm values template: %w", err)
 		}
@@ -356,12 +411,7 @@ func processTemplateValues(helmValues map[string]interface{}, templateContext ma
 		return nil, fmt.Errorf("failed to marshal helm values section into a template: %w", err)
 	}
 
-	// fleet.yaml must be valid yaml, however '{}[]' are YAML control
-	// characters and will be interpreted as JSON data structures. This
-	// causes issues when parsing the fleet.yaml so we change the delims
-	// for templating to '${ }'
-	tmpl := template.New("values").Funcs(tplFuncMap()).Option("missingkey=error").Delims("${", "}")
-	tmpl, err = tmpl.Parse(string(data))
+	tmpl, err := clustertemplate.New("values").Parse(string(data))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse helm values template: %w", err)
 	}
@@ -0,0 +1,140 @@
+package target
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// ClusterGroupAncestors returns the ancestors of the cluster group, starting with its parent. groups maps the names
+// of all cluster groups in the namespace to the groups. If a parent is missing or the parent references form a
+// cycle, the ancestors found so far are returned along with an error.
+func ClusterGroupAncestors(group *fleet.ClusterGroup, groups map[string]*fleet.ClusterGroup) ([]*fleet.ClusterGroup, error) {
+	var (
+		result  []*fleet.ClusterGroup
+		path    = []string{group.Name}
+		current = group
+	)
+	for current.Spec.Parent != "" {
+		parent, ok := groups[current.Spec.Parent]
+		if !ok {
+			return result, fmt.Errorf("parent cluster group %q of %q not found", current.Spec.Parent, current.Name)
+		}
+		path = append(path, parent.Name)
+		if parent.Name == group.Name || slices.ContainsFunc(result, func(cg *fleet.ClusterGroup) bool {
+			return cg.Name == parent.Name
+		}) {
+			return result, fmt.Errorf("cycle in cluster group parents: %s", strings.Join(path, " -> "))
+		}
+		result = append(result, parent)
+		current = parent
+	}
+	return result, nil
+}
+
+// ClusterGroupMembers returns the clusters of the cluster group and of all its descendants, sorted by name.
+func ClusterGroupMembers(ctx context.Context, c client.Reader, group *fleet.ClusterGroup) ([]fleet.Cluster, error) {
+	cgs := &fleet.ClusterGroupList{}
+	if err := c.List(ctx, cgs, client.InNamespace(group.Namespace)); err != nil {
+		return nil, err
+	}
+
+	members := map[string]fleet.Cluster{}
+	for _, cg := range append([]*fleet.ClusterGroup{group}, clusterGroupDescendants(group, cgs.Items)...) {
+		if cg.Spec.Selector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(cg.Spec.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector on cluster group %q: %w", cg.Name, err)
+		}
+		clusters := &fleet.ClusterList{}
+		err = c.List(ctx, clusters, client.InNamespace(group.Namespace), client.MatchingLabelsSelector{Selector: selector})
+		if err != nil {
+			return nil, err
+		}
+		for _, cluster := range clusters.Items {
+			members[cluster.Name] = cluster
+		}
+	}
+
+	result := make([]fleet.Cluster, 0, len(members))
+	for _, cluster := range members {
+		result = append(result, cluster)
+	}
+	slices.SortFunc(result, func(a, b fleet.Cluster) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return result, nil
+}
+
+// clusterGroupDescendants returns the children of the cluster group and their descendants. Cycles are ignored.
+func clusterGroupDescendants(group *fleet.ClusterGroup, cgs []fleet.ClusterGroup) []*fleet.ClusterGroup {
+	var (
+		result  []*fleet.ClusterGroup
+		visited = map[string]bool{group.Name: true}
+		queue   = []string{group.Name}
+	)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for i := range cgs {
+			child := &cgs[i]
+			if child.Spec.Parent != name || visited[child.Name] {
+				continue
+			}
+			visited[child.Name] = true
+			result = append(result, child)
+			queue = append(queue, child.Name)
+		}
+	}
+	return result
+}
+
+// restrictClusterGroups returns the cluster groups of the cluster, which are used to match targets in namespaces
+// whose GitRepoRestrictions allow cluster groups. A cluster is only a member of an allowed group via one of its
+// descendants, if the group selecting the cluster is allowed as well. Otherwise, everyone who can create a cluster
+// group could add clusters to an allowed group, by setting it as parent.
+// groups are the groups of the cluster, as returned by ClusterGroupsForCluster.
+func restrictClusterGroups(cluster *fleet.Cluster, groups []*fleet.ClusterGroup, allowed []string) []*fleet.ClusterGroup {
+	byName := make(map[string]*fleet.ClusterGroup, len(groups))
+	for _, cg := range groups {
+		byName[cg.Name] = cg
+	}
+
+	// trusted are the groups, whose membership is not inherited from a group which is not allowed
+	trusted := map[string]bool{}
+	for _, cg := range groups {
+		if cg.Spec.Selector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(cg.Spec.Selector)
+		if err != nil || !selector.Matches(labels.Set(cluster.Labels)) {
+			continue
+		}
+		trusted[cg.Name] = true
+		if !slices.Contains(allowed, cg.Name) {
+			continue
+		}
+		ancestors, _ := ClusterGroupAncestors(cg, byName)
+		for _, ancestor := range ancestors {
+			trusted[ancestor.Name] = true
+		}
+	}
+
+	var result []*fleet.ClusterGroup
+	for _, cg := range groups {
+		if trusted[cg.Name] || !slices.Contains(allowed, cg.Name) {
+			result = append(result, cg)
+		}
+	}
+	return result
+}
@@ -0,0 +1,127 @@
+package target
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+func clusterGroup(name, parent string, matchLabels map[string]string) *v1alpha1.ClusterGroup {
+	cg := &v1alpha1.ClusterGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "fleet-default"},
+		Spec:       v1alpha1.ClusterGroupSpec{Parent: parent},
+	}
+	if matchLabels != nil {
+		cg.Spec.Selector = &metav1.LabelSelector{MatchLabels: matchLabels}
+	}
+	return cg
+}
+
+func clusterGroupNames(cgs []*v1alpha1.ClusterGroup) []string {
+	names := []string{}
+	for _, cg := range cgs {
+		names = append(names, cg.Name)
+	}
+	return names
+}
+
+func TestClusterGroupAncestors(t *testing.T) {
+	groups := map[string]*v1alpha1.ClusterGroup{}
+	for _, cg := range []*v1alpha1.ClusterGroup{
+		clusterGroup("eu", "", nil),
+		clusterGroup("eu-prod", "eu", nil),
+		clusterGroup("eu-prod-web", "eu-prod", nil),
+		clusterGroup("orphan", "missing", nil),
+		clusterGroup("a", "b", nil),
+		clusterGroup("b", "c", nil),
+		clusterGroup("c", "a", nil),
+	} {
+		groups[cg.Name] = cg
+	}
+
+	ancestors, err := ClusterGroupAncestors(groups["eu-prod-web"], groups)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"eu-prod", "eu"}, clusterGroupNames(ancestors))
+
+	ancestors, err = ClusterGroupAncestors(groups["eu"], groups)
+	require.NoError(t, err)
+	assert.Empty(t, ancestors)
+
+	_, err = ClusterGroupAncestors(groups["orphan"], groups)
+	assert.EqualError(t, err, `parent cluster group "missing" of "orphan" not found`)
+
+	ancestors, err = ClusterGroupAncestors(groups["a"], groups)
+	assert.EqualError(t, err, "cycle in cluster group parents: a -> b -> c -> a")
+	assert.Equal(t, []string{"b", "c"}, clusterGroupNames(ancestors))
+}
+
+func TestClusterGroupHierarchy(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
+
+	objs := []client.Object{
+		clusterGroup("eu", "", nil),
+		clusterGroup("eu-prod", "eu", map[string]string{"env": "prod", "region": "eu"}),
+		clusterGroup("eu-dev", "eu", map[string]string{"env": "dev", "region": "eu"}),
+		clusterGroup("all", "", map[string]string{}),
+		clusterGroup("web", "eu-prod", map[string]string{"app": "web"}),
+	}
+	for _, name := range []string{"prod-1", "dev-1", "web-1"} {
+		cluster := &v1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "fleet-default"}}
+		switch name {
+		case "prod-1":
+			cluster.Labels = map[string]string{"env": "prod", "region": "eu"}
+		case "dev-1":
+			cluster.Labels = map[string]string{"env": "dev", "region": "eu"}
+		case "web-1":
+			cluster.Labels = map[string]string{"app": "web"}
+		}
+		objs = append(objs, cluster)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+	ctx := context.Background()
+
+	cgs, err := ClusterGroupsForCluster(ctx, c, &v1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "fleet-default",
+		Labels:    map[string]string{"app": "web"},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"all", "eu", "eu-prod", "web"}, clusterGroupNames(cgs), "ancestors are ordered first")
+
+	members, err := ClusterGroupMembers(ctx, c, clusterGroup("eu", "", nil))
+	require.NoError(t, err)
+	var names []string
+	for _, cluster := range members {
+		names = append(names, cluster.Name)
+	}
+	assert.Equal(t, []string{"dev-1", "prod-1", "web-1"}, names)
+}
+
+func TestRestrictClusterGroups(t *testing.T) {
+	cluster := &v1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "c1", Namespace: "fleet-default", Labels: map[string]string{"env": "prod"}},
+	}
+	groups := []*v1alpha1.ClusterGroup{
+		clusterGroup("prod", "", nil),
+		clusterGroup("staging", "", nil),
+		clusterGroup("other", "", nil),
+		clusterGroup("prod-eu", "prod", map[string]string{"env": "prod"}),
+		clusterGroup("rogue", "staging", map[string]string{"env": "prod"}),
+	}
+
+	result := restrictClusterGroups(cluster, groups, []string{"prod", "prod-eu", "staging"})
+	assert.Equal(t, []string{"prod", "other", "prod-eu", "rogue"}, clusterGroupNames(result))
+
+	// without allowed children, clusters are only members of allowed groups selecting them
+	result = restrictClusterGroups(cluster, groups, []string{"prod", "staging"})
+	assert.Equal(t, []string{"other", "prod-eu", "rogue"}, clusterGroupNames(result))
+}
@@ -1,6 +1,9 @@
 package matcher
 
 import (
+	"context"
+	"fmt"
+
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 )
 
@@ -10,7 +13,7 @@ type BundleMatch struct {
 	matcher *matcher
 }
 
-type findCriteriaMatch func(targetMatch targetMatch, clusterName, clusterGroup string, clusterGroupLabels, clusterLabels map[string]string) bool
+type findCriteriaMatch func(ctx context.Context, targetMatch targetMatch, cluster *Cluster, clusterGroup string, clusterGroupLabels map[string]string) bool
 
 func New(bundle *fleet.Bundle) (*BundleMatch, error) {
 	bm := &BundleMatch{
@@ -33,8 +36,8 @@ func (a *BundleMatch) MatchForTarget(name string) *fleet.BundleTarget {
 // It checks for restrictions, which means that just targets included in the GitRepo can be returned. TargetCustomizations
 // described in the fleet.yaml will be ignored.
 // All GitRepo targets are added as TargetRestrictions, which acts as a whitelist.
-func (a *BundleMatch) Match(clusterName string, clusterGroups map[string]map[string]string, clusterLabels map[string]string) *fleet.BundleTarget {
-	if m := a.matcher.match(clusterName, clusterLabels, clusterGroups, a.matcher.criteriaWithRestrictions); m != nil {
+func (a *BundleMatch) Match(ctx context.Context, cluster *Cluster) *fleet.BundleTarget {
+	if m := a.matcher.match(ctx, cluster, a.matcher.criteriaWithRestrictions); m != nil {
 		return m
 	}
 
@@ -43,8 +46,8 @@ func (a *BundleMatch) Match(clusterName string, clusterGroups map[string]map[str
 
 // MatchTargetCustomizations returns the first BundleTarget that matches the target criteria. Targets are evaluated in order.
 // It doesn't check for restrictions, which means TargetCustomizations described in the fleet.yaml are considered.
-func (a *BundleMatch) MatchTargetCustomizations(clusterName string, clusterGroups map[string]map[string]string, clusterLabels map[string]string) *fleet.BundleTarget {
-	if m := a.matcher.match(clusterName, clusterLabels, clusterGroups, criteriaWithoutRestrictions); m != nil {
+func (a *BundleMatch) MatchTargetCustomizations(ctx context.Context, cluster *Cluster) *fleet.BundleTarget {
+	if m := a.matcher.match(ctx, cluster, criteriaWithoutRestrictions); m != nil {
 		return m
 	}
 
@@ -53,21 +56,21 @@ func (a *BundleMatch) MatchTargetCustomizations(clusterName string, clusterGroup
 
 type targetMatch struct {
 	bundleTarget *fleet.BundleTarget
-	criteria     *ClusterMatcher
+	criteria     *TargetMatcher
 }
 
 type matcher struct {
 	matches      []targetMatch
-	restrictions []*ClusterMatcher
+	restrictions []*TargetMatcher
 }
 
 func (a *BundleMatch) initMatcher() error {
 	m := &matcher{}
 
 	for i, target := range a.bundle.Spec.Targets {
-		clusterMatcher, err := NewClusterMatcher(target.ClusterName, target.ClusterGroup, target.ClusterGroupSelector, target.ClusterSelector)
+		clusterMatcher, err := NewTargetMatcher(target.ClusterName, target.ClusterGroup, target.ClusterGroupSelector, target.ClusterSelector, target.ClusterFactSelector, target.ClusterExpression)
 		if err != nil {
-			return err
+			return fmt.Errorf("target %q: %w", target.Name, err)
 		}
 		t := targetMatch{
 			bundleTarget: &a.bundle.Spec.Targets[i],
@@ -78,9 +81,9 @@ func (a *BundleMatch) initMatcher() error {
 	}
 
 	for _, target := range a.bundle.Spec.TargetRestrictions {
-		clusterMatcher, err := NewClusterMatcher(target.ClusterName, target.ClusterGroup, target.ClusterGroupSelector, target.ClusterSelector)
+		clusterMatcher, err := NewTargetMatcher(target.ClusterName, target.ClusterGroup, target.ClusterGroupSelector, target.ClusterSelector, target.ClusterFactSelector, target.ClusterExpression)
 		if err != nil {
-			return err
+			return fmt.Errorf("target restriction %q: %w", target.Name, err)
 		}
 		m.restrictions = append(m.restrictions, clusterMatcher)
 	}
@@ -89,14 +92,14 @@ func (a *BundleMatch) initMatcher() error {
 	return nil
 }
 
-func (m *matcher) isRestricted(clusterName, clusterGroup string, clusterGroupLabels, clusterLabels map[string]string) bool {
+func (m *matcher) isRestricted(ctx context.Context, cluster *Cluster, clusterGroup string, clusterGroupLabels map[string]string) bool {
 	// There are no restrictions. That means this Bundle was not created by a GitRepo, and there are no targetCustomizations
 	if len(m.restrictions) == 0 {
 		return false
 	}
 
 	for _, restriction := range m.restrictions {
-		if restriction.Match(clusterName, clusterGroup, clusterGroupLabels, clusterLabels) {
+		if restriction.Match(ctx, cluster, clusterGroup, clusterGroupLabels) {
 			return false
 		}
 	}
@@ -107,30 +110,30 @@ func (m *matcher) isRestricted(clusterName, clusterGroup string, clusterGroupLab
 
 // checks if criteria is matched just if the target is inside the targetRestrictions. This is used for Targets defined
 // in the GitRepo, since these targets are also added as targetRestrictions.
-func (m *matcher) criteriaWithRestrictions(targetMatch targetMatch, clusterName, clusterGroup string, clusterGroupLabels, clusterLabels map[string]string) bool {
-	if !m.isRestricted(clusterName, clusterGroup, clusterGroupLabels, clusterLabels) &&
-		targetMatch.criteria.Match(clusterName, clusterGroup, clusterGroupLabels, clusterLabels) {
+func (m *matcher) criteriaWithRestrictions(ctx context.Context, targetMatch targetMatch, cluster *Cluster, clusterGroup string, clusterGroupLabels map[string]string) bool {
+	if !m.isRestricted(ctx, cluster, clusterGroup, clusterGroupLabels) &&
+		targetMatch.criteria.Match(ctx, cluster, clusterGroup, clusterGroupLabels) {
 		return true
 	}
 
 	return false
 }
 
-// Checks targetMatch's criteria for a match on the specified cluster name, group and labels, without checking if target is inside the targetRestrictions. This is used for TargetCustomizations.
-func criteriaWithoutRestrictions(targetMatch targetMatch, clusterName, clusterGroup string, clusterGroupLabels, clusterLabels map[string]string) bool {
-	return targetMatch.criteria.Match(clusterName, clusterGroup, clusterGroupLabels, clusterLabels)
+// Checks targetMatch's criteria for a match on the specified cluster and group, without checking if target is inside the targetRestrictions. This is used for TargetCustomizations.
+func criteriaWithoutRestrictions(ctx context.Context, targetMatch targetMatch, cluster *Cluster, clusterGroup string, clusterGroupLabels map[string]string) bool {
+	return targetMatch.criteria.Match(ctx, cluster, clusterGroup, clusterGroupLabels)
 }
 
-// match returns the first BundleTarget, from the matcher's target matches, which matches the specified cluster name, groups and labels, using matching logic implemented via findCriteriaMatch.
-func (m *matcher) match(clusterName string, clusterLabels map[string]string, clusterGroups map[string]map[string]string, findCriteriaMatch findCriteriaMatch) *fleet.BundleTarget {
+// match returns the first BundleTarget, from the matcher's target matches, which matches the specified cluster, using matching logic implemented via findCriteriaMatch.
+func (m *matcher) match(ctx context.Context, cluster *Cluster, findCriteriaMatch findCriteriaMatch) *fleet.BundleTarget {
 	for _, targetMatch := range m.matches {
-		if len(clusterGroups) == 0 {
-			if findCriteriaMatch(targetMatch, clusterName, "", nil, clusterLabels) {
+		if len(cluster.Groups) == 0 {
+			if findCriteriaMatch(ctx, targetMatch, cluster, "", nil) {
 				return targetMatch.bundleTarget
 			}
 		} else {
-			for clusterGroup, clusterGroupLabels := range clusterGroups {
-				if findCriteriaMatch(targetMatch, clusterName, clusterGroup, clusterGroupLabels, clusterLabels) {
+			for clusterGroup, clusterGroupLabels := range cluster.Groups {
+				if findCriteriaMatch(ctx, targetMatch, cluster, clusterGroup, clusterGroupLabels) {
 					return targetMatch.bundleTarget
 				}
 			}
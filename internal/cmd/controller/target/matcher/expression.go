@@ -0,0 +1,122 @@
+package matcher
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+// Cluster is a cluster, as seen by target matchers.
+type Cluster struct {
+	Name        string
+	Namespace   string
+	Labels      map[string]string
+	Annotations map[string]string
+	// Groups maps the names of the cluster groups the cluster is a member of to their labels.
+	Groups map[string]map[string]string
+	// Facts are the facts reported by the agent, converted by FactLabels.
+	Facts map[string]string
+	// Values are the template values of the cluster.
+	Values map[string]interface{}
+
+	// activation holds the variables of cluster expressions, it is built on first use.
+	activation map[string]any
+}
+
+// NewCluster returns the cluster to match, groups maps the names of its cluster groups to their labels.
+func NewCluster(cluster *fleet.Cluster, groups map[string]map[string]string) *Cluster {
+	c := &Cluster{
+		Name:        cluster.Name,
+		Namespace:   cluster.Namespace,
+		Labels:      cluster.Labels,
+		Annotations: cluster.Annotations,
+		Groups:      groups,
+		Facts:       FactLabels(cluster.Status.Facts),
+	}
+	if cluster.Spec.TemplateValues != nil {
+		c.Values = cluster.Spec.TemplateValues.Data
+	}
+	return c
+}
+
+// variables returns the variables of cluster expressions. The cluster is available as the "cluster" variable, with
+// the keys "name", "namespace", "labels", "annotations", "groups" (a sorted list of names) and "values".
+func (c *Cluster) variables() map[string]any {
+	if c.activation != nil {
+		return c.activation
+	}
+	c.activation = map[string]any{
+		"cluster": map[string]any{
+			"name":        c.Name,
+			"namespace":   c.Namespace,
+			"labels":      orEmpty(c.Labels),
+			"annotations": orEmpty(c.Annotations),
+			"groups":      slices.Sorted(maps.Keys(c.Groups)),
+			"values":      orEmpty(c.Values),
+		},
+	}
+	return c.activation
+}
+
+func orEmpty[V any](m map[string]V) map[string]V {
+	if m == nil {
+		return map[string]V{}
+	}
+	return m
+}
+
+const (
+	// costLimit bounds the cost of evaluating a cluster expression for a single cluster. Expressions are provided by
+	// users and evaluated for each cluster whenever targets are computed.
+	costLimit = 1_000_000
+	// interruptCheckFrequency is the number of comprehension iterations after which the evaluation checks whether its
+	// context is cancelled.
+	interruptCheckFrequency = 100
+)
+
+var celEnv = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(cel.Variable("cluster", cel.MapType(cel.StringType, cel.DynType)))
+})
+
+// ClusterExpression is a compiled CEL expression, which selects clusters.
+type ClusterExpression struct {
+	expression string
+	program    cel.Program
+}
+
+// NewClusterExpression compiles the expression, which must evaluate to a boolean.
+func NewClusterExpression(expression string) (*ClusterExpression, error) {
+	env, err := celEnv()
+	if err != nil {
+		return nil, err
+	}
+	ast, iss := env.Compile(expression)
+	if iss.Err() != nil {
+		return nil, fmt.Errorf("invalid cluster expression %q: %w", expression, iss.Err())
+	}
+	if t := ast.OutputType(); t != cel.BoolType && t != cel.DynType {
+		return nil, fmt.Errorf("invalid cluster expression %q: must evaluate to bool, not %s", expression, t)
+	}
+	program, err := env.Program(ast, cel.CostLimit(costLimit), cel.InterruptCheckFrequency(interruptCheckFrequency))
+	if err != nil {
+		return nil, fmt.Errorf("invalid cluster expression %q: %w", expression, err)
+	}
+	return &ClusterExpression{expression: expression, program: program}, nil
+}
+
+// Matches returns true if the expression evaluates to true for the cluster. Evaluation errors, like accessing a
+// missing label, exceeding the cost limit or a cancelled context, do not match.
+func (e *ClusterExpression) Matches(ctx context.Context, cluster *Cluster) bool {
+	out, _, err := e.program.ContextEval(ctx, cluster.variables())
+	if err != nil {
+		return false
+	}
+	match, ok := out.Value().(bool)
+	return ok && match
+}
@@ -0,0 +1,119 @@
+package matcher
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+func TestClusterExpression(t *testing.T) {
+	cluster := NewCluster(&fleet.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "prod-eu",
+			Namespace:   "fleet-default",
+			Labels:      map[string]string{"region": "eu-west", "tier": "prod"},
+			Annotations: map[string]string{"example.com/pci": "true"},
+		},
+		Spec: fleet.ClusterSpec{
+			TemplateValues: &fleet.GenericMap{Data: map[string]interface{}{"replicas": 3}},
+		},
+	}, map[string]map[string]string{"europe": {}, "all": {}})
+
+	tests := []struct {
+		expression string
+		expected   bool
+	}{
+		{`cluster.labels.region in ["eu-west", "eu-central"] && cluster.labels.tier != "dev"`, true},
+		{`cluster.labels.region == "us-east" || "example.com/pci" in cluster.annotations`, true},
+		{`cluster.name.startsWith("prod-") && cluster.namespace == "fleet-default"`, true},
+		{`"europe" in cluster.groups && cluster.groups.size() == 2`, true},
+		{`cluster.values.replicas > 2`, true},
+		{`has(cluster.labels.zone)`, false},
+		// missing keys fail evaluation and do not match
+		{`cluster.labels.zone == "a"`, false},
+		{`cluster.labels.tier == "dev"`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			e, err := NewClusterExpression(tt.expression)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, e.Matches(context.Background(), cluster))
+		})
+	}
+}
+
+func TestClusterExpressionLimits(t *testing.T) {
+	cluster := &Cluster{Name: "cluster"}
+	list := "[" + strings.Repeat("0,", 99) + "0]"
+
+	// 100^4 iterations exceed the cost limit
+	e, err := NewClusterExpression(strings.Repeat(list+".all(a, ", 4) + "true" + strings.Repeat(")", 4))
+	require.NoError(t, err)
+	assert.False(t, e.Matches(context.Background(), cluster), "expressions exceeding the cost limit do not match")
+
+	e, err = NewClusterExpression(list + `.all(a, cluster.name == "cluster")`)
+	require.NoError(t, err)
+	assert.True(t, e.Matches(context.Background(), cluster))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.False(t, e.Matches(ctx, cluster), "evaluation stops when the context is cancelled")
+}
+
+func TestClusterExpressionErrors(t *testing.T) {
+	for _, expression := range []string{
+		`cluster.labels.region ==`,
+		`unknown.name == "a"`,
+		`cluster.name.size()`,
+	} {
+		_, err := NewClusterExpression(expression)
+		assert.Error(t, err, expression)
+	}
+
+	_, err := New(&fleet.Bundle{Spec: fleet.BundleSpec{
+		Targets: []fleet.BundleTarget{{Name: "invalid", ClusterExpression: `cluster.name ==`}},
+	}})
+	assert.ErrorContains(t, err, `target "invalid": invalid cluster expression`)
+}
+
+func TestBundleMatchClusterExpression(t *testing.T) {
+	bundle := &fleet.Bundle{
+		Spec: fleet.BundleSpec{
+			Targets: []fleet.BundleTarget{
+				{Name: "eu", ClusterExpression: `cluster.labels.region.startsWith("eu-")`},
+				{Name: "group", ClusterGroup: "europe", ClusterExpression: `cluster.labels.tier == "prod"`},
+			},
+			TargetRestrictions: []fleet.BundleTargetRestriction{
+				{Name: "group", ClusterGroup: "europe", ClusterExpression: `cluster.labels.tier == "prod"`},
+			},
+		},
+	}
+	bm, err := New(bundle)
+	require.NoError(t, err)
+
+	prod := &Cluster{
+		Name:   "prod",
+		Labels: map[string]string{"region": "eu-west", "tier": "prod"},
+		Groups: map[string]map[string]string{"europe": nil},
+	}
+	target := bm.Match(context.Background(), prod)
+	require.NotNil(t, target)
+	assert.Equal(t, "eu", target.Name)
+
+	dev := &Cluster{
+		Name:   "dev",
+		Labels: map[string]string{"region": "eu-west", "tier": "dev"},
+		Groups: map[string]map[string]string{"europe": nil},
+	}
+	assert.Nil(t, bm.Match(context.Background(), dev), "restrictions exclude the cluster")
+
+	target = bm.MatchTargetCustomizations(context.Background(), dev)
+	require.NotNil(t, target)
+	assert.Equal(t, "eu", target.Name)
+}
@@ -0,0 +1,44 @@
+package matcher
+
+import (
+	"strconv"
+	"strings"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+// FactLabels converts the facts of a cluster into labels, which are matched by the clusterFactSelector of targets.
+// It returns nil if the agent did not report facts.
+func FactLabels(facts *fleet.ClusterFacts) map[string]string {
+	if facts == nil {
+		return nil
+	}
+
+	result := map[string]string{}
+	if facts.KubernetesVersion != "" {
+		// build metadata, like "+k3s1", is not a valid label value
+		version, _, _ := strings.Cut(facts.KubernetesVersion, "+")
+		result["kubernetes-version"] = version
+		if major, rest, ok := strings.Cut(strings.TrimPrefix(version, "v"), "."); ok {
+			minor, _, _ := strings.Cut(rest, ".")
+			result["kubernetes-minor-version"] = major + "." + minor
+		}
+	}
+	result["node-count"] = strconv.Itoa(facts.NodeCount)
+	if facts.DefaultStorageClass != "" {
+		result["default-storageclass"] = facts.DefaultStorageClass
+	}
+
+	for prefix, values := range map[string][]string{
+		"os":           facts.OperatingSystems,
+		"arch":         facts.Architectures,
+		"provider":     facts.ProviderIDPrefixes,
+		"apigroup":     facts.APIGroups,
+		"storageclass": facts.StorageClasses,
+	} {
+		for _, v := range values {
+			result[prefix+"/"+v] = "true"
+		}
+	}
+	return result
+}
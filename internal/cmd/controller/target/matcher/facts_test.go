@@ -0,0 +1,88 @@
+package matcher
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+func TestFactLabels(t *testing.T) {
+	assert.Nil(t, FactLabels(nil))
+
+	labels := FactLabels(&fleet.ClusterFacts{
+		KubernetesVersion:   "v1.31.2+k3s1",
+		NodeCount:           3,
+		OperatingSystems:    []string{"linux"},
+		Architectures:       []string{"arm64"},
+		ProviderIDPrefixes:  []string{"aws"},
+		APIGroups:           []string{"cert-manager.io"},
+		StorageClasses:      []string{"gp3"},
+		DefaultStorageClass: "gp3",
+	})
+	assert.Equal(t, map[string]string{
+		"kubernetes-version":       "v1.31.2",
+		"kubernetes-minor-version": "1.31",
+		"node-count":               "3",
+		"default-storageclass":     "gp3",
+		"os/linux":                 "true",
+		"arch/arm64":               "true",
+		"provider/aws":             "true",
+		"apigroup/cert-manager.io": "true",
+		"storageclass/gp3":         "true",
+	}, labels)
+}
+
+func TestBundleMatchClusterFacts(t *testing.T) {
+	certManager := &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "apigroup/cert-manager.io", Operator: metav1.LabelSelectorOpExists},
+		},
+	}
+	bundle := &fleet.Bundle{
+		Spec: fleet.BundleSpec{
+			Targets: []fleet.BundleTarget{
+				{Name: "cert-manager", ClusterFactSelector: certManager},
+				{
+					Name:                "arm",
+					ClusterSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}},
+					ClusterFactSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"arch/arm64": "true"}},
+				},
+			},
+		},
+	}
+	bm, err := New(bundle)
+	require.NoError(t, err)
+
+	withCertManager := FactLabels(&fleet.ClusterFacts{APIGroups: []string{"cert-manager.io"}})
+	arm := FactLabels(&fleet.ClusterFacts{Architectures: []string{"arm64"}})
+	prod := map[string]string{"env": "prod"}
+
+	tests := []struct {
+		name     string
+		labels   map[string]string
+		facts    map[string]string
+		expected string
+	}{
+		{name: "fact selector alone matches", facts: withCertManager, expected: "cert-manager"},
+		{name: "facts and labels must both match", labels: prod, facts: arm, expected: "arm"},
+		{name: "labels alone do not match", labels: prod, facts: withCertManager, expected: "cert-manager"},
+		{name: "facts alone do not match", facts: arm},
+		{name: "cluster without facts does not match", labels: prod},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := bm.Match(context.Background(), &Cluster{Name: "cluster", Labels: tt.labels, Facts: tt.facts})
+			if tt.expected == "" {
+				assert.Nil(t, target)
+				return
+			}
+			require.NotNil(t, target)
+			assert.Equal(t, tt.expected, target.Name)
+		})
+	}
+}
@@ -1,13 +1,15 @@
 package matcher
 
 import (
+	"context"
+
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 )
 
 // ScheduleMatch stores the schedule and the matcher for the schedule
 type ScheduleMatch struct {
 	schedule        *fleet.Schedule
-	clusterMatchers []ClusterMatcher
+	clusterMatchers []*TargetMatcher
 }
 
 // NewScheduleMatch returns a new ScheduleMatch initialized
@@ -19,20 +21,11 @@ func NewScheduleMatch(schedule *fleet.Schedule) (*ScheduleMatch, error) {
 	return bm, bm.initMatcher()
 }
 
-// MatchCluster returns true if the given cluster name, cluster groups or cluster labels
-// match any of the schedule matchers.
-func (m *ScheduleMatch) MatchCluster(clusterName string, clusterGroups map[string]map[string]string, clusterLabels map[string]string) bool {
+// MatchCluster returns true if the given cluster matches any of the schedule matchers.
+func (m *ScheduleMatch) MatchCluster(ctx context.Context, cluster *Cluster) bool {
 	for _, m := range m.clusterMatchers {
-		if len(clusterGroups) == 0 {
-			if m.Match(clusterName, "", nil, clusterLabels) {
-				return true
-			}
-		} else {
-			for clusterGroup, clusterGroupLabels := range clusterGroups {
-				if m.Match(clusterName, clusterGroup, clusterGroupLabels, clusterLabels) {
-					return true
-				}
-			}
+		if m.MatchAny(ctx, cluster) {
+			return true
 		}
 	}
 
@@ -41,16 +34,18 @@ func (m *ScheduleMatch) MatchCluster(clusterName string, clusterGroups map[strin
 
 func (m *ScheduleMatch) initMatcher() error {
 	for _, target := range m.schedule.Spec.Targets.Clusters {
-		clusterMatcher, err := NewClusterMatcher(
+		clusterMatcher, err := NewTargetMatcher(
 			target.ClusterName,
 			target.ClusterGroup,
 			target.ClusterGroupSelector,
 			target.ClusterSelector,
+			nil,
+			target.ClusterExpression,
 		)
 		if err != nil {
 			return err
 		}
-		m.clusterMatchers = append(m.clusterMatchers, *clusterMatcher)
+		m.clusterMatchers = append(m.clusterMatchers, clusterMatcher)
 	}
 
 	return nil
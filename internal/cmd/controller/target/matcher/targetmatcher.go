@@ -0,0 +1,63 @@
+package matcher
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// TargetMatcher extends a ClusterMatcher with a selector for cluster facts and a cluster expression.
+type TargetMatcher struct {
+	cluster    *ClusterMatcher
+	facts      labels.Selector
+	expression *ClusterExpression
+}
+
+func NewTargetMatcher(clusterName, clusterGroup string, clusterGroupSelector, clusterSelector, clusterFactSelector *metav1.LabelSelector, clusterExpression string) (*TargetMatcher, error) {
+	clusterMatcher, err := NewClusterMatcher(clusterName, clusterGroup, clusterGroupSelector, clusterSelector)
+	if err != nil {
+		return nil, err
+	}
+	m := &TargetMatcher{cluster: clusterMatcher}
+	if clusterFactSelector != nil {
+		if m.facts, err = toSelector(clusterFactSelector); err != nil {
+			return nil, err
+		}
+	}
+	if clusterExpression != "" {
+		if m.expression, err = NewClusterExpression(clusterExpression); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Match returns true if the cluster, as a member of the cluster group, matches all criteria. A fact selector or a
+// cluster expression alone is sufficient to match clusters, clusters without facts never match a fact selector.
+func (m *TargetMatcher) Match(ctx context.Context, cluster *Cluster, clusterGroup string, clusterGroupLabels map[string]string) bool {
+	if m.facts == nil && m.expression == nil {
+		return m.cluster.Match(cluster.Name, clusterGroup, clusterGroupLabels, cluster.Labels)
+	}
+	if m.facts != nil && (cluster.Facts == nil || !m.facts.Matches(labels.Set(cluster.Facts))) {
+		return false
+	}
+	if m.expression != nil && !m.expression.Matches(ctx, cluster) {
+		return false
+	}
+	return len(m.cluster.criteria) == 0 || m.cluster.Match(cluster.Name, clusterGroup, clusterGroupLabels, cluster.Labels)
+}
+
+// MatchAny returns true if the cluster matches as a member of any of its cluster groups, or without a group if it is
+// not a member of any.
+func (m *TargetMatcher) MatchAny(ctx context.Context, cluster *Cluster) bool {
+	if len(cluster.Groups) == 0 {
+		return m.Match(ctx, cluster, "", nil)
+	}
+	for clusterGroup, clusterGroupLabels := range cluster.Groups {
+		if m.Match(ctx, cluster, clusterGroup, clusterGroupLabels) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package target
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// bundleOutputs returns the outputs reported for the bundle deployments on the cluster, which the bundle depends on.
+// The outputs are keyed by bundle name and output name.
+func (m *Manager) bundleOutputs(ctx context.Context, bundle *fleet.Bundle, cluster *fleet.Cluster) (map[string]interface{}, error) {
+	result := map[string]interface{}{}
+	if cluster.Status.Namespace == "" {
+		return result, nil
+	}
+
+	for _, depend := range bundle.Spec.DependsOn {
+		selector, err := DependencySelector(depend, bundle.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		if selector == nil {
+			continue
+		}
+
+		bds := &fleet.BundleDeploymentList{}
+		err = m.client.List(ctx, bds, client.InNamespace(cluster.Status.Namespace), client.MatchingLabelsSelector{Selector: selector})
+		if err != nil {
+			return nil, err
+		}
+		for _, bd := range bds.Items {
+			_, name := BundleFromDeployment(bd.Labels)
+			if name == "" || len(bd.Status.Outputs) == 0 {
+				continue
+			}
+			outputs := make(map[string]interface{}, len(bd.Status.Outputs))
+			for k, v := range bd.Status.Outputs {
+				outputs[k] = v
+			}
+			result[name] = outputs
+		}
+	}
+
+	return result, nil
+}
+
+// DependencySelector returns the selector for the bundle deployments of a dependency, like the agent does when
+// checking dependencies. It returns nil for empty references.
+func DependencySelector(depend fleet.BundleRef, bundleNamespace string) (labels.Selector, error) {
+	if depend.Name == "" && depend.Selector == nil {
+		return nil, nil
+	}
+
+	ls := &metav1.LabelSelector{}
+	if depend.Selector != nil {
+		// the bundle is read from the cache, AddLabelToSelector must not modify its selector
+		ls = depend.Selector.DeepCopy()
+	}
+	// depend.Name is just a shortcut for matchLabels: {bundle-name: name}
+	if depend.Name != "" {
+		ls = metav1.AddLabelToSelector(ls, fleet.BundleLabel, depend.Name)
+		ls = metav1.AddLabelToSelector(ls, fleet.BundleNamespaceLabel, bundleNamespace)
+	}
+	return metav1.LabelSelectorAsSelector(ls)
+}
@@ -0,0 +1,85 @@
+package target
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+func bundleDeployment(name, bundle string, outputs map[string]string) *v1alpha1.BundleDeployment {
+	return &v1alpha1.BundleDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "cluster-fleet-default-prod",
+			Labels: map[string]string{
+				v1alpha1.BundleLabel:          bundle,
+				v1alpha1.BundleNamespaceLabel: "fleet-default",
+				"tier":                        "infra",
+			},
+		},
+		Status: v1alpha1.BundleDeploymentStatus{Outputs: outputs},
+	}
+}
+
+func TestTemplateContextBundleOutputs(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		bundleDeployment("db", "db", map[string]string{"host": "db.example.com"}),
+		bundleDeployment("lb", "lb", map[string]string{"hostname": "lb.example.com"}),
+		bundleDeployment("dns", "dns", nil),
+		bundleDeployment("unrelated", "unrelated", map[string]string{"x": "y"}),
+	).Build()
+	m := New(c, c)
+
+	bundle := &v1alpha1.Bundle{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "fleet-default"},
+		Spec: v1alpha1.BundleSpec{
+			DependsOn: []v1alpha1.BundleRef{
+				{Name: "db"},
+				{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{v1alpha1.BundleLabel: "lb"}}},
+				{Name: "dns"},
+				{},
+			},
+		},
+	}
+	cluster := &v1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "prod", Namespace: "fleet-default"},
+		Status:     v1alpha1.ClusterStatus{Namespace: "cluster-fleet-default-prod"},
+	}
+
+	templateContext, err := m.templateContext(context.Background(), bundle, cluster, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"db": map[string]interface{}{"host": "db.example.com"},
+		"lb": map[string]interface{}{"hostname": "lb.example.com"},
+	}, templateContext["BundleOutputs"])
+	assert.Equal(t, "prod", templateContext["ClusterName"])
+
+	// outputs are not available before the cluster is registered
+	cluster.Status.Namespace = ""
+	templateContext, err = m.templateContext(context.Background(), bundle, cluster, nil)
+	require.NoError(t, err)
+	assert.Empty(t, templateContext["BundleOutputs"])
+}
+
+func TestDependencySelectorDoesNotModifyBundleRef(t *testing.T) {
+	depend := v1alpha1.BundleRef{
+		Name:     "db",
+		Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "infra"}},
+	}
+
+	selector, err := DependencySelector(depend, "fleet-default")
+	require.NoError(t, err)
+	assert.Equal(t, "fleet.cattle.io/bundle-name=db,fleet.cattle.io/bundle-namespace=fleet-default,tier=infra", selector.String())
+	assert.Equal(t, map[string]string{"tier": "infra"}, depend.Selector.MatchLabels)
+}
@@ -1,6 +1,8 @@
 package target
 
 import (
+	"context"
+
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -14,8 +16,8 @@ type partition struct {
 // UpdatePartitions recomputes status, including partitions, from data in allTargets.
 // It creates Deployments in allTargets if they are missing.
 // It updates Deployments in allTargets if they are out of sync (DeploymentID != StagedDeploymentID).
-func UpdatePartitions(status *fleet.BundleStatus, allTargets []*Target) (err error) {
-	partitions, err := partitions(allTargets)
+func UpdatePartitions(ctx context.Context, status *fleet.BundleStatus, allTargets []*Target) (err error) {
+	partitions, err := partitions(ctx, allTargets)
 	if err != nil {
 		return err
 	}
@@ -1,7 +1,10 @@
 package target
 
 import (
+	"cmp"
 	"context"
+	"slices"
+	"strings"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -32,7 +35,7 @@ func (m *Manager) BundlesForCluster(ctx context.Context, cluster *fleet.Cluster)
 			return nil, nil, err
 		}
 
-		match := bm.Match(cluster.Name, ClusterGroupsToLabelMap(cgs), cluster.Labels)
+		match := bm.Match(ctx, matcher.NewCluster(cluster, ClusterGroupsToLabelMap(cgs)))
 		if match != nil {
 			bundlesToRefresh = append(bundlesToRefresh, bundle)
 		} else {
@@ -90,7 +93,8 @@ func (m *Manager) clusterGroupsForCluster(ctx context.Context, cluster *fleet.Cl
 	return ClusterGroupsForCluster(ctx, m.client, cluster)
 }
 
-// ClusterGroupsForCluster returns all cluster groups that match the given cluster.
+// ClusterGroupsForCluster returns all cluster groups that match the given cluster, including the ancestors of
+// matching groups. Groups are ordered by their depth in the hierarchy, starting with the root groups, and by name.
 func ClusterGroupsForCluster(ctx context.Context, c client.Client, cluster *fleet.Cluster) (result []*fleet.ClusterGroup, _ error) {
 	cgs := &fleet.ClusterGroupList{}
 	err := c.List(ctx, cgs, client.InNamespace(cluster.Namespace))
@@ -98,8 +102,14 @@ func ClusterGroupsForCluster(ctx context.Context, c client.Client, cluster *flee
 		return nil, err
 	}
 
+	groups := make(map[string]*fleet.ClusterGroup, len(cgs.Items))
+	for i := range cgs.Items {
+		groups[cgs.Items[i].Name] = &cgs.Items[i]
+	}
+
 	logger := log.FromContext(ctx).WithName("target")
-	for _, cg := range cgs.Items {
+	depth := map[string]int{}
+	for _, cg := range groups {
 		if cg.Spec.Selector == nil {
 			continue
 		}
@@ -109,10 +119,26 @@ func ClusterGroupsForCluster(ctx context.Context, c client.Client, cluster *flee
 				"selector", cg.Spec.Selector)
 			continue
 		}
-		if sel.Matches(labels.Set(cluster.Labels)) {
-			result = append(result, &cg)
+		if !sel.Matches(labels.Set(cluster.Labels)) {
+			continue
 		}
+
+		ancestors, err := ClusterGroupAncestors(cg, groups)
+		if err != nil {
+			logger.Error(err, "invalid parent of clusterGroup", "namespace", cg.Namespace, "name", cg.Name)
+		}
+		depth[cg.Name] = len(ancestors)
+		for i, ancestor := range ancestors {
+			depth[ancestor.Name] = len(ancestors) - i - 1
+		}
+	}
+
+	for name := range depth {
+		result = append(result, groups[name])
 	}
+	slices.SortFunc(result, func(a, b *fleet.ClusterGroup) int {
+		return cmp.Or(cmp.Compare(depth[a.Name], depth[b.Name]), strings.Compare(a.Name, b.Name))
+	})
 
 	return result, nil
 }
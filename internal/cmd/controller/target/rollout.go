@@ -1,21 +1,36 @@
 package target
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/rancher/fleet/internal/cmd/controller/target/matcher"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/lru"
 )
 
+// partitionMatchersCacheSize is the number of bundle generations, whose compiled partition matchers are cached.
+const partitionMatchersCacheSize = 1024
+
+// partitionMatchers caches the compiled matchers of the rollout partitions by bundle UID and generation, so cluster
+// expressions are not compiled again on every reconcile.
+var partitionMatchers = lru.New(partitionMatchersCacheSize)
+
+type partitionMatchersKey struct {
+	uid        types.UID
+	generation int64
+}
+
 // partitions distributes targets into partitions based on the rollout strategy (pure function)
-func partitions(targets []*Target) ([]partition, error) {
+func partitions(ctx context.Context, targets []*Target) ([]partition, error) {
 	rollout := getRollout(targets)
 	if len(rollout.Partitions) == 0 {
 		return autoPartition(rollout, targets)
 	}
 
-	return manualPartition(rollout, targets)
+	return manualPartition(ctx, rollout, targets)
 }
 
 // getRollout returns the rollout strategy for the specified targets (pure
@@ -37,29 +52,27 @@ func getRollout(targets []*Target) *fleet.RolloutStrategy {
 }
 
 // manualPartition computes a slice of Partition given some targets and rollout strategy that already has partitions (pure function)
-func manualPartition(rollout *fleet.RolloutStrategy, targets []*Target) ([]partition, error) {
+func manualPartition(ctx context.Context, rollout *fleet.RolloutStrategy, targets []*Target) ([]partition, error) {
 	var (
 		partitions []partition
 	)
 
-	for _, partitionDef := range rollout.Partitions {
-		matcher, err := matcher.NewClusterMatcher(partitionDef.ClusterName, partitionDef.ClusterGroup, partitionDef.ClusterGroupSelector, partitionDef.ClusterSelector)
-		if err != nil {
-			return nil, err
-		}
+	var bundle *fleet.Bundle
+	if len(targets) > 0 {
+		bundle = targets[0].Bundle
+	}
+	compiled, err := compilePartitions(bundle, rollout)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, partitionDef := range rollout.Partitions {
+		partitionMatcher := compiled[i]
 
 		var partitionTargets []*Target
-	targetLoop:
 		for _, target := range targets {
-			for _, cg := range target.ClusterGroups {
-				if matcher.Match(target.Cluster.Name, cg.Name, cg.Labels, target.Cluster.Labels) {
-					partitionTargets = append(partitionTargets, target)
-					continue targetLoop
-				}
-			}
-			if len(target.ClusterGroups) == 0 && matcher.Match(target.Cluster.Name, "", nil, target.Cluster.Labels) {
+			if partitionMatcher.MatchAny(ctx, matcher.NewCluster(target.Cluster, ClusterGroupsToLabelMap(target.ClusterGroups))) {
 				partitionTargets = append(partitionTargets, target)
-				continue targetLoop
 			}
 		}
 
@@ -72,6 +85,71 @@ func manualPartition(rollout *fleet.RolloutStrategy, targets []*Target) ([]parti
 	return partitions, nil
 }
 
+// compilePartitions returns the matchers of the rollout's partitions. They are cached for the generation of the
+// bundle, as the rollout strategy is part of the bundle's spec.
+func compilePartitions(bundle *fleet.Bundle, rollout *fleet.RolloutStrategy) ([]*matcher.TargetMatcher, error) {
+	var key partitionMatchersKey
+	if bundle != nil && bundle.UID != "" {
+		key = partitionMatchersKey{uid: bundle.UID, generation: bundle.Generation}
+		if cached, ok := partitionMatchers.Get(key); ok {
+			return cached.([]*matcher.TargetMatcher), nil
+		}
+	}
+
+	matchers := make([]*matcher.TargetMatcher, 0, len(rollout.Partitions))
+	for _, partitionDef := range rollout.Partitions {
+		partitionMatcher, err := matcher.NewTargetMatcher(partitionDef.ClusterName, partitionDef.ClusterGroup, partitionDef.ClusterGroupSelector, partitionDef.ClusterSelector, nil, partitionDef.ClusterExpression)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, partitionMatcher)
+	}
+
+	if key.uid != "" {
+		partitionMatchers.Add(key, matchers)
+	}
+	return matchers, nil
+}
+
+// ValidateRolloutStrategy returns an error if the cluster criteria of a partition, or a maxUnavailable or
+// autoPartitionSize value of the rollout strategy is invalid.
+func ValidateRolloutStrategy(rollout *fleet.RolloutStrategy) error {
+	if rollout == nil {
+		return nil
+	}
+
+	values := []struct {
+		name  string
+		value *intstr.IntOrString
+	}{
+		{"maxUnavailable", rollout.MaxUnavailable},
+		{"maxUnavailablePartitions", rollout.MaxUnavailablePartitions},
+		{"autoPartitionSize", rollout.AutoPartitionSize},
+	}
+	for _, v := range values {
+		if v.value == nil {
+			continue
+		}
+		if _, err := limit(1, v.value); err != nil {
+			return fmt.Errorf("%s: %w", v.name, err)
+		}
+	}
+
+	for _, partitionDef := range rollout.Partitions {
+		if _, err := matcher.NewTargetMatcher(partitionDef.ClusterName, partitionDef.ClusterGroup, partitionDef.ClusterGroupSelector, partitionDef.ClusterSelector, nil, partitionDef.ClusterExpression); err != nil {
+			return fmt.Errorf("partition %q: %w", partitionDef.Name, err)
+		}
+		if partitionDef.MaxUnavailable == nil {
+			continue
+		}
+		if _, err := limit(1, partitionDef.MaxUnavailable); err != nil {
+			return fmt.Errorf("partition %q: maxUnavailable: %w", partitionDef.Name, err)
+		}
+	}
+
+	return nil
+}
+
 // getAutoPartitionThreshold returns the minimum number of clusters required before auto-partitioning is enabled
 func getAutoPartitionThreshold(rollout *fleet.RolloutStrategy) int {
 	if rollout.AutoPartitionThreshold != nil {
@@ -1,6 +1,7 @@
 package target
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"testing"
@@ -458,7 +459,7 @@ func Test_manualPartition(t *testing.T) {
 			} else {
 				targets = tt.targetsFn()
 			}
-			got, gotErr := manualPartition(tt.rollout, targets)
+			got, gotErr := manualPartition(context.Background(), tt.rollout, targets)
 			if gotErr != nil {
 				if !tt.wantErr {
 					t.Errorf("manualPartition() failed: %v", gotErr)
@@ -476,3 +477,31 @@ func Test_manualPartition(t *testing.T) {
 		})
 	}
 }
+
+func Test_compilePartitionsCache(t *testing.T) {
+	bundle := &fleet.Bundle{ObjectMeta: metav1.ObjectMeta{UID: "compile-partitions", Generation: 1}}
+	rollout := &fleet.RolloutStrategy{
+		Partitions: []fleet.Partition{{Name: "prod", ClusterExpression: `cluster.labels.env == "prod"`}},
+	}
+
+	first, err := compilePartitions(bundle, rollout)
+	if err != nil {
+		t.Fatalf("compilePartitions() failed: %v", err)
+	}
+	second, err := compilePartitions(bundle, rollout)
+	if err != nil {
+		t.Fatalf("compilePartitions() failed: %v", err)
+	}
+	if first[0] != second[0] {
+		t.Error("expected matchers to be compiled once per bundle generation")
+	}
+
+	bundle.Generation = 2
+	third, err := compilePartitions(bundle, rollout)
+	if err != nil {
+		t.Fatalf("compilePartitions() failed: %v", err)
+	}
+	if first[0] == third[0] {
+		t.Error("expected matchers to be compiled again for a new bundle generation")
+	}
+}
@@ -0,0 +1,82 @@
+package target
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/binary"
+	"slices"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+// chooseClusters returns at most maxClusters of the targets matching the bundle target. Each cluster is scored by a
+// hash of the bundle, the bundle target and the cluster, and the clusters with the highest scores are chosen. This is
+// rendezvous hashing: a cluster joining or leaving changes at most one of the chosen clusters.
+// If spreadByLabel is set, the clusters are grouped by the value of the label and chosen round-robin from the groups,
+// each round starting with the highest score. The scores still keep the choice stable, but a cluster joining or
+// leaving can add or remove a group, or change which groups get an extra cluster in the last round, and so change
+// several of the chosen clusters.
+func chooseClusters(bundle *fleet.Bundle, bundleTarget *fleet.BundleTarget, candidates []*Target) []*Target {
+	maxClusters := *bundleTarget.MaxClusters
+	if maxClusters >= len(candidates) {
+		return candidates
+	}
+	if maxClusters <= 0 {
+		return nil
+	}
+
+	scores := make(map[*Target]uint64, len(candidates))
+	for _, t := range candidates {
+		scores[t] = score(bundle, bundleTarget.Name, t.Cluster)
+	}
+	byScore := func(a, b *Target) int {
+		return cmp.Or(cmp.Compare(scores[b], scores[a]), cmp.Compare(a.Cluster.Name, b.Cluster.Name))
+	}
+
+	groups := map[string][]*Target{}
+	for _, t := range candidates {
+		value := ""
+		if bundleTarget.SpreadByLabel != "" {
+			value = t.Cluster.Labels[bundleTarget.SpreadByLabel]
+		}
+		groups[value] = append(groups[value], t)
+	}
+	for _, group := range groups {
+		slices.SortFunc(group, byScore)
+	}
+
+	var result []*Target
+	for round := 0; len(result) < maxClusters; round++ {
+		var picks []*Target
+		for _, group := range groups {
+			if round < len(group) {
+				picks = append(picks, group[round])
+			}
+		}
+		slices.SortFunc(picks, byScore)
+		result = append(result, picks[:min(len(picks), maxClusters-len(result))]...)
+	}
+	return result
+}
+
+func score(bundle *fleet.Bundle, targetName string, cluster *fleet.Cluster) uint64 {
+	h := sha256.New()
+	for _, s := range []string{bundle.Namespace, bundle.Name, targetName, cluster.Namespace, cluster.Name} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return binary.BigEndian.Uint64(h.Sum(nil))
+}
+
+// targetSelection returns the status of a bundle target, for which clusters were chosen.
+func targetSelection(bundleTarget *fleet.BundleTarget, matching int, chosen []*Target) fleet.TargetSelection {
+	selection := fleet.TargetSelection{
+		Name:             bundleTarget.Name,
+		MatchingClusters: matching,
+	}
+	for _, t := range chosen {
+		selection.Clusters = append(selection.Clusters, t.Cluster.Namespace+"/"+t.Cluster.Name)
+	}
+	slices.Sort(selection.Clusters)
+	return selection
+}
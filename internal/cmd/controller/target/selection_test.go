@@ -0,0 +1,105 @@
+package target
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+func clusterTargets(n int, labels func(i int) map[string]string) []*Target {
+	var targets []*Target
+	for i := range n {
+		targets = append(targets, &Target{Cluster: &fleet.Cluster{ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("cluster-%02d", i),
+			Namespace: "fleet-default",
+			Labels:    labels(i),
+		}}})
+	}
+	return targets
+}
+
+func clusterNames(targets []*Target) []string {
+	var names []string
+	for _, t := range targets {
+		names = append(names, t.Cluster.Name)
+	}
+	return names
+}
+
+func TestChooseClusters(t *testing.T) {
+	bundle := &fleet.Bundle{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "fleet-default"}}
+	maxClusters := 3
+	bundleTarget := &fleet.BundleTarget{Name: "canary", MaxClusters: &maxClusters}
+	noLabels := func(int) map[string]string { return nil }
+
+	candidates := clusterTargets(10, noLabels)
+	chosen := chooseClusters(bundle, bundleTarget, candidates)
+	assert.Len(t, chosen, 3)
+	assert.Equal(t, clusterNames(chosen), clusterNames(chooseClusters(bundle, bundleTarget, clusterTargets(10, noLabels))))
+
+	// a cluster joining replaces at most one of the chosen clusters
+	joined := chooseClusters(bundle, bundleTarget, clusterTargets(11, noLabels))
+	assert.Len(t, joined, 3)
+	assert.GreaterOrEqual(t, len(intersect(clusterNames(chosen), clusterNames(joined))), 2)
+
+	// a chosen cluster leaving is replaced, the others stay
+	var remaining []*Target
+	for _, c := range clusterTargets(10, noLabels) {
+		if c.Cluster.Name != chosen[0].Cluster.Name {
+			remaining = append(remaining, c)
+		}
+	}
+	left := chooseClusters(bundle, bundleTarget, remaining)
+	assert.Len(t, left, 3)
+	assert.Subset(t, clusterNames(left), clusterNames(chosen)[1:])
+
+	// fewer candidates than maxClusters
+	assert.Len(t, chooseClusters(bundle, bundleTarget, clusterTargets(2, noLabels)), 2)
+
+	zero := 0
+	assert.Empty(t, chooseClusters(bundle, &fleet.BundleTarget{MaxClusters: &zero}, candidates))
+}
+
+func TestChooseClustersSpreadByLabel(t *testing.T) {
+	bundle := &fleet.Bundle{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "fleet-default"}}
+	maxClusters := 4
+	bundleTarget := &fleet.BundleTarget{Name: "canary", MaxClusters: &maxClusters, SpreadByLabel: "region"}
+	regions := []string{"eu", "us", "ap"}
+	candidates := clusterTargets(12, func(i int) map[string]string {
+		return map[string]string{"region": regions[i%len(regions)]}
+	})
+
+	chosen := chooseClusters(bundle, bundleTarget, candidates)
+	assert.Len(t, chosen, 4)
+
+	perRegion := map[string]int{}
+	for _, c := range chosen {
+		perRegion[c.Cluster.Labels["region"]]++
+	}
+	assert.Len(t, perRegion, 3)
+	for region, n := range perRegion {
+		assert.LessOrEqual(t, n, 2, region)
+	}
+
+	selection := targetSelection(bundleTarget, len(candidates), chosen)
+	assert.Equal(t, "canary", selection.Name)
+	assert.Equal(t, 12, selection.MatchingClusters)
+	assert.Len(t, selection.Clusters, 4)
+	assert.IsIncreasing(t, selection.Clusters)
+}
+
+func intersect(a, b []string) []string {
+	var result []string
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				result = append(result, x)
+			}
+		}
+	}
+	return result
+}
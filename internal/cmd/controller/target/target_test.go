@@ -2,13 +2,17 @@ package target
 
 import (
 	"bytes"
+	"reflect"
 	"testing"
 
 	"github.com/pkg/errors"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/yaml"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
+	"github.com/rancher/fleet/internal/clustertemplate"
+	"github.com/rancher/fleet/internal/cmd/controller/options"
 	"github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 )
 
@@ -440,7 +444,7 @@ func TestDisablePreProcessFlagEnabled(t *testing.T) {
 		t.Fatal(err.Error())
 	}
 
-	err = preprocessHelmValues(zap.New(), bundle, cluster)
+	err = preprocessHelmValues(zap.New(), bundle, cluster, clustertemplate.Context(cluster, nil))
 	if err != nil {
 		t.Fatalf("error during cluster processing %v", err)
 	}
@@ -497,7 +501,7 @@ func TestDisablePreProcessFlagDisabled(t *testing.T) {
 		t.Fatal(err.Error())
 	}
 
-	err = preprocessHelmValues(zap.New(), bundle, cluster)
+	err = preprocessHelmValues(zap.New(), bundle, cluster, clustertemplate.Context(cluster, nil))
 	if err != nil {
 		t.Fatalf("error during cluster processing %v", err)
 	}
@@ -541,7 +545,7 @@ func TestDisablePreProcessFlagMissing(t *testing.T) {
 		t.Fatal(err.Error())
 	}
 
-	err = preprocessHelmValues(zap.New(), bundle, cluster)
+	err = preprocessHelmValues(zap.New(), bundle, cluster, clustertemplate.Context(cluster, nil))
 	if err != nil {
 		t.Fatalf("error during cluster processing %v", err)
 	}
@@ -560,3 +564,151 @@ func TestDisablePreProcessFlagMissing(t *testing.T) {
 	}
 
 }
+
+const bundleYamlWithClusterFacts = `namespace: default
+helm:
+  releaseName: facts
+  values:
+    version: "${ .ClusterFacts.kubernetesVersion }"
+    arm: '${ has "arm64" .ClusterFacts.architectures }'
+    storageClass: '${ get .ClusterFacts "defaultStorageClass" | default "standard" }'
+`
+
+func TestClusterFactsTemplateValues(t *testing.T) {
+	cluster, bundle, err := getClusterAndBundle(bundleYamlWithClusterFacts)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	cluster.Status.Facts = &v1alpha1.ClusterFacts{
+		KubernetesVersion: "v1.31.2+k3s1",
+		Architectures:     []string{"amd64", "arm64"},
+	}
+
+	err = preprocessHelmValues(zap.New(), bundle, cluster, clustertemplate.Context(cluster, nil))
+	if err != nil {
+		t.Fatalf("error during cluster processing %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"version":      "v1.31.2+k3s1",
+		"arm":          true,
+		"storageClass": "standard",
+	}
+	if !reflect.DeepEqual(bundle.Helm.Values.Data, expected) {
+		t.Fatalf("unexpected values: %v", bundle.Helm.Values.Data)
+	}
+}
+
+func TestMergeImageRewrites(t *testing.T) {
+	cluster := &v1alpha1.Cluster{
+		Spec: v1alpha1.ClusterSpec{
+			ImageRewrite: &v1alpha1.ImageRewrite{
+				Rules: []v1alpha1.ImageRewriteRule{{From: "docker.io/*", To: "cluster.local/*"}},
+			},
+		},
+	}
+	groups := []*v1alpha1.ClusterGroup{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "b"},
+			Spec: v1alpha1.ClusterGroupSpec{
+				ImageRewrite: &v1alpha1.ImageRewrite{
+					Rules: []v1alpha1.ImageRewriteRule{{From: "quay.io/*", To: "group-b.local/*"}},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "a"},
+			Spec: v1alpha1.ClusterGroupSpec{
+				ImageRewrite: &v1alpha1.ImageRewrite{
+					Rules: []v1alpha1.ImageRewriteRule{{From: "docker.io/*", To: "group-a.local/*"}},
+					Paths: []v1alpha1.ImageRewritePath{{Kind: "App", Paths: []string{"spec.image"}}},
+				},
+			},
+		},
+		{ObjectMeta: metav1.ObjectMeta{Name: "c"}},
+	}
+	opts := &v1alpha1.BundleDeploymentOptions{
+		ImageRewrite: &v1alpha1.ImageRewrite{
+			Rules: []v1alpha1.ImageRewriteRule{{From: "ghcr.io/*", To: "bundle.local/*"}},
+		},
+	}
+
+	mergeImageRewrites(opts, cluster, groups)
+
+	expected := &v1alpha1.ImageRewrite{
+		Rules: []v1alpha1.ImageRewriteRule{
+			{From: "docker.io/*", To: "cluster.local/*"},
+			{From: "docker.io/*", To: "group-a.local/*"},
+			{From: "quay.io/*", To: "group-b.local/*"},
+			{From: "ghcr.io/*", To: "bundle.local/*"},
+		},
+		Paths: []v1alpha1.ImageRewritePath{{Kind: "App", Paths: []string{"spec.image"}}},
+	}
+	if !reflect.DeepEqual(expected, opts.ImageRewrite) {
+		t.Fatalf("unexpected image rewrite options, expected %#v, got %#v", expected, opts.ImageRewrite)
+	}
+
+	// groups are not reordered in place
+	if groups[0].Name != "b" {
+		t.Fatalf("cluster groups were reordered")
+	}
+
+	opts = &v1alpha1.BundleDeploymentOptions{}
+	mergeImageRewrites(opts, &v1alpha1.Cluster{}, nil)
+	if opts.ImageRewrite != nil {
+		t.Fatalf("expected no image rewrite options, got %#v", opts.ImageRewrite)
+	}
+}
+
+func TestSetResourceTemplateContext(t *testing.T) {
+	cluster := &v1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "prod",
+			Namespace: "fleet-default",
+			Labels:    map[string]string{"region": "eu-west"},
+		},
+		Status: v1alpha1.ClusterStatus{
+			Facts: &v1alpha1.ClusterFacts{NodeCount: 3},
+		},
+	}
+	bundle := &v1alpha1.Bundle{
+		Spec: v1alpha1.BundleSpec{
+			Resources: []v1alpha1.BundleResource{{
+				Name:    "cm.yaml",
+				Content: "name: ${ .ClusterName }\nregion: ${ get .ClusterLabels \"region\" }\n",
+			}},
+		},
+	}
+
+	opts := &v1alpha1.BundleDeploymentOptions{}
+	setResourceTemplateContext(opts, bundle, clustertemplate.Context(cluster, nil))
+	if opts.ResourceTemplating != nil {
+		t.Fatalf("expected no resource templating, got %v", opts.ResourceTemplating)
+	}
+
+	enabled := &v1alpha1.ResourceTemplating{Enabled: true}
+	opts = &v1alpha1.BundleDeploymentOptions{ResourceTemplating: enabled}
+	setResourceTemplateContext(opts, bundle, clustertemplate.Context(cluster, nil))
+	if enabled.Context != nil {
+		t.Fatal("expected the bundle options to be left unchanged")
+	}
+	expected := map[string]interface{}{
+		"ClusterName":   "prod",
+		"ClusterLabels": map[string]interface{}{"region": "eu-west"},
+	}
+	if !reflect.DeepEqual(expected, opts.ResourceTemplating.Context.Data) {
+		t.Fatalf("unexpected template context: %v", opts.ResourceTemplating.Context.Data)
+	}
+
+	// unreferenced facts do not change the deployment ID
+	id, err := options.DeploymentID("manifest", *opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cluster.Status.Facts.NodeCount = 4
+	opts = &v1alpha1.BundleDeploymentOptions{ResourceTemplating: enabled}
+	setResourceTemplateContext(opts, bundle, clustertemplate.Context(cluster, nil))
+	if changed, err := options.DeploymentID("manifest", *opts); err != nil || changed != id {
+		t.Fatalf("expected deployment ID %s, got %s (%v)", id, changed, err)
+	}
+}
@@ -0,0 +1,335 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	caCertKey = "ca.crt"
+	caKeyKey  = "ca.key"
+
+	caValidity   = 10 * 365 * 24 * time.Hour
+	certValidity = 365 * 24 * time.Hour
+	// renewBefore is how long before expiry certificates are renewed
+	renewBefore = 30 * 24 * time.Hour
+)
+
+// certificates manages the serving certificate of the webhook server. The
+// certificate and its CA are stored in a secret, which is shared by all
+// replicas of the controller. The CA outlives the serving certificate, so
+// renewing the serving certificate doesn't change the CA bundle of the webhook
+// configurations.
+type certificates struct {
+	client    client.Client
+	reader    client.Reader
+	namespace string
+	service   string
+	certDir   string
+}
+
+// dnsNames returns the names of the webhook service, which the API server connects to.
+func (c *certificates) dnsNames() []string {
+	return []string{
+		c.service,
+		fmt.Sprintf("%s.%s", c.service, c.namespace),
+		fmt.Sprintf("%s.%s.svc", c.service, c.namespace),
+	}
+}
+
+// ensure makes sure a valid serving certificate exists, writes it to the
+// certificate directory of the webhook server and sets the CA bundle of the
+// webhook configurations.
+func (c *certificates) ensure(ctx context.Context) error {
+	secret, err := c.ensureSecret(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to ensure webhook certificate: %w", err)
+	}
+	if err := c.writeFiles(secret); err != nil {
+		return fmt.Errorf("failed to write webhook certificate: %w", err)
+	}
+	if err := c.injectCABundle(ctx, secret.Data[caCertKey]); err != nil {
+		return fmt.Errorf("failed to set CA bundle of webhook configurations: %w", err)
+	}
+	return nil
+}
+
+// ensureSecret returns the secret of the serving certificate. It is created,
+// or renewed if the certificate expires soon. If another replica creates or
+// updates the secret at the same time, its result is used.
+func (c *certificates) ensureSecret(ctx context.Context, now time.Time) (*corev1.Secret, error) {
+	key := types.NamespacedName{Namespace: c.namespace, Name: secretName(c.service)}
+	secret := &corev1.Secret{}
+	err := c.reader.Get(ctx, key, secret)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	exists := err == nil
+	if exists && c.valid(secret, now) {
+		return secret, nil
+	}
+
+	data, err := c.generate(secret.Data, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if exists {
+		secret.Data = data
+		err = c.client.Update(ctx, secret)
+	} else {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Type:       corev1.SecretTypeTLS,
+			Data:       data,
+		}
+		err = c.client.Create(ctx, secret)
+	}
+	if apierrors.IsAlreadyExists(err) || apierrors.IsConflict(err) {
+		if err := c.reader.Get(ctx, key, secret); err != nil {
+			return nil, err
+		}
+		if !c.valid(secret, now) {
+			return nil, errors.New("certificate created by another replica is invalid")
+		}
+		return secret, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	log.FromContext(ctx).Info("Created webhook certificate", "secret", key)
+	return secret, nil
+}
+
+// valid returns true if the serving certificate is valid for the service, is signed by the CA and doesn't expire soon.
+func (c *certificates) valid(secret *corev1.Secret, now time.Time) bool {
+	ca, _, err := parseKeyPair(secret.Data[caCertKey], secret.Data[caKeyKey])
+	if err != nil || now.Add(renewBefore).After(ca.NotAfter) {
+		return false
+	}
+	cert, _, err := parseKeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil || now.Add(renewBefore).After(cert.NotAfter) {
+		return false
+	}
+	if err := cert.CheckSignatureFrom(ca); err != nil {
+		return false
+	}
+	for _, name := range c.dnsNames() {
+		if !slices.Contains(cert.DNSNames, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// generate returns the secret data with a new serving certificate. The CA is reused, unless it is invalid or expires
+// soon.
+func (c *certificates) generate(data map[string][]byte, now time.Time) (map[string][]byte, error) {
+	ca, caKey, err := parseKeyPair(data[caCertKey], data[caKeyKey])
+	if err != nil || now.Add(renewBefore).After(ca.NotAfter) {
+		ca, caKey, err = newCertificate(&x509.Certificate{
+			Subject:               pkix.Name{CommonName: "fleet-controller-webhook-ca"},
+			NotBefore:             now.Add(-time.Hour),
+			NotAfter:              now.Add(caValidity),
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+		}, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cert, key, err := newCertificate(&x509.Certificate{
+		Subject:     pkix.Name{CommonName: c.dnsNames()[2]},
+		DNSNames:    c.dnsNames(),
+		NotBefore:   now.Add(-time.Hour),
+		NotAfter:    now.Add(certValidity),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca, caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	caKeyPEM, err := encodeKey(caKey)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string][]byte{
+		caCertKey:               encodeCert(ca),
+		caKeyKey:                caKeyPEM,
+		corev1.TLSCertKey:       encodeCert(cert),
+		corev1.TLSPrivateKeyKey: keyPEM,
+	}, nil
+}
+
+// writeFiles writes the serving certificate to the certificate directory. The
+// webhook server watches the files and reloads the certificate when they
+// change.
+func (c *certificates) writeFiles(secret *corev1.Secret) error {
+	if err := os.MkdirAll(c.certDir, 0700); err != nil {
+		return err
+	}
+	for _, key := range []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey} {
+		path := filepath.Join(c.certDir, key)
+		if current, err := os.ReadFile(path); err == nil && bytes.Equal(current, secret.Data[key]) {
+			continue
+		}
+		if err := os.WriteFile(path, secret.Data[key], 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// injectCABundle sets the CA bundle of the webhook configurations, which are
+// installed by the helm chart.
+func (c *certificates) injectCABundle(ctx context.Context, caBundle []byte) error {
+	validating := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+	if err := c.reader.Get(ctx, types.NamespacedName{Name: c.service}, validating); client.IgnoreNotFound(err) != nil {
+		return err
+	} else if err == nil {
+		orig := validating.DeepCopy()
+		for i := range validating.Webhooks {
+			validating.Webhooks[i].ClientConfig.CABundle = caBundle
+		}
+		if err := c.patch(ctx, orig, validating); err != nil {
+			return err
+		}
+	}
+
+	mutating := &admissionregistrationv1.MutatingWebhookConfiguration{}
+	if err := c.reader.Get(ctx, types.NamespacedName{Name: c.service}, mutating); client.IgnoreNotFound(err) != nil {
+		return err
+	} else if err == nil {
+		orig := mutating.DeepCopy()
+		for i := range mutating.Webhooks {
+			mutating.Webhooks[i].ClientConfig.CABundle = caBundle
+		}
+		if err := c.patch(ctx, orig, mutating); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *certificates) patch(ctx context.Context, orig, obj client.Object) error {
+	patch := client.MergeFrom(orig)
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+	if string(data) == "{}" {
+		return nil
+	}
+	return c.client.Patch(ctx, obj, patch)
+}
+
+// rotator renews the serving certificate periodically. It runs on all
+// replicas, as each of them serves the webhook.
+type rotator struct {
+	certs    *certificates
+	interval time.Duration
+}
+
+func (r *rotator) Start(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := r.certs.ensure(ctx); err != nil {
+				log.FromContext(ctx).Error(err, "Failed to renew webhook certificate")
+			}
+		}
+	}
+}
+
+func (r *rotator) NeedLeaderElection() bool {
+	return false
+}
+
+func secretName(service string) string {
+	return service + "-tls"
+}
+
+func newCertificate(template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	template.SerialNumber = serial
+
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+func parseKeyPair(certPEM, keyPEM []byte) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, ok := pair.PrivateKey.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, nil, fmt.Errorf("unexpected private key type %T", pair.PrivateKey)
+	}
+	return pair.Leaf, key, nil
+}
+
+func encodeCert(cert *x509.Certificate) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+}
+
+func encodeKey(key *ecdsa.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+}
@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCertificates(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&admissionregistrationv1.ValidatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: ServiceName},
+			Webhooks:   []admissionregistrationv1.ValidatingWebhook{{Name: "vgitrepo.fleet.cattle.io"}, {Name: "vbundle.fleet.cattle.io"}},
+		},
+		&admissionregistrationv1.MutatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: ServiceName},
+			Webhooks:   []admissionregistrationv1.MutatingWebhook{{Name: "mbundle.fleet.cattle.io"}},
+		},
+	).Build()
+
+	certs := &certificates{
+		client:    c,
+		reader:    c,
+		namespace: "cattle-fleet-system",
+		service:   ServiceName,
+		certDir:   t.TempDir(),
+	}
+	ctx := context.Background()
+	require.NoError(t, certs.ensure(ctx))
+
+	secret := &corev1.Secret{}
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Namespace: "cattle-fleet-system", Name: "fleet-controller-webhook-tls"}, secret))
+	assert.Equal(t, corev1.SecretTypeTLS, secret.Type)
+	now := time.Now()
+	assert.True(t, certs.valid(secret, now))
+
+	cert, _, err := parseKeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+	require.NoError(t, err)
+	assert.Contains(t, cert.DNSNames, "fleet-controller-webhook.cattle-fleet-system.svc")
+
+	written, err := os.ReadFile(filepath.Join(certs.certDir, corev1.TLSCertKey))
+	require.NoError(t, err)
+	assert.Equal(t, secret.Data[corev1.TLSCertKey], written)
+
+	validating := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: ServiceName}, validating))
+	for _, webhook := range validating.Webhooks {
+		assert.Equal(t, secret.Data[caCertKey], webhook.ClientConfig.CABundle)
+	}
+	mutating := &admissionregistrationv1.MutatingWebhookConfiguration{}
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: ServiceName}, mutating))
+	assert.Equal(t, secret.Data[caCertKey], mutating.Webhooks[0].ClientConfig.CABundle)
+
+	// a valid certificate is reused
+	reused, err := certs.ensureSecret(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, secret.Data, reused.Data)
+
+	// an expiring certificate is renewed with the same CA
+	later := now.Add(certValidity - renewBefore + time.Hour)
+	assert.False(t, certs.valid(secret, later))
+	renewed, err := certs.ensureSecret(ctx, later)
+	require.NoError(t, err)
+	assert.NotEqual(t, secret.Data[corev1.TLSCertKey], renewed.Data[corev1.TLSCertKey])
+	assert.Equal(t, secret.Data[caCertKey], renewed.Data[caCertKey])
+	assert.True(t, certs.valid(renewed, later))
+}
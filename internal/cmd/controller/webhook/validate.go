@@ -0,0 +1,157 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rancher/fleet/internal/bundlereader"
+	gitops "github.com/rancher/fleet/internal/cmd/controller/gitops/reconciler"
+	helmops "github.com/rancher/fleet/internal/cmd/controller/helmops/reconciler"
+	"github.com/rancher/fleet/internal/cmd/controller/reconciler"
+	"github.com/rancher/fleet/internal/cmd/controller/restrictions"
+	"github.com/rancher/fleet/internal/cmd/controller/target"
+	"github.com/rancher/fleet/internal/cmd/controller/target/matcher"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// validator implements admission.CustomValidator for a fleet resource. Updates
+// are only validated if the spec changes, so objects created before the
+// webhook was enabled can still be updated by controllers, e.g. to remove
+// finalizers. Objects being deleted are never rejected.
+type validator[T client.Object] struct {
+	validate func(ctx context.Context, obj T) error
+	spec     func(obj T) any
+}
+
+var _ admission.CustomValidator = &validator[*fleet.GitRepo]{}
+
+func (v *validator[T]) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	o, ok := obj.(T)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object type %T", obj)
+	}
+	return nil, v.validate(ctx, o)
+}
+
+func (v *validator[T]) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	o, ok := oldObj.(T)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object type %T", oldObj)
+	}
+	n, ok := newObj.(T)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object type %T", newObj)
+	}
+	if !n.GetDeletionTimestamp().IsZero() || equality.Semantic.DeepEqual(v.spec(o), v.spec(n)) {
+		return nil, nil
+	}
+	return nil, v.validate(ctx, n)
+}
+
+func (v *validator[T]) ValidateDelete(context.Context, runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func gitRepoValidator(c client.Client) *validator[*fleet.GitRepo] {
+	return &validator[*fleet.GitRepo]{
+		spec: func(gitrepo *fleet.GitRepo) any { return gitrepo.Spec },
+		validate: func(ctx context.Context, gitrepo *fleet.GitRepo) error {
+			if err := gitops.ValidateSources(gitrepo.Spec.Sources); err != nil {
+				return fmt.Errorf("invalid sources: %w", err)
+			}
+			for _, t := range gitrepo.Spec.Targets {
+				if _, err := matcher.NewTargetMatcher(t.ClusterName, t.ClusterGroup, t.ClusterGroupSelector, t.ClusterSelector, t.ClusterFactSelector, t.ClusterExpression); err != nil {
+					return fmt.Errorf("invalid target %q: %w", t.Name, err)
+				}
+			}
+			// AuthorizeAndAssignDefaults assigns defaults, which are not stored
+			if err := gitops.AuthorizeAndAssignDefaults(ctx, c, gitrepo.DeepCopy()); err != nil {
+				return fmt.Errorf("denied by GitRepoRestriction: %w", err)
+			}
+			return nil
+		},
+	}
+}
+
+func helmOpValidator() *validator[*fleet.HelmOp] {
+	return &validator[*fleet.HelmOp]{
+		spec: func(helmop *fleet.HelmOp) any { return helmop.Spec },
+		validate: func(_ context.Context, helmop *fleet.HelmOp) error {
+			if err := helmops.Validate(*helmop); err != nil {
+				return err
+			}
+			return validateBundleSpec(&helmop.Spec.BundleSpec)
+		},
+	}
+}
+
+func scheduleValidator() *validator[*fleet.Schedule] {
+	return &validator[*fleet.Schedule]{
+		spec: func(schedule *fleet.Schedule) any { return schedule.Spec },
+		validate: func(_ context.Context, schedule *fleet.Schedule) error {
+			if err := reconciler.ValidateSchedule(schedule); err != nil {
+				return fmt.Errorf("invalid schedule: %w", err)
+			}
+			return nil
+		},
+	}
+}
+
+func bundleValidator(c client.Reader, mapper meta.RESTMapper) *validator[*fleet.Bundle] {
+	return &validator[*fleet.Bundle]{
+		spec: func(bundle *fleet.Bundle) any { return bundle.Spec },
+		validate: func(ctx context.Context, bundle *fleet.Bundle) error {
+			if err := validateBundleSpec(&bundle.Spec); err != nil {
+				return err
+			}
+			restriction, err := restrictions.Get(ctx, c, bundle.Namespace)
+			if err != nil {
+				return err
+			}
+			if restriction == nil {
+				return nil
+			}
+			if err := restrictions.CheckBundle(restriction, mapper, bundle); err != nil {
+				return fmt.Errorf("denied by GitRepoRestriction: %w", err)
+			}
+			return nil
+		},
+	}
+}
+
+// validateBundleSpec validates the targets and the rollout strategy, like the bundle reconciler does when building
+// targets and partitions.
+func validateBundleSpec(spec *fleet.BundleSpec) error {
+	if _, err := matcher.New(&fleet.Bundle{Spec: *spec}); err != nil {
+		return fmt.Errorf("invalid targets: %w", err)
+	}
+	if err := target.ValidateRolloutStrategy(spec.RolloutStrategy); err != nil {
+		return fmt.Errorf("invalid rolloutStrategy: %w", err)
+	}
+	return nil
+}
+
+// bundleDefaulter names unnamed targets of bundles, like `fleet apply` does.
+// Target names are used in the bundle status and to choose clusters for
+// targets with maxClusters.
+type bundleDefaulter struct{}
+
+var _ admission.CustomDefaulter = bundleDefaulter{}
+
+func (bundleDefaulter) Default(_ context.Context, obj runtime.Object) error {
+	bundle, ok := obj.(*fleet.Bundle)
+	if !ok {
+		return fmt.Errorf("unexpected object type %T", obj)
+	}
+	if !bundle.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	bundlereader.SetTargetNames(&bundle.Spec)
+	return nil
+}
@@ -0,0 +1,235 @@
+package webhook
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+func newFakeClient(objs ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(fleet.AddToScheme(scheme))
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
+
+func TestValidateGitRepo(t *testing.T) {
+	c := newFakeClient(&fleet.GitRepoRestriction{
+		ObjectMeta:           metav1.ObjectMeta{Name: "restriction", Namespace: "tenant"},
+		AllowedClusterGroups: []string{"tenant"},
+		AllowedRepoPatterns:  []string{"https://github.com/tenant/.*"},
+	})
+	v := gitRepoValidator(c)
+
+	cases := []struct {
+		name        string
+		gitrepo     fleet.GitRepo
+		expectedErr string
+	}{
+		{
+			name: "valid",
+			gitrepo: fleet.GitRepo{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default"},
+				Spec: fleet.GitRepoSpec{
+					Repo:    "https://github.com/rancher/fleet-examples",
+					Targets: []fleet.GitTarget{{ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "dev"}}}},
+				},
+			},
+		},
+		{
+			name: "invalid selector",
+			gitrepo: fleet.GitRepo{Spec: fleet.GitRepoSpec{
+				Targets: []fleet.GitTarget{{Name: "dev", ClusterSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "env", Operator: "Equals", Values: []string{"dev"}}},
+				}}},
+			}},
+			expectedErr: `invalid target "dev"`,
+		},
+		{
+			name: "invalid expression",
+			gitrepo: fleet.GitRepo{Spec: fleet.GitRepoSpec{
+				Targets: []fleet.GitTarget{{Name: "dev", ClusterExpression: "cluster.labels["}},
+			}},
+			expectedErr: `invalid target "dev"`,
+		},
+		{
+			name: "invalid source",
+			gitrepo: fleet.GitRepo{Spec: fleet.GitRepoSpec{
+				Sources: []fleet.GitRepoSource{{Name: ".."}},
+			}},
+			expectedErr: `invalid sources: invalid source name ".."`,
+		},
+		{
+			name: "restriction violation",
+			gitrepo: fleet.GitRepo{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "tenant"},
+				Spec: fleet.GitRepoSpec{
+					Targets: []fleet.GitTarget{{Name: "other", ClusterGroup: "other"}},
+				},
+			},
+			expectedErr: `denied by GitRepoRestriction: disallowed targets: target "other"`,
+		},
+		{
+			name: "restriction violation by source",
+			gitrepo: fleet.GitRepo{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "tenant"},
+				Spec: fleet.GitRepoSpec{
+					Repo:    "https://github.com/tenant/app",
+					Sources: []fleet.GitRepoSource{{Name: "values", Repo: "https://github.com/other/values"}},
+					Targets: []fleet.GitTarget{{Name: "tenant", ClusterGroup: "tenant"}},
+				},
+			},
+			expectedErr: `denied by GitRepoRestriction: disallowed repo https://github.com/other/values of source values`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := v.ValidateCreate(context.Background(), &c.gitrepo)
+			if c.expectedErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), c.expectedErr)
+		})
+	}
+}
+
+func TestValidateUpdate(t *testing.T) {
+	v := helmOpValidator()
+	invalid := &fleet.HelmOp{ObjectMeta: metav1.ObjectMeta{Name: "invalid"}}
+
+	_, err := v.ValidateCreate(context.Background(), invalid)
+	require.ErrorContains(t, err, "helm options are empty")
+
+	// unchanged specs are not validated, e.g. when a controller adds a finalizer
+	updated := invalid.DeepCopy()
+	updated.Finalizers = []string{"fleet.cattle.io/helmop-finalizer"}
+	_, err = v.ValidateUpdate(context.Background(), invalid, updated)
+	require.NoError(t, err)
+
+	updated.Spec.Labels = map[string]string{"a": "b"}
+	_, err = v.ValidateUpdate(context.Background(), invalid, updated)
+	require.Error(t, err)
+
+	// objects being deleted are not validated
+	now := metav1.Now()
+	updated.DeletionTimestamp = &now
+	_, err = v.ValidateUpdate(context.Background(), invalid, updated)
+	require.NoError(t, err)
+
+	_, err = v.ValidateCreate(context.Background(), &fleet.GitRepo{})
+	require.ErrorContains(t, err, "unexpected object type")
+}
+
+func TestValidateHelmOp(t *testing.T) {
+	v := helmOpValidator()
+	helmop := &fleet.HelmOp{Spec: fleet.HelmOpSpec{BundleSpec: fleet.BundleSpec{
+		BundleDeploymentOptions: fleet.BundleDeploymentOptions{
+			Helm: &fleet.HelmOptions{Repo: "https://charts.example.com", Chart: "app"},
+		},
+	}}}
+	_, err := v.ValidateCreate(context.Background(), helmop)
+	require.NoError(t, err)
+
+	invalid := intstr.FromString("ten%")
+	helmop.Spec.RolloutStrategy = &fleet.RolloutStrategy{MaxUnavailable: &invalid}
+	_, err = v.ValidateCreate(context.Background(), helmop)
+	require.ErrorContains(t, err, "invalid rolloutStrategy: maxUnavailable")
+
+	helmop.Spec.RolloutStrategy = &fleet.RolloutStrategy{Partitions: []fleet.Partition{{Name: "canary", MaxUnavailable: &invalid}}}
+	_, err = v.ValidateCreate(context.Background(), helmop)
+	require.ErrorContains(t, err, `invalid rolloutStrategy: partition "canary": maxUnavailable`)
+}
+
+func TestValidateSchedule(t *testing.T) {
+	v := scheduleValidator()
+
+	cases := []struct {
+		name        string
+		spec        fleet.ScheduleSpec
+		expectedErr string
+	}{
+		{
+			name: "valid",
+			spec: fleet.ScheduleSpec{Schedule: "0 0 * * * *", Duration: metav1.Duration{Duration: time.Minute}},
+		},
+		{
+			name:        "invalid cron expression",
+			spec:        fleet.ScheduleSpec{Schedule: "every hour", Duration: metav1.Duration{Duration: time.Minute}},
+			expectedErr: "invalid schedule",
+		},
+		{
+			name:        "duration overlaps next execution",
+			spec:        fleet.ScheduleSpec{Schedule: "0 0 * * * *", Duration: metav1.Duration{Duration: 2 * time.Hour}},
+			expectedErr: "duration is too long",
+		},
+		{
+			name:        "invalid location",
+			spec:        fleet.ScheduleSpec{Schedule: "0 0 * * * *", Location: "Nowhere/Town"},
+			expectedErr: "invalid schedule",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := v.ValidateCreate(context.Background(), &fleet.Schedule{Spec: c.spec})
+			if c.expectedErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, c.expectedErr)
+		})
+	}
+}
+
+func TestValidateBundle(t *testing.T) {
+	c := newFakeClient(&fleet.GitRepoRestriction{
+		ObjectMeta:          metav1.ObjectMeta{Name: "restriction", Namespace: "tenant"},
+		DeniedResourceKinds: []string{"Secret"},
+	})
+	v := bundleValidator(c, meta.NewDefaultRESTMapper(nil))
+
+	bundle := &fleet.Bundle{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "tenant"},
+		Spec: fleet.BundleSpec{
+			Resources: []fleet.BundleResource{{Name: "cm.yaml", Content: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\n"}},
+			Targets:   []fleet.BundleTarget{{Name: "all", ClusterExpression: "true"}},
+		},
+	}
+	_, err := v.ValidateCreate(context.Background(), bundle)
+	require.NoError(t, err)
+
+	bundle.Spec.Resources = append(bundle.Spec.Resources, fleet.BundleResource{Name: "secret.yaml", Content: "apiVersion: v1\nkind: Secret\nmetadata:\n  name: s\n"})
+	_, err = v.ValidateCreate(context.Background(), bundle)
+	require.ErrorContains(t, err, "denied by GitRepoRestriction: resource secret.yaml: kind Secret is denied")
+
+	bundle.Spec.Targets[0].ClusterExpression = "cluster.labels["
+	_, err = v.ValidateCreate(context.Background(), bundle)
+	require.ErrorContains(t, err, `invalid targets: target "all"`)
+}
+
+func TestBundleDefaulter(t *testing.T) {
+	bundle := &fleet.Bundle{Spec: fleet.BundleSpec{Targets: []fleet.BundleTarget{
+		{ClusterGroup: "a"},
+		{Name: "b", ClusterGroup: "b"},
+		{ClusterGroup: "c"},
+	}}}
+
+	require.NoError(t, bundleDefaulter{}.Default(context.Background(), bundle))
+	assert.Equal(t, "target000", bundle.Spec.Targets[0].Name)
+	assert.Equal(t, "b", bundle.Spec.Targets[1].Name)
+	assert.Equal(t, "target002", bundle.Spec.Targets[2].Name)
+}
@@ -0,0 +1,68 @@
+// Package webhook serves the admission webhook of the fleet controller. It validates GitRepos, HelmOps, Schedules and
+// Bundles before they are stored, with the checks the reconcilers would otherwise only report in the status.
+package webhook
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	// ServiceName is the name of the webhook service and of the webhook configurations, as installed by the helm
+	// chart.
+	ServiceName = "fleet-controller-webhook"
+
+	rotationInterval = 12 * time.Hour
+)
+
+// CertDir is the directory the webhook server reads its serving certificate from.
+var CertDir = filepath.Join(os.TempDir(), "fleet-webhook-certs")
+
+// Setup creates the serving certificate, sets the CA bundle of the webhook configurations and registers the webhooks
+// with the manager's webhook server. It has to be called before the manager is started.
+func Setup(ctx context.Context, mgr manager.Manager, systemNamespace string) error {
+	certs := &certificates{
+		client:    mgr.GetClient(),
+		reader:    mgr.GetAPIReader(),
+		namespace: systemNamespace,
+		service:   ServiceName,
+		certDir:   CertDir,
+	}
+	if err := certs.ensure(ctx); err != nil {
+		return err
+	}
+	if err := mgr.Add(&rotator{certs: certs, interval: rotationInterval}); err != nil {
+		return err
+	}
+
+	if err := ctrl.NewWebhookManagedBy(mgr).
+		For(&fleet.GitRepo{}).
+		WithValidator(gitRepoValidator(mgr.GetClient())).
+		Complete(); err != nil {
+		return err
+	}
+	if err := ctrl.NewWebhookManagedBy(mgr).
+		For(&fleet.HelmOp{}).
+		WithValidator(helmOpValidator()).
+		Complete(); err != nil {
+		return err
+	}
+	if err := ctrl.NewWebhookManagedBy(mgr).
+		For(&fleet.Schedule{}).
+		WithValidator(scheduleValidator()).
+		Complete(); err != nil {
+		return err
+	}
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&fleet.Bundle{}).
+		WithDefaulter(bundleDefaulter{}).
+		WithValidator(bundleValidator(mgr.GetClient(), mgr.GetRESTMapper())).
+		Complete()
+}
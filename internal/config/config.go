@@ -141,6 +141,14 @@ type Config struct {
 
 	// AgentWorkers specifies the maximum number of workers for each agent reconciler.
 	AgentWorkers AgentWorkers `json:"agentWorkers,omitempty"`
+
+	// OCIStorageGCInterval determines how often the fleet-controller checks OCI storage registries and deletes
+	// orphaned artifacts. A non-existent value or 0 will result in an interval of 1 hour.
+	OCIStorageGCInterval metav1.Duration `json:"ociStorageGCInterval,omitempty"`
+
+	// OCIStorageRetention is how long an OCI storage artifact is kept after the fleet-controller found it is no
+	// longer referenced by any bundle. A non-existent value or 0 will result in a retention of 24 hours.
+	OCIStorageRetention metav1.Duration `json:"ociStorageRetention,omitempty"`
 }
 
 type AgentWorkers struct {
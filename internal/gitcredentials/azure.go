@@ -0,0 +1,73 @@
+package gitcredentials
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// AzureTenantIDKey is the key of the Microsoft Entra ID tenant of the federated application.
+	AzureTenantIDKey = "azure_tenant_id"
+	// AzureClientIDKey is the key of the client ID of the application or managed identity trusting the service
+	// account.
+	AzureClientIDKey = "azure_client_id"
+	// AzureAuthorityHostKey is the key of the Microsoft Entra ID endpoint. It defaults to the public cloud.
+	AzureAuthorityHostKey = "azure_authority_host"
+
+	defaultAzureAuthorityHost = "https://login.microsoftonline.com"
+	// azureFederationAudience is the audience Microsoft Entra ID expects in federated service account tokens.
+	azureFederationAudience = "api://AzureADTokenExchange"
+	// azureDevOpsScope is the scope of the Azure DevOps resource.
+	azureDevOpsScope = "499b84ac-1321-427f-aa17-267ca6975798/.default"
+)
+
+type azureDevOps struct {
+	secret   *corev1.Secret
+	opts     Options
+	endpoint string
+	clientID string
+}
+
+func newAzureDevOps(secret *corev1.Secret, opts Options) (*azureDevOps, error) {
+	values, err := requiredKeys(secret, AzureTenantIDKey, AzureClientIDKey, ServiceAccountKey)
+	if err != nil {
+		return nil, err
+	}
+
+	host := defaultAzureAuthorityHost
+	if v := string(secret.Data[AzureAuthorityHostKey]); v != "" {
+		host = strings.TrimSuffix(v, "/")
+	}
+
+	return &azureDevOps{
+		secret:   secret,
+		opts:     opts,
+		endpoint: host + "/" + url.PathEscape(values[0]) + "/oauth2/v2.0/token",
+		clientID: values[1],
+	}, nil
+}
+
+// Token exchanges a token of the service account for a Microsoft Entra ID access token to Azure DevOps, which is
+// accepted as a bearer token by Azure Repos.
+func (a *azureDevOps) Token(ctx context.Context) (*Token, error) {
+	assertion, err := subjectToken(ctx, a.secret, a.opts, azureFederationAudience, a.endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	token, expiry, err := requestToken(ctx, a.opts, a.endpoint, url.Values{
+		"grant_type":            {"client_credentials"},
+		"client_id":             {a.clientID},
+		"scope":                 {azureDevOpsScope},
+		"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+		"client_assertion":      {assertion},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Token{Password: token, Bearer: true, Expiry: expiry}, nil
+}
@@ -0,0 +1,90 @@
+package gitcredentials
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Cache hands out the tokens of the providers configured by client secrets until they expire, so that polling,
+// cloning and pushing do not mint a new token each time.
+type Cache struct {
+	opts Options
+
+	mu      sync.Mutex
+	entries map[types.NamespacedName]*cacheEntry
+}
+
+type cacheEntry struct {
+	// mu serializes minting tokens for a secret, as rotating a GitLab token revokes the previous one.
+	mu     sync.Mutex
+	config string
+	token  *Token
+}
+
+func NewCache(opts Options) *Cache {
+	return &Cache{
+		opts:    opts,
+		entries: map[types.NamespacedName]*cacheEntry{},
+	}
+}
+
+// Token returns the cached token of the provider configured by the secret, or mints a new one if it expired or the
+// configuration of the provider changed.
+func (c *Cache) Token(ctx context.Context, secret *corev1.Secret) (*Token, error) {
+	key := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &cacheEntry{}
+		c.entries[key] = entry
+	}
+	c.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	config := configHash(secret)
+	if entry.token != nil && entry.config == config && c.opts.now().Before(entry.token.Expiry) {
+		return entry.token, nil
+	}
+
+	provider, err := New(secret, c.opts)
+	if err != nil {
+		return nil, err
+	}
+	token, err := provider.Token(ctx)
+	if err != nil {
+		entry.token = nil
+		return nil, err
+	}
+	entry.config = config
+	entry.token = token
+	return token, nil
+}
+
+// configHash hashes the data of the secret, apart from the keys updated by the providers themselves.
+func configHash(secret *corev1.Secret) string {
+	keys := make([]string, 0, len(secret.Data))
+	for k := range secret.Data {
+		if k == GitLabTokenKey || k == GitLabTokenExpiresAtKey {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	h := sha256.New()
+	for _, k := range keys {
+		h.Write([]byte(k))
+		h.Write([]byte{0})
+		h.Write(secret.Data[k])
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,61 @@
+package gitcredentials
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "token", "expires_in": 3600})
+	}))
+	defer srv.Close()
+
+	clock := now
+	cache := NewCache(Options{
+		Now: func() time.Time { return clock },
+		SubjectToken: func(context.Context, string, string, string, string) (string, error) {
+			return "sa-token", nil
+		},
+	})
+	secret := newSecret(map[string]string{
+		ProviderKey:       ProviderOIDC,
+		OIDCTokenURLKey:   srv.URL,
+		ServiceAccountKey: "puller",
+	})
+
+	token := func() {
+		t.Helper()
+		if _, err := cache.Token(context.Background(), secret); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	token()
+	token()
+	if requests != 1 {
+		t.Errorf("expected cached token, got %d requests", requests)
+	}
+
+	clock = clock.Add(time.Hour - expiryMargin)
+	token()
+	if requests != 2 {
+		t.Errorf("expected expired token to be replaced, got %d requests", requests)
+	}
+
+	secret.Data[OIDCScopeKey] = []byte("read_repository")
+	token()
+	if requests != 3 {
+		t.Errorf("expected token to be replaced after configuration change, got %d requests", requests)
+	}
+
+	if _, err := cache.Token(context.Background(), newSecret(map[string]string{"username": "foo"})); err != ErrNoProvider {
+		t.Errorf("expected ErrNoProvider, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package gitcredentials
+
+import (
+	"context"
+	"encoding/json"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// serviceAccountTokenSeconds is the lifetime of the service account tokens exchanged by the providers. They are
+// only used once, right after being requested.
+const serviceAccountTokenSeconds = 10 * 60
+
+// NewClientCache returns a cache of tokens with ClientOptions, or nil if the credential providers are not enabled.
+func NewClientCache(c client.Client, reader client.Reader) *Cache {
+	if !Enabled() {
+		return nil
+	}
+	return NewCache(ClientOptions(c, reader))
+}
+
+// ClientOptions returns options, which request service account tokens and update secrets with the client. Service
+// accounts are read with the uncached reader, as they are only read when a token is requested.
+func ClientOptions(c client.Client, reader client.Reader) Options {
+	return Options{
+		SubjectToken: func(ctx context.Context, namespace, serviceAccount, audience, tokenURL string) (string, error) {
+			sa := &corev1.ServiceAccount{}
+			if err := reader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: serviceAccount}, sa); err != nil {
+				return "", err
+			}
+			if err := AuthorizeServiceAccount(sa, audience, tokenURL); err != nil {
+				return "", err
+			}
+
+			expiration := int64(serviceAccountTokenSeconds)
+			req := &authenticationv1.TokenRequest{
+				Spec: authenticationv1.TokenRequestSpec{
+					Audiences:         []string{audience},
+					ExpirationSeconds: &expiration,
+				},
+			}
+			if err := c.SubResource("token").Create(ctx, sa, req); err != nil {
+				return "", err
+			}
+			return req.Status.Token, nil
+		},
+		// The data is patched, instead of updating the secret, as the rotated token would be lost on conflicts.
+		UpdateSecret: func(ctx context.Context, secret *corev1.Secret) error {
+			patch, err := json.Marshal(map[string]any{"data": secret.Data})
+			if err != nil {
+				return err
+			}
+			return c.Patch(ctx, secret.DeepCopy(), client.RawPatch(types.MergePatchType, patch))
+		},
+	}
+}
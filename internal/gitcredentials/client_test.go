@@ -0,0 +1,41 @@
+package gitcredentials
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestClientOptionsSubjectToken(t *testing.T) {
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-local", Name: "puller"}}
+	c := fake.NewClientBuilder().WithObjects(sa).Build()
+	opts := ClientOptions(c, c)
+
+	_, err := opts.SubjectToken(context.Background(), "fleet-local", "puller", "https://sts.example.com", "https://sts.example.com")
+	if err == nil || !strings.Contains(err.Error(), AudiencesAnnotation) {
+		t.Errorf("expected tokens of service accounts without annotations to be refused, got %v", err)
+	}
+
+	_, err = opts.SubjectToken(context.Background(), "fleet-local", "missing", "https://sts.example.com", "https://sts.example.com")
+	if err == nil {
+		t.Error("expected error for a missing service account")
+	}
+}
+
+func TestNewClientCache(t *testing.T) {
+	c := fake.NewClientBuilder().Build()
+
+	t.Setenv(EnabledEnv, "")
+	if NewClientCache(c, c) != nil {
+		t.Error("expected no cache if the credential providers are not enabled")
+	}
+
+	t.Setenv(EnabledEnv, "true")
+	if NewClientCache(c, c) == nil {
+		t.Error("expected a cache if the credential providers are enabled")
+	}
+}
@@ -0,0 +1,160 @@
+package gitcredentials
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// GitLabURLKey is the key of the URL of the GitLab instance, e.g. https://gitlab.com.
+	GitLabURLKey = "gitlab_url"
+	// GitLabProjectKey is the key of the ID or path of the project owning the access token.
+	GitLabProjectKey = "gitlab_project"
+	// GitLabGroupKey is the key of the ID or path of the group owning the access token. It is used if no project
+	// is set.
+	GitLabGroupKey = "gitlab_group"
+	// GitLabTokenKey is the key of the current access token. It is replaced on each rotation.
+	GitLabTokenKey = "gitlab_token"
+	// GitLabTokenExpiresAtKey is the key of the expiry date of the current access token, in the format YYYY-MM-DD.
+	// It is replaced on each rotation. If it is missing, the token is rotated when first used.
+	GitLabTokenExpiresAtKey = "gitlab_token_expires_at"
+	// GitLabTokenLifetimeKey is the key of the number of days rotated tokens are valid for. It defaults to 7.
+	GitLabTokenLifetimeKey = "gitlab_token_lifetime_days"
+
+	// gitlabUsername is the username for project and group access tokens, which accept any non-empty username.
+	gitlabUsername = "oauth2"
+	// gitlabRotateBefore is the remaining lifetime of a GitLab token, below which it is rotated. Expiry dates are
+	// days, so the token expires at the start of its expiry date.
+	gitlabRotateBefore = 24 * time.Hour
+
+	defaultGitLabTokenLifetimeDays = 7
+)
+
+type gitLab struct {
+	secret   *corev1.Secret
+	opts     Options
+	endpoint string
+	lifetime int
+}
+
+func newGitLab(secret *corev1.Secret, opts Options) (*gitLab, error) {
+	values, err := requiredKeys(secret, GitLabURLKey, GitLabTokenKey)
+	if err != nil {
+		return nil, err
+	}
+	if opts.UpdateSecret == nil {
+		return nil, fmt.Errorf("gitlab credential provider of secret %s/%s cannot store rotated tokens", secret.Namespace, secret.Name)
+	}
+
+	var owner string
+	switch {
+	case len(secret.Data[GitLabProjectKey]) > 0:
+		owner = "projects/" + url.PathEscape(string(secret.Data[GitLabProjectKey]))
+	case len(secret.Data[GitLabGroupKey]) > 0:
+		owner = "groups/" + url.PathEscape(string(secret.Data[GitLabGroupKey]))
+	default:
+		return nil, fmt.Errorf("missing %s or %s in secret %s/%s", GitLabProjectKey, GitLabGroupKey, secret.Namespace, secret.Name)
+	}
+
+	lifetime := defaultGitLabTokenLifetimeDays
+	if v := string(secret.Data[GitLabTokenLifetimeKey]); v != "" {
+		lifetime, err = strconv.Atoi(v)
+		if err != nil || lifetime < 2 {
+			return nil, fmt.Errorf("%s in secret %s/%s must be a number of at least 2 days", GitLabTokenLifetimeKey, secret.Namespace, secret.Name)
+		}
+	}
+
+	return &gitLab{
+		secret:   secret.DeepCopy(),
+		opts:     opts,
+		endpoint: strings.TrimSuffix(values[0], "/") + "/api/v4/" + owner + "/access_tokens/self/rotate",
+		lifetime: lifetime,
+	}, nil
+}
+
+// Token returns the access token stored in the secret, rotating it first if it is about to expire.
+func (g *gitLab) Token(ctx context.Context) (*Token, error) {
+	token := string(g.secret.Data[GitLabTokenKey])
+	if expiresAt, err := time.Parse(time.DateOnly, string(g.secret.Data[GitLabTokenExpiresAtKey])); err == nil {
+		if expiry := expiresAt.Add(-gitlabRotateBefore); g.opts.now().Before(expiry) {
+			return &Token{Username: gitlabUsername, Password: token, Expiry: expiry}, nil
+		}
+	}
+
+	rotated, expiresAt, err := g.rotate(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	g.secret.Data[GitLabTokenKey] = []byte(rotated)
+	g.secret.Data[GitLabTokenExpiresAtKey] = []byte(expiresAt.Format(time.DateOnly))
+	if err := g.opts.UpdateSecret(ctx, g.secret); err != nil {
+		// the previous token has been revoked, the rotated one is lost
+		return nil, fmt.Errorf("failed to store rotated GitLab token in secret %s/%s: %w", g.secret.Namespace, g.secret.Name, err)
+	}
+
+	return &Token{Username: gitlabUsername, Password: rotated, Expiry: expiresAt.Add(-gitlabRotateBefore)}, nil
+}
+
+type gitlabRotateResponse struct {
+	Token     string `json:"token"`
+	ExpiresAt string `json:"expires_at"`
+	Message   any    `json:"message"`
+}
+
+// rotate rotates the token with itself and returns the new token and its expiry date. GitLab revokes the previous
+// token.
+func (g *gitLab) rotate(ctx context.Context, token string) (string, time.Time, error) {
+	expiresAt := g.opts.now().UTC().Truncate(24*time.Hour).AddDate(0, 0, g.lifetime)
+	body, err := json.Marshal(map[string]string{"expires_at": expiresAt.Format(time.DateOnly)})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("PRIVATE-TOKEN", token)
+
+	resp, err := g.opts.httpClient().Do(req)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	var rotated gitlabRotateResponse
+	if err := json.Unmarshal(data, &rotated); err != nil && resp.StatusCode == http.StatusOK {
+		return "", time.Time{}, fmt.Errorf("failed to parse response of GitLab token rotation %s: %w", g.endpoint, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if rotated.Message != nil {
+			return "", time.Time{}, fmt.Errorf("GitLab token rotation %s returned %s: %v", g.endpoint, resp.Status, rotated.Message)
+		}
+		return "", time.Time{}, fmt.Errorf("GitLab token rotation %s returned %s", g.endpoint, resp.Status)
+	}
+	if rotated.Token == "" {
+		return "", time.Time{}, fmt.Errorf("GitLab token rotation %s returned no token", g.endpoint)
+	}
+
+	// GitLab may shorten the lifetime to the maximum allowed by the instance
+	if t, err := time.Parse(time.DateOnly, rotated.ExpiresAt); err == nil {
+		expiresAt = t
+	}
+	return rotated.Token, expiresAt, nil
+}
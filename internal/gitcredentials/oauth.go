@@ -0,0 +1,66 @@
+package gitcredentials
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// expiryMargin is the remaining lifetime of a token, below which it is no longer handed out. It leaves time for a
+// clone or push to finish.
+const expiryMargin = 5 * time.Minute
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// requestToken posts the form to an OAuth 2.0 token endpoint and returns the access token and the time it is handed
+// out until.
+func requestToken(ctx context.Context, opts Options, endpoint string, form url.Values) (string, time.Time, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	now := opts.now()
+	resp, err := opts.httpClient().Do(req)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	var tok tokenResponse
+	if err := json.Unmarshal(body, &tok); err != nil && resp.StatusCode == http.StatusOK {
+		return "", time.Time{}, fmt.Errorf("failed to parse response of token endpoint %s: %w", endpoint, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if tok.Error != "" {
+			return "", time.Time{}, fmt.Errorf("token endpoint %s returned %s: %s %s", endpoint, resp.Status, tok.Error, tok.ErrorDescription)
+		}
+		return "", time.Time{}, fmt.Errorf("token endpoint %s returned %s", endpoint, resp.Status)
+	}
+	if tok.AccessToken == "" {
+		return "", time.Time{}, fmt.Errorf("token endpoint %s returned no access token", endpoint)
+	}
+
+	// tokens without an expiry are refreshed like the shortest-lived ones
+	lifetime := time.Duration(tok.ExpiresIn) * time.Second
+	if lifetime <= expiryMargin {
+		lifetime = 2 * expiryMargin
+	}
+	return tok.AccessToken, now.Add(lifetime - expiryMargin), nil
+}
@@ -0,0 +1,96 @@
+package gitcredentials
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// OIDCTokenURLKey is the key of the token exchange endpoint.
+	OIDCTokenURLKey = "token_url"
+	// OIDCSubjectAudienceKey is the key of the audience of the service account token. It defaults to the token URL.
+	OIDCSubjectAudienceKey = "subject_audience"
+	// OIDCAudienceKey is the key of the optional audience of the requested token.
+	OIDCAudienceKey = "audience"
+	// OIDCScopeKey is the key of the optional, space separated scopes of the requested token.
+	OIDCScopeKey = "scope"
+	// OIDCClientIDKey is the key of the optional client ID sent to the token exchange endpoint.
+	OIDCClientIDKey = "client_id"
+	// OIDCUsernameKey is the key of the username sent with the token using basic authentication. It defaults to
+	// oauth2.
+	OIDCUsernameKey = "username"
+	// OIDCBearerKey is the key of the flag, which sends the token as a bearer token instead.
+	OIDCBearerKey = "bearer"
+
+	defaultOIDCUsername = "oauth2"
+)
+
+type oidc struct {
+	secret   *corev1.Secret
+	opts     Options
+	endpoint string
+	audience string
+	username string
+	bearer   bool
+}
+
+func newOIDC(secret *corev1.Secret, opts Options) (*oidc, error) {
+	values, err := requiredKeys(secret, OIDCTokenURLKey, ServiceAccountKey)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &oidc{
+		secret:   secret,
+		opts:     opts,
+		endpoint: values[0],
+		audience: values[0],
+		username: defaultOIDCUsername,
+	}
+	if v := string(secret.Data[OIDCSubjectAudienceKey]); v != "" {
+		o.audience = v
+	}
+	if v := string(secret.Data[OIDCUsernameKey]); v != "" {
+		o.username = v
+	}
+	if v := string(secret.Data[OIDCBearerKey]); v != "" {
+		if o.bearer, err = strconv.ParseBool(v); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+// Token exchanges a token of the service account for an access token, as defined by RFC 8693.
+func (o *oidc) Token(ctx context.Context) (*Token, error) {
+	subject, err := subjectToken(ctx, o.secret, o.opts, o.audience, o.endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":           {"urn:ietf:params:oauth:grant-type:token-exchange"},
+		"subject_token":        {subject},
+		"subject_token_type":   {"urn:ietf:params:oauth:token-type:jwt"},
+		"requested_token_type": {"urn:ietf:params:oauth:token-type:access_token"},
+	}
+	for param, key := range map[string]string{
+		"audience":  OIDCAudienceKey,
+		"scope":     OIDCScopeKey,
+		"client_id": OIDCClientIDKey,
+	} {
+		if v := string(o.secret.Data[key]); v != "" {
+			form.Set(param, v)
+		}
+	}
+
+	token, expiry, err := requestToken(ctx, o.opts, o.endpoint, form)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Token{Username: o.username, Password: token, Bearer: o.bearer, Expiry: expiry}, nil
+}
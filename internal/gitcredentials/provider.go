@@ -0,0 +1,191 @@
+// Package gitcredentials mints short-lived tokens for Git repositories from external identity providers, instead of
+// storing long-lived credentials in the client secret of a GitRepo.
+//
+// A client secret selects a provider with its credential_provider key. The other keys of the secret configure the
+// provider:
+//
+//   - gitlab: rotates a GitLab project or group access token, storing the new token in the secret.
+//   - azure-devops: exchanges a Kubernetes service account token for a Microsoft Entra ID token, using workload
+//     identity federation.
+//   - oidc: exchanges a Kubernetes service account token at an OAuth 2.0 token exchange endpoint (RFC 8693).
+//
+// The providers are only enabled by the GIT_CREDENTIAL_PROVIDERS env variable. Service accounts have to allow the
+// audiences and token endpoints their tokens are requested for, see AudiencesAnnotation and TokenURLsAnnotation.
+package gitcredentials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	httpgit "github.com/go-git/go-git/v5/plumbing/transport/http"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ProviderKey is the key of the client secret selecting the credential provider.
+	ProviderKey = "credential_provider"
+
+	ProviderGitLab      = "gitlab"
+	ProviderAzureDevOps = "azure-devops"
+	ProviderOIDC        = "oidc"
+
+	// ServiceAccountKey is the key of the service account, in the namespace of the secret, whose token is exchanged
+	// by the azure-devops and oidc providers.
+	ServiceAccountKey = "service_account"
+
+	// AudiencesAnnotation lists the comma separated audiences, for which tokens of the annotated service account
+	// are requested by the providers. Tokens of service accounts without it are never requested, so that secrets
+	// can't select any service account of the namespace.
+	AudiencesAnnotation = "fleet.cattle.io/git-credentials-audiences"
+	// TokenURLsAnnotation lists the comma separated token endpoints, to which tokens of the annotated service
+	// account are sent by the providers.
+	TokenURLsAnnotation = "fleet.cattle.io/git-credentials-token-urls"
+
+	// EnabledEnv is the env variable enabling the credential providers.
+	EnabledEnv = "GIT_CREDENTIAL_PROVIDERS"
+)
+
+var ErrNoProvider = errors.New("secret does not configure a credential provider")
+
+// Token is a short-lived credential for a Git repository.
+type Token struct {
+	// Username is the username for basic authentication. It is ignored for bearer tokens.
+	Username string
+	// Password is the token.
+	Password string
+	// Bearer is true if the token has to be sent as a bearer token, instead of as the password of basic
+	// authentication.
+	Bearer bool
+	// Expiry is the time until which the token can be handed out. Providers return it early enough for the token to
+	// be used for a clone.
+	Expiry time.Time
+}
+
+// AuthMethod returns the go-git authentication method of the token.
+func (t *Token) AuthMethod() transport.AuthMethod {
+	if t.Bearer {
+		return &httpgit.TokenAuth{Token: t.Password}
+	}
+	return &httpgit.BasicAuth{Username: t.Username, Password: t.Password}
+}
+
+// Provider mints tokens for a Git repository.
+type Provider interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// Options are the dependencies of the providers.
+type Options struct {
+	// HTTPClient is used to call the identity providers. It defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// SubjectToken returns a token of the service account, in the namespace of the secret, for the audience, which
+	// is sent to the token endpoint. It is required by the azure-devops and oidc providers.
+	SubjectToken func(ctx context.Context, namespace, serviceAccount, audience, tokenURL string) (string, error)
+	// UpdateSecret stores the rotated token in the secret. It is required by the gitlab provider.
+	UpdateSecret func(ctx context.Context, secret *corev1.Secret) error
+	// Now returns the current time. It defaults to time.Now.
+	Now func() time.Time
+}
+
+func (o Options) httpClient() *http.Client {
+	if o.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return o.HTTPClient
+}
+
+func (o Options) now() time.Time {
+	if o.Now == nil {
+		return time.Now()
+	}
+	return o.Now()
+}
+
+// HasProvider returns true if the secret configures a credential provider.
+func HasProvider(secret *corev1.Secret) bool {
+	return secret != nil && len(secret.Data[ProviderKey]) > 0
+}
+
+// New returns the provider configured by the secret, or ErrNoProvider.
+func New(secret *corev1.Secret, opts Options) (Provider, error) {
+	if !HasProvider(secret) {
+		return nil, ErrNoProvider
+	}
+
+	switch name := string(secret.Data[ProviderKey]); name {
+	case ProviderGitLab:
+		return newGitLab(secret, opts)
+	case ProviderAzureDevOps:
+		return newAzureDevOps(secret, opts)
+	case ProviderOIDC:
+		return newOIDC(secret, opts)
+	default:
+		return nil, fmt.Errorf("unknown credential provider %q in secret %s/%s", name, secret.Namespace, secret.Name)
+	}
+}
+
+// Enabled returns true if the credential providers are enabled by the GIT_CREDENTIAL_PROVIDERS env variable.
+func Enabled() bool {
+	value, err := strconv.ParseBool(os.Getenv(EnabledEnv))
+	return err == nil && value
+}
+
+// AuthorizeServiceAccount returns an error, unless the annotations of the service account allow sending its tokens
+// for the audience to the token endpoint.
+func AuthorizeServiceAccount(sa *corev1.ServiceAccount, audience, tokenURL string) error {
+	if !slices.Contains(annotationList(sa, AudiencesAnnotation), audience) {
+		return fmt.Errorf("audience %q is not allowed by the %s annotation of service account %s/%s", audience, AudiencesAnnotation, sa.Namespace, sa.Name)
+	}
+	if !slices.Contains(annotationList(sa, TokenURLsAnnotation), tokenURL) {
+		return fmt.Errorf("token URL %q is not allowed by the %s annotation of service account %s/%s", tokenURL, TokenURLsAnnotation, sa.Namespace, sa.Name)
+	}
+	return nil
+}
+
+func annotationList(sa *corev1.ServiceAccount, key string) []string {
+	var values []string
+	for _, v := range strings.Split(sa.Annotations[key], ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// subjectToken returns the token of the service account configured in the secret, for the audience, to be sent to
+// the token endpoint.
+func subjectToken(ctx context.Context, secret *corev1.Secret, opts Options, audience, tokenURL string) (string, error) {
+	sa := string(secret.Data[ServiceAccountKey])
+	if sa == "" {
+		return "", fmt.Errorf("missing %s in secret %s/%s", ServiceAccountKey, secret.Namespace, secret.Name)
+	}
+	if opts.SubjectToken == nil {
+		return "", errors.New("service account tokens are not available")
+	}
+	tok, err := opts.SubjectToken(ctx, secret.Namespace, sa, audience, tokenURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to get token of service account %s/%s: %w", secret.Namespace, sa, err)
+	}
+	return tok, nil
+}
+
+// requiredKeys returns the values of the keys of the secret, or an error naming the first missing key.
+func requiredKeys(secret *corev1.Secret, keys ...string) ([]string, error) {
+	values := make([]string, 0, len(keys))
+	for _, k := range keys {
+		v := string(secret.Data[k])
+		if v == "" {
+			return nil, fmt.Errorf("missing %s in secret %s/%s", k, secret.Namespace, secret.Name)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
@@ -0,0 +1,287 @@
+package gitcredentials
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var now = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+func newSecret(data map[string]string) *corev1.Secret {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-local", Name: "git-auth"},
+		Data:       map[string][]byte{},
+	}
+	for k, v := range data {
+		secret.Data[k] = []byte(v)
+	}
+	return secret
+}
+
+func subjectTokens(t *testing.T, wantAudience, wantTokenURL string) func(context.Context, string, string, string, string) (string, error) {
+	return func(_ context.Context, namespace, serviceAccount, audience, tokenURL string) (string, error) {
+		if namespace != "fleet-local" || serviceAccount != "puller" {
+			t.Errorf("requested token of service account %s/%s", namespace, serviceAccount)
+		}
+		if audience != wantAudience {
+			t.Errorf("requested token for audience %q, want %q", audience, wantAudience)
+		}
+		if tokenURL != wantTokenURL {
+			t.Errorf("requested token for token URL %q, want %q", tokenURL, wantTokenURL)
+		}
+		return "sa-token", nil
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(newSecret(map[string]string{"username": "foo"}), Options{}); err != ErrNoProvider {
+		t.Errorf("expected ErrNoProvider, got %v", err)
+	}
+	if _, err := New(newSecret(map[string]string{ProviderKey: "vault"}), Options{}); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+	if _, err := New(newSecret(map[string]string{ProviderKey: ProviderOIDC, OIDCTokenURLKey: "https://sts"}), Options{}); err == nil {
+		t.Error("expected error for missing service account")
+	}
+}
+
+func TestGitLab(t *testing.T) {
+	var rotations int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/v4/projects/fleet%2Fexamples/access_tokens/self/rotate" {
+			t.Errorf("unexpected path %s", r.URL.EscapedPath())
+		}
+		if r.Header.Get("PRIVATE-TOKEN") != "old" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message":"401 Unauthorized"}`))
+			return
+		}
+		var body struct {
+			ExpiresAt string `json:"expires_at"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		if body.ExpiresAt != "2026-10-26" {
+			t.Errorf("requested expiry %s, want 2026-10-26", body.ExpiresAt)
+		}
+		rotations++
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": "new", "expires_at": "2026-10-25"})
+	}))
+	defer srv.Close()
+
+	var updated *corev1.Secret
+	opts := Options{
+		Now: func() time.Time { return now },
+		UpdateSecret: func(_ context.Context, secret *corev1.Secret) error {
+			updated = secret
+			return nil
+		},
+	}
+
+	secret := newSecret(map[string]string{
+		ProviderKey:      ProviderGitLab,
+		GitLabURLKey:     srv.URL + "/",
+		GitLabProjectKey: "fleet/examples",
+		GitLabTokenKey:   "old",
+	})
+
+	t.Run("rotates tokens without expiry", func(t *testing.T) {
+		p, err := New(secret, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tok, err := p.Token(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tok.Password != "new" || tok.Username != gitlabUsername || tok.Bearer {
+			t.Errorf("unexpected token %+v", tok)
+		}
+		if want := time.Date(2026, 10, 24, 0, 0, 0, 0, time.UTC); !tok.Expiry.Equal(want) {
+			t.Errorf("token expires at %s, want %s", tok.Expiry, want)
+		}
+		if updated == nil || string(updated.Data[GitLabTokenKey]) != "new" || string(updated.Data[GitLabTokenExpiresAtKey]) != "2026-10-25" {
+			t.Errorf("rotated token was not stored: %v", updated)
+		}
+		if string(secret.Data[GitLabTokenKey]) != "old" {
+			t.Error("secret passed to the provider was modified")
+		}
+	})
+
+	t.Run("hands out valid tokens", func(t *testing.T) {
+		valid := secret.DeepCopy()
+		valid.Data[GitLabTokenExpiresAtKey] = []byte("2026-10-21")
+		p, err := New(valid, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tok, err := p.Token(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tok.Password != "old" || rotations != 1 {
+			t.Errorf("unexpected token %+v after %d rotations", tok, rotations)
+		}
+	})
+
+	t.Run("rotates tokens about to expire", func(t *testing.T) {
+		expiring := secret.DeepCopy()
+		expiring.Data[GitLabTokenExpiresAtKey] = []byte("2026-10-20")
+		p, err := New(expiring, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := p.Token(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if rotations != 2 {
+			t.Errorf("expected token to be rotated, got %d rotations", rotations)
+		}
+	})
+
+	t.Run("returns errors of GitLab", func(t *testing.T) {
+		revoked := secret.DeepCopy()
+		revoked.Data[GitLabTokenKey] = []byte("revoked")
+		p, err := New(revoked, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := p.Token(context.Background()); err == nil {
+			t.Error("expected error for revoked token")
+		}
+	})
+}
+
+func TestAzureDevOps(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tenant/oauth2/v2.0/token" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		for k, v := range map[string]string{
+			"grant_type":       "client_credentials",
+			"client_id":        "app",
+			"scope":            azureDevOpsScope,
+			"client_assertion": "sa-token",
+		} {
+			if got := r.PostForm.Get(k); got != v {
+				t.Errorf("form value %s is %q, want %q", k, got, v)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "entra", "expires_in": 3600})
+	}))
+	defer srv.Close()
+
+	p, err := New(newSecret(map[string]string{
+		ProviderKey:           ProviderAzureDevOps,
+		AzureTenantIDKey:      "tenant",
+		AzureClientIDKey:      "app",
+		AzureAuthorityHostKey: srv.URL,
+		ServiceAccountKey:     "puller",
+	}), Options{
+		Now:          func() time.Time { return now },
+		SubjectToken: subjectTokens(t, azureFederationAudience, srv.URL+"/tenant/oauth2/v2.0/token"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := p.Token(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.Password != "entra" || !tok.Bearer {
+		t.Errorf("unexpected token %+v", tok)
+	}
+	if want := now.Add(time.Hour - expiryMargin); !tok.Expiry.Equal(want) {
+		t.Errorf("token expires at %s, want %s", tok.Expiry, want)
+	}
+}
+
+func TestOIDC(t *testing.T) {
+	var status int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		for k, v := range map[string]string{
+			"grant_type":    "urn:ietf:params:oauth:grant-type:token-exchange",
+			"subject_token": "sa-token",
+			"audience":      "git.example.com",
+		} {
+			if got := r.PostForm.Get(k); got != v {
+				t.Errorf("form value %s is %q, want %q", k, got, v)
+			}
+		}
+		if status != 0 {
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant","error_description":"subject token expired"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "exchanged", "expires_in": 60})
+	}))
+	defer srv.Close()
+
+	p, err := New(newSecret(map[string]string{
+		ProviderKey:       ProviderOIDC,
+		OIDCTokenURLKey:   srv.URL,
+		OIDCAudienceKey:   "git.example.com",
+		OIDCUsernameKey:   "fleet",
+		ServiceAccountKey: "puller",
+	}), Options{
+		Now:          func() time.Time { return now },
+		SubjectToken: subjectTokens(t, srv.URL, srv.URL),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := p.Token(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.Username != "fleet" || tok.Password != "exchanged" || tok.Bearer {
+		t.Errorf("unexpected token %+v", tok)
+	}
+	// short-lived tokens are handed out until they would be refreshed anyway
+	if want := now.Add(expiryMargin); !tok.Expiry.Equal(want) {
+		t.Errorf("token expires at %s, want %s", tok.Expiry, want)
+	}
+
+	status = http.StatusBadRequest
+	if _, err := p.Token(context.Background()); err == nil {
+		t.Error("expected error of token endpoint")
+	}
+}
+
+func TestAuthorizeServiceAccount(t *testing.T) {
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "fleet-local",
+		Name:      "puller",
+		Annotations: map[string]string{
+			AudiencesAnnotation: "api://AzureADTokenExchange, https://sts.example.com",
+			TokenURLsAnnotation: "https://sts.example.com/token",
+		},
+	}}
+
+	if err := AuthorizeServiceAccount(sa, "https://sts.example.com", "https://sts.example.com/token"); err != nil {
+		t.Errorf("expected allowed audience and token URL, got %v", err)
+	}
+	if err := AuthorizeServiceAccount(sa, "https://other.example.com", "https://sts.example.com/token"); err == nil {
+		t.Error("expected error for an audience which is not allowed")
+	}
+	if err := AuthorizeServiceAccount(sa, "https://sts.example.com", "https://attacker.example.com/token"); err == nil {
+		t.Error("expected error for a token URL which is not allowed")
+	}
+	if err := AuthorizeServiceAccount(&corev1.ServiceAccount{}, "", ""); err == nil {
+		t.Error("expected error for a service account without annotations")
+	}
+}
@@ -0,0 +1,160 @@
+// Package gitorg lists the repositories of GitHub organizations, GitLab groups and Gitea organizations, using the
+// REST APIs of the providers.
+package gitorg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+	ProviderGitea  = "gitea"
+
+	defaultGitHubURL = "https://api.github.com"
+	defaultGitLabURL = "https://gitlab.com"
+)
+
+// Repository is a repository of an organization.
+type Repository struct {
+	// Name is the name of the repository, without its organization.
+	Name string
+	// FullName is the name of the repository, including its organization and, for GitLab, subgroups.
+	FullName      string
+	CloneURL      string
+	SSHURL        string
+	DefaultBranch string
+	Topics        []string
+	Archived      bool
+}
+
+// Scanner lists the repositories of an organization.
+type Scanner interface {
+	// Repositories returns all repositories of the organization.
+	Repositories(ctx context.Context) ([]Repository, error)
+	// HasFile returns true if the file exists in the repository at the ref.
+	HasFile(ctx context.Context, repo Repository, ref, path string) (bool, error)
+}
+
+// Options configure a scanner.
+type Options struct {
+	// URL is the base URL of the API. It defaults to the public instance of GitHub and GitLab.
+	URL string
+	// Organization is the organization or group to scan.
+	Organization string
+	// Token is sent as a bearer token, if set.
+	Token string
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// New returns the scanner of the provider.
+func New(provider string, opts Options) (Scanner, error) {
+	if opts.Organization == "" {
+		return nil, fmt.Errorf("missing organization")
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+
+	switch provider {
+	case ProviderGitHub:
+		if opts.URL == "" {
+			opts.URL = defaultGitHubURL
+		}
+		return &gitHub{api: newAPI(opts)}, nil
+	case ProviderGitLab:
+		if opts.URL == "" {
+			opts.URL = defaultGitLabURL
+		}
+		return &gitLab{api: newAPI(opts)}, nil
+	case ProviderGitea:
+		if opts.URL == "" {
+			return nil, fmt.Errorf("missing URL of Gitea instance")
+		}
+		return &gitea{api: newAPI(opts)}, nil
+	default:
+		return nil, fmt.Errorf("unknown provider %q", provider)
+	}
+}
+
+// api sends requests to the REST API of a provider.
+type api struct {
+	base   string
+	org    string
+	token  string
+	client *http.Client
+}
+
+func newAPI(opts Options) api {
+	return api{
+		base:   strings.TrimSuffix(opts.URL, "/"),
+		org:    opts.Organization,
+		token:  opts.Token,
+		client: opts.HTTPClient,
+	}
+}
+
+func (a api) do(ctx context.Context, method, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, a.base+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if a.token != "" {
+		req.Header.Set("Authorization", "Bearer "+a.token)
+	}
+	return a.client.Do(req)
+}
+
+// get decodes the JSON response to the GET request into v.
+func (a api) get(ctx context.Context, path string, v any) error {
+	resp, err := a.do(ctx, http.MethodGet, path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("GET %s returned %s: %s", a.base+path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response of GET %s: %w", a.base+path, err)
+	}
+	return nil
+}
+
+// exists returns true if the request succeeds and false if the resource is not found.
+func (a api) exists(ctx context.Context, method, path string) (bool, error) {
+	resp, err := a.do(ctx, method, path)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("%s %s returned %s", method, a.base+path, resp.Status)
+	}
+}
+
+// escapePath escapes each segment of the slash separated path.
+func escapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,154 @@
+package gitorg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeAPI serves the repositories and files of an organization in the API of the provider.
+func fakeAPI(t *testing.T, provider string, repos int) *httptest.Server {
+	mux := http.NewServeMux()
+	auth := func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			h(w, r)
+		}
+	}
+	page := func(r *http.Request, size int) (int, int) {
+		var p int
+		_, _ = fmt.Sscan(r.URL.Query().Get("page"), &p)
+		first := (p - 1) * size
+		return min(first, repos), min(first+size, repos)
+	}
+
+	switch provider {
+	case ProviderGitHub, ProviderGitea:
+		list, contents, size := "/orgs/example/repos", "/repos/example/app-0/contents/fleet.yaml", pageSize
+		if provider == ProviderGitea {
+			list, contents, size = "/api/v1/orgs/example/repos", "/api/v1/repos/example/app-0/contents/fleet.yaml", giteaPageSize
+		}
+		mux.HandleFunc("GET "+list, auth(func(w http.ResponseWriter, r *http.Request) {
+			first, last := page(r, size)
+			items := []map[string]any{}
+			for i := first; i < last; i++ {
+				items = append(items, map[string]any{
+					"name":           fmt.Sprintf("app-%d", i),
+					"full_name":      fmt.Sprintf("example/app-%d", i),
+					"clone_url":      fmt.Sprintf("https://git.example.com/example/app-%d.git", i),
+					"ssh_url":        fmt.Sprintf("git@git.example.com:example/app-%d.git", i),
+					"default_branch": "main",
+					"topics":         []string{"fleet"},
+				})
+			}
+			_ = json.NewEncoder(w).Encode(items)
+		}))
+		mux.HandleFunc("GET "+contents, auth(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("ref") != "main" {
+				t.Errorf("unexpected ref %q", r.URL.Query().Get("ref"))
+			}
+			_, _ = w.Write([]byte(`{}`))
+		}))
+	case ProviderGitLab:
+		mux.HandleFunc("GET /api/v4/groups/example/projects", auth(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("include_subgroups") != "true" {
+				t.Error("expected subgroups to be included")
+			}
+			first, last := page(r, pageSize)
+			items := []map[string]any{}
+			for i := first; i < last; i++ {
+				items = append(items, map[string]any{
+					"path":                fmt.Sprintf("app-%d", i),
+					"path_with_namespace": fmt.Sprintf("example/team/app-%d", i),
+					"http_url_to_repo":    fmt.Sprintf("https://git.example.com/example/team/app-%d.git", i),
+					"ssh_url_to_repo":     fmt.Sprintf("git@git.example.com:example/team/app-%d.git", i),
+					"default_branch":      "main",
+					"topics":              []string{"fleet"},
+					"archived":            i == 1,
+				})
+			}
+			_ = json.NewEncoder(w).Encode(items)
+		}))
+		mux.HandleFunc("HEAD /api/v4/projects/{project}/repository/files/{file}", auth(func(w http.ResponseWriter, r *http.Request) {
+			if r.PathValue("project") != "example/team/app-0" || r.PathValue("file") != "fleet.yaml" {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+	}
+	return httptest.NewServer(mux)
+}
+
+func TestScanners(t *testing.T) {
+	for _, provider := range []string{ProviderGitHub, ProviderGitLab, ProviderGitea} {
+		t.Run(provider, func(t *testing.T) {
+			srv := fakeAPI(t, provider, 120)
+			defer srv.Close()
+
+			s, err := New(provider, Options{URL: srv.URL, Organization: "example", Token: "secret"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			repos, err := s.Repositories(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(repos) != 120 {
+				t.Fatalf("expected all pages to be listed, got %d repositories", len(repos))
+			}
+
+			prefix := "example/"
+			if provider == ProviderGitLab {
+				prefix = "example/team/"
+			}
+			expected := Repository{
+				Name:          "app-1",
+				FullName:      prefix + "app-1",
+				CloneURL:      "https://git.example.com/" + prefix + "app-1.git",
+				SSHURL:        "git@git.example.com:" + prefix + "app-1.git",
+				DefaultBranch: "main",
+				Topics:        []string{"fleet"},
+				Archived:      provider == ProviderGitLab,
+			}
+			if !cmp.Equal(repos[1], expected) {
+				t.Errorf("unexpected repository: %s", cmp.Diff(expected, repos[1]))
+			}
+
+			found, err := s.HasFile(context.Background(), repos[0], "main", "fleet.yaml")
+			if err != nil || !found {
+				t.Errorf("expected fleet.yaml in %s, got %v, %v", repos[0].FullName, found, err)
+			}
+			found, err = s.HasFile(context.Background(), repos[2], "main", "fleet.yaml")
+			if err != nil || found {
+				t.Errorf("expected no fleet.yaml in %s, got %v, %v", repos[2].FullName, found, err)
+			}
+		})
+	}
+}
+
+func TestScannerErrors(t *testing.T) {
+	srv := fakeAPI(t, ProviderGitHub, 1)
+	defer srv.Close()
+
+	s, err := New(ProviderGitHub, Options{URL: srv.URL, Organization: "example"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Repositories(context.Background()); err == nil {
+		t.Error("expected error for unauthorized request")
+	}
+
+	if _, err := New(ProviderGitea, Options{Organization: "example"}); err == nil {
+		t.Error("expected error for Gitea without URL")
+	}
+	if _, err := New("bitbucket", Options{Organization: "example"}); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+}
@@ -0,0 +1,120 @@
+package gitorg
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const pageSize = 100
+
+type gitHub struct {
+	api
+}
+
+type gitHubRepository struct {
+	Name          string   `json:"name"`
+	FullName      string   `json:"full_name"`
+	CloneURL      string   `json:"clone_url"`
+	SSHURL        string   `json:"ssh_url"`
+	DefaultBranch string   `json:"default_branch"`
+	Topics        []string `json:"topics"`
+	Archived      bool     `json:"archived"`
+}
+
+func (g *gitHub) Repositories(ctx context.Context) ([]Repository, error) {
+	var repos []Repository
+	for page := 1; ; page++ {
+		var list []gitHubRepository
+		path := fmt.Sprintf("/orgs/%s/repos?type=all&per_page=%d&page=%d", url.PathEscape(g.org), pageSize, page)
+		if err := g.get(ctx, path, &list); err != nil {
+			return nil, err
+		}
+		for _, r := range list {
+			repos = append(repos, Repository(r))
+		}
+		if len(list) < pageSize {
+			return repos, nil
+		}
+	}
+}
+
+func (g *gitHub) HasFile(ctx context.Context, repo Repository, ref, path string) (bool, error) {
+	return g.exists(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/contents/%s?ref=%s",
+		escapePath(repo.FullName), escapePath(path), url.QueryEscape(ref)))
+}
+
+type gitLab struct {
+	api
+}
+
+type gitLabProject struct {
+	Path              string   `json:"path"`
+	PathWithNamespace string   `json:"path_with_namespace"`
+	HTTPURLToRepo     string   `json:"http_url_to_repo"`
+	SSHURLToRepo      string   `json:"ssh_url_to_repo"`
+	DefaultBranch     string   `json:"default_branch"`
+	Topics            []string `json:"topics"`
+	Archived          bool     `json:"archived"`
+}
+
+func (g *gitLab) Repositories(ctx context.Context) ([]Repository, error) {
+	var repos []Repository
+	for page := 1; ; page++ {
+		var list []gitLabProject
+		path := fmt.Sprintf("/api/v4/groups/%s/projects?include_subgroups=true&per_page=%d&page=%d", url.PathEscape(g.org), pageSize, page)
+		if err := g.get(ctx, path, &list); err != nil {
+			return nil, err
+		}
+		for _, p := range list {
+			repos = append(repos, Repository{
+				Name:          p.Path,
+				FullName:      p.PathWithNamespace,
+				CloneURL:      p.HTTPURLToRepo,
+				SSHURL:        p.SSHURLToRepo,
+				DefaultBranch: p.DefaultBranch,
+				Topics:        p.Topics,
+				Archived:      p.Archived,
+			})
+		}
+		if len(list) < pageSize {
+			return repos, nil
+		}
+	}
+}
+
+func (g *gitLab) HasFile(ctx context.Context, repo Repository, ref, path string) (bool, error) {
+	// projects and files are identified by their URL encoded paths
+	return g.exists(ctx, http.MethodHead, fmt.Sprintf("/api/v4/projects/%s/repository/files/%s?ref=%s",
+		url.PathEscape(repo.FullName), url.PathEscape(path), url.QueryEscape(ref)))
+}
+
+type gitea struct {
+	api
+}
+
+// giteaPageSize is the default maximum page size of Gitea instances.
+const giteaPageSize = 50
+
+func (g *gitea) Repositories(ctx context.Context) ([]Repository, error) {
+	var repos []Repository
+	for page := 1; ; page++ {
+		var list []gitHubRepository // Gitea follows the GitHub API
+		path := fmt.Sprintf("/api/v1/orgs/%s/repos?limit=%d&page=%d", url.PathEscape(g.org), giteaPageSize, page)
+		if err := g.get(ctx, path, &list); err != nil {
+			return nil, err
+		}
+		for _, r := range list {
+			repos = append(repos, Repository(r))
+		}
+		if len(list) < giteaPageSize {
+			return repos, nil
+		}
+	}
+}
+
+func (g *gitea) HasFile(ctx context.Context, repo Repository, ref, path string) (bool, error) {
+	return g.exists(ctx, http.MethodGet, fmt.Sprintf("/api/v1/repos/%s/contents/%s?ref=%s",
+		escapePath(repo.FullName), escapePath(path), url.QueryEscape(ref)))
+}
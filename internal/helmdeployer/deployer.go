@@ -51,6 +51,16 @@ type Helm struct {
 	defaultNamespace string
 	labelPrefix      string
 	labelSuffix      string
+	// valuesResolver resolves valuesFrom entries which are not stored on
+	// the downstream cluster. It is nil if these are not supported.
+	valuesResolver ValuesResolver
+}
+
+// ValuesResolver resolves release values from sources outside of the
+// downstream cluster, like upstream secrets or external secret stores.
+type ValuesResolver interface {
+	UpstreamSecretValues(ctx context.Context, ref *fleet.UpstreamSecretKeySelector) (map[string]interface{}, error)
+	ExternalSecretValues(ctx context.Context, ref *fleet.ExternalSecretRef) (map[string]interface{}, error)
 }
 
 // Resources contains information from a helm release
@@ -81,6 +91,11 @@ func New(agentNamespace, defaultNamespace, labelPrefix, labelSuffix string) *Hel
 	}
 }
 
+// SetValuesResolver enables upstream and external valuesFrom sources.
+func (h *Helm) SetValuesResolver(r ValuesResolver) {
+	h.valuesResolver = r
+}
+
 func (h *Helm) Setup(ctx context.Context, client client.Client, getter genericclioptions.RESTClientGetter) error {
 	h.client = client
 	h.getter = getter
@@ -0,0 +1,350 @@
+// Package imagerewrite rewrites container image references in rendered resources, e.g. to pull images from a
+// private registry mirror on clusters which cannot reach public registries.
+package imagerewrite
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// RewrittenImagesAnnotation is added to resources containing rewritten image references. It holds a JSON object
+	// mapping the original to the rewritten image references.
+	RewrittenImagesAnnotation = "fleet.cattle.io/rewritten-images"
+
+	defaultDomain    = "docker.io"
+	officialRepoName = "library"
+	wildcard         = "*"
+)
+
+var containerFields = []string{"initContainers", "containers", "ephemeralContainers"}
+
+// podSpecPaths returns the location of the pod spec for core workload kinds.
+var podSpecPaths = map[schema.GroupKind][]string{
+	{Group: "", Kind: "Pod"}:                   {"spec"},
+	{Group: "", Kind: "ReplicationController"}: {"spec", "template", "spec"},
+	{Group: "", Kind: "PodTemplate"}:           {"template", "spec"},
+	{Group: "apps", Kind: "Deployment"}:        {"spec", "template", "spec"},
+	{Group: "apps", Kind: "ReplicaSet"}:        {"spec", "template", "spec"},
+	{Group: "apps", Kind: "StatefulSet"}:       {"spec", "template", "spec"},
+	{Group: "apps", Kind: "DaemonSet"}:         {"spec", "template", "spec"},
+	{Group: "batch", Kind: "Job"}:              {"spec", "template", "spec"},
+	{Group: "batch", Kind: "CronJob"}:          {"spec", "jobTemplate", "spec", "template", "spec"},
+	{Group: "extensions", Kind: "Deployment"}:  {"spec", "template", "spec"},
+	{Group: "extensions", Kind: "DaemonSet"}:   {"spec", "template", "spec"},
+	{Group: "extensions", Kind: "ReplicaSet"}:  {"spec", "template", "spec"},
+}
+
+// Rewriter applies image rewrite rules to resources.
+type Rewriter struct {
+	rules []fleet.ImageRewriteRule
+	paths []fleet.ImageRewritePath
+}
+
+// New returns a Rewriter for the given options. It returns nil if opts does not contain any rules.
+func New(opts *fleet.ImageRewrite) (*Rewriter, error) {
+	if opts == nil || len(opts.Rules) == 0 {
+		return nil, nil
+	}
+
+	for _, r := range opts.Rules {
+		if r.From == "" || r.To == "" {
+			return nil, fmt.Errorf("invalid image rewrite rule %q -> %q: from and to must not be empty", r.From, r.To)
+		}
+		if strings.Contains(strings.TrimSuffix(r.From, wildcard), wildcard) {
+			return nil, fmt.Errorf("invalid image rewrite rule %q: only a trailing wildcard is supported", r.From)
+		}
+		if strings.HasSuffix(r.To, wildcard) && !strings.HasSuffix(r.From, wildcard) {
+			return nil, fmt.Errorf("invalid image rewrite rule %q -> %q: to contains a wildcard, but from does not", r.From, r.To)
+		}
+	}
+	for _, p := range opts.Paths {
+		for _, path := range p.Paths {
+			if _, err := parsePath(path); err != nil {
+				return nil, fmt.Errorf("invalid image rewrite path %q for kind %q: %w", path, p.Kind, err)
+			}
+		}
+	}
+
+	return &Rewriter{
+		rules: opts.Rules,
+		paths: opts.Paths,
+	}, nil
+}
+
+// Rewrite rewrites the image references in obj in place. Only unstructured objects are supported, which is what
+// the post renderer produces.
+func (r *Rewriter) Rewrite(obj runtime.Object) error {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil
+	}
+
+	objRewrites := map[string]string{}
+	gvk := u.GroupVersionKind()
+
+	if podSpec, ok := podSpecPaths[gvk.GroupKind()]; ok {
+		for _, field := range containerFields {
+			path := append(append([]string{}, podSpec...), field)
+			containers, found, err := unstructured.NestedSlice(u.Object, path...)
+			if err != nil || !found {
+				continue
+			}
+			for i, c := range containers {
+				container, ok := c.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				image, ok := container["image"].(string)
+				if !ok {
+					continue
+				}
+				if rewritten, ok := r.rewriteImage(image); ok {
+					container["image"] = rewritten
+					containers[i] = container
+					objRewrites[image] = rewritten
+				}
+			}
+			if err := unstructured.SetNestedSlice(u.Object, containers, path...); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, p := range r.paths {
+		if p.Kind != gvk.Kind || (p.APIVersion != "" && p.APIVersion != u.GetAPIVersion()) {
+			continue
+		}
+		for _, path := range p.Paths {
+			segments, err := parsePath(path)
+			if err != nil {
+				return err
+			}
+			walk(u.Object, segments, func(image string) (string, bool) {
+				rewritten, ok := r.rewriteImage(image)
+				if ok {
+					objRewrites[image] = rewritten
+				}
+				return rewritten, ok
+			})
+		}
+	}
+
+	if len(objRewrites) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(objRewrites)
+	if err != nil {
+		return err
+	}
+	annotations := u.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[RewrittenImagesAnnotation] = string(data)
+	u.SetAnnotations(annotations)
+
+	return nil
+}
+
+// FromObjects collects the rewritten images from the annotations of already deployed objects, so they can be
+// reported in the bundle deployment status.
+func FromObjects(objs []runtime.Object) []fleet.RewrittenImage {
+	images := map[string]string{}
+	for _, obj := range objs {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		value, ok := u.GetAnnotations()[RewrittenImagesAnnotation]
+		if !ok {
+			continue
+		}
+		rewrites := map[string]string{}
+		if err := json.Unmarshal([]byte(value), &rewrites); err != nil {
+			continue
+		}
+		for k, v := range rewrites {
+			images[k] = v
+		}
+	}
+
+	if len(images) == 0 {
+		return nil
+	}
+	result := make([]fleet.RewrittenImage, 0, len(images))
+	for k, v := range images {
+		result = append(result, fleet.RewrittenImage{Original: k, Rewritten: v})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Original < result[j].Original
+	})
+
+	return result
+}
+
+// rewriteImage applies the first matching rule to image. It returns false if no rule matches or the image is
+// unchanged.
+func (r *Rewriter) rewriteImage(image string) (string, bool) {
+	if image == "" {
+		return "", false
+	}
+	normalized := Normalize(image)
+	for _, rule := range r.rules {
+		var result string
+		if prefix, ok := strings.CutSuffix(rule.From, wildcard); ok {
+			suffix, found := strings.CutPrefix(normalized, prefix)
+			if !found {
+				continue
+			}
+			if to, ok := strings.CutSuffix(rule.To, wildcard); ok {
+				result = to + suffix
+			} else {
+				result = rule.To
+			}
+		} else {
+			repo, tag := splitTag(normalized)
+			if repo != rule.From {
+				continue
+			}
+			result = rule.To + tag
+		}
+		if result == image {
+			return "", false
+		}
+		return result, true
+	}
+
+	return "", false
+}
+
+// Normalize expands an image reference to its fully qualified form, e.g. "nginx" becomes
+// "docker.io/library/nginx". Tags and digests are preserved.
+func Normalize(image string) string {
+	domain, remainder, found := strings.Cut(image, "/")
+	if !found || (!strings.ContainsAny(domain, ".:") && domain != "localhost" && strings.ToLower(domain) == domain) {
+		domain, remainder = defaultDomain, image
+	}
+	if domain == "index.docker.io" {
+		domain = defaultDomain
+	}
+	if domain == defaultDomain && !strings.Contains(remainder, "/") {
+		remainder = officialRepoName + "/" + remainder
+	}
+
+	return domain + "/" + remainder
+}
+
+// splitTag splits a normalized image reference into the repository and the tag or digest, including its
+// separator.
+func splitTag(image string) (string, string) {
+	if i := strings.Index(image, "@"); i >= 0 {
+		return image[:i], image[i:]
+	}
+	lastSlash := strings.LastIndex(image, "/")
+	if i := strings.LastIndex(image, ":"); i > lastSlash {
+		return image[:i], image[i:]
+	}
+
+	return image, ""
+}
+
+type segment struct {
+	field string
+	// index is -1 if the field is not a list, -2 for all list elements
+	index int
+}
+
+const (
+	noIndex  = -1
+	allIndex = -2
+)
+
+func parsePath(path string) ([]segment, error) {
+	path = strings.TrimPrefix(path, ".")
+	if path == "" {
+		return nil, fmt.Errorf("path is empty")
+	}
+
+	var result []segment
+	for _, s := range strings.Split(path, ".") {
+		field, rest, isList := strings.Cut(s, "[")
+		if field == "" {
+			return nil, fmt.Errorf("empty field name in %q", path)
+		}
+		if !isList {
+			result = append(result, segment{field: field, index: noIndex})
+			continue
+		}
+		idx, ok := strings.CutSuffix(rest, "]")
+		if !ok {
+			return nil, fmt.Errorf("missing closing bracket in %q", s)
+		}
+		if idx == wildcard {
+			result = append(result, segment{field: field, index: allIndex})
+			continue
+		}
+		i, err := strconv.Atoi(idx)
+		if err != nil || i < 0 {
+			return nil, fmt.Errorf("invalid list index in %q", s)
+		}
+		result = append(result, segment{field: field, index: i})
+	}
+
+	return result, nil
+}
+
+// walk follows the path segments in obj and calls fn for every string value found at the end of the path. If fn
+// returns true, the value is replaced.
+func walk(obj map[string]interface{}, segments []segment, fn func(string) (string, bool)) {
+	if len(segments) == 0 {
+		return
+	}
+	s := segments[0]
+	last := len(segments) == 1
+
+	value, ok := obj[s.field]
+	if !ok {
+		return
+	}
+
+	visit := func(v interface{}, set func(interface{})) {
+		if last {
+			if str, ok := v.(string); ok {
+				if newValue, ok := fn(str); ok {
+					set(newValue)
+				}
+			}
+			return
+		}
+		if m, ok := v.(map[string]interface{}); ok {
+			walk(m, segments[1:], fn)
+		}
+	}
+
+	if s.index == noIndex {
+		visit(value, func(v interface{}) { obj[s.field] = v })
+		return
+	}
+
+	list, ok := value.([]interface{})
+	if !ok {
+		return
+	}
+	for i := range list {
+		if s.index != allIndex && s.index != i {
+			continue
+		}
+		visit(list[i], func(v interface{}) { list[i] = v })
+	}
+}
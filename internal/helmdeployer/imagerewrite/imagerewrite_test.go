@@ -0,0 +1,309 @@
+package imagerewrite
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"nginx":                            "docker.io/library/nginx",
+		"nginx:1.25":                       "docker.io/library/nginx:1.25",
+		"rancher/fleet:v0.12":              "docker.io/rancher/fleet:v0.12",
+		"docker.io/rancher/fleet":          "docker.io/rancher/fleet",
+		"index.docker.io/library/busybox":  "docker.io/library/busybox",
+		"ghcr.io/org/app@sha256:abcd":      "ghcr.io/org/app@sha256:abcd",
+		"localhost/app:dev":                "localhost/app:dev",
+		"registry.local:5000/team/app:1.0": "registry.local:5000/team/app:1.0",
+	}
+
+	for image, expected := range tests {
+		if actual := Normalize(image); actual != expected {
+			t.Errorf("Normalize(%q) = %q, expected %q", image, actual, expected)
+		}
+	}
+}
+
+func TestRewriteImage(t *testing.T) {
+	r, err := New(&fleet.ImageRewrite{
+		Rules: []fleet.ImageRewriteRule{
+			{From: "docker.io/library/redis", To: "mirror.local/redis"},
+			{From: "docker.io/*", To: "mirror.local/dockerhub/*"},
+			{From: "quay.io/*", To: "mirror.local/quay/*"},
+			{From: "ghcr.io/org/pinned:*", To: "mirror.local/pinned:1.0"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		image    string
+		expected string
+		ok       bool
+	}{
+		"short name":           {image: "nginx:1.25", expected: "mirror.local/dockerhub/library/nginx:1.25", ok: true},
+		"docker hub org":       {image: "rancher/fleet:v0.12", expected: "mirror.local/dockerhub/rancher/fleet:v0.12", ok: true},
+		"exact repo keeps tag": {image: "redis:7", expected: "mirror.local/redis:7", ok: true},
+		"exact repo digest":    {image: "redis@sha256:abcd", expected: "mirror.local/redis@sha256:abcd", ok: true},
+		"other registry":       {image: "quay.io/jetstack/cert-manager:v1", expected: "mirror.local/quay/jetstack/cert-manager:v1", ok: true},
+		"fixed replacement":    {image: "ghcr.io/org/pinned:2.0", expected: "mirror.local/pinned:1.0", ok: true},
+		"no match":             {image: "registry.k8s.io/pause:3.9", ok: false},
+		"empty":                {image: "", ok: false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual, ok := r.rewriteImage(tc.image)
+			if ok != tc.ok {
+				t.Fatalf("expected ok=%v, got %v", tc.ok, ok)
+			}
+			if actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := map[string]struct {
+		opts    *fleet.ImageRewrite
+		wantNil bool
+		wantErr bool
+	}{
+		"nil options": {opts: nil, wantNil: true},
+		"no rules":    {opts: &fleet.ImageRewrite{}, wantNil: true},
+		"empty from": {
+			opts:    &fleet.ImageRewrite{Rules: []fleet.ImageRewriteRule{{From: "", To: "mirror.local/*"}}},
+			wantErr: true,
+		},
+		"wildcard in the middle": {
+			opts:    &fleet.ImageRewrite{Rules: []fleet.ImageRewriteRule{{From: "docker.io/*/app", To: "mirror.local/app"}}},
+			wantErr: true,
+		},
+		"wildcard only in to": {
+			opts:    &fleet.ImageRewrite{Rules: []fleet.ImageRewriteRule{{From: "docker.io/app", To: "mirror.local/*"}}},
+			wantErr: true,
+		},
+		"invalid path": {
+			opts: &fleet.ImageRewrite{
+				Rules: []fleet.ImageRewriteRule{{From: "docker.io/*", To: "mirror.local/*"}},
+				Paths: []fleet.ImageRewritePath{{Kind: "App", Paths: []string{"spec.images[x]"}}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			r, err := New(tc.opts)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, err)
+			}
+			if tc.wantNil && r != nil {
+				t.Errorf("expected nil rewriter")
+			}
+		})
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	r, err := New(&fleet.ImageRewrite{
+		Rules: []fleet.ImageRewriteRule{{From: "docker.io/*", To: "mirror.local/dockerhub/*"}},
+		Paths: []fleet.ImageRewritePath{
+			{APIVersion: "example.com/v1", Kind: "App", Paths: []string{"spec.image", "spec.sidecars[*].image"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		obj      map[string]interface{}
+		expected map[string]interface{}
+	}{
+		"deployment": {
+			obj: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata":   map[string]interface{}{"name": "app"},
+				"spec": map[string]interface{}{
+					"template": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"initContainers": []interface{}{
+								map[string]interface{}{"name": "init", "image": "busybox"},
+							},
+							"containers": []interface{}{
+								map[string]interface{}{"name": "app", "image": "nginx:1.25"},
+								map[string]interface{}{"name": "other", "image": "quay.io/org/app:1"},
+							},
+						},
+					},
+				},
+			},
+			expected: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata": map[string]interface{}{
+					"name": "app",
+					"annotations": map[string]interface{}{
+						RewrittenImagesAnnotation: `{"busybox":"mirror.local/dockerhub/library/busybox","nginx:1.25":"mirror.local/dockerhub/library/nginx:1.25"}`,
+					},
+				},
+				"spec": map[string]interface{}{
+					"template": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"initContainers": []interface{}{
+								map[string]interface{}{"name": "init", "image": "mirror.local/dockerhub/library/busybox"},
+							},
+							"containers": []interface{}{
+								map[string]interface{}{"name": "app", "image": "mirror.local/dockerhub/library/nginx:1.25"},
+								map[string]interface{}{"name": "other", "image": "quay.io/org/app:1"},
+							},
+						},
+					},
+				},
+			},
+		},
+		"cronjob": {
+			obj: map[string]interface{}{
+				"apiVersion": "batch/v1",
+				"kind":       "CronJob",
+				"metadata":   map[string]interface{}{"name": "job"},
+				"spec": map[string]interface{}{
+					"jobTemplate": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"template": map[string]interface{}{
+								"spec": map[string]interface{}{
+									"containers": []interface{}{
+										map[string]interface{}{"name": "job", "image": "alpine:3"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: map[string]interface{}{
+				"apiVersion": "batch/v1",
+				"kind":       "CronJob",
+				"metadata": map[string]interface{}{
+					"name": "job",
+					"annotations": map[string]interface{}{
+						RewrittenImagesAnnotation: `{"alpine:3":"mirror.local/dockerhub/library/alpine:3"}`,
+					},
+				},
+				"spec": map[string]interface{}{
+					"jobTemplate": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"template": map[string]interface{}{
+								"spec": map[string]interface{}{
+									"containers": []interface{}{
+										map[string]interface{}{"name": "job", "image": "mirror.local/dockerhub/library/alpine:3"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"custom resource": {
+			obj: map[string]interface{}{
+				"apiVersion": "example.com/v1",
+				"kind":       "App",
+				"metadata":   map[string]interface{}{"name": "app"},
+				"spec": map[string]interface{}{
+					"image": "app:1",
+					"sidecars": []interface{}{
+						map[string]interface{}{"image": "envoy:1"},
+						map[string]interface{}{"image": "quay.io/org/proxy:1"},
+					},
+				},
+			},
+			expected: map[string]interface{}{
+				"apiVersion": "example.com/v1",
+				"kind":       "App",
+				"metadata": map[string]interface{}{
+					"name": "app",
+					"annotations": map[string]interface{}{
+						RewrittenImagesAnnotation: `{"app:1":"mirror.local/dockerhub/library/app:1","envoy:1":"mirror.local/dockerhub/library/envoy:1"}`,
+					},
+				},
+				"spec": map[string]interface{}{
+					"image": "mirror.local/dockerhub/library/app:1",
+					"sidecars": []interface{}{
+						map[string]interface{}{"image": "mirror.local/dockerhub/library/envoy:1"},
+						map[string]interface{}{"image": "quay.io/org/proxy:1"},
+					},
+				},
+			},
+		},
+		"custom resource with other api version": {
+			obj: map[string]interface{}{
+				"apiVersion": "example.com/v2",
+				"kind":       "App",
+				"metadata":   map[string]interface{}{"name": "app"},
+				"spec":       map[string]interface{}{"image": "app:1"},
+			},
+			expected: map[string]interface{}{
+				"apiVersion": "example.com/v2",
+				"kind":       "App",
+				"metadata":   map[string]interface{}{"name": "app"},
+				"spec":       map[string]interface{}{"image": "app:1"},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{Object: tc.obj}
+			if err := r.Rewrite(obj); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.expected, obj.Object); diff != "" {
+				t.Errorf("unexpected object (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFromObjects(t *testing.T) {
+	objs := []runtime.Object{
+		&unstructured.Unstructured{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{
+					RewrittenImagesAnnotation: `{"nginx":"mirror.local/nginx"}`,
+				},
+			},
+		}},
+		&unstructured.Unstructured{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{
+					RewrittenImagesAnnotation: `{"busybox":"mirror.local/busybox","nginx":"mirror.local/nginx"}`,
+				},
+			},
+		}},
+		&unstructured.Unstructured{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "no-annotations"},
+		}},
+	}
+
+	expected := []fleet.RewrittenImage{
+		{Original: "busybox", Rewritten: "mirror.local/busybox"},
+		{Original: "nginx", Rewritten: "mirror.local/nginx"},
+	}
+	if diff := cmp.Diff(expected, FromObjects(objs)); diff != "" {
+		t.Errorf("unexpected images (-want +got):\n%s", diff)
+	}
+
+	if images := FromObjects(nil); images != nil {
+		t.Errorf("expected nil, got %v", images)
+	}
+}
@@ -51,6 +51,12 @@ type impersonatingGetter struct {
 }
 
 func newImpersonatingGetter(namespace, name string, getter genericclioptions.RESTClientGetter) (genericclioptions.RESTClientGetter, error) {
+	// without an agent, the service account is impersonated on the cluster
+	// of the kubeconfig, not with the credentials of the pod
+	if kg, ok := getter.(*kubeConfigGetter); ok {
+		return kg.impersonate(namespace, name), nil
+	}
+
 	config := clientcmd.NewDefaultClientConfig(impersonationConfig(namespace, name), &clientcmd.ConfigOverrides{})
 
 	restConfig, err := config.ClientConfig()
@@ -17,8 +17,11 @@ import (
 	releasev1 "helm.sh/helm/v4/pkg/release/v1"
 	"helm.sh/helm/v4/pkg/storage/driver"
 
+	"github.com/rancher/fleet/internal/clustertemplate"
 	"github.com/rancher/fleet/internal/experimental"
+	"github.com/rancher/fleet/internal/helmdeployer/imagerewrite"
 	"github.com/rancher/fleet/internal/helmdeployer/render"
+	"github.com/rancher/fleet/internal/helmdeployer/sops"
 	"github.com/rancher/fleet/internal/manifest"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 
@@ -42,6 +45,16 @@ func (h *Helm) Deploy(ctx context.Context, bundleID string, manifest *manifest.M
 		options.Kustomize = &fleet.KustomizeOptions{}
 	}
 
+	manifest, options, err := h.decrypt(ctx, manifest, options)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err = clustertemplate.RenderManifest(manifest, options)
+	if err != nil {
+		return nil, err
+	}
+
 	tar, err := render.HelmChart(bundleID, manifest, options)
 	if err != nil {
 		return nil, err
@@ -73,6 +86,48 @@ func (h *Helm) Deploy(ctx context.Context, bundleID string, manifest *manifest.M
 	return h.install(ctx, bundleID, manifest, chart, options, getDryRunConfig(chart, false))
 }
 
+// decrypt decrypts SOPS encrypted resources of the manifest and merges the values of encrypted values files into a
+// copy of options. Decryption happens before anything is installed, so a missing key does not lead to a partially
+// applied release.
+func (h *Helm) decrypt(ctx context.Context, m *manifest.Manifest, options fleet.BundleDeploymentOptions) (*manifest.Manifest, fleet.BundleDeploymentOptions, error) {
+	result, err := sops.DecryptManifest(m, options, func() (*sops.Decryptor, error) {
+		if options.Sops == nil || options.Sops.SecretName == "" {
+			return nil, fmt.Errorf("%w: bundle contains encrypted files, but no sops.secretName is configured", sops.ErrMissingKey)
+		}
+		if h.client == nil {
+			return nil, fmt.Errorf("%w: cannot read secret %s without a cluster connection", sops.ErrMissingKey, options.Sops.SecretName)
+		}
+		namespace := options.Sops.Namespace
+		if namespace == "" {
+			namespace = h.agentNamespace
+		}
+		secret := &corev1.Secret{}
+		if err := h.client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: options.Sops.SecretName}, secret); err != nil {
+			return nil, fmt.Errorf("%w: failed to get secret %s/%s: %w", sops.ErrMissingKey, namespace, options.Sops.SecretName, err)
+		}
+		return sops.NewDecryptor(secret)
+	})
+	if err != nil {
+		return nil, options, err
+	}
+	if len(result.Values) == 0 {
+		return result.Manifest, options, nil
+	}
+
+	options = *options.DeepCopy()
+	if options.Helm.Values == nil {
+		options.Helm.Values = &fleet.GenericMap{}
+	}
+	if options.Helm.Values.Data == nil {
+		options.Helm.Values.Data = map[string]interface{}{}
+	}
+	for _, values := range result.Values {
+		options.Helm.Values.Data = mergeValues(options.Helm.Values.Data, values)
+	}
+
+	return result.Manifest, options, nil
+}
+
 // install runs helm install or upgrade and supports dry running the action. Will run helm rollback in case of a failed upgrade.
 func (h *Helm) install(ctx context.Context, bundleID string, manifest *manifest.Manifest, chart *chartv2.Chart, options fleet.BundleDeploymentOptions, dryRunCfg dryRunConfig) (*releasev1.Release, error) {
 	logger := log.FromContext(ctx).WithName("helm-deployer").WithName("install").WithValues("commit", manifest.Commit, "dryRun", dryRunCfg.DryRun)
@@ -124,7 +179,7 @@ func (h *Helm) install(ctx context.Context, bundleID string, manifest *manifest.
 }
 
 // createPostRenderer creates a post-renderer for Helm charts that handles label/annotation
-// transformations and CRD deletion policies based on Fleet bundle deployment options.
+// transformations, image rewrites and CRD deletion policies based on Fleet bundle deployment options.
 func (h *Helm) createPostRenderer(cfg *action.Configuration, bundleID string, manifest *manifest.Manifest, chart *chartv2.Chart, options fleet.BundleDeploymentOptions) (*postRender, error) {
 	pr := &postRender{
 		labelPrefix: h.labelPrefix,
@@ -135,6 +190,12 @@ func (h *Helm) createPostRenderer(cfg *action.Configuration, bundleID string, ma
 		chart:       chart,
 	}
 
+	rewriter, err := imagerewrite.New(options.ImageRewrite)
+	if err != nil {
+		return nil, err
+	}
+	pr.imageRewriter = rewriter
+
 	if !h.useGlobalCfg {
 		mapper, err := cfg.RESTClientGetter.ToRESTMapper()
 		if err != nil {
@@ -427,6 +488,24 @@ func (h *Helm) getValues(ctx context.Context, options fleet.BundleDeploymentOpti
 			if tempValues != nil {
 				values = mergeValues(values, tempValues)
 			}
+
+			if (valuesFrom.UpstreamSecretKeyRef != nil || valuesFrom.ExternalSecretRef != nil) && h.valuesResolver == nil {
+				return nil, errors.New("valuesFrom upstream secrets and external secret stores are only supported by the agent")
+			}
+			if valuesFrom.UpstreamSecretKeyRef != nil {
+				tempValues, err := h.valuesResolver.UpstreamSecretValues(ctx, valuesFrom.UpstreamSecretKeyRef)
+				if err != nil {
+					return nil, err
+				}
+				values = mergeValues(values, tempValues)
+			}
+			if valuesFrom.ExternalSecretRef != nil {
+				tempValues, err := h.valuesResolver.ExternalSecretValues(ctx, valuesFrom.ExternalSecretRef)
+				if err != nil {
+					return nil, err
+				}
+				values = mergeValues(values, tempValues)
+			}
 		}
 	}
 
@@ -672,3 +672,40 @@ func TestValuesFromErrorWhenCopiedDownstreamButExperimentalDisabled(t *testing.T
 	// get will fail trying to read from provided-ns and should report not found
 	a.True(apierrors.IsNotFound(err), "expected a NotFound error when valuesFrom references resources and experimental feature is disabled")
 }
+
+type fakeValuesResolver struct{}
+
+func (fakeValuesResolver) UpstreamSecretValues(_ context.Context, ref *fleet.UpstreamSecretKeySelector) (map[string]interface{}, error) {
+	return map[string]interface{}{"upstream": ref.Name, "shared": "upstream"}, nil
+}
+
+func (fakeValuesResolver) ExternalSecretValues(_ context.Context, ref *fleet.ExternalSecretRef) (map[string]interface{}, error) {
+	return map[string]interface{}{"external": ref.Path, "shared": "external"}, nil
+}
+
+func TestValuesFromUpstreamAndExternalSources(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	opts := fleet.BundleDeploymentOptions{
+		Helm: &fleet.HelmOptions{
+			Values: &fleet.GenericMap{Data: map[string]interface{}{"shared": "values"}},
+			ValuesFrom: []fleet.ValuesFrom{
+				{UpstreamSecretKeyRef: &fleet.UpstreamSecretKeySelector{LocalObjectReference: fleet.LocalObjectReference{Name: "upstream-secret"}}},
+				{ExternalSecretRef: &fleet.ExternalSecretRef{StoreSecretName: "vault", Path: "app/config"}},
+			},
+		},
+	}
+
+	h := &Helm{client: fake.NewClientBuilder().Build()}
+	_, err := h.getValues(context.TODO(), opts, "default")
+	r.Error(err, "expected an error without a values resolver")
+
+	h.SetValuesResolver(fakeValuesResolver{})
+	vals, err := h.getValues(context.TODO(), opts, "default")
+	r.NoError(err)
+	a.Equal("upstream-secret", vals["upstream"])
+	a.Equal("app/config", vals["external"])
+	// sources are merged in order
+	a.Equal("external", vals["shared"])
+}
@@ -0,0 +1,78 @@
+package helmdeployer
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// kubeConfigGetter is a RESTClientGetter for a cluster which is accessed with
+// a kubeconfig, instead of the in-cluster config of the pod. It is used to
+// deploy to clusters without an agent.
+type kubeConfigGetter struct {
+	config     clientcmd.ClientConfig
+	restConfig *rest.Config
+	discovery  discovery.CachedDiscoveryInterface
+	mapper     meta.RESTMapper
+}
+
+var _ genericclioptions.RESTClientGetter = &kubeConfigGetter{}
+
+// NewKubeConfigGetter returns a RESTClientGetter for the cluster in the
+// kubeconfig. Releases which use a service account impersonate it on that
+// cluster.
+func NewKubeConfigGetter(config clientcmd.ClientConfig) (genericclioptions.RESTClientGetter, error) {
+	restConfig, err := config.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	dc, err := discovery.NewDiscoveryClientForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	cached := memory.NewMemCacheClient(dc)
+
+	return &kubeConfigGetter{
+		config:     config,
+		restConfig: restConfig,
+		discovery:  cached,
+		mapper:     restmapper.NewShortcutExpander(restmapper.NewDeferredDiscoveryRESTMapper(cached), cached, nil),
+	}, nil
+}
+
+func (g *kubeConfigGetter) ToRESTConfig() (*rest.Config, error) {
+	return rest.CopyConfig(g.restConfig), nil
+}
+
+func (g *kubeConfigGetter) ToDiscoveryClient() (discovery.CachedDiscoveryInterface, error) {
+	return g.discovery, nil
+}
+
+func (g *kubeConfigGetter) ToRESTMapper() (meta.RESTMapper, error) {
+	return g.mapper, nil
+}
+
+func (g *kubeConfigGetter) ToRawKubeConfigLoader() clientcmd.ClientConfig {
+	return g.config
+}
+
+// impersonate returns a getter for the same cluster, which impersonates the
+// service account.
+func (g *kubeConfigGetter) impersonate(namespace, name string) *kubeConfigGetter {
+	restConfig := rest.CopyConfig(g.restConfig)
+	restConfig.Impersonate = rest.ImpersonationConfig{
+		UserName: fmt.Sprintf("system:serviceaccount:%s:%s", namespace, name),
+	}
+	return &kubeConfigGetter{
+		config:     g.config,
+		restConfig: restConfig,
+		discovery:  g.discovery,
+		mapper:     g.mapper,
+	}
+}
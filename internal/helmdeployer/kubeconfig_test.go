@@ -0,0 +1,54 @@
+package helmdeployer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const testKubeConfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: downstream
+  cluster:
+    server: https://downstream.example.com:6443
+contexts:
+- name: downstream
+  context:
+    cluster: downstream
+    user: admin
+current-context: downstream
+users:
+- name: admin
+  user:
+    token: secret
+`
+
+func TestKubeConfigGetter(t *testing.T) {
+	config, err := clientcmd.NewClientConfigFromBytes([]byte(testKubeConfig))
+	require.NoError(t, err)
+	getter, err := NewKubeConfigGetter(config)
+	require.NoError(t, err)
+
+	restConfig, err := getter.ToRESTConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "https://downstream.example.com:6443", restConfig.Host)
+	assert.Equal(t, "secret", restConfig.BearerToken)
+	assert.Empty(t, restConfig.Impersonate.UserName)
+
+	// the service account is impersonated on the cluster of the kubeconfig
+	impersonating, err := newImpersonatingGetter("cattle-fleet-system", "fleet-default", getter)
+	require.NoError(t, err)
+	restConfig, err = impersonating.ToRESTConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "https://downstream.example.com:6443", restConfig.Host)
+	assert.Equal(t, "system:serviceaccount:cattle-fleet-system:fleet-default", restConfig.Impersonate.UserName)
+
+	// the original getter is not modified
+	restConfig, err = getter.ToRESTConfig()
+	require.NoError(t, err)
+	assert.Empty(t, restConfig.Impersonate.UserName)
+}
@@ -10,6 +10,7 @@ import (
 	chartv2 "helm.sh/helm/v4/pkg/chart/v2"
 
 	"github.com/rancher/fleet/internal/cmd/agent/deployer/desiredset"
+	"github.com/rancher/fleet/internal/helmdeployer/imagerewrite"
 	"github.com/rancher/fleet/internal/helmdeployer/kustomize"
 	"github.com/rancher/fleet/internal/helmdeployer/rawyaml"
 	"github.com/rancher/fleet/internal/manifest"
@@ -30,6 +31,8 @@ type postRender struct {
 	chart       *chartv2.Chart
 	mapper      meta.RESTMapper
 	opts        fleet.BundleDeploymentOptions
+	// imageRewriter is nil if no image rewrite rules are configured
+	imageRewriter *imagerewrite.Rewriter
 }
 
 func (p *postRender) Run(renderedManifests *bytes.Buffer) (modifiedManifests *bytes.Buffer, err error) {
@@ -91,6 +94,12 @@ func (p *postRender) Run(renderedManifests *bytes.Buffer) (modifiedManifests *by
 		m.SetLabels(mergeMaps(m.GetLabels(), labels))
 		m.SetAnnotations(objAnnotations)
 
+		if p.imageRewriter != nil {
+			if err := p.imageRewriter.Rewrite(obj); err != nil {
+				return nil, err
+			}
+		}
+
 		if p.opts.TargetNamespace != "" {
 			if p.mapper != nil {
 				gvk := obj.GetObjectKind().GroupVersionKind()
@@ -0,0 +1,273 @@
+// Package sops decrypts SOPS encrypted files in bundle resources. Files stay encrypted in contents and OCI storage,
+// they are only decrypted by the agent right before rendering the release.
+//
+// The file format is decoded in tree.go instead of using github.com/getsops/sops/v3/decrypt. That package imports
+// cmd/sops/common and with it the key service, which links the AWS, GCP, Azure and Vault KMS clients into the agent.
+// It also reads age identities and PGP keys only from the environment, i.e. SOPS_AGE_KEY and GNUPGHOME, so the keys
+// of a bundle's secret would have to be set process wide, racing with concurrent deployments. Only age and PGP keys
+// are supported here, and tree.go is tested against files encrypted by the sops command line tool.
+package sops
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	pgparmor "github.com/ProtonMail/go-crypto/openpgp/armor"
+
+	"github.com/rancher/fleet/internal/content"
+	"github.com/rancher/fleet/internal/manifest"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// AgeKeySuffix is the suffix of secret keys containing age identities.
+	AgeKeySuffix = ".agekey"
+	// PGPKeySuffix is the suffix of secret keys containing armored PGP private keys.
+	PGPKeySuffix = ".asc"
+)
+
+// ErrMissingKey is returned if encrypted files are found, but no key is configured to decrypt them.
+var ErrMissingKey = errors.New("SOPS decryption key is missing")
+
+// format returns the format of the file name, or an empty string if the format is not supported.
+func format(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".json":
+		return "json"
+	}
+	return ""
+}
+
+// IsEncrypted returns true if data is a SOPS encrypted YAML or JSON document.
+func IsEncrypted(name string, data []byte) bool {
+	if format(name) == "" {
+		return false
+	}
+	var doc struct {
+		Sops *struct {
+			MAC          string `json:"mac"`
+			LastModified string `json:"lastmodified"`
+		} `json:"sops"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return false
+	}
+	return doc.Sops != nil && doc.Sops.MAC != "" && doc.Sops.LastModified != ""
+}
+
+// Decryptor decrypts SOPS files with age identities and PGP keys.
+type Decryptor struct {
+	ageIdentities []age.Identity
+	pgpKeys       openpgp.EntityList
+}
+
+// NewDecryptor reads the age identities and PGP keys from secret. Keys ending in AgeKeySuffix contain age
+// identities, keys ending in PGPKeySuffix contain armored PGP private keys.
+func NewDecryptor(secret *corev1.Secret) (*Decryptor, error) {
+	d := &Decryptor{}
+	for name, value := range secret.Data {
+		switch {
+		case strings.HasSuffix(name, AgeKeySuffix):
+			identities, err := age.ParseIdentities(bytes.NewReader(value))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read age identities from %s/%s key %q: %w", secret.Namespace, secret.Name, name, err)
+			}
+			d.ageIdentities = append(d.ageIdentities, identities...)
+		case strings.HasSuffix(name, PGPKeySuffix):
+			keys, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(value))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read PGP keys from %s/%s key %q: %w", secret.Namespace, secret.Name, name, err)
+			}
+			d.pgpKeys = append(d.pgpKeys, keys...)
+		}
+	}
+
+	if len(d.ageIdentities) == 0 && len(d.pgpKeys) == 0 {
+		return nil, fmt.Errorf("%w: secret %s/%s does not contain any keys ending in %q or %q",
+			ErrMissingKey, secret.Namespace, secret.Name, AgeKeySuffix, PGPKeySuffix)
+	}
+
+	return d, nil
+}
+
+// Decrypt decrypts the SOPS encrypted file and verifies its integrity. Comments are removed from the decrypted file.
+func (d *Decryptor) Decrypt(name string, data []byte) ([]byte, error) {
+	f := format(name)
+	if f == "" {
+		return nil, fmt.Errorf("unsupported format for SOPS encrypted file %s", name)
+	}
+
+	t, err := loadTree(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load SOPS encrypted file %s: %w", name, err)
+	}
+
+	key, err := d.dataKey(t.metadata)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get data key of %s: %w", ErrMissingKey, name, err)
+	}
+
+	if err := t.decrypt(key); err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w", name, err)
+	}
+
+	if f == "json" {
+		return t.emitJSON()
+	}
+	return t.emitYAML()
+}
+
+// dataKey decrypts the data key of a file with the first age or PGP key that succeeds. Files with multiple key
+// groups, which split the data key with Shamir's secret sharing, are not supported.
+func (d *Decryptor) dataKey(m metadata) ([]byte, error) {
+	group := keyGroup{Age: m.Age, PGP: m.PGP}
+	switch len(m.KeyGroups) {
+	case 0:
+	case 1:
+		group = m.KeyGroups[0]
+	default:
+		return nil, fmt.Errorf("files with %d key groups are not supported", len(m.KeyGroups))
+	}
+	if len(group.Age) == 0 && len(group.PGP) == 0 {
+		return nil, errors.New("file is not encrypted with age or PGP keys, other key types are not supported")
+	}
+
+	var errs []error
+	if len(group.Age) > 0 && len(d.ageIdentities) == 0 {
+		errs = append(errs, errors.New("no age identities configured"))
+	} else {
+		for _, k := range group.Age {
+			key, err := d.decryptAge(k)
+			if err == nil {
+				return key, nil
+			}
+			errs = append(errs, fmt.Errorf("age recipient %s: %w", k.Recipient, err))
+		}
+	}
+	if len(group.PGP) > 0 && len(d.pgpKeys) == 0 {
+		errs = append(errs, errors.New("no PGP keys configured"))
+	} else {
+		for _, k := range group.PGP {
+			key, err := d.decryptPGP(k)
+			if err == nil {
+				return key, nil
+			}
+			errs = append(errs, fmt.Errorf("PGP key %s: %w", k.Fingerprint, err))
+		}
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+func (d *Decryptor) decryptAge(k ageKey) ([]byte, error) {
+	r, err := age.Decrypt(armor.NewReader(strings.NewReader(k.Enc)), d.ageIdentities...)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func (d *Decryptor) decryptPGP(k pgpKey) ([]byte, error) {
+	block, err := pgparmor.Decode(strings.NewReader(k.Enc))
+	if err != nil {
+		return nil, fmt.Errorf("armor decoding failed: %w", err)
+	}
+	md, err := openpgp.ReadMessage(block.Body, d.pgpKeys, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PGP message: %w", err)
+	}
+	return io.ReadAll(md.UnverifiedBody)
+}
+
+// Result contains the decrypted manifest and the values of encrypted values files.
+type Result struct {
+	Manifest *manifest.Manifest
+	// Values contains the values of the encrypted values files, in the order of SopsValuesFiles.
+	Values []map[string]interface{}
+}
+
+// DecryptManifest decrypts all SOPS encrypted resources of m. The resources listed in the SopsValuesFiles helm
+// option are removed from the returned manifest and their values are returned instead. getDecryptor is only called
+// if encrypted resources are found. m is not modified and returned as is, if it does not contain encrypted resources.
+func DecryptManifest(m *manifest.Manifest, opts fleet.BundleDeploymentOptions, getDecryptor func() (*Decryptor, error)) (*Result, error) {
+	var valuesFiles []string
+	if opts.Helm != nil {
+		for _, f := range opts.Helm.SopsValuesFiles {
+			f = path.Clean(f)
+			if !slices.Contains(valuesFiles, f) {
+				valuesFiles = append(valuesFiles, f)
+			}
+		}
+	}
+
+	var (
+		decryptor *Decryptor
+		modified  bool
+		resources = make([]fleet.BundleResource, 0, len(m.Resources))
+		values    = make([]map[string]interface{}, len(valuesFiles))
+		found     = 0
+	)
+	for _, resource := range m.Resources {
+		data, err := content.Decode(resource.Content, resource.Encoding)
+		if err != nil {
+			return nil, err
+		}
+
+		idx := slices.Index(valuesFiles, path.Clean(resource.Name))
+		encrypted := IsEncrypted(resource.Name, data)
+		if idx < 0 && !encrypted {
+			resources = append(resources, resource)
+			continue
+		}
+		modified = true
+
+		if encrypted {
+			if decryptor == nil {
+				if decryptor, err = getDecryptor(); err != nil {
+					return nil, err
+				}
+			}
+			if data, err = decryptor.Decrypt(resource.Name, data); err != nil {
+				return nil, err
+			}
+		}
+
+		if idx >= 0 {
+			found++
+			if err := yaml.Unmarshal(data, &values[idx]); err != nil {
+				return nil, fmt.Errorf("failed to read values file %s: %w", resource.Name, err)
+			}
+			continue
+		}
+
+		resources = append(resources, fleet.BundleResource{
+			Name:    resource.Name,
+			Content: string(data),
+		})
+	}
+
+	if found != len(valuesFiles) {
+		return nil, fmt.Errorf("encrypted values files %v not found in bundle resources", valuesFiles)
+	}
+	if !modified {
+		return &Result{Manifest: m}, nil
+	}
+
+	result := manifest.New(resources)
+	result.Commit = m.Commit
+
+	return &Result{Manifest: result, Values: values}, nil
+}
@@ -0,0 +1,497 @@
+package sops
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	pgparmor "github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/google/go-cmp/cmp"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/rancher/fleet/internal/manifest"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const secretYAML = `apiVersion: v1
+kind: Secret
+metadata:
+    name: db
+stringData:
+    password: s3cr3t
+`
+
+type testKeys struct {
+	age    *age.X25519Identity
+	pgp    *openpgp.Entity
+	secret *corev1.Secret
+}
+
+func newTestKeys(t *testing.T) testKeys {
+	t.Helper()
+
+	ageIdentity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entity, err := openpgp.NewEntity("fleet", "", "fleet@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pgpKey bytes.Buffer
+	w, err := pgparmor.Encode(&pgpKey, openpgp.PrivateKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.SerializePrivate(w, nil); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	return testKeys{
+		age: ageIdentity,
+		pgp: entity,
+		secret: &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "sops-keys", Namespace: "cattle-fleet-system"},
+			Data: map[string][]byte{
+				"identity.agekey": []byte(ageIdentity.String()),
+				"private.asc":     pgpKey.Bytes(),
+			},
+		},
+	}
+}
+
+// encrypt encrypts the YAML document plain like `sops --encrypt` would, for the age identity and/or the PGP key.
+func encrypt(t *testing.T, plain string, ageIdentity *age.X25519Identity, entity *openpgp.Entity) string {
+	t.Helper()
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		t.Fatal(err)
+	}
+
+	var m metadata
+	if ageIdentity != nil {
+		var buf bytes.Buffer
+		aw := armor.NewWriter(&buf)
+		w, err := age.Encrypt(aw, ageIdentity.Recipient())
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write(dataKey)
+		w.Close()
+		aw.Close()
+		m.Age = append(m.Age, ageKey{Recipient: ageIdentity.Recipient().String(), Enc: buf.String()})
+	}
+	if entity != nil {
+		var buf bytes.Buffer
+		aw, err := pgparmor.Encode(&buf, "PGP MESSAGE", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pw, err := openpgp.Encrypt(aw, openpgp.EntityList{entity}, nil, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = pw.Write(dataKey)
+		pw.Close()
+		aw.Close()
+		m.PGP = append(m.PGP, pgpKey{Fingerprint: strings.ToUpper(hex.EncodeToString(entity.PrimaryKey.Fingerprint)), Enc: buf.String()})
+	}
+
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal([]byte(plain), doc); err != nil {
+		t.Fatal(err)
+	}
+	h := sha512.New()
+	var walk func(node *yaml.Node, path []string)
+	walk = func(node *yaml.Node, path []string) {
+		switch node.Kind {
+		case yaml.DocumentNode, yaml.SequenceNode:
+			for _, n := range node.Content {
+				walk(n, path)
+			}
+		case yaml.MappingNode:
+			for i := 0; i < len(node.Content); i += 2 {
+				walk(node.Content[i+1], append(slices.Clone(path), node.Content[i].Value))
+			}
+		case yaml.ScalarNode:
+			var value interface{}
+			if err := node.Decode(&value); err != nil {
+				t.Fatal(err)
+			}
+			b, err := toBytes(value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			h.Write(b)
+			if strings.HasSuffix(path[len(path)-1], defaultUnencryptedSuffix) {
+				return
+			}
+			typ := map[string]string{"!!str": "str", "!!int": "int", "!!float": "float", "!!bool": "bool"}[node.Tag]
+			node.SetString(encryptValue(t, dataKey, node.Value, typ, strings.Join(path, ":")+":"))
+		}
+	}
+	walk(doc, nil)
+
+	m.LastModified = time.Now().UTC().Format(time.RFC3339)
+	m.MAC = encryptValue(t, dataKey, fmt.Sprintf("%X", h.Sum(nil)), "str", m.LastModified)
+	m.UnencryptedSuffix = defaultUnencryptedSuffix
+	var sopsNode yaml.Node
+	if err := sopsNode.Encode(m); err != nil {
+		t.Fatal(err)
+	}
+	root := doc.Content[0]
+	root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: metadataKey}, &sopsNode)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(4)
+	if err := enc.Encode(doc); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// encryptValue encrypts a value with AES-256-GCM, like SOPS does.
+func encryptValue(t *testing.T, key []byte, value, typ, additionalData string) string {
+	t.Helper()
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iv := make([]byte, 32)
+	if _, err := rand.Read(iv); err != nil {
+		t.Fatal(err)
+	}
+	out := gcm.Seal(nil, iv, []byte(value), []byte(additionalData))
+	data, tag := out[:len(out)-gcm.Overhead()], out[len(out)-gcm.Overhead():]
+	b64 := base64.StdEncoding.EncodeToString
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:%s]", b64(data), b64(iv), b64(tag), typ)
+}
+
+// fixtureSecret returns a secret with the given keys from testdata, which were used to encrypt the fixtures.
+func fixtureSecret(t *testing.T, keys ...string) *corev1.Secret {
+	t.Helper()
+
+	secret := &corev1.Secret{Data: map[string][]byte{}}
+	for _, name := range keys {
+		b, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		secret.Data[name] = b
+	}
+	return secret
+}
+
+func TestIsEncrypted(t *testing.T) {
+	k := newTestKeys(t)
+	encrypted := encrypt(t, secretYAML, k.age, nil)
+	encryptedJSON, err := os.ReadFile(filepath.Join("testdata", "values.sops.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		name     string
+		data     string
+		expected bool
+	}{
+		"encrypted yaml":       {name: "secret.yaml", data: encrypted, expected: true},
+		"encrypted json":       {name: "values.json", data: string(encryptedJSON), expected: true},
+		"plain yaml":           {name: "secret.yaml", data: secretYAML},
+		"unsupported format":   {name: "secret.txt", data: encrypted},
+		"sops key without mac": {name: "values.yaml", data: "sops:\n  version: 3.10.2\n"},
+		"invalid yaml":         {name: "values.yaml", data: "{"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if actual := IsEncrypted(tc.name, []byte(tc.data)); actual != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDecrypt(t *testing.T) {
+	k := newTestKeys(t)
+	d, err := NewDecryptor(k.secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		ageIdentity *age.X25519Identity
+		entity      *openpgp.Entity
+	}{
+		"age":         {ageIdentity: k.age},
+		"pgp":         {entity: k.pgp},
+		"age and pgp": {ageIdentity: k.age, entity: k.pgp},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			encrypted := encrypt(t, secretYAML, tc.ageIdentity, tc.entity)
+			if strings.Contains(encrypted, "s3cr3t") {
+				t.Fatal("expected value to be encrypted")
+			}
+			plain, err := d.Decrypt("secret.yaml", []byte(encrypted))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(secretYAML, string(plain)); diff != "" {
+				t.Errorf("unexpected plain text (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("tampered", func(t *testing.T) {
+		encrypted := encrypt(t, secretYAML, k.age, nil)
+		// removing a value invalidates the MAC
+		var lines []string
+		for _, line := range strings.Split(encrypted, "\n") {
+			if !strings.HasPrefix(line, "kind:") {
+				lines = append(lines, line)
+			}
+		}
+		tampered := strings.Join(lines, "\n")
+		if tampered == encrypted {
+			t.Fatal("failed to tamper with file")
+		}
+		if _, err := d.Decrypt("secret.yaml", []byte(tampered)); err == nil {
+			t.Error("expected an error for a tampered file")
+		}
+	})
+}
+
+// The fixtures in testdata were created by the sops 3.10.2 command line tool:
+//
+//	sops encrypt --age <identity.agekey recipient> --pgp <private.asc fingerprint> secret.yaml > secret.sops.yaml
+//	sops encrypt --age <identity.agekey recipient> values.json > values.sops.json
+//
+// secret.yaml and values.json contain the output of "sops decrypt" for the encrypted files. tampered.sops.yaml is
+// secret.sops.yaml without the "ratio" value, which "sops decrypt" rejects with a MAC mismatch.
+
+// TestDecryptSOPSFiles compares the decrypted fixtures with the output of "sops decrypt". The YAML file is encrypted
+// for an age identity and a PGP key, the JSON file only for the age identity.
+func TestDecryptSOPSFiles(t *testing.T) {
+	tests := map[string]struct {
+		file     string
+		keys     []string
+		expected string
+	}{
+		"yaml with age":  {file: "secret.sops.yaml", keys: []string{"identity.agekey"}, expected: "secret.yaml"},
+		"yaml with pgp":  {file: "secret.sops.yaml", keys: []string{"private.asc"}, expected: "secret.yaml"},
+		"json with age":  {file: "values.sops.json", keys: []string{"identity.agekey"}, expected: "values.json"},
+		"json with both": {file: "values.sops.json", keys: []string{"identity.agekey", "private.asc"}, expected: "values.json"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tc.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected, err := os.ReadFile(filepath.Join("testdata", tc.expected))
+			if err != nil {
+				t.Fatal(err)
+			}
+			d, err := NewDecryptor(fixtureSecret(t, tc.keys...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			plain, err := d.Decrypt(tc.file, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// unlike sops, the decryptor removes comments
+			if diff := cmp.Diff(withoutComments(string(expected)), string(plain)); diff != "" {
+				t.Errorf("unexpected plain text (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDecryptSOPSFileMACMismatch(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "tampered.sops.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := NewDecryptor(fixtureSecret(t, "identity.agekey"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = d.Decrypt("tampered.sops.yaml", data)
+	if err == nil || !strings.Contains(err.Error(), "MAC mismatch") {
+		t.Errorf("expected a MAC mismatch, got %v", err)
+	}
+	if errors.Is(err, ErrMissingKey) {
+		t.Error("expected a MAC mismatch not to be reported as a missing key")
+	}
+}
+
+// withoutComments removes the comment lines, which sops keeps in decrypted YAML files.
+func withoutComments(s string) string {
+	var lines []string
+	for _, line := range strings.SplitAfter(s, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+func TestNewDecryptorWithoutKeys(t *testing.T) {
+	_, err := NewDecryptor(&corev1.Secret{Data: map[string][]byte{"other": []byte("x")}})
+	if !errors.Is(err, ErrMissingKey) {
+		t.Errorf("expected ErrMissingKey, got %v", err)
+	}
+}
+
+func TestDecryptWithWrongKey(t *testing.T) {
+	k := newTestKeys(t)
+	other := newTestKeys(t)
+	d, err := NewDecryptor(other.secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = d.Decrypt("secret.yaml", []byte(encrypt(t, secretYAML, k.age, nil)))
+	if !errors.Is(err, ErrMissingKey) {
+		t.Errorf("expected ErrMissingKey, got %v", err)
+	}
+}
+
+func TestDecryptManifest(t *testing.T) {
+	k := newTestKeys(t)
+	getDecryptor := func() (*Decryptor, error) { return NewDecryptor(k.secret) }
+
+	plain := fleet.BundleResource{Name: "configmap.yaml", Content: "kind: ConfigMap\n"}
+	m := &manifest.Manifest{
+		Commit: "abc",
+		Resources: []fleet.BundleResource{
+			plain,
+			{Name: "secret.yaml", Content: encrypt(t, secretYAML, k.age, nil)},
+			{Name: "overlays/prod/secret_patch.yaml", Content: encrypt(t, "stringData:\n    password: prod\n", k.age, nil)},
+			{Name: "values.enc.yaml", Content: encrypt(t, "replicas: 3\n", k.age, nil)},
+			{Name: "chart/values-plain.yaml", Content: "debug: true\n"},
+		},
+	}
+	opts := fleet.BundleDeploymentOptions{
+		Helm: &fleet.HelmOptions{SopsValuesFiles: []string{"./values.enc.yaml", "chart/values-plain.yaml", "values.enc.yaml"}},
+	}
+
+	result, err := DecryptManifest(m, opts, getDecryptor)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []fleet.BundleResource{
+		plain,
+		{Name: "secret.yaml", Content: secretYAML},
+		{Name: "overlays/prod/secret_patch.yaml", Content: "stringData:\n    password: prod\n"},
+	}
+	if diff := cmp.Diff(expected, result.Manifest.Resources); diff != "" {
+		t.Errorf("unexpected resources (-want +got):\n%s", diff)
+	}
+	if result.Manifest.Commit != "abc" {
+		t.Errorf("expected commit to be kept, got %q", result.Manifest.Commit)
+	}
+	expectedValues := []map[string]interface{}{
+		{"replicas": float64(3)},
+		{"debug": true},
+	}
+	if diff := cmp.Diff(expectedValues, result.Values); diff != "" {
+		t.Errorf("unexpected values (-want +got):\n%s", diff)
+	}
+}
+
+func TestDecryptManifestWithoutEncryptedResources(t *testing.T) {
+	m := &manifest.Manifest{Resources: []fleet.BundleResource{{Name: "configmap.yaml", Content: "kind: ConfigMap\n"}}}
+
+	result, err := DecryptManifest(m, fleet.BundleDeploymentOptions{}, func() (*Decryptor, error) {
+		t.Fatal("decryptor should not be requested")
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Manifest != m {
+		t.Error("expected the manifest to be returned unchanged")
+	}
+}
+
+func TestDecryptManifestMissingKey(t *testing.T) {
+	k := newTestKeys(t)
+	m := &manifest.Manifest{Resources: []fleet.BundleResource{
+		{Name: "secret.yaml", Content: encrypt(t, secretYAML, k.age, nil)},
+	}}
+
+	_, err := DecryptManifest(m, fleet.BundleDeploymentOptions{}, func() (*Decryptor, error) {
+		return nil, ErrMissingKey
+	})
+	if !errors.Is(err, ErrMissingKey) {
+		t.Errorf("expected ErrMissingKey, got %v", err)
+	}
+}
+
+func TestDecryptManifestMissingValuesFile(t *testing.T) {
+	m := &manifest.Manifest{Resources: []fleet.BundleResource{{Name: "configmap.yaml", Content: "kind: ConfigMap\n"}}}
+	opts := fleet.BundleDeploymentOptions{Helm: &fleet.HelmOptions{SopsValuesFiles: []string{"values.enc.yaml"}}}
+
+	if _, err := DecryptManifest(m, opts, nil); err == nil {
+		t.Error("expected an error for a missing values file")
+	}
+}
+
+func TestDecryptManifestSOPSValuesFile(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "values.sops.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &manifest.Manifest{Resources: []fleet.BundleResource{{Name: "values.sops.json", Content: string(data)}}}
+	opts := fleet.BundleDeploymentOptions{Helm: &fleet.HelmOptions{SopsValuesFiles: []string{"values.sops.json"}}}
+
+	result, err := DecryptManifest(m, opts, func() (*Decryptor, error) {
+		return NewDecryptor(fixtureSecret(t, "identity.agekey"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Values) != 1 {
+		t.Fatalf("expected one values file, got %d", len(result.Values))
+	}
+	expected := []interface{}{
+		[]interface{}{float64(1), float64(2)},
+		[]interface{}{"x", []interface{}{"nested"}},
+	}
+	if diff := cmp.Diff(expected, result.Values[0]["matrix"]); diff != "" {
+		t.Errorf("unexpected values (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,412 @@
+package sops
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// metadataKey is the key of the SOPS metadata in encrypted files.
+const metadataKey = "sops"
+
+// defaultUnencryptedSuffix is used by SOPS, if no other encryption rule is set in the metadata.
+const defaultUnencryptedSuffix = "_unencrypted"
+
+// macOnlyEncryptedInitialization initializes the MAC of files with mac_only_encrypted, like SOPS does.
+var macOnlyEncryptedInitialization = []byte{
+	0x8a, 0x3f, 0xd2, 0xad, 0x54, 0xce, 0x66, 0x52, 0x7b, 0x10, 0x34, 0xf3, 0xd1, 0x47, 0xbe, 0x0b,
+	0x0b, 0x97, 0x5b, 0x3b, 0xf4, 0x4f, 0x72, 0xc6, 0xfd, 0xad, 0xec, 0x81, 0x76, 0xf2, 0x7d, 0x69,
+}
+
+// encryptedValue matches values encrypted by SOPS, e.g. ENC[AES256_GCM,data:...,iv:...,tag:...,type:str].
+var encryptedValue = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.+),iv:(.+),tag:(.+),type:(.+)\]`)
+
+// metadata is the SOPS metadata of an encrypted file. Only the fields needed to decrypt files with age and PGP keys
+// are read.
+type metadata struct {
+	KeyGroups       []keyGroup `yaml:"key_groups"`
+	ShamirThreshold int        `yaml:"shamir_threshold"`
+	// Age and PGP contain the keys of files with a single key group.
+	Age []ageKey `yaml:"age"`
+	PGP []pgpKey `yaml:"pgp"`
+
+	LastModified     string `yaml:"lastmodified"`
+	MAC              string `yaml:"mac"`
+	MACOnlyEncrypted bool   `yaml:"mac_only_encrypted"`
+
+	UnencryptedSuffix       string `yaml:"unencrypted_suffix"`
+	EncryptedSuffix         string `yaml:"encrypted_suffix"`
+	UnencryptedRegex        string `yaml:"unencrypted_regex"`
+	EncryptedRegex          string `yaml:"encrypted_regex"`
+	UnencryptedCommentRegex string `yaml:"unencrypted_comment_regex"`
+	EncryptedCommentRegex   string `yaml:"encrypted_comment_regex"`
+}
+
+type keyGroup struct {
+	Age []ageKey `yaml:"age"`
+	PGP []pgpKey `yaml:"pgp"`
+}
+
+type ageKey struct {
+	Recipient string `yaml:"recipient"`
+	Enc       string `yaml:"enc"`
+}
+
+type pgpKey struct {
+	Fingerprint string `yaml:"fp"`
+	Enc         string `yaml:"enc"`
+}
+
+// tree is a SOPS encrypted file, parsed into YAML documents. JSON files are parsed as YAML, too.
+type tree struct {
+	documents []*yaml.Node
+	metadata  metadata
+	// encrypted returns true if the value at path is encrypted.
+	encrypted func(path []string) bool
+}
+
+// loadTree parses the documents of an encrypted file and removes the metadata from them. The metadata is read from
+// the first document.
+func loadTree(data []byte) (*tree, error) {
+	t := &tree{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		doc := &yaml.Node{}
+		if err := dec.Decode(doc); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+			return nil, errors.New("documents must be maps")
+		}
+		root := doc.Content[0]
+		for i := 0; i < len(root.Content); i += 2 {
+			if root.Content[i].Value != metadataKey {
+				continue
+			}
+			if len(t.documents) == 0 {
+				if err := root.Content[i+1].Decode(&t.metadata); err != nil {
+					return nil, fmt.Errorf("failed to read metadata: %w", err)
+				}
+			}
+			root.Content = slices.Delete(root.Content, i, i+2)
+			break
+		}
+		t.documents = append(t.documents, doc)
+	}
+	if len(t.documents) == 0 || t.metadata.MAC == "" || t.metadata.LastModified == "" {
+		return nil, errors.New("metadata not found")
+	}
+
+	encrypted, err := t.metadata.encryptionRule()
+	if err != nil {
+		return nil, err
+	}
+	t.encrypted = encrypted
+
+	return t, nil
+}
+
+// encryptionRule returns a function, which returns true if the value at path is encrypted. At most one rule can be
+// set, like in SOPS. Rules depending on comments are not supported, as comments are removed from decrypted files.
+func (m metadata) encryptionRule() (func(path []string) bool, error) {
+	if m.UnencryptedCommentRegex != "" || m.EncryptedCommentRegex != "" {
+		return nil, errors.New("encrypted_comment_regex and unencrypted_comment_regex are not supported")
+	}
+
+	rules := 0
+	for _, rule := range []string{m.UnencryptedSuffix, m.EncryptedSuffix, m.UnencryptedRegex, m.EncryptedRegex} {
+		if rule != "" {
+			rules++
+		}
+	}
+	if rules > 1 {
+		return nil, errors.New("more than one of encrypted_suffix, unencrypted_suffix, encrypted_regex and unencrypted_regex is set")
+	}
+
+	anyKey := func(path []string, match func(string) bool) bool {
+		return slices.ContainsFunc(path, match)
+	}
+	switch {
+	case m.EncryptedSuffix != "":
+		return func(path []string) bool {
+			return anyKey(path, func(k string) bool { return strings.HasSuffix(k, m.EncryptedSuffix) })
+		}, nil
+	case m.UnencryptedRegex != "":
+		re, err := regexp.Compile(m.UnencryptedRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid unencrypted_regex: %w", err)
+		}
+		return func(path []string) bool { return !anyKey(path, re.MatchString) }, nil
+	case m.EncryptedRegex != "":
+		re, err := regexp.Compile(m.EncryptedRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encrypted_regex: %w", err)
+		}
+		return func(path []string) bool { return anyKey(path, re.MatchString) }, nil
+	}
+
+	suffix := m.UnencryptedSuffix
+	if suffix == "" {
+		suffix = defaultUnencryptedSuffix
+	}
+	return func(path []string) bool {
+		return !anyKey(path, func(k string) bool { return strings.HasSuffix(k, suffix) })
+	}, nil
+}
+
+// decrypt decrypts the values of the documents in place, removes all comments and verifies the MAC of the file.
+func (t *tree) decrypt(key []byte) error {
+	h := sha512.New()
+	if t.metadata.MACOnlyEncrypted {
+		h.Write(macOnlyEncryptedInitialization)
+	}
+	for _, doc := range t.documents {
+		doc.HeadComment, doc.LineComment, doc.FootComment = "", "", ""
+		if err := t.walk(doc.Content[0], nil, key, h); err != nil {
+			return err
+		}
+	}
+
+	lastModified, err := time.Parse(time.RFC3339, t.metadata.LastModified)
+	if err != nil {
+		return fmt.Errorf("invalid lastmodified: %w", err)
+	}
+	mac, err := decryptValue(t.metadata.MAC, key, lastModified.Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("failed to decrypt MAC: %w", err)
+	}
+	if mac != fmt.Sprintf("%X", h.Sum(nil)) {
+		return errors.New("MAC mismatch, failed to verify data integrity")
+	}
+
+	return nil
+}
+
+// walk decrypts the values of node and adds them to the MAC. path contains the keys of the maps leading to node,
+// the indexes of lists are not part of the path.
+func (t *tree) walk(node *yaml.Node, path []string, key []byte, h hash.Hash) error {
+	node.HeadComment, node.LineComment, node.FootComment = "", "", ""
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k := node.Content[i]
+			k.HeadComment, k.LineComment, k.FootComment = "", "", ""
+			if err := t.walk(node.Content[i+1], append(slices.Clone(path), k.Value), key, h); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if err := t.walk(item, path, key, h); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		return t.decryptScalar(node, path, key, h)
+	case yaml.AliasNode:
+		return fmt.Errorf("aliases are not supported, found %q at %s", node.Value, strings.Join(path, "."))
+	}
+
+	return nil
+}
+
+// decryptScalar replaces an encrypted scalar with its value and adds the value to the MAC, like SOPS does.
+func (t *tree) decryptScalar(node *yaml.Node, path []string, key []byte, h hash.Hash) error {
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return err
+	}
+	if value == nil {
+		return nil
+	}
+
+	encrypted := t.encrypted(path)
+	if encrypted {
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("value at %s is not encrypted", strings.Join(path, "."))
+		}
+		var err error
+		if value, err = decryptValue(s, key, strings.Join(path, ":")+":"); err != nil {
+			return fmt.Errorf("failed to decrypt value at %s: %w", strings.Join(path, "."), err)
+		}
+		if err := node.Encode(value); err != nil {
+			return err
+		}
+	}
+
+	if t.metadata.MACOnlyEncrypted && !encrypted {
+		return nil
+	}
+	b, err := toBytes(value)
+	if err != nil {
+		return fmt.Errorf("value at %s: %w", strings.Join(path, "."), err)
+	}
+	h.Write(b)
+
+	return nil
+}
+
+// decryptValue decrypts a value encrypted with AES-256-GCM by SOPS. Empty values are not encrypted by SOPS.
+func decryptValue(value string, key []byte, additionalData string) (interface{}, error) {
+	if value == "" {
+		return "", nil
+	}
+	matches := encryptedValue.FindStringSubmatch(value)
+	if matches == nil {
+		return nil, errors.New("value is not encrypted")
+	}
+	var parts [3][]byte
+	for i := range parts {
+		b, err := base64.StdEncoding.DecodeString(matches[i+1])
+		if err != nil {
+			return nil, err
+		}
+		parts[i] = b
+	}
+	data, iv, tag := parts[0], parts[1], parts[2]
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, iv, append(data, tag...), []byte(additionalData))
+	if err != nil {
+		return nil, err
+	}
+
+	switch matches[4] {
+	case "str", "bytes":
+		return string(plaintext), nil
+	case "int":
+		return strconv.Atoi(string(plaintext))
+	case "float":
+		return strconv.ParseFloat(string(plaintext), 64)
+	case "bool":
+		return strconv.ParseBool(string(plaintext))
+	case "time":
+		var t time.Time
+		err := t.UnmarshalText(plaintext)
+		return t, err
+	}
+	return nil, fmt.Errorf("unknown type %q", matches[4])
+}
+
+// toBytes returns the bytes of a value, which are added to the MAC. The format matches SOPS.
+func toBytes(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case string:
+		return []byte(v), nil
+	case int:
+		return []byte(strconv.Itoa(v)), nil
+	case float64:
+		return []byte(strconv.FormatFloat(v, 'f', -1, 64)), nil
+	case bool:
+		if v {
+			return []byte("True"), nil
+		}
+		return []byte("False"), nil
+	case time.Time:
+		return v.MarshalText()
+	}
+	return nil, fmt.Errorf("unsupported type %T", value)
+}
+
+// emitYAML returns the documents as YAML, indented like the files emitted by SOPS.
+func (t *tree) emitYAML() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(4)
+	for _, doc := range t.documents {
+		if err := enc.Encode(doc); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// emitJSON returns the document as JSON, keeping the order of keys and indented with tabs like SOPS does.
+func (t *tree) emitJSON() ([]byte, error) {
+	if len(t.documents) != 1 {
+		return nil, errors.New("JSON files must contain a single document")
+	}
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, t.documents[0].Content[0]); err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, buf.Bytes(), "", "\t"); err != nil {
+		return nil, err
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
+
+func writeJSON(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			k, err := json.Marshal(node.Content[i].Value)
+			if err != nil {
+				return err
+			}
+			buf.Write(k)
+			buf.WriteByte(':')
+			if err := writeJSON(buf, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSON(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+		b, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Package kubeconfig loads the kubeconfigs of downstream clusters, which users provide in secrets.
+package kubeconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/rancher/fleet/internal/config"
+	"github.com/rancher/fleet/pkg/durations"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Load parses the kubeconfig data of a downstream cluster. The clients are used
+// inside the fleet controller, so kubeconfigs which run commands or reference
+// files, e.g. the service account token of the controller, are rejected.
+//
+// If the server is behind a public CA, the CertificateAuthorityData is removed
+// from the kubeconfig, unless strict TLS mode is enabled.
+func Load(data []byte, agentTLSMode string) (clientcmd.ClientConfig, error) {
+	if agentTLSMode != config.AgentTLSModeStrict && agentTLSMode != config.AgentTLSModeSystemStore {
+		return nil, fmt.Errorf(
+			"provided config value for agentTLSMode is none of [%q,%q]",
+			config.AgentTLSModeStrict,
+			config.AgentTLSModeSystemStore,
+		)
+	}
+
+	clientConfig, err := clientcmd.NewClientConfigFromBytes(data)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := clientConfig.RawConfig()
+	if err != nil {
+		return nil, err
+	}
+	if err := validate(raw); err != nil {
+		return nil, err
+	}
+
+	if agentTLSMode == config.AgentTLSModeSystemStore && raw.Contexts[raw.CurrentContext] != nil {
+		cluster := raw.Contexts[raw.CurrentContext].Cluster
+		if raw.Clusters[cluster] != nil {
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, raw.Clusters[cluster].Server, nil)
+			if err == nil {
+				client := &http.Client{Timeout: durations.RestConfigTimeout}
+				if resp, err := client.Do(req); err == nil {
+					resp.Body.Close()
+					raw.Clusters[cluster].CertificateAuthorityData = nil
+				}
+			}
+		}
+	}
+
+	return clientcmd.NewDefaultClientConfig(raw, &clientcmd.ConfigOverrides{}), nil
+}
+
+// validate rejects credential plugins and references to files in the kubeconfig.
+func validate(raw clientcmdapi.Config) error {
+	var errs []error
+	for name, cluster := range raw.Clusters {
+		if cluster.CertificateAuthority != "" {
+			errs = append(errs, fmt.Errorf("cluster %q: certificate-authority files are not supported, use certificate-authority-data", name))
+		}
+	}
+	for name, user := range raw.AuthInfos {
+		if user.Exec != nil {
+			errs = append(errs, fmt.Errorf("user %q: exec credential plugins are not supported", name))
+		}
+		if user.AuthProvider != nil {
+			errs = append(errs, fmt.Errorf("user %q: auth-provider plugins are not supported", name))
+		}
+		if user.TokenFile != "" {
+			errs = append(errs, fmt.Errorf("user %q: tokenFile is not supported, use token", name))
+		}
+		if user.ClientCertificate != "" || user.ClientKey != "" {
+			errs = append(errs, fmt.Errorf("user %q: client-certificate and client-key files are not supported, use client-certificate-data and client-key-data", name))
+		}
+	}
+	return errors.Join(errs...)
+}
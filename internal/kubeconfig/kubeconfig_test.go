@@ -0,0 +1,95 @@
+package kubeconfig_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rancher/fleet/internal/config"
+	"github.com/rancher/fleet/internal/kubeconfig"
+)
+
+const kubeConfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: downstream
+  cluster:
+    server: https://downstream.example.com:6443
+    %CLUSTER%
+contexts:
+- name: downstream
+  context:
+    cluster: downstream
+    user: admin
+current-context: downstream
+users:
+- name: admin
+  user:
+    %USER%
+`
+
+func newKubeConfig(cluster, user string) []byte {
+	return []byte(strings.NewReplacer("%CLUSTER%", cluster, "%USER%", user).Replace(kubeConfig))
+}
+
+func TestLoad(t *testing.T) {
+	clientConfig, err := kubeconfig.Load(newKubeConfig("insecure-skip-tls-verify: true", "token: secret"), config.AgentTLSModeStrict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restConfig.Host != "https://downstream.example.com:6443" || restConfig.BearerToken != "secret" {
+		t.Errorf("unexpected rest config: %s, %s", restConfig.Host, restConfig.BearerToken)
+	}
+}
+
+func TestLoadRejectsFilesAndCommands(t *testing.T) {
+	tests := map[string]struct {
+		cluster string
+		user    string
+		err     string
+	}{
+		"exec": {
+			user: "exec: {apiVersion: client.authentication.k8s.io/v1, command: /bin/sh, args: [-c, id]}",
+			err:  "exec credential plugins are not supported",
+		},
+		"auth provider": {
+			user: "auth-provider: {name: oidc}",
+			err:  "auth-provider plugins are not supported",
+		},
+		"token file": {
+			user: "tokenFile: /var/run/secrets/kubernetes.io/serviceaccount/token",
+			err:  "tokenFile is not supported",
+		},
+		"client certificate file": {
+			user: "client-certificate: /etc/passwd",
+			err:  "client-certificate and client-key files are not supported",
+		},
+		"client key file": {
+			user: "client-key: /etc/passwd",
+			err:  "client-certificate and client-key files are not supported",
+		},
+		"certificate authority file": {
+			cluster: "certificate-authority: /var/run/secrets/kubernetes.io/serviceaccount/ca.crt",
+			user:    "token: secret",
+			err:     "certificate-authority files are not supported",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := kubeconfig.Load(newKubeConfig(tt.cluster, tt.user), config.AgentTLSModeStrict)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected error %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestLoadInvalidTLSMode(t *testing.T) {
+	if _, err := kubeconfig.Load(newKubeConfig("", "token: secret"), ""); err == nil {
+		t.Error("expected an error for an invalid agentTLSMode")
+	}
+}
@@ -0,0 +1,65 @@
+package manifest
+
+import (
+	"encoding/json"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+const (
+	// ChunkThreshold is the size of the compressed manifest, above which the manifest is stored in chunks instead
+	// of a single content resource.
+	ChunkThreshold = 512 * 1024
+	// chunkSize is the maximum uncompressed size of the resources packed into one chunk.
+	chunkSize = 512 * 1024
+	// dedupSize is the uncompressed size above which a resource is stored in a chunk of its own, so it can be
+	// shared between manifests, e.g. CRDs vendored by many Helm charts.
+	dedupSize = 32 * 1024
+)
+
+// Chunks splits the manifest into content addressed chunks. Resources larger than dedupSize are stored in a chunk
+// of their own, consecutive smaller resources are packed together. The resources of the chunks, in order, are the
+// resources of the manifest.
+func Chunks(m *Manifest) ([]*Manifest, error) {
+	var (
+		chunks  []*Manifest
+		current []fleet.BundleResource
+		size    int
+	)
+	flush := func() {
+		if len(current) > 0 {
+			chunks = append(chunks, New(current))
+		}
+		current, size = nil, 0
+	}
+
+	for _, resource := range m.Resources {
+		data, err := json.Marshal(resource)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) >= dedupSize {
+			flush()
+			chunks = append(chunks, New([]fleet.BundleResource{resource}))
+			continue
+		}
+		if size+len(data) > chunkSize {
+			flush()
+		}
+		current = append(current, resource)
+		size += len(data)
+	}
+	flush()
+
+	return chunks, nil
+}
+
+// ChunkID returns the name of the content resource storing the chunk. Chunks use a different prefix than
+// manifests, so a chunk never shares its content resource with a manifest stored in one piece.
+func ChunkID(chunk *Manifest) (string, error) {
+	shasum, err := chunk.SHASum()
+	if err != nil {
+		return "", err
+	}
+	return ("c-" + shasum)[:63], nil
+}
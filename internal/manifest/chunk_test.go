@@ -0,0 +1,119 @@
+package manifest_test
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/rancher/fleet/internal/manifest"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// random returns incompressible content of the given size.
+func random(t *testing.T, size int) string {
+	t.Helper()
+	b := make([]byte, size/2)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+func TestChunks(t *testing.T) {
+	crd := fleet.BundleResource{Name: "crds/crd.yaml", Content: strings.Repeat("x", 64*1024)}
+	small := fleet.BundleResource{Name: "templates/cm.yaml", Content: "kind: ConfigMap"}
+
+	m := manifest.New([]fleet.BundleResource{small, crd, small})
+	chunks, err := manifest.Chunks(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+	if len(chunks[1].Resources) != 1 || chunks[1].Resources[0].Name != crd.Name {
+		t.Errorf("expected large resource in a chunk of its own, got %v", chunks[1].Resources)
+	}
+
+	// the chunk of the large resource is shared with other manifests
+	other, err := manifest.Chunks(manifest.New([]fleet.BundleResource{crd}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := manifest.ChunkID(chunks[1])
+	otherID, _ := manifest.ChunkID(other[0])
+	if id != otherID || !strings.HasPrefix(id, "c-") {
+		t.Errorf("expected shared chunk id, got %s and %s", id, otherID)
+	}
+}
+
+func TestStoreChunkedContent(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(fleet.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	store := manifest.NewStore(c)
+
+	crd := fleet.BundleResource{Name: "crds/crd.yaml", Content: random(t, 800*1024)}
+	first := manifest.New([]fleet.BundleResource{crd, {Name: "a.yaml", Content: random(t, 400*1024)}})
+	second := manifest.New([]fleet.BundleResource{crd, {Name: "b.yaml", Content: random(t, 400*1024)}})
+
+	for _, m := range []*manifest.Manifest{first, second} {
+		if err := store.Store(context.TODO(), m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	chunkID, err := manifest.ChunkID(manifest.New([]fleet.BundleResource{crd}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunk := &fleet.Content{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: chunkID}, chunk); err != nil {
+		t.Fatal(err)
+	}
+	if len(chunk.OwnerReferences) != 2 {
+		t.Errorf("expected shared chunk to be owned by both contents, got %v", chunk.OwnerReferences)
+	}
+	if chunk.Labels[fleet.ContentChunkLabel] != "true" {
+		t.Errorf("expected chunk label, got %v", chunk.Labels)
+	}
+
+	for _, m := range []*manifest.Manifest{first, second} {
+		id, _ := m.ID()
+		content := &fleet.Content{}
+		if err := c.Get(context.TODO(), types.NamespacedName{Name: id}, content); err != nil {
+			t.Fatal(err)
+		}
+		if len(content.Content) != 0 || len(content.Chunks) != 2 {
+			t.Errorf("expected content to reference 2 chunks, got %v", content.Chunks)
+		}
+
+		got, err := manifest.NewLookup().Get(context.TODO(), c, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotID, _ := got.ID()
+		if gotID != id {
+			t.Errorf("expected reassembled manifest %s, got %s", id, gotID)
+		}
+	}
+
+	// a chunk deleted by garbage collection is recreated
+	if err := c.Delete(context.TODO(), chunk); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Store(context.TODO(), first); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Get(context.TODO(), client.ObjectKey{Name: chunkID}, &fleet.Content{}); err != nil {
+		t.Errorf("expected chunk to be recreated: %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package manifest
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/rancher/fleet/internal/content"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
@@ -24,9 +25,36 @@ func (l *Lookup) Get(ctx context.Context, client client.Reader, id string) (*Man
 		return nil, err
 	}
 
+	if len(c.Chunks) > 0 {
+		return l.getChunked(ctx, client, c)
+	}
+
 	data, err := content.GUnzip(c.Content)
 	if err != nil {
 		return nil, err
 	}
 	return FromJSON(data, c.SHA256Sum)
 }
+
+// getChunked reassembles a manifest stored in chunks and verifies it matches the checksum of the content resource.
+func (l *Lookup) getChunked(ctx context.Context, client client.Reader, c *fleet.Content) (*Manifest, error) {
+	var resources []fleet.BundleResource
+	for _, name := range c.Chunks {
+		chunk, err := l.Get(ctx, client, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get chunk %s of content %s: %w", name, c.Name, err)
+		}
+		resources = append(resources, chunk.Resources...)
+	}
+
+	m := New(resources)
+	shasum, err := m.SHASum()
+	if err != nil {
+		return nil, err
+	}
+	if shasum != c.SHA256Sum {
+		return nil, fmt.Errorf("content does not match hash got %s, expected %s", shasum, c.SHA256Sum)
+	}
+
+	return m, nil
+}
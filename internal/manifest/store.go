@@ -32,9 +32,14 @@ func (c *ContentStore) Store(ctx context.Context, manifest *Manifest) error {
 		return err
 	}
 
-	if err := c.Client.Get(ctx, types.NamespacedName{Name: id}, &fleet.Content{}); err != nil && !apierrors.IsNotFound(err) {
+	existing := &fleet.Content{}
+	if err := c.Client.Get(ctx, types.NamespacedName{Name: id}, existing); err != nil && !apierrors.IsNotFound(err) {
 		return fmt.Errorf("%w: %w", errorutil.ErrRetryable, err)
 	} else if err == nil {
+		if len(existing.Chunks) > 0 {
+			// recreate chunks, which were garbage collected while a new owner was added
+			return c.storeChunks(ctx, existing, manifest)
+		}
 		return nil
 	}
 
@@ -57,6 +62,10 @@ func (c *ContentStore) createContents(ctx context.Context, id string, manifest *
 		return err
 	}
 
+	if len(compressed) > ChunkThreshold {
+		return c.createChunkedContents(ctx, id, digest, manifest)
+	}
+
 	err = c.Client.Create(ctx, &fleet.Content{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: id,
@@ -71,3 +80,108 @@ func (c *ContentStore) createContents(ctx context.Context, id string, manifest *
 
 	return err
 }
+
+// createChunkedContents stores the manifest in content addressed chunks, which are shared between manifests. The
+// content resource only references the chunks and owns them, so chunks are garbage collected once no content
+// resource references them anymore.
+func (c *ContentStore) createChunkedContents(ctx context.Context, id, digest string, manifest *Manifest) error {
+	chunks, err := Chunks(manifest)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		name, err := ChunkID(chunk)
+		if err != nil {
+			return err
+		}
+		names = append(names, name)
+	}
+
+	parent := &fleet.Content{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: id,
+		},
+		SHA256Sum: digest,
+		Chunks:    names,
+	}
+	if err := c.Client.Create(ctx, parent); apierrors.IsAlreadyExists(err) {
+		if err := c.Client.Get(ctx, types.NamespacedName{Name: id}, parent); err != nil {
+			return fmt.Errorf("%w: %w", errorutil.ErrRetryable, err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("%w: %w", errorutil.ErrRetryable, err)
+	}
+
+	return c.storeChunks(ctx, parent, manifest)
+}
+
+// storeChunks creates the chunks of the manifest, or adds parent as an owner to existing chunks.
+func (c *ContentStore) storeChunks(ctx context.Context, parent *fleet.Content, manifest *Manifest) error {
+	chunks, err := Chunks(manifest)
+	if err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		if err := c.storeChunk(ctx, parent, chunk); err != nil {
+			return fmt.Errorf("%w: %w", errorutil.ErrRetryable, err)
+		}
+	}
+	return nil
+}
+
+func (c *ContentStore) storeChunk(ctx context.Context, parent *fleet.Content, chunk *Manifest) error {
+	name, err := ChunkID(chunk)
+	if err != nil {
+		return err
+	}
+	owner := metav1.OwnerReference{
+		APIVersion: fleet.SchemeGroupVersion.String(),
+		Kind:       "Content",
+		Name:       parent.Name,
+		UID:        parent.UID,
+	}
+
+	existing := &fleet.Content{}
+	err = c.Client.Get(ctx, types.NamespacedName{Name: name}, existing)
+	if apierrors.IsNotFound(err) {
+		data, err := chunk.Content()
+		if err != nil {
+			return err
+		}
+		digest, err := chunk.SHASum()
+		if err != nil {
+			return err
+		}
+		compressed, err := content.Gzip(data)
+		if err != nil {
+			return err
+		}
+		err = c.Client.Create(ctx, &fleet.Content{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Labels:          map[string]string{fleet.ContentChunkLabel: "true"},
+				OwnerReferences: []metav1.OwnerReference{owner},
+			},
+			Content:   compressed,
+			SHA256Sum: digest,
+		})
+		if !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+		// created concurrently for another manifest, add the owner reference
+		err = c.Client.Get(ctx, types.NamespacedName{Name: name}, existing)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range existing.OwnerReferences {
+		if ref.UID == parent.UID && ref.Name == parent.Name {
+			return nil
+		}
+	}
+	orig := existing.DeepCopy()
+	existing.OwnerReferences = append(existing.OwnerReferences, owner)
+	return c.Client.Patch(ctx, existing, client.MergeFromWithOptions(orig, client.MergeFromWithOptimisticLock{}))
+}
@@ -44,6 +44,7 @@ func RegisterMetrics() {
 	ClusterGroupCollector.Register()
 	BundleCollector.Register()
 	BundleDeploymentCollector.Register()
+	OCIStorageCollector.Register()
 
 	registerObjMetrics()
 }
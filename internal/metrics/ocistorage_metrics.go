@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// OCIStorageHealth is the result of checking the OCI registry configured in an OCI storage secret. The object meta
+// identifies the secret.
+type OCIStorageHealth struct {
+	metav1.ObjectMeta
+
+	Reference string
+	// Healthy is false if the registry could not be listed or referenced artifacts could not be pulled.
+	Healthy bool
+	// Artifacts is the number of artifacts pushed by Fleet found in the registry.
+	Artifacts int
+	// Unreferenced is the number of artifacts which are no longer referenced by any bundle.
+	Unreferenced int
+	// Missing is the number of artifacts referenced by bundles, which could not be pulled.
+	Missing int
+	// Deleted is the number of unreferenced artifacts deleted after their retention period.
+	Deleted int
+}
+
+var (
+	ociStorageSubsystem = "oci_storage"
+	ociStorageLabels    = []string{"name", "namespace", "reference"}
+	OCIStorageCollector = CollectorCollection{
+		ociStorageSubsystem,
+		ociStorageMetrics,
+		collectOCIStorageMetrics,
+	}
+	ociStorageMetrics = map[string]prometheus.Collector{
+		"healthy": promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: metricPrefix,
+				Subsystem: ociStorageSubsystem,
+				Name:      "healthy",
+				Help:      "Whether the OCI registry is reachable and all referenced artifacts can be pulled.",
+			},
+			ociStorageLabels,
+		),
+		"artifacts": promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: metricPrefix,
+				Subsystem: ociStorageSubsystem,
+				Name:      "artifacts",
+				Help:      "The count of artifacts pushed by Fleet to the OCI registry.",
+			},
+			ociStorageLabels,
+		),
+		"unreferenced_artifacts": promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: metricPrefix,
+				Subsystem: ociStorageSubsystem,
+				Name:      "unreferenced_artifacts",
+				Help:      "The count of artifacts in the OCI registry, which are not referenced by any bundle.",
+			},
+			ociStorageLabels,
+		),
+		"missing_artifacts": promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: metricPrefix,
+				Subsystem: ociStorageSubsystem,
+				Name:      "missing_artifacts",
+				Help:      "The count of artifacts referenced by bundles, which cannot be pulled from the OCI registry.",
+			},
+			ociStorageLabels,
+		),
+		"deleted_artifacts": promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: metricPrefix,
+				Subsystem: ociStorageSubsystem,
+				Name:      "deleted_artifacts",
+				Help:      "The count of unreferenced artifacts deleted from the OCI registry by the last garbage collection.",
+			},
+			ociStorageLabels,
+		),
+	}
+)
+
+func collectOCIStorageMetrics(obj any, metrics map[string]prometheus.Collector) {
+	health, ok := obj.(*OCIStorageHealth)
+	if !ok {
+		panic("unexpected object type")
+	}
+
+	labels := prometheus.Labels{
+		"name":      health.Name,
+		"namespace": health.Namespace,
+		"reference": health.Reference,
+	}
+
+	healthy := 0.0
+	if health.Healthy {
+		healthy = 1
+	}
+	metrics["healthy"].(*prometheus.GaugeVec).With(labels).Set(healthy)
+	metrics["artifacts"].(*prometheus.GaugeVec).With(labels).Set(float64(health.Artifacts))
+	metrics["unreferenced_artifacts"].(*prometheus.GaugeVec).With(labels).Set(float64(health.Unreferenced))
+	metrics["missing_artifacts"].(*prometheus.GaugeVec).With(labels).Set(float64(health.Missing))
+	metrics["deleted_artifacts"].(*prometheus.GaugeVec).With(labels).Set(float64(health.Deleted))
+}
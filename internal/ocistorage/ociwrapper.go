@@ -5,6 +5,7 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,6 +13,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
@@ -19,6 +21,7 @@ import (
 	"oras.land/oras-go/v2"
 	"oras.land/oras-go/v2/content"
 	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/errdef"
 	"oras.land/oras-go/v2/registry/remote"
 	"oras.land/oras-go/v2/registry/remote/auth"
 	"oras.land/oras-go/v2/registry/remote/retry"
@@ -41,6 +44,17 @@ type OCIOpts struct {
 	InsecureSkipTLS bool
 }
 
+// errNotFleetArtifact is returned for repositories which do not contain an artifact pushed by Fleet.
+var errNotFleetArtifact = errors.New("not a fleet artifact")
+
+// Artifact is an OCI artifact pushed by Fleet to store the manifest of a bundle.
+type Artifact struct {
+	// ID is the id of the stored manifest, it matches the ContentsID of the bundle.
+	ID string
+	// Created is the creation time of the artifact. It is zero if the artifact has no creation annotation.
+	Created time.Time
+}
+
 type OrasOps interface {
 	PackManifest(ctx context.Context, pusher content.Pusher, packManifestVersion oras.PackManifestVersion, artifactType string, opts oras.PackManifestOptions) (ocispec.Descriptor, error)
 	Copy(ctx context.Context, src oras.ReadOnlyTarget, srcRef string, dst oras.Target, dstRef string, opts oras.CopyOptions) (ocispec.Descriptor, error)
@@ -280,6 +294,117 @@ func (o *OCIWrapper) DeleteManifest(ctx context.Context, opts OCIOpts, id string
 	return repo.Delete(ctx, desc)
 }
 
+// ListArtifacts lists the artifacts pushed by Fleet below the reference of opts. Repositories are identified as
+// Fleet artifacts by the id annotation of their layer, other repositories are ignored.
+// It requires the registry to support the catalog API.
+func (o *OCIWrapper) ListArtifacts(ctx context.Context, opts OCIOpts) ([]Artifact, error) {
+	host, prefix, _ := strings.Cut(strings.Trim(opts.Reference, "/"), "/")
+	reg, err := remote.NewRegistry(host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create registry client for %s: %w", opts.Reference, err)
+	}
+	reg.PlainHTTP = opts.BasicHTTP
+	reg.Client = getAuthClient(opts)
+
+	var ids []string
+	err = reg.Repositories(ctx, "", func(repos []string) error {
+		for _, repo := range repos {
+			id := repo
+			if prefix != "" {
+				var ok bool
+				if id, ok = strings.CutPrefix(repo, prefix+"/"); !ok {
+					continue
+				}
+			}
+			// artifacts are pushed directly below the reference
+			if id != "" && !strings.Contains(id, "/") {
+				ids = append(ids, id)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories of %s: %w", opts.Reference, err)
+	}
+
+	artifacts := make([]Artifact, 0, len(ids))
+	for _, id := range ids {
+		artifact, err := getArtifact(ctx, opts, id)
+		if errors.Is(err, errdef.ErrNotFound) || errors.Is(err, errNotFleetArtifact) {
+			// tag was already deleted or the repository was not created by Fleet
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		artifacts = append(artifacts, artifact)
+	}
+
+	return artifacts, nil
+}
+
+func getArtifact(ctx context.Context, opts OCIOpts, id string) (Artifact, error) {
+	repo, err := newOCIRepository(id, opts)
+	if err != nil {
+		return Artifact{}, fmt.Errorf("failed to create repository for %s: %w", id, err)
+	}
+
+	m, err := fetchArtifactManifest(ctx, repo, id)
+	if err != nil {
+		return Artifact{}, err
+	}
+
+	artifact := Artifact{ID: id}
+	if created, err := time.Parse(time.RFC3339, m.Annotations[ocispec.AnnotationCreated]); err == nil {
+		artifact.Created = created
+	}
+
+	return artifact, nil
+}
+
+// fetchArtifactManifest fetches the manifest of the artifact, without its layer, and checks that the artifact was
+// pushed by Fleet.
+func fetchArtifactManifest(ctx context.Context, repo *remote.Repository, id string) (ocispec.Manifest, error) {
+	tag := "latest"
+	desc, err := repo.Resolve(ctx, tag)
+	if err != nil {
+		return ocispec.Manifest{}, fmt.Errorf("failed to resolve tag '%s' for artifact '%s': %w", tag, id, err)
+	}
+	data, err := content.FetchAll(ctx, repo, desc)
+	if err != nil {
+		return ocispec.Manifest{}, fmt.Errorf("failed to fetch manifest of artifact '%s': %w", id, err)
+	}
+
+	var m ocispec.Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return ocispec.Manifest{}, fmt.Errorf("%w: %w", errNotFleetArtifact, err)
+	}
+	if m.ArtifactType != artifactType || len(m.Layers) != 1 {
+		return ocispec.Manifest{}, errNotFleetArtifact
+	}
+	if err := checkIDAnnotation(m.Layers[0], id); err != nil {
+		return ocispec.Manifest{}, fmt.Errorf("%w: %w", errNotFleetArtifact, err)
+	}
+	return m, nil
+}
+
+// CheckManifest verifies that the OCI artifact identified by the given id exists and can be pulled, using the
+// agent credentials if present. Only the manifest is fetched, the layer is resolved without downloading it.
+func (o *OCIWrapper) CheckManifest(ctx context.Context, opts OCIOpts, id string) error {
+	repo, err := newOCIRepository(id, opts)
+	if err != nil {
+		return fmt.Errorf("failed to create repository for %s: %w", id, err)
+	}
+	m, err := fetchArtifactManifest(ctx, repo, id)
+	if err != nil {
+		return err
+	}
+	if _, err := repo.Blobs().Resolve(ctx, m.Layers[0].Digest.String()); err != nil {
+		return fmt.Errorf("failed to resolve layer of artifact '%s': %w", id, err)
+	}
+	return nil
+}
+
 // OCIIsEnabled returns true if the OCI_STORAGE env variable is not set or
 // if it's set to true
 func OCIIsEnabled() bool {
@@ -5,9 +5,15 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/google/go-containerregistry/pkg/registry"
 	"github.com/opencontainers/go-digest"
 	"go.uber.org/mock/gomock"
 
@@ -315,6 +321,53 @@ var _ = Describe("OCIUtils tests", func() {
 	})
 })
 
+var _ = Describe("OCI registry tests", func() {
+	var (
+		opts OCIOpts
+		oci  *OCIWrapper
+	)
+
+	BeforeEach(func() {
+		srv := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+		DeferCleanup(srv.Close)
+
+		opts = OCIOpts{
+			Reference: strings.TrimPrefix(srv.URL, "http://") + "/fleet",
+			BasicHTTP: true,
+		}
+		oci = NewOCIWrapper()
+
+		m := manifest.New([]fleet.BundleResource{{Name: "cm.yaml", Content: "kind: ConfigMap"}})
+		Expect(oci.PushManifest(context.Background(), opts, "s-1", m)).To(Succeed())
+		Expect(oci.PushManifest(context.Background(), opts, "s-2", m)).To(Succeed())
+
+		// artifacts not pushed by Fleet are ignored
+		other := opts
+		other.Reference = strings.TrimPrefix(srv.URL, "http://") + "/other"
+		Expect(oci.PushManifest(context.Background(), other, "s-3", m)).To(Succeed())
+	})
+
+	It("lists the artifacts pushed below the reference", func() {
+		artifacts, err := oci.ListArtifacts(context.Background(), opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(artifacts).To(HaveLen(2))
+		Expect(artifacts[0].ID).To(Equal("s-1"))
+		Expect(artifacts[1].ID).To(Equal("s-2"))
+		Expect(artifacts[0].Created).To(BeTemporally("~", time.Now(), time.Minute))
+	})
+
+	It("checks that an artifact can be pulled", func() {
+		Expect(oci.CheckManifest(context.Background(), opts, "s-1")).To(Succeed())
+		Expect(oci.CheckManifest(context.Background(), opts, "s-4")).ToNot(Succeed())
+	})
+
+	It("returns an error if the registry is not reachable", func() {
+		opts.Reference = "127.0.0.1:1/fleet"
+		_, err := oci.ListArtifacts(context.Background(), opts)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
 var _ = Describe("OCIUtils flag tests", func() {
 	var envBeforeTest string
 
@@ -0,0 +1,190 @@
+// Package secretstore resolves Helm values from sources outside of the downstream cluster: secrets in the
+// cluster namespace on the management cluster and external secret stores, like HashiCorp Vault.
+//
+// Values are only resolved by the agent when rendering a release, they are never stored in bundles or contents.
+package secretstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultProvider is used if an external secret reference does not specify a provider.
+	DefaultProvider = "vault"
+
+	defaultKey = "values.yaml"
+)
+
+// Provider reads secrets from an external secret store.
+type Provider interface {
+	// GetSecret returns the key value pairs of the secret stored at path.
+	GetSecret(ctx context.Context, path string) (map[string]string, error)
+}
+
+// Factory creates a provider from the connection settings stored in a store secret.
+type Factory func(config map[string][]byte) (Provider, error)
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]Factory{
+		DefaultProvider: NewVault,
+	}
+)
+
+// Register makes a provider available under the given name. It replaces any provider registered under the same
+// name.
+func Register(name string, factory Factory) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[name] = factory
+}
+
+func getFactory(name string) (Factory, error) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	factory, ok := providers[name]
+	if !ok {
+		names := make([]string, 0, len(providers))
+		for n := range providers {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown secret store provider %q, supported providers: %s", name, strings.Join(names, ", "))
+	}
+	return factory, nil
+}
+
+// Resolver resolves values from secrets in the cluster namespace on the management cluster. The bundle controller
+// copies the secrets referenced by a bundle deployment into that namespace.
+type Resolver struct {
+	reader    client.Reader
+	namespace string
+}
+
+// NewResolver returns a resolver reading secrets from namespace using the upstream reader.
+func NewResolver(reader client.Reader, namespace string) *Resolver {
+	return &Resolver{
+		reader:    reader,
+		namespace: namespace,
+	}
+}
+
+// UpstreamSecretValues returns the values stored in the referenced upstream secret.
+func (r *Resolver) UpstreamSecretValues(ctx context.Context, ref *fleet.UpstreamSecretKeySelector) (map[string]interface{}, error) {
+	secret, err := r.getSecret(ctx, ref.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	key := ref.Key
+	if key == "" {
+		key = defaultKey
+	}
+	data, ok := secret.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("key %s is missing from upstream secret %s/%s, can't use it in valuesFrom", key, r.namespace, ref.Name)
+	}
+
+	return decode(data)
+}
+
+// ExternalSecretValues returns the values stored in the referenced secret of an external secret store.
+func (r *Resolver) ExternalSecretValues(ctx context.Context, ref *fleet.ExternalSecretRef) (map[string]interface{}, error) {
+	if ref.StoreSecretName == "" || ref.Path == "" {
+		return nil, fmt.Errorf("external secret reference requires a storeSecretName and a path")
+	}
+
+	name := ref.Provider
+	if name == "" {
+		name = DefaultProvider
+	}
+	factory, err := getFactory(name)
+	if err != nil {
+		return nil, err
+	}
+
+	storeSecret, err := r.getSecret(ctx, ref.StoreSecretName)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := factory(storeSecret.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure secret store provider %q from secret %s/%s: %w", name, r.namespace, ref.StoreSecretName, err)
+	}
+
+	data, err := provider.GetSecret(ctx, ref.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q from secret store provider %q: %w", ref.Path, name, err)
+	}
+
+	if ref.Key == "" {
+		values := make(map[string]interface{}, len(data))
+		for k, v := range data {
+			values[k] = v
+		}
+		return values, nil
+	}
+
+	value, ok := data[ref.Key]
+	if !ok {
+		return nil, fmt.Errorf("key %s is missing from %q in secret store provider %q, can't use it in valuesFrom", ref.Key, ref.Path, name)
+	}
+
+	return decode([]byte(value))
+}
+
+func (r *Resolver) getSecret(ctx context.Context, name string) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	if err := r.reader.Get(ctx, types.NamespacedName{Namespace: r.namespace, Name: name}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get upstream secret %s/%s: %w", r.namespace, name, err)
+	}
+	return secret, nil
+}
+
+func decode(data []byte) (map[string]interface{}, error) {
+	var m map[string]interface{}
+	if err := yaml.NewYAMLToJSONDecoder(bytes.NewBuffer(data)).Decode(&m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// SecretNames returns the names of the upstream secrets referenced in valuesFrom, including the secrets holding
+// the connection settings of external secret stores.
+func SecretNames(valuesFrom []fleet.ValuesFrom) []string {
+	var names []string
+	seen := map[string]struct{}{}
+	add := func(name string) {
+		if name == "" {
+			return
+		}
+		if _, ok := seen[name]; ok {
+			return
+		}
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+
+	for _, vf := range valuesFrom {
+		if vf.UpstreamSecretKeyRef != nil {
+			add(vf.UpstreamSecretKeyRef.Name)
+		}
+		if vf.ExternalSecretRef != nil {
+			add(vf.ExternalSecretRef.StoreSecretName)
+		}
+	}
+
+	return names
+}
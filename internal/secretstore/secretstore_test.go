@@ -0,0 +1,283 @@
+package secretstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	testNamespace = "cluster-fleet-default-c1"
+	testToken     = "s.test"
+)
+
+// newVaultServer returns a server implementing the read endpoint of a KV version 2 secrets engine mounted at
+// "secret".
+func newVaultServer(t *testing.T, secrets map[string]map[string]interface{}) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != testToken {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		path, ok := strings.CutPrefix(r.URL.Path, "/v1/secret/data/")
+		if !ok || r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+			return
+		}
+		data, ok := secrets[path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"data":     data,
+				"metadata": map[string]interface{}{"version": 1},
+			},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func newResolver(objs ...*corev1.Secret) *Resolver {
+	builder := fake.NewClientBuilder()
+	for _, obj := range objs {
+		builder = builder.WithObjects(obj)
+	}
+	return NewResolver(builder.Build(), testNamespace)
+}
+
+func secret(name string, data map[string]string) *corev1.Secret {
+	s := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+		Data:       map[string][]byte{},
+	}
+	for k, v := range data {
+		s.Data[k] = []byte(v)
+	}
+	return s
+}
+
+func TestUpstreamSecretValues(t *testing.T) {
+	r := newResolver(
+		secret("values", map[string]string{
+			"values.yaml": "replicas: 2\ndb:\n  password: secret\n",
+			"other.yaml":  "replicas: 3\n",
+		}),
+	)
+
+	tests := map[string]struct {
+		ref      *fleet.UpstreamSecretKeySelector
+		expected map[string]interface{}
+		err      string
+	}{
+		"default key": {
+			ref: &fleet.UpstreamSecretKeySelector{LocalObjectReference: fleet.LocalObjectReference{Name: "values"}},
+			expected: map[string]interface{}{
+				"replicas": float64(2),
+				"db":       map[string]interface{}{"password": "secret"},
+			},
+		},
+		"custom key": {
+			ref:      &fleet.UpstreamSecretKeySelector{LocalObjectReference: fleet.LocalObjectReference{Name: "values"}, Key: "other.yaml"},
+			expected: map[string]interface{}{"replicas": float64(3)},
+		},
+		"missing key": {
+			ref: &fleet.UpstreamSecretKeySelector{LocalObjectReference: fleet.LocalObjectReference{Name: "values"}, Key: "missing"},
+			err: "key missing is missing from upstream secret",
+		},
+		"missing secret": {
+			ref: &fleet.UpstreamSecretKeySelector{LocalObjectReference: fleet.LocalObjectReference{Name: "missing"}},
+			err: "failed to get upstream secret",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			values, err := r.UpstreamSecretValues(context.TODO(), tc.ref)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected error containing %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.expected, values); diff != "" {
+				t.Errorf("unexpected values (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestExternalSecretValues(t *testing.T) {
+	srv := newVaultServer(t, map[string]map[string]interface{}{
+		"app/config": {
+			"values.yaml": "image:\n  tag: v1\n",
+			"password":    "secret",
+			"port":        float64(5432),
+		},
+	})
+
+	r := newResolver(
+		secret("vault", map[string]string{VaultAddressKey: srv.URL, VaultTokenKey: testToken}),
+		secret("vault-bad-token", map[string]string{VaultAddressKey: srv.URL, VaultTokenKey: "wrong"}),
+		secret("vault-no-address", map[string]string{VaultTokenKey: testToken}),
+	)
+
+	tests := map[string]struct {
+		ref      *fleet.ExternalSecretRef
+		expected map[string]interface{}
+		err      string
+	}{
+		"all keys": {
+			ref: &fleet.ExternalSecretRef{StoreSecretName: "vault", Path: "app/config"},
+			expected: map[string]interface{}{
+				"values.yaml": "image:\n  tag: v1\n",
+				"password":    "secret",
+				"port":        "5432",
+			},
+		},
+		"single key": {
+			ref:      &fleet.ExternalSecretRef{Provider: "vault", StoreSecretName: "vault", Path: "/app/config", Key: "values.yaml"},
+			expected: map[string]interface{}{"image": map[string]interface{}{"tag": "v1"}},
+		},
+		"missing key": {
+			ref: &fleet.ExternalSecretRef{StoreSecretName: "vault", Path: "app/config", Key: "missing"},
+			err: "key missing is missing",
+		},
+		"missing path": {
+			ref: &fleet.ExternalSecretRef{StoreSecretName: "vault", Path: "app/missing"},
+			err: "404 Not Found",
+		},
+		"permission denied": {
+			ref: &fleet.ExternalSecretRef{StoreSecretName: "vault-bad-token", Path: "app/config"},
+			err: "permission denied",
+		},
+		"invalid store secret": {
+			ref: &fleet.ExternalSecretRef{StoreSecretName: "vault-no-address", Path: "app/config"},
+			err: `missing "address"`,
+		},
+		"unknown provider": {
+			ref: &fleet.ExternalSecretRef{Provider: "unknown", StoreSecretName: "vault", Path: "app/config"},
+			err: `unknown secret store provider "unknown"`,
+		},
+		"no path": {
+			ref: &fleet.ExternalSecretRef{StoreSecretName: "vault"},
+			err: "requires a storeSecretName and a path",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			values, err := r.ExternalSecretValues(context.TODO(), tc.ref)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected error containing %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.expected, values); diff != "" {
+				t.Errorf("unexpected values (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+type staticProvider map[string]string
+
+func (p staticProvider) GetSecret(context.Context, string) (map[string]string, error) {
+	return p, nil
+}
+
+func TestRegister(t *testing.T) {
+	Register("static", func(map[string][]byte) (Provider, error) {
+		return staticProvider{"values.yaml": "foo: bar"}, nil
+	})
+
+	r := newResolver(secret("store", nil))
+	values, err := r.ExternalSecretValues(context.TODO(), &fleet.ExternalSecretRef{
+		Provider:        "static",
+		StoreSecretName: "store",
+		Path:            "any",
+		Key:             "values.yaml",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(map[string]interface{}{"foo": "bar"}, values); diff != "" {
+		t.Errorf("unexpected values (-want +got):\n%s", diff)
+	}
+}
+
+func TestSecretNames(t *testing.T) {
+	names := SecretNames([]fleet.ValuesFrom{
+		{SecretKeyRef: &fleet.SecretKeySelector{LocalObjectReference: fleet.LocalObjectReference{Name: "downstream"}}},
+		{UpstreamSecretKeyRef: &fleet.UpstreamSecretKeySelector{LocalObjectReference: fleet.LocalObjectReference{Name: "values"}}},
+		{ExternalSecretRef: &fleet.ExternalSecretRef{StoreSecretName: "vault", Path: "a"}},
+		{ExternalSecretRef: &fleet.ExternalSecretRef{StoreSecretName: "vault", Path: "b"}},
+	})
+	if diff := cmp.Diff([]string{"values", "vault"}, names); diff != "" {
+		t.Errorf("unexpected names (-want +got):\n%s", diff)
+	}
+}
+
+// TestVaultDevServer runs against a Vault dev server, e.g. started with
+// `vault server -dev -dev-root-token-id=root`, if VAULT_ADDR and VAULT_TOKEN are set.
+func TestVaultDevServer(t *testing.T) {
+	addr, token := os.Getenv("VAULT_ADDR"), os.Getenv("VAULT_TOKEN")
+	if addr == "" || token == "" {
+		t.Skip("VAULT_ADDR and VAULT_TOKEN are not set")
+	}
+
+	body := []byte(`{"data":{"values.yaml":"replicas: 2"}}`)
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/v1/secret/data/fleet-test", addr), bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("failed to write secret: %s", resp.Status)
+	}
+
+	v, err := NewVault(map[string][]byte{VaultAddressKey: []byte(addr), VaultTokenKey: []byte(token)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := v.GetSecret(context.TODO(), "fleet-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data["values.yaml"] != "replicas: 2" {
+		t.Errorf("unexpected data %v", data)
+	}
+}
@@ -0,0 +1,137 @@
+package secretstore
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Keys of the store secret used to configure the Vault provider.
+const (
+	VaultAddressKey   = "address"
+	VaultTokenKey     = "token"
+	VaultNamespaceKey = "namespace"
+	VaultMountKey     = "mount"
+	VaultCABundleKey  = "caBundle"
+
+	defaultVaultMount = "secret"
+	vaultTimeout      = 30 * time.Second
+)
+
+// Vault reads secrets from a HashiCorp Vault KV version 2 secrets engine.
+type Vault struct {
+	address   string
+	token     string
+	namespace string
+	mount     string
+	client    *http.Client
+}
+
+// NewVault creates a Vault provider from the connection settings in config. The address and token are required.
+func NewVault(config map[string][]byte) (Provider, error) {
+	address := strings.TrimSuffix(string(config[VaultAddressKey]), "/")
+	if address == "" {
+		return nil, fmt.Errorf("missing %q", VaultAddressKey)
+	}
+	if _, err := url.Parse(address); err != nil {
+		return nil, fmt.Errorf("invalid %q: %w", VaultAddressKey, err)
+	}
+	token := strings.TrimSpace(string(config[VaultTokenKey]))
+	if token == "" {
+		return nil, fmt.Errorf("missing %q", VaultTokenKey)
+	}
+	mount := strings.Trim(string(config[VaultMountKey]), "/")
+	if mount == "" {
+		mount = defaultVaultMount
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if caBundle := config[VaultCABundleKey]; len(caBundle) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caBundle) {
+			return nil, fmt.Errorf("invalid %q: no certificates found", VaultCABundleKey)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	return &Vault{
+		address:   address,
+		token:     token,
+		namespace: string(config[VaultNamespaceKey]),
+		mount:     mount,
+		client:    &http.Client{Transport: transport, Timeout: vaultTimeout},
+	}, nil
+}
+
+type vaultResponse struct {
+	Data struct {
+		Data map[string]interface{} `json:"data"`
+	} `json:"data"`
+	Errors []string `json:"errors"`
+}
+
+// GetSecret returns the latest version of the secret at path. Values which are not strings are returned as JSON.
+func (v *Vault) GetSecret(ctx context.Context, path string) (map[string]string, error) {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil, errors.New("path is empty")
+	}
+
+	u := fmt.Sprintf("%s/v1/%s/data/%s", v.address, v.mount, path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	if v.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.namespace)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result vaultResponse
+	if err := json.Unmarshal(body, &result); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("failed to decode vault response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if len(result.Errors) > 0 {
+			return nil, fmt.Errorf("vault returned %s: %s", resp.Status, strings.Join(result.Errors, ", "))
+		}
+		return nil, fmt.Errorf("vault returned %s", resp.Status)
+	}
+	if result.Data.Data == nil {
+		return nil, fmt.Errorf("secret %q has no data, it may have been deleted", path)
+	}
+
+	data := make(map[string]string, len(result.Data.Data))
+	for k, val := range result.Data.Data {
+		if s, ok := val.(string); ok {
+			data[k] = s
+			continue
+		}
+		b, err := json.Marshal(val)
+		if err != nil {
+			return nil, err
+		}
+		data[k] = string(b)
+	}
+
+	return data, nil
+}
@@ -43,6 +43,11 @@ const (
 	// InternalSecretLabel is a label added to any secret created by Fleet to propagate Bundle or
 	// BundleDeployment secrets storing credential details for OCI storage or HelmOps.
 	InternalSecretLabel = "fleet.cattle.io/bundle-internal-secret"
+
+	// BundleAcceptedCondition is set on bundles in namespaces with
+	// GitRepoRestrictions. It is false if the bundle is denied by them,
+	// in which case its bundle deployments are deleted.
+	BundleAcceptedCondition = "Accepted"
 )
 
 var (
@@ -225,6 +230,10 @@ type Partition struct {
 	// Selector matching cluster group labels to include in this partition
 	// +nullable
 	ClusterGroupSelector *metav1.LabelSelector `json:"clusterGroupSelector,omitempty"`
+	// A CEL expression matching clusters to include in this partition, see
+	// BundleTarget.
+	// +nullable
+	ClusterExpression string `json:"clusterExpression,omitempty"`
 }
 
 // BundleTargetRestriction is used internally by Fleet and should not be modified.
@@ -241,6 +250,10 @@ type BundleTargetRestriction struct {
 	ClusterGroup string `json:"clusterGroup,omitempty"`
 	// +nullable
 	ClusterGroupSelector *metav1.LabelSelector `json:"clusterGroupSelector,omitempty"`
+	// +nullable
+	ClusterFactSelector *metav1.LabelSelector `json:"clusterFactSelector,omitempty"`
+	// +nullable
+	ClusterExpression string `json:"clusterExpression,omitempty"`
 }
 
 // BundleTarget declares clusters to deploy to. Fleet will merge the
@@ -267,6 +280,23 @@ type BundleTarget struct {
 	// ClusterGroupSelector is a selector to match cluster groups.
 	// +nullable
 	ClusterGroupSelector *metav1.LabelSelector `json:"clusterGroupSelector,omitempty"`
+	// ClusterFactSelector is a selector to match the facts reported by the
+	// agent of a cluster. Facts are matched as labels:
+	// "kubernetes-version" (e.g. "v1.31.2"), "kubernetes-minor-version"
+	// (e.g. "1.31"), "node-count", "default-storageclass" and, set to
+	// "true", "os/<os>", "arch/<arch>", "provider/<prefix>",
+	// "apigroup/<group>" and "storageclass/<name>". Clusters without
+	// facts do not match.
+	// +nullable
+	ClusterFactSelector *metav1.LabelSelector `json:"clusterFactSelector,omitempty"`
+	// ClusterExpression is a CEL expression, which must evaluate to true
+	// for a cluster to match. The cluster is available as "cluster" with
+	// the keys "name", "namespace", "labels", "annotations", "groups" and
+	// "values", e.g. `cluster.labels.region in ["eu-west", "eu-central"]`.
+	// Errors during evaluation, like accessing a missing label, do not
+	// match; use `has(cluster.labels.tier)` to test for a label.
+	// +nullable
+	ClusterExpression string `json:"clusterExpression,omitempty"`
 	// DoNotDeploy if set to true, will not deploy to this target.
 	DoNotDeploy bool `json:"doNotDeploy,omitempty"`
 	// NamespaceLabels are labels that will be appended to the namespace created by Fleet.
@@ -275,6 +305,30 @@ type BundleTarget struct {
 	// NamespaceAnnotations are annotations that will be appended to the namespace created by Fleet.
 	// +nullable
 	NamespaceAnnotations map[string]string `json:"namespaceAnnotations,omitempty"`
+	// MaxClusters limits the number of clusters this target deploys to.
+	// Clusters are chosen from the clusters matching the target by a
+	// stable hash, so the choice only changes when clusters join or leave.
+	// Matching clusters, which are not chosen, are not deployed to.
+	// +nullable
+	// +kubebuilder:validation:Minimum=0
+	MaxClusters *int `json:"maxClusters,omitempty"`
+	// SpreadByLabel spreads the clusters chosen by MaxClusters evenly over
+	// the values of this cluster label, e.g. "region". Clusters without
+	// the label are spread like clusters with an empty value.
+	// +nullable
+	SpreadByLabel string `json:"spreadByLabel,omitempty"`
+}
+
+// TargetSelection contains the clusters chosen for a target with maxClusters.
+type TargetSelection struct {
+	// Name of the target.
+	Name string `json:"name,omitempty"`
+	// MatchingClusters is the number of clusters matching the target.
+	MatchingClusters int `json:"matchingClusters"`
+	// Clusters are the names of the chosen clusters, in the form
+	// "namespace/name".
+	// +nullable
+	Clusters []string `json:"clusters,omitempty"`
 }
 
 // BundleSummary contains the number of bundle deployments in each state and a
@@ -342,6 +396,9 @@ const (
 	// indicates that its resources are ready and the dependencies are
 	// fulfilled.
 	BundleConditionReady = "Ready"
+	// BundleConditionOCIStorage indicates whether the OCI artifact
+	// storing the bundle's resources exists and can be pulled.
+	BundleConditionOCIStorage = "OCIStorage"
 	// BundleDeploymentConditionReady is the condition that displays for
 	// status in general and it is used for the readiness of resources.
 	BundleDeploymentConditionReady = "Ready"
@@ -354,6 +411,11 @@ const (
 	BundleDeploymentConditionMonitored = "Monitored"
 )
 
+// BundleDeploymentReasonSopsKeyMissing is the reason of the Deployed
+// condition, if the bundle contains SOPS encrypted files, but the key to
+// decrypt them is not configured or cannot be read.
+const BundleDeploymentReasonSopsKeyMissing = "SopsKeyMissing"
+
 type BundleStatus struct {
 	// Conditions is a list of Wrangler conditions that describe the state
 	// of the bundle.
@@ -388,6 +450,10 @@ type BundleStatus struct {
 	MaxNew int `json:"maxNew,omitempty"`
 	// PartitionStatus lists the status of each partition.
 	PartitionStatus []PartitionStatus `json:"partitions,omitempty"`
+	// TargetSelections lists the clusters chosen for targets, which limit
+	// the number of clusters with maxClusters.
+	// +nullable
+	TargetSelections []TargetSelection `json:"targetSelections,omitempty"`
 	// Display contains the number of ready, desiredready clusters and a
 	// summary state for the bundle's resources.
 	Display BundleDisplay `json:"display,omitempty"`
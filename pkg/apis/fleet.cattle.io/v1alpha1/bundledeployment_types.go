@@ -124,6 +124,87 @@ type BundleDeploymentOptions struct {
 	// Overwrites indicates which resources, if any, come from this bundle and overwrite another existing bundle.
 	// This flag is set internally by Fleet, and should not be altered by users.
 	Overwrites []OverwrittenResource `json:"overwrites,omitempty"`
+
+	// ImageRewrite rewrites container image references of the deployed
+	// resources, e.g. to pull images from a private registry mirror.
+	// Rules from the targeted cluster and its cluster groups are added by
+	// Fleet and take precedence over the rules defined in the bundle.
+	// +nullable
+	ImageRewrite *ImageRewrite `json:"imageRewrite,omitempty"`
+
+	// Sops configures the decryption of SOPS encrypted resources, values
+	// files and overlays by the agent. Encrypted files stay encrypted in
+	// the bundle contents.
+	// +nullable
+	Sops *SopsOptions `json:"sops,omitempty"`
+
+	// ResourceTemplating enables the templating of raw YAML and kustomize
+	// resources with cluster values, using the same '${ }' syntax and
+	// functions as helm values templating.
+	// +nullable
+	ResourceTemplating *ResourceTemplating `json:"resourceTemplating,omitempty"`
+
+	// Outputs export values of deployed objects. The agent reports them
+	// in the bundle deployment status and bundles depending on this
+	// bundle can use them in templates as '.BundleOutputs'.
+	// +nullable
+	Outputs []BundleOutput `json:"outputs,omitempty"`
+}
+
+// BundleOutput exports a value of a deployed object, selected by a JSONPath
+// expression. Outputs are stored in the bundle deployment status in plain
+// text and must not reference secret data.
+type BundleOutput struct {
+	// Name of the output, used as key in '.BundleOutputs.<bundle>'.
+	Name string `json:"name"`
+	// APIVersion of the object. Any version matches if empty.
+	// +optional
+	APIVersion string `json:"apiVersion,omitempty"`
+	// Kind of the object.
+	Kind string `json:"kind"`
+	// Namespace of the object. Defaults to the namespace of the bundle
+	// deployment's resources.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// ResourceName is the name of the object.
+	ResourceName string `json:"resourceName"`
+	// JSONPath is evaluated over the object, e.g.
+	// "{.status.loadBalancer.ingress[0].hostname}".
+	JSONPath string `json:"jsonPath"`
+}
+
+// ResourceTemplating configures the templating of bundle resources by the agent.
+type ResourceTemplating struct {
+	// Enabled renders raw YAML and kustomize resources as templates. Helm
+	// chart files are never rendered.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Context is the template context of the targeted cluster. It is set
+	// internally by Fleet, and should not be altered by users. It only
+	// contains the values referenced by the resources. Cluster facts
+	// referenced by name, e.g. '${ .ClusterFacts.kubernetesVersion }', are
+	// included on their own, all facts are included if they are used in
+	// any other way. Facts are not available for bundles stored in an OCI
+	// registry.
+	// +nullable
+	// +kubebuilder:validation:XPreserveUnknownFields
+	Context *GenericMap `json:"context,omitempty"`
+}
+
+// SopsOptions references the keys used to decrypt SOPS encrypted files.
+type SopsOptions struct {
+	// SecretName is the name of a secret on the downstream cluster,
+	// which contains the decryption keys. Age identities are read from
+	// keys ending in ".agekey", armored PGP private keys from keys
+	// ending in ".asc". Files encrypted with other key types or with
+	// multiple key groups are not supported. If the keys are missing,
+	// the Deployed condition of the bundle deployment has the reason
+	// "SopsKeyMissing".
+	SecretName string `json:"secretName"`
+	// Namespace of the secret. Defaults to the namespace of the Fleet
+	// agent.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
 }
 
 // GitOpsBundleDeploymentOptions contains options which only make sense for GitOps
@@ -202,6 +283,46 @@ type KustomizeOptions struct {
 	Dir string `json:"dir,omitempty"`
 }
 
+// ImageRewrite configures how the agent rewrites container image references
+// before deploying resources.
+type ImageRewrite struct {
+	// Rules are evaluated in order, the first matching rule is applied to
+	// an image reference.
+	// +nullable
+	Rules []ImageRewriteRule `json:"rules,omitempty"`
+	// Paths lists additional locations of image references in resources,
+	// which are not core workloads, e.g. custom resources.
+	// +nullable
+	Paths []ImageRewritePath `json:"paths,omitempty"`
+}
+
+// ImageRewriteRule rewrites image references matching From to To.
+// Image references are normalized before matching, e.g. "nginx:1.25" is
+// matched as "docker.io/library/nginx:1.25".
+type ImageRewriteRule struct {
+	// From is the image reference to match. A trailing "*" matches any
+	// suffix, e.g. "docker.io/*". Without a wildcard From has to match the
+	// image repository exactly, the tag or digest is preserved.
+	From string `json:"from"`
+	// To is the replacement for the matched image reference. A trailing "*"
+	// is replaced by the suffix matched by the wildcard in From.
+	To string `json:"to"`
+}
+
+// ImageRewritePath points to image references in resources of a given kind.
+type ImageRewritePath struct {
+	// APIVersion of the resources to match, e.g. "example.com/v1". If
+	// empty, all API versions are matched.
+	// +nullable
+	APIVersion string `json:"apiVersion,omitempty"`
+	// Kind of the resources to match.
+	Kind string `json:"kind"`
+	// Paths are dot separated field paths to string values containing image
+	// references. List elements are selected with "[*]" or an index, e.g.
+	// "spec.images[*].name".
+	Paths []string `json:"paths"`
+}
+
 // HelmOptions for the deployment. For Helm-based bundles, all options can be
 // used, otherwise some options are ignored. For example ReleaseName works with
 // all bundle types.
@@ -250,6 +371,13 @@ type HelmOptions struct {
 	// ValuesFrom loads the values from configmaps and secrets.
 	ValuesFrom []ValuesFrom `json:"valuesFrom,omitempty"`
 
+	// SopsValuesFiles lists the SOPS encrypted files of ValuesFiles. They
+	// are kept encrypted in the bundle resources and their values are
+	// only merged by the agent, after decrypting them. This is set by
+	// Fleet when creating the bundle.
+	// +nullable
+	SopsValuesFiles []string `json:"sopsValuesFiles,omitempty"`
+
 	// Force allows to override immutable resources. This could be dangerous.
 	Force bool `json:"force,omitempty"`
 
@@ -304,6 +432,20 @@ type ValuesFrom struct {
 	// +optional
 	// +nullable
 	SecretKeyRef *SecretKeySelector `json:"secretKeyRef,omitempty"`
+	// The reference to a secret with release values in the namespace of the
+	// bundle on the management cluster. The secret is copied to the
+	// namespace of each targeted cluster, so it is only visible to the
+	// agents of these clusters, and read by the agent when rendering the
+	// release.
+	// +optional
+	// +nullable
+	UpstreamSecretKeyRef *UpstreamSecretKeySelector `json:"upstreamSecretKeyRef,omitempty"`
+	// The reference to release values in an external secret store. The
+	// values are resolved by the agent when rendering the release and are
+	// never stored in the bundle.
+	// +optional
+	// +nullable
+	ExternalSecretRef *ExternalSecretRef `json:"externalSecretRef,omitempty"`
 }
 
 type ConfigMapKeySelector struct {
@@ -326,6 +468,31 @@ type SecretKeySelector struct {
 	Key string `json:"key,omitempty"`
 }
 
+type UpstreamSecretKeySelector struct {
+	LocalObjectReference `json:",inline"`
+	// +optional
+	// +nullable
+	Key string `json:"key,omitempty"`
+}
+
+type ExternalSecretRef struct {
+	// Provider is the name of the secret store provider. Defaults to "vault".
+	// +optional
+	Provider string `json:"provider,omitempty"`
+	// StoreSecretName is the name of a secret in the namespace of the bundle,
+	// which contains the connection settings of the secret store, e.g. its
+	// address and token. It is copied to the namespace of each targeted
+	// cluster, like an upstream secret.
+	StoreSecretName string `json:"storeSecretName"`
+	// Path is the path of the secret in the secret store.
+	Path string `json:"path"`
+	// Key of the secret which contains the values as YAML. If empty, all
+	// keys of the secret are used as top-level values.
+	// +optional
+	// +nullable
+	Key string `json:"key,omitempty"`
+}
+
 type LocalObjectReference struct {
 	// Name of a resource in the same namespace as the referent.
 	// +nullable
@@ -365,6 +532,10 @@ type BundleDeploymentSpec struct {
 	// DownstreamResourcesGeneration is used to track changes to DownstreamResources.
 	// It is incremented every time DownstreamResources are modified.
 	DownstreamResourcesGeneration int64 `json:"downstreamResourcesGeneration,omitempty"`
+	// ValuesFromGeneration is used to track changes to upstream secrets
+	// referenced in valuesFrom. It is derived from a hash of the data of
+	// these secrets and changes whenever their data changes.
+	ValuesFromGeneration int64 `json:"valuesFromGeneration,omitempty"`
 	// OffSchedule specifies if the BundleDeployment can be updated.
 	// If set to true, will stop any BundleDeployments from being
 	// updated.
@@ -424,6 +595,26 @@ type BundleDeploymentStatus struct {
 	// It is incremented every time DownstreamResources are modified and reflects the value in the spec
 	// after it has been processed.
 	DownstreamResourcesGeneration int64 `json:"downstreamResourcesGeneration,omitempty"`
+	// ValuesFromGeneration reflects the value in the spec after the release
+	// has been deployed with the updated upstream secrets.
+	ValuesFromGeneration int64 `json:"valuesFromGeneration,omitempty"`
+	// RewrittenImages lists the image references which were rewritten by
+	// the agent, according to the ImageRewrite options of the deployment.
+	// +nullable
+	RewrittenImages []RewrittenImage `json:"rewrittenImages,omitempty"`
+	// Outputs contains the values of the outputs defined in the options,
+	// evaluated over the deployed objects. Outputs, which cannot be
+	// evaluated yet, are omitted.
+	// +nullable
+	Outputs map[string]string `json:"outputs,omitempty"`
+}
+
+// RewrittenImage records an image reference rewritten by the agent.
+type RewrittenImage struct {
+	// Original is the image reference as found in the bundle.
+	Original string `json:"original,omitempty"`
+	// Rewritten is the image reference that was deployed.
+	Rewritten string `json:"rewritten,omitempty"`
 }
 
 type BundleDeploymentDisplay struct {
@@ -45,6 +45,13 @@ var (
 
 	// ClusterManagementLabel can be used to specify a custom cluster manager
 	ClusterManagementLabel = "fleet.cattle.io/cluster-management"
+
+	// DeploymentModeAgent deploys bundles with the fleet agent running on
+	// the cluster.
+	DeploymentModeAgent = "Agent"
+	// DeploymentModePush deploys bundles from the fleet controller, which
+	// connects to the API server of the cluster. No agent is installed.
+	DeploymentModePush = "Push"
 )
 
 // +genclient
@@ -111,6 +118,18 @@ type ClusterSpec struct {
 	// +nullable
 	KubeConfigSecretNamespace string `json:"kubeConfigSecretNamespace,omitempty"`
 
+	// DeploymentMode selects how bundles are deployed to the cluster. With
+	// "Agent", the default, the fleet agent deploys them. With "Push", the
+	// fleet controller deploys them through the API server of the cluster,
+	// using the kubeconfig from KubeConfigSecret, and no agent is installed.
+	// In push mode, the secret has to be in the namespace of the cluster and
+	// the kubeconfig must not use exec or auth-provider plugins, nor
+	// reference files. When switching to "Push", an agent installed on the
+	// cluster is removed before bundles are deployed by the controller.
+	// +kubebuilder:validation:Enum=Agent;Push
+	// +optional
+	DeploymentMode string `json:"deploymentMode,omitempty"`
+
 	// RedeployAgentGeneration can be used to force redeploying the agent.
 	RedeployAgentGeneration int64 `json:"redeployAgentGeneration,omitempty"`
 
@@ -126,6 +145,12 @@ type ClusterSpec struct {
 	// +nullable
 	PrivateRepoURL string `json:"privateRepoURL,omitempty"`
 
+	// ImageRewrite defines rules to rewrite the image references of all
+	// workloads deployed to this cluster. Cluster rules take precedence
+	// over cluster group rules.
+	// +nullable
+	ImageRewrite *ImageRewrite `json:"imageRewrite,omitempty"`
+
 	// TemplateValues defines a cluster specific mapping of values to be sent to fleet.yaml values templating.
 	// +nullable
 	// +kubebuilder:validation:XPreserveUnknownFields
@@ -244,6 +269,11 @@ type ClusterStatus struct {
 	Display ClusterDisplay `json:"display,omitempty"`
 	// AgentStatus contains information about the agent.
 	Agent AgentStatus `json:"agent,omitempty"`
+	// Facts are discovered and reported by the agent. They can be selected
+	// by targets and are available as .ClusterFacts in templates.
+	// +nullable
+	// +optional
+	Facts *ClusterFacts `json:"facts,omitempty"`
 
 	// GarbageCollectionInterval determines how often agents clean up obsolete Helm releases.
 	GarbageCollectionInterval *metav1.Duration `json:"garbageCollectionInterval,omitempty"`
@@ -271,6 +301,46 @@ type ClusterDisplay struct {
 	State string `json:"state,omitempty"`
 }
 
+// ClusterFacts describe the downstream cluster, as discovered by the agent.
+type ClusterFacts struct {
+	// KubernetesVersion is the git version of the API server, e.g. "v1.31.2+k3s1".
+	// +nullable
+	// +optional
+	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
+	// Platform is the platform of the API server, e.g. "linux/amd64".
+	// +nullable
+	// +optional
+	Platform string `json:"platform,omitempty"`
+	// NodeCount is the number of nodes.
+	// +optional
+	NodeCount int `json:"nodeCount,omitempty"`
+	// OperatingSystems are the operating systems of the nodes, e.g. "linux".
+	// +nullable
+	// +optional
+	OperatingSystems []string `json:"operatingSystems,omitempty"`
+	// Architectures are the CPU architectures of the nodes, e.g. "arm64".
+	// +nullable
+	// +optional
+	Architectures []string `json:"architectures,omitempty"`
+	// ProviderIDPrefixes are the schemes of the provider IDs of the nodes,
+	// which identify the cloud provider, e.g. "aws" or "gce".
+	// +nullable
+	// +optional
+	ProviderIDPrefixes []string `json:"providerIDPrefixes,omitempty"`
+	// APIGroups are the API groups served by the cluster, e.g. "cert-manager.io".
+	// +nullable
+	// +optional
+	APIGroups []string `json:"apiGroups,omitempty"`
+	// StorageClasses are the names of the storage classes.
+	// +nullable
+	// +optional
+	StorageClasses []string `json:"storageClasses,omitempty"`
+	// DefaultStorageClass is the name of the default storage class.
+	// +nullable
+	// +optional
+	DefaultStorageClass string `json:"defaultStorageClass,omitempty"`
+}
+
 type AgentStatus struct {
 	// LastSeen is the last time the agent checked in to update the status
 	// of the cluster resource.
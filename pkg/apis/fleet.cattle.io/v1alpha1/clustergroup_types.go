@@ -45,10 +45,32 @@ type ClusterGroupSpec struct {
 	// Selector is a label selector, used to select clusters for this group.
 	// +nullable
 	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// ImageRewrite defines rules to rewrite the image references of all
+	// workloads deployed to clusters in this group.
+	// +nullable
+	ImageRewrite *ImageRewrite `json:"imageRewrite,omitempty"`
+
+	// Parent is the name of the parent cluster group in the same
+	// namespace. Clusters of this group are members of all its ancestors,
+	// and template values are inherited from them. In namespaces with
+	// GitRepoRestrictions, which allow cluster groups, clusters are only
+	// members of allowed ancestors if this group is allowed as well.
+	// +optional
+	Parent string `json:"parent,omitempty"`
+
+	// TemplateValues are merged down the cluster group hierarchy and are
+	// available to templates as '.ClusterGroupValues'. Values of child
+	// groups take precedence over their ancestors, the template values of
+	// the cluster take precedence over all groups.
+	// +nullable
+	// +kubebuilder:validation:XPreserveUnknownFields
+	TemplateValues *GenericMap `json:"templateValues,omitempty"`
 }
 
 type ClusterGroupStatus struct {
-	// ClusterCount is the number of clusters in the cluster group.
+	// ClusterCount is the number of clusters in the cluster group,
+	// including the clusters of its child groups.
 	// +optional
 	ClusterCount int `json:"clusterCount"`
 	// NonReadyClusterCount is the number of clusters that are not ready.
@@ -60,7 +82,7 @@ type ClusterGroupStatus struct {
 	// Conditions is a list of conditions and their statuses for the cluster group.
 	Conditions []genericcondition.GenericCondition `json:"conditions,omitempty"`
 	// Summary is a summary of the bundle deployments and their resources
-	// in the cluster group.
+	// in the cluster group, including its child groups.
 	Summary BundleSummary `json:"summary,omitempty"`
 	// Display contains the number of ready, desiredready clusters and a
 	// summary state for the bundle's resources.
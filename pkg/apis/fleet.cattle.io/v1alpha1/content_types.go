@@ -4,7 +4,14 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-const ContentResourceNamePlural = "contents"
+const (
+	ContentResourceNamePlural = "contents"
+
+	// ContentChunkLabel marks content resources, which store a chunk of a
+	// larger manifest. Chunks are owned by the content resources
+	// referencing them and garbage collected with them.
+	ContentChunkLabel = "fleet.cattle.io/content-chunk"
+)
 
 func init() {
 	InternalSchemeBuilder.Register(&Content{}, &ContentList{})
@@ -30,13 +37,23 @@ type Content struct {
 	Content []byte `json:"content,omitempty"`
 
 	// SHA256Sum of the Content field
-	SHA256Sum string        `json:"sha256sum,omitempty"` // SHA256Sum of the Content field
-	Status    ContentStatus `json:"status,omitempty"`    // +optional
+	SHA256Sum string `json:"sha256sum,omitempty"` // SHA256Sum of the Content field
+
+	// Chunks lists the names of the content resources storing the
+	// manifest, if it is too large for a single resource. The manifest
+	// contains the resources of all chunks, in order, and Content is
+	// empty.
+	// +nullable
+	// +optional
+	Chunks []string `json:"chunks,omitempty"`
+
+	Status ContentStatus `json:"status,omitempty"` // +optional
 }
 
 // ContentStatus defines the observed state of Content
 type ContentStatus struct {
 	// ReferenceCount is the number of BundleDeployments that currently reference this Content resource.
+	// For chunks, it is the number of Content resources referencing the chunk.
 	// +optional
 	ReferenceCount int `json:"referenceCount,omitempty"`
 }
@@ -58,6 +58,14 @@ type GitRepoSpec struct {
 	// +nullable
 	Branch string `json:"branch,omitempty"`
 
+	// TagSemver is a semantic version constraint, e.g. ">=1.4.0 <2.0.0".
+	// If set, Fleet follows the highest tag of the repository matching the
+	// constraint instead of a branch. Tags may be prefixed with "v".
+	// It cannot be used together with Revision.
+	// +nullable
+	// +optional
+	TagSemver string `json:"tagSemver,omitempty"`
+
 	// Revision A specific commit or tag to operate on.
 	// +nullable
 	Revision string `json:"revision,omitempty"`
@@ -99,6 +107,19 @@ type GitRepoSpec struct {
 	// +nullable
 	Paths []string `json:"paths,omitempty"`
 
+	// SparseCheckout only checks out the directories of Paths or Bundles,
+	// and the local Helm charts, values files and kustomize directories
+	// their fleet.yaml and kustomization files reference, instead of the
+	// whole repository. The git fetcher always checks out all files.
+	// +optional
+	SparseCheckout bool `json:"sparseCheckout,omitempty"`
+
+	// LFS downloads the Git LFS objects of the checked out files with the
+	// credentials of the repository, instead of deploying their pointer
+	// files.
+	// +optional
+	LFS bool `json:"lfs,omitempty"`
+
 	// Paused, when true, causes changes in Git not to be propagated down to the clusters but instead to mark
 	// resources as OutOfSync.
 	Paused bool `json:"paused,omitempty"`
@@ -144,6 +165,139 @@ type GitRepoSpec struct {
 	// Bundles defines the paths of bundles to be read.
 	// This drives the fleet resource scanner that simply loads the specified folders
 	Bundles []BundlePath `json:"bundles,omitempty"`
+
+	// Archive configures Fleet to poll an archive served over HTTP, the
+	// objects of an S3-compatible bucket or an OCI artifact, instead of a git
+	// repository. Repo is then the URL of the archive, e.g.
+	// "https://artifacts.example.com/config.tar.gz", the bucket and prefix,
+	// e.g. "s3://config/production/", or the OCI repository, e.g.
+	// "oci://ghcr.io/example/config". The prefix is a directory, unless it
+	// is the key of a single object.
+	// +optional
+	Archive *ArchiveSource `json:"archive,omitempty"`
+
+	// Sources are additional git repositories, which are cloned into
+	// subdirectories of the repository, e.g. to reference kustomize bases
+	// or Helm values files of another repository in a fleet.yaml. A new
+	// commit in any of them triggers a new sync and Commit in the status is
+	// then a revision combining the commits of all sources. Sources are
+	// resolved by polling, webhooks are ignored for such GitRepos.
+	// +optional
+	Sources []GitRepoSource `json:"sources,omitempty"`
+}
+
+// GitRepoSource is an additional git repository of a GitRepo. It uses the
+// CA bundle, TLS and LFS settings of the GitRepo and is always checked out
+// completely.
+type GitRepoSource struct {
+	// Name is the subdirectory of the repository the source is mounted at.
+	// A directory of the same name in the repository is hidden by it.
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name"`
+
+	// Repo is a URL to a git repo to clone and index.
+	Repo string `json:"repo"`
+
+	// Branch the source follows, "master" if neither branch nor revision
+	// is set.
+	// +optional
+	Branch string `json:"branch,omitempty"`
+
+	// Revision, a git commit or tag, to check out instead of following a
+	// branch.
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// Path is the directory of the repository, which is mounted. Defaults
+	// to the root of the repository.
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// ClientSecretName is the name of the client secret to be used to
+	// connect to the repo. The default git credentials secret is used if
+	// empty, like for the GitRepo.
+	// +optional
+	ClientSecretName string `json:"clientSecretName,omitempty"`
+}
+
+// GitRepoSourceStatus is the commit of an additional source of a GitRepo.
+type GitRepoSourceStatus struct {
+	// Name of the source.
+	Name string `json:"name"`
+	// Commit is the Git commit hash of the source.
+	Commit string `json:"commit,omitempty"`
+}
+
+// GitRepoPathStatus is the result of creating the bundle of a path of the
+// GitRepo.
+type GitRepoPathStatus struct {
+	// Path of the bundle directory, relative to the repository.
+	Path string `json:"path"`
+	// Options is the options file of the bundle, if it is not the
+	// fleet.yaml in the bundle directory.
+	// +optional
+	Options string `json:"options,omitempty"`
+	// BundleName is the name of the bundle created from the path. It is
+	// empty if the bundle could not be generated.
+	// +optional
+	BundleName string `json:"bundleName,omitempty"`
+	// Error is true if the bundle of the path could not be generated or
+	// stored.
+	// +optional
+	Error bool `json:"error,omitempty"`
+	// Message is the error message.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// ContentHash is the SHA256 sum of the resources of the bundle, like
+	// the resourcesSha256Sum in the status of the bundle.
+	// +optional
+	ContentHash string `json:"contentHash,omitempty"`
+}
+
+const (
+	// ArchiveTypeHTTP is an archive downloaded over HTTP(S).
+	ArchiveTypeHTTP = "http"
+	// ArchiveTypeS3 are the objects in a directory of an S3-compatible bucket, or a single object.
+	ArchiveTypeS3 = "s3"
+	// ArchiveTypeOCI is an OCI artifact, e.g. pushed by ORAS or "flux push artifact".
+	ArchiveTypeOCI = "oci"
+)
+
+// ArchiveSource is a source of resources, which is not a git repository.
+// Changes are detected by the ETags of the archive or the objects, or by
+// the checksum of the archive if the server does not send an ETag. For OCI
+// artifacts, changes are detected by their digest.
+// Archives (.tar.gz, .tgz, .zip, ...) and tar layers are unpacked. The
+// credentials are read from the basic auth secret referenced by
+// ClientSecretName, which contains the access key ID and the secret access
+// key for S3.
+// Branch is ignored. For OCI artifacts, Revision is the tag or digest to
+// follow, "latest" if empty, and TagSemver selects the highest tag matching
+// the constraint instead. They are ignored for other types.
+type ArchiveSource struct {
+	// Type of the source, "http", "s3" or "oci".
+	// +kubebuilder:validation:Enum=http;s3;oci
+	Type string `json:"type"`
+
+	// Endpoint of the S3-compatible service, e.g. "minio.example.com:9000".
+	// Defaults to AWS S3.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Region of the S3 bucket, "us-east-1" if empty.
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// Insecure connects to the S3 endpoint or the OCI registry over plain
+	// HTTP.
+	// +optional
+	Insecure bool `json:"insecure,omitempty"`
+
+	// CosignPublicKey is a PEM encoded public key. If set, OCI artifacts are
+	// only deployed if they have a cosign signature made with the key.
+	// +optional
+	CosignPublicKey string `json:"cosignPublicKey,omitempty"`
 }
 
 type BundlePath struct {
@@ -171,6 +325,23 @@ type GitTarget struct {
 	// ClusterGroupSelector is a label selector to select cluster groups.
 	// +nullable
 	ClusterGroupSelector *metav1.LabelSelector `json:"clusterGroupSelector,omitempty"`
+	// ClusterFactSelector is a label selector to select clusters by the
+	// facts reported by their agents.
+	// +nullable
+	ClusterFactSelector *metav1.LabelSelector `json:"clusterFactSelector,omitempty"`
+	// ClusterExpression is a CEL expression to select clusters, see
+	// BundleTarget.
+	// +nullable
+	ClusterExpression string `json:"clusterExpression,omitempty"`
+	// MaxClusters limits the number of clusters this target deploys to,
+	// see BundleTarget.
+	// +nullable
+	// +kubebuilder:validation:Minimum=0
+	MaxClusters *int `json:"maxClusters,omitempty"`
+	// SpreadByLabel spreads the clusters chosen by MaxClusters evenly over
+	// the values of this cluster label, see BundleTarget.
+	// +nullable
+	SpreadByLabel string `json:"spreadByLabel,omitempty"`
 }
 
 type GitRepoStatus struct {
@@ -190,12 +361,74 @@ type GitRepoStatus struct {
 	// PollingCommit is the latest Git commit hash received from polling
 	// +optional
 	PollingCommit string `json:"pollingCommit,omitempty"`
+	// Tag is the Git tag matching spec.tagSemver, which was selected when
+	// resolving the latest commit.
+	// +optional
+	Tag string `json:"tag,omitempty"`
+	// RepoCommit is the Git commit hash of the repository, if the GitRepo
+	// has additional sources and Commit combines the commits of all of
+	// them.
+	// +optional
+	RepoCommit string `json:"repoCommit,omitempty"`
+	// Sources are the commits of the additional sources, which were
+	// resolved together with RepoCommit.
+	// +optional
+	Sources []GitRepoSourceStatus `json:"sources,omitempty"`
 	// GitJobStatus is the status of the last Git job run, e.g. "Current" if there was no error.
 	GitJobStatus string `json:"gitJobStatus,omitempty"`
+	// Paths are the results of creating the bundle of each path, by the
+	// last Git job or git fetcher sync which got to create bundles. A path
+	// which fails does not prevent the bundles of the other paths from
+	// being created.
+	// +optional
+	Paths []GitRepoPathStatus `json:"paths,omitempty"`
 	// LastSyncedImageScanTime is the time of the last image scan.
 	LastSyncedImageScanTime metav1.Time `json:"lastSyncedImageScanTime,omitempty"`
 	// LastPollingTime is the last time the polling check was triggered
 	LastPollingTime metav1.Time `json:"lastPollingTriggered,omitempty"`
+	// FetcherRequest is the sync requested from the git fetcher, which is
+	// used instead of Git jobs if the git fetcher is enabled.
+	// +optional
+	FetcherRequest *GitFetcherRequest `json:"fetcherRequest,omitempty"`
+	// FetcherResult is the result of the last sync by the git fetcher.
+	// +optional
+	FetcherResult *GitFetcherResult `json:"fetcherResult,omitempty"`
+}
+
+// GitFetcherRequest is a request for the git fetcher to create the bundles
+// of a commit.
+type GitFetcherRequest struct {
+	// ID identifies the request. It changes with the commit, the generation
+	// and the force sync generation of the GitRepo.
+	ID string `json:"id,omitempty"`
+	// Commit is the Git commit hash to create the bundles from.
+	Commit string `json:"commit,omitempty"`
+	// PreviousCommit is the Git commit hash of the last successful sync. If
+	// set, only bundles affected by the changes since then are regenerated.
+	// +optional
+	PreviousCommit string `json:"previousCommit,omitempty"`
+	// RepoCommit is the Git commit hash of the repository, if Commit
+	// combines the commits of several sources.
+	// +optional
+	RepoCommit string `json:"repoCommit,omitempty"`
+	// Sources are the commits of the additional sources to check out.
+	// +optional
+	Sources []GitRepoSourceStatus `json:"sources,omitempty"`
+}
+
+// GitFetcherResult is the result of a sync by the git fetcher.
+type GitFetcherResult struct {
+	// ID of the request, which was synced.
+	ID string `json:"id,omitempty"`
+	// Status of the sync, "InProgress", "Current" or "Failed", like the
+	// status of a Git job.
+	Status string `json:"status,omitempty"`
+	// Message is the error message of a failed sync.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// Paths are the results of creating the bundle of each path.
+	// +optional
+	Paths []GitRepoPathStatus `json:"paths,omitempty"`
 }
 
 type GitRepoDisplay struct {
@@ -0,0 +1,175 @@
+package v1alpha1
+
+import (
+	"github.com/rancher/wrangler/v3/pkg/genericcondition"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func init() {
+	InternalSchemeBuilder.Register(&GitRepoGenerator{}, &GitRepoGeneratorList{})
+}
+
+const (
+	// GitRepoGeneratorLabel is the label of GitRepos, which holds the name of the GitRepoGenerator that created
+	// them.
+	GitRepoGeneratorLabel = "fleet.cattle.io/gitrepo-generator"
+
+	GitRepoGeneratorProviderGitHub = "github"
+	GitRepoGeneratorProviderGitLab = "gitlab"
+	GitRepoGeneratorProviderGitea  = "gitea"
+
+	GitRepoGeneratorScannedCondition = "Scanned"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:categories=fleet,path=gitrepogenerators
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Provider",type=string,JSONPath=`.spec.provider`
+// +kubebuilder:printcolumn:name="Organization",type=string,JSONPath=`.spec.organization`
+// +kubebuilder:printcolumn:name="GitRepos",type=integer,JSONPath=`.status.gitRepoCount`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.conditions[?(@.type=="Scanned")].message`
+
+// GitRepoGenerator scans a GitHub organization, GitLab group or Gitea
+// organization for repositories and creates a GitRepo from a template for
+// each matching repository. GitRepos of repositories, which no longer match,
+// are deleted.
+type GitRepoGenerator struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GitRepoGeneratorSpec   `json:"spec,omitempty"`
+	Status GitRepoGeneratorStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// GitRepoGeneratorList contains a list of GitRepoGenerator
+type GitRepoGeneratorList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GitRepoGenerator `json:"items"`
+}
+
+type GitRepoGeneratorSpec struct {
+	// Provider is the Git hosting service to scan.
+	// +required
+	// +kubebuilder:validation:Enum=github;gitlab;gitea
+	Provider string `json:"provider,omitempty"`
+
+	// URL is the base URL of the API of the provider. It defaults to
+	// https://api.github.com for GitHub and https://gitlab.com for GitLab,
+	// and is required for Gitea.
+	// +nullable
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// Organization is the GitHub organization, GitLab group or Gitea
+	// organization to scan. Subgroups of GitLab groups are included.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	Organization string `json:"organization,omitempty"`
+
+	// ClientSecretName is the name of the secret holding the credentials
+	// for the API of the provider. The password of a basic-auth secret is
+	// used as a token. For GitHub, the secret may hold the keys of a GitHub
+	// App instead. The secret may also configure a credential provider.
+	// +nullable
+	// +optional
+	ClientSecretName string `json:"clientSecretName,omitempty"`
+
+	// Filters select the repositories to create GitRepos for. A repository
+	// is selected if it matches any of the filters. All repositories are
+	// selected if no filter is given. Archived repositories are never
+	// selected.
+	// +nullable
+	// +optional
+	Filters []GitRepoGeneratorFilter `json:"filters,omitempty"`
+
+	// FleetYAMLPath is the path of the file a repository must contain on
+	// its branch to be selected. It defaults to fleet.yaml.
+	// +nullable
+	// +optional
+	FleetYAMLPath string `json:"fleetYAMLPath,omitempty"`
+
+	// SSH selects the SSH URLs of the repositories for the GitRepos, instead
+	// of their HTTPS URLs.
+	// +optional
+	SSH bool `json:"ssh,omitempty"`
+
+	// Interval is the time between scans of the organization. It defaults
+	// to one hour.
+	// +nullable
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// Template is the template of the created GitRepos.
+	// +optional
+	Template GitRepoGeneratorTemplate `json:"template,omitempty"`
+}
+
+// GitRepoGeneratorFilter matches repositories by name and topics.
+type GitRepoGeneratorFilter struct {
+	// NamePattern is a regular expression the name of the repository must
+	// match.
+	// +nullable
+	// +optional
+	NamePattern string `json:"namePattern,omitempty"`
+
+	// Topics are topics the repository must all have.
+	// +nullable
+	// +optional
+	Topics []string `json:"topics,omitempty"`
+}
+
+// GitRepoGeneratorTemplate is the template of the GitRepos created by a
+// GitRepoGenerator.
+type GitRepoGeneratorTemplate struct {
+	// Labels are added to the created GitRepos.
+	// +nullable
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations are added to the created GitRepos.
+	// +nullable
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Spec is the spec of the created GitRepos, e.g. their targets and
+	// service account. The repository is set by the generator. The branch
+	// defaults to the default branch of the repository.
+	// +optional
+	Spec GitRepoSpec `json:"spec,omitempty"`
+}
+
+type GitRepoGeneratorStatus struct {
+	// +optional
+	Conditions []genericcondition.GenericCondition `json:"conditions,omitempty"`
+
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastScanTime is the time of the last successful scan.
+	// +optional
+	LastScanTime metav1.Time `json:"lastScanTime,omitempty"`
+
+	// GitRepoCount is the number of GitRepos created by the generator.
+	// +optional
+	GitRepoCount int `json:"gitRepoCount"`
+
+	// Repositories are the selected repositories and their GitRepos.
+	// +nullable
+	// +optional
+	Repositories []GitRepoGeneratorRepository `json:"repositories,omitempty"`
+}
+
+// GitRepoGeneratorRepository is a repository selected by a GitRepoGenerator.
+type GitRepoGeneratorRepository struct {
+	// Name is the full name of the repository, including its organization.
+	Name string `json:"name,omitempty"`
+	// Repo is the URL of the repository.
+	Repo string `json:"repo,omitempty"`
+	// GitRepoName is the name of the GitRepo created for the repository.
+	GitRepoName string `json:"gitRepoName,omitempty"`
+}
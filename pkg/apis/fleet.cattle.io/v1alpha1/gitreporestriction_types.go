@@ -1,6 +1,7 @@
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -28,14 +29,15 @@ type GitRepoRestriction struct {
 	// +nullable
 	AllowedServiceAccounts []string `json:"allowedServiceAccounts,omitempty"`
 	// AllowedRepoPatterns is a list of regex patterns that restrict the
-	// valid values of the Repo field of a GitRepo.
+	// valid values of the Repo field of a GitRepo and of its sources.
 	// +nullable
 	AllowedRepoPatterns []string `json:"allowedRepoPatterns,omitempty"`
 
-	// DefaultClientSecretName overrides the GitRepo's default client secret.
+	// DefaultClientSecretName overrides the default client secret of
+	// GitRepos and their sources.
 	// +nullable
 	DefaultClientSecretName string `json:"defaultClientSecretName,omitempty"`
-	// AllowedClientSecretNames is a list of client secret names that GitRepos are allowed to use.
+	// AllowedClientSecretNames is a list of client secret names that GitRepos and their sources are allowed to use.
 	// +nullable
 	AllowedClientSecretNames []string `json:"allowedClientSecretNames,omitempty"`
 
@@ -44,6 +46,48 @@ type GitRepoRestriction struct {
 	// be set.
 	// +nullable
 	AllowedTargetNamespaces []string `json:"allowedTargetNamespaces,omitempty"`
+
+	// AllowedClusterGroups restricts targets to the given cluster groups.
+	// A target is allowed if its clusterGroup is one of them, or if it is
+	// allowed by AllowedClusterSelectors. Targets of GitRepos without
+	// targets use the "default" cluster group.
+	// Clusters of child groups are only members of an allowed group, if
+	// the child group is allowed as well.
+	// +nullable
+	AllowedClusterGroups []string `json:"allowedClusterGroups,omitempty"`
+	// AllowedClusterSelectors restricts targets to clusters matching one
+	// of the given selectors. A target is allowed if its clusterSelector
+	// contains all requirements of one of the selectors, i.e. it selects a
+	// subset of the allowed clusters.
+	// +nullable
+	AllowedClusterSelectors []metav1.LabelSelector `json:"allowedClusterSelectors,omitempty"`
+
+	// AllowedResourceKinds restricts the resources of bundles to the given
+	// kinds. Kinds are given as "Kind" or "Kind.group", e.g. "ConfigMap"
+	// or "Deployment.apps".
+	// Only plain YAML resources can be checked. While resource kinds are
+	// restricted, bundles which contain helm charts, kustomizations or
+	// SOPS encrypted files, enable resource templating, use remote helm
+	// charts, are created by HelmOps or are stored in an OCI registry are
+	// rejected.
+	// +nullable
+	AllowedResourceKinds []string `json:"allowedResourceKinds,omitempty"`
+	// DeniedResourceKinds denies resources of the given kinds, see
+	// AllowedResourceKinds.
+	// +nullable
+	DeniedResourceKinds []string `json:"deniedResourceKinds,omitempty"`
+	// DenyClusterScopedResources denies cluster-scoped resources, like
+	// ClusterRoles. Resources of kinds unknown to the management cluster
+	// are denied, too, as their scope cannot be determined.
+	// +optional
+	DenyClusterScopedResources bool `json:"denyClusterScopedResources,omitempty"`
+
+	// MaxBundleSize is the maximum size of a bundle's resources, as
+	// stored in the bundle. Bundles which use remote helm charts, are
+	// created by HelmOps or are stored in an OCI registry are rejected, as
+	// their size is not known.
+	// +nullable
+	MaxBundleSize *resource.Quantity `json:"maxBundleSize,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -77,4 +77,8 @@ type ScheduleTarget struct {
 	// ClusterGroupSelector is a label selector to select cluster groups.
 	// +nullable
 	ClusterGroupSelector *metav1.LabelSelector `json:"clusterGroupSelector,omitempty"`
+	// ClusterExpression is a CEL expression to select clusters, see
+	// BundleTarget.
+	// +nullable
+	ClusterExpression string `json:"clusterExpression,omitempty"`
 }
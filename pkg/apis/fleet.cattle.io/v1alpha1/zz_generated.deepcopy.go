@@ -84,6 +84,21 @@ func (in *AlphabeticalPolicy) DeepCopy() *AlphabeticalPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArchiveSource) DeepCopyInto(out *ArchiveSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArchiveSource.
+func (in *ArchiveSource) DeepCopy() *ArchiveSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ArchiveSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Bundle) DeepCopyInto(out *Bundle) {
 	*out = *in
@@ -233,6 +248,26 @@ func (in *BundleDeploymentOptions) DeepCopyInto(out *BundleDeploymentOptions) {
 		*out = make([]OverwrittenResource, len(*in))
 		copy(*out, *in)
 	}
+	if in.ImageRewrite != nil {
+		in, out := &in.ImageRewrite, &out.ImageRewrite
+		*out = new(ImageRewrite)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Sops != nil {
+		in, out := &in.Sops, &out.Sops
+		*out = new(SopsOptions)
+		**out = **in
+	}
+	if in.ResourceTemplating != nil {
+		in, out := &in.ResourceTemplating, &out.ResourceTemplating
+		*out = new(ResourceTemplating)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Outputs != nil {
+		in, out := &in.Outputs, &out.Outputs
+		*out = make([]BundleOutput, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BundleDeploymentOptions.
@@ -329,6 +364,18 @@ func (in *BundleDeploymentStatus) DeepCopyInto(out *BundleDeploymentStatus) {
 		}
 	}
 	out.ResourceCounts = in.ResourceCounts
+	if in.RewrittenImages != nil {
+		in, out := &in.RewrittenImages, &out.RewrittenImages
+		*out = make([]RewrittenImage, len(*in))
+		copy(*out, *in)
+	}
+	if in.Outputs != nil {
+		in, out := &in.Outputs, &out.Outputs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BundleDeploymentStatus.
@@ -470,6 +517,21 @@ func (in *BundleNamespaceMappingList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BundleOutput) DeepCopyInto(out *BundleOutput) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BundleOutput.
+func (in *BundleOutput) DeepCopy() *BundleOutput {
+	if in == nil {
+		return nil
+	}
+	out := new(BundleOutput)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BundlePath) DeepCopyInto(out *BundlePath) {
 	*out = *in
@@ -593,6 +655,13 @@ func (in *BundleStatus) DeepCopyInto(out *BundleStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.TargetSelections != nil {
+		in, out := &in.TargetSelections, &out.TargetSelections
+		*out = make([]TargetSelection, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	out.Display = in.Display
 	if in.ResourceKey != nil {
 		in, out := &in.ResourceKey, &out.ResourceKey
@@ -647,6 +716,11 @@ func (in *BundleTarget) DeepCopyInto(out *BundleTarget) {
 		*out = new(v1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ClusterFactSelector != nil {
+		in, out := &in.ClusterFactSelector, &out.ClusterFactSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.NamespaceLabels != nil {
 		in, out := &in.NamespaceLabels, &out.NamespaceLabels
 		*out = make(map[string]string, len(*in))
@@ -661,6 +735,11 @@ func (in *BundleTarget) DeepCopyInto(out *BundleTarget) {
 			(*out)[key] = val
 		}
 	}
+	if in.MaxClusters != nil {
+		in, out := &in.MaxClusters, &out.MaxClusters
+		*out = new(int)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BundleTarget.
@@ -686,6 +765,11 @@ func (in *BundleTargetRestriction) DeepCopyInto(out *BundleTargetRestriction) {
 		*out = new(v1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ClusterFactSelector != nil {
+		in, out := &in.ClusterFactSelector, &out.ClusterFactSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BundleTargetRestriction.
@@ -740,6 +824,46 @@ func (in *ClusterDisplay) DeepCopy() *ClusterDisplay {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterFacts) DeepCopyInto(out *ClusterFacts) {
+	*out = *in
+	if in.OperatingSystems != nil {
+		in, out := &in.OperatingSystems, &out.OperatingSystems
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Architectures != nil {
+		in, out := &in.Architectures, &out.Architectures
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ProviderIDPrefixes != nil {
+		in, out := &in.ProviderIDPrefixes, &out.ProviderIDPrefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.APIGroups != nil {
+		in, out := &in.APIGroups, &out.APIGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StorageClasses != nil {
+		in, out := &in.StorageClasses, &out.StorageClasses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterFacts.
+func (in *ClusterFacts) DeepCopy() *ClusterFacts {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterFacts)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterGroup) DeepCopyInto(out *ClusterGroup) {
 	*out = *in
@@ -822,6 +946,15 @@ func (in *ClusterGroupSpec) DeepCopyInto(out *ClusterGroupSpec) {
 		*out = new(v1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ImageRewrite != nil {
+		in, out := &in.ImageRewrite, &out.ImageRewrite
+		*out = new(ImageRewrite)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TemplateValues != nil {
+		in, out := &in.TemplateValues, &out.TemplateValues
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterGroupSpec.
@@ -1098,6 +1231,11 @@ func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ImageRewrite != nil {
+		in, out := &in.ImageRewrite, &out.ImageRewrite
+		*out = new(ImageRewrite)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.TemplateValues != nil {
 		in, out := &in.TemplateValues, &out.TemplateValues
 		*out = (*in).DeepCopy()
@@ -1158,6 +1296,11 @@ func (in *ClusterStatus) DeepCopyInto(out *ClusterStatus) {
 	}
 	out.Display = in.Display
 	in.Agent.DeepCopyInto(&out.Agent)
+	if in.Facts != nil {
+		in, out := &in.Facts, &out.Facts
+		*out = new(ClusterFacts)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.GarbageCollectionInterval != nil {
 		in, out := &in.GarbageCollectionInterval, &out.GarbageCollectionInterval
 		*out = new(v1.Duration)
@@ -1241,6 +1384,11 @@ func (in *Content) DeepCopyInto(out *Content) {
 		*out = make([]byte, len(*in))
 		copy(*out, *in)
 	}
+	if in.Chunks != nil {
+		in, out := &in.Chunks, &out.Chunks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	out.Status = in.Status
 }
 
@@ -1361,6 +1509,21 @@ func (in *DownstreamResource) DeepCopy() *DownstreamResource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalSecretRef) DeepCopyInto(out *ExternalSecretRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalSecretRef.
+func (in *ExternalSecretRef) DeepCopy() *ExternalSecretRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalSecretRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FleetYAML) DeepCopyInto(out *FleetYAML) {
 	*out = *in
@@ -1415,6 +1578,46 @@ func (in *GenericMap) DeepCopy() *GenericMap {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitFetcherRequest) DeepCopyInto(out *GitFetcherRequest) {
+	*out = *in
+	if in.Sources != nil {
+		in, out := &in.Sources, &out.Sources
+		*out = make([]GitRepoSourceStatus, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitFetcherRequest.
+func (in *GitFetcherRequest) DeepCopy() *GitFetcherRequest {
+	if in == nil {
+		return nil
+	}
+	out := new(GitFetcherRequest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitFetcherResult) DeepCopyInto(out *GitFetcherResult) {
+	*out = *in
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]GitRepoPathStatus, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitFetcherResult.
+func (in *GitFetcherResult) DeepCopy() *GitFetcherResult {
+	if in == nil {
+		return nil
+	}
+	out := new(GitFetcherResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitOpsBundleDeploymentOptions) DeepCopyInto(out *GitOpsBundleDeploymentOptions) {
 	*out = *in
@@ -1502,6 +1705,184 @@ func (in *GitRepoDisplay) DeepCopy() *GitRepoDisplay {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitRepoGenerator) DeepCopyInto(out *GitRepoGenerator) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoGenerator.
+func (in *GitRepoGenerator) DeepCopy() *GitRepoGenerator {
+	if in == nil {
+		return nil
+	}
+	out := new(GitRepoGenerator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GitRepoGenerator) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitRepoGeneratorFilter) DeepCopyInto(out *GitRepoGeneratorFilter) {
+	*out = *in
+	if in.Topics != nil {
+		in, out := &in.Topics, &out.Topics
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoGeneratorFilter.
+func (in *GitRepoGeneratorFilter) DeepCopy() *GitRepoGeneratorFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(GitRepoGeneratorFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitRepoGeneratorList) DeepCopyInto(out *GitRepoGeneratorList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GitRepoGenerator, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoGeneratorList.
+func (in *GitRepoGeneratorList) DeepCopy() *GitRepoGeneratorList {
+	if in == nil {
+		return nil
+	}
+	out := new(GitRepoGeneratorList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GitRepoGeneratorList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitRepoGeneratorRepository) DeepCopyInto(out *GitRepoGeneratorRepository) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoGeneratorRepository.
+func (in *GitRepoGeneratorRepository) DeepCopy() *GitRepoGeneratorRepository {
+	if in == nil {
+		return nil
+	}
+	out := new(GitRepoGeneratorRepository)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitRepoGeneratorSpec) DeepCopyInto(out *GitRepoGeneratorSpec) {
+	*out = *in
+	if in.Filters != nil {
+		in, out := &in.Filters, &out.Filters
+		*out = make([]GitRepoGeneratorFilter, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	in.Template.DeepCopyInto(&out.Template)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoGeneratorSpec.
+func (in *GitRepoGeneratorSpec) DeepCopy() *GitRepoGeneratorSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GitRepoGeneratorSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitRepoGeneratorStatus) DeepCopyInto(out *GitRepoGeneratorStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]genericcondition.GenericCondition, len(*in))
+		copy(*out, *in)
+	}
+	in.LastScanTime.DeepCopyInto(&out.LastScanTime)
+	if in.Repositories != nil {
+		in, out := &in.Repositories, &out.Repositories
+		*out = make([]GitRepoGeneratorRepository, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoGeneratorStatus.
+func (in *GitRepoGeneratorStatus) DeepCopy() *GitRepoGeneratorStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GitRepoGeneratorStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitRepoGeneratorTemplate) DeepCopyInto(out *GitRepoGeneratorTemplate) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoGeneratorTemplate.
+func (in *GitRepoGeneratorTemplate) DeepCopy() *GitRepoGeneratorTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(GitRepoGeneratorTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitRepoList) DeepCopyInto(out *GitRepoList) {
 	*out = *in
@@ -1534,6 +1915,21 @@ func (in *GitRepoList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitRepoPathStatus) DeepCopyInto(out *GitRepoPathStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoPathStatus.
+func (in *GitRepoPathStatus) DeepCopy() *GitRepoPathStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GitRepoPathStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitRepoRestriction) DeepCopyInto(out *GitRepoRestriction) {
 	*out = *in
@@ -1559,6 +1955,33 @@ func (in *GitRepoRestriction) DeepCopyInto(out *GitRepoRestriction) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.AllowedClusterGroups != nil {
+		in, out := &in.AllowedClusterGroups, &out.AllowedClusterGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedClusterSelectors != nil {
+		in, out := &in.AllowedClusterSelectors, &out.AllowedClusterSelectors
+		*out = make([]v1.LabelSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AllowedResourceKinds != nil {
+		in, out := &in.AllowedResourceKinds, &out.AllowedResourceKinds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DeniedResourceKinds != nil {
+		in, out := &in.DeniedResourceKinds, &out.DeniedResourceKinds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxBundleSize != nil {
+		in, out := &in.MaxBundleSize, &out.MaxBundleSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoRestriction.
@@ -1611,6 +2034,36 @@ func (in *GitRepoRestrictionList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitRepoSource) DeepCopyInto(out *GitRepoSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoSource.
+func (in *GitRepoSource) DeepCopy() *GitRepoSource {
+	if in == nil {
+		return nil
+	}
+	out := new(GitRepoSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitRepoSourceStatus) DeepCopyInto(out *GitRepoSourceStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoSourceStatus.
+func (in *GitRepoSourceStatus) DeepCopy() *GitRepoSourceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GitRepoSourceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitRepoSpec) DeepCopyInto(out *GitRepoSpec) {
 	*out = *in
@@ -1656,6 +2109,16 @@ func (in *GitRepoSpec) DeepCopyInto(out *GitRepoSpec) {
 		*out = make([]BundlePath, len(*in))
 		copy(*out, *in)
 	}
+	if in.Archive != nil {
+		in, out := &in.Archive, &out.Archive
+		*out = new(ArchiveSource)
+		**out = **in
+	}
+	if in.Sources != nil {
+		in, out := &in.Sources, &out.Sources
+		*out = make([]GitRepoSource, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoSpec.
@@ -1672,8 +2135,28 @@ func (in *GitRepoSpec) DeepCopy() *GitRepoSpec {
 func (in *GitRepoStatus) DeepCopyInto(out *GitRepoStatus) {
 	*out = *in
 	in.StatusBase.DeepCopyInto(&out.StatusBase)
+	if in.Sources != nil {
+		in, out := &in.Sources, &out.Sources
+		*out = make([]GitRepoSourceStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]GitRepoPathStatus, len(*in))
+		copy(*out, *in)
+	}
 	in.LastSyncedImageScanTime.DeepCopyInto(&out.LastSyncedImageScanTime)
 	in.LastPollingTime.DeepCopyInto(&out.LastPollingTime)
+	if in.FetcherRequest != nil {
+		in, out := &in.FetcherRequest, &out.FetcherRequest
+		*out = new(GitFetcherRequest)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FetcherResult != nil {
+		in, out := &in.FetcherResult, &out.FetcherResult
+		*out = new(GitFetcherResult)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoStatus.
@@ -1699,6 +2182,16 @@ func (in *GitTarget) DeepCopyInto(out *GitTarget) {
 		*out = new(v1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ClusterFactSelector != nil {
+		in, out := &in.ClusterFactSelector, &out.ClusterFactSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaxClusters != nil {
+		in, out := &in.MaxClusters, &out.MaxClusters
+		*out = new(int)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitTarget.
@@ -1837,6 +2330,11 @@ func (in *HelmOptions) DeepCopyInto(out *HelmOptions) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.SopsValuesFiles != nil {
+		in, out := &in.SopsValuesFiles, &out.SopsValuesFiles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmOptions.
@@ -1902,6 +2400,68 @@ func (in *ImagePolicyChoice) DeepCopy() *ImagePolicyChoice {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageRewrite) DeepCopyInto(out *ImageRewrite) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]ImageRewriteRule, len(*in))
+		copy(*out, *in)
+	}
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]ImageRewritePath, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageRewrite.
+func (in *ImageRewrite) DeepCopy() *ImageRewrite {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageRewrite)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageRewritePath) DeepCopyInto(out *ImageRewritePath) {
+	*out = *in
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageRewritePath.
+func (in *ImageRewritePath) DeepCopy() *ImageRewritePath {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageRewritePath)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageRewriteRule) DeepCopyInto(out *ImageRewriteRule) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageRewriteRule.
+func (in *ImageRewriteRule) DeepCopy() *ImageRewriteRule {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageRewriteRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ImageScan) DeepCopyInto(out *ImageScan) {
 	*out = *in
@@ -2320,6 +2880,40 @@ func (in *ResourceKey) DeepCopy() *ResourceKey {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceTemplating) DeepCopyInto(out *ResourceTemplating) {
+	*out = *in
+	if in.Context != nil {
+		in, out := &in.Context, &out.Context
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceTemplating.
+func (in *ResourceTemplating) DeepCopy() *ResourceTemplating {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceTemplating)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RewrittenImage) DeepCopyInto(out *RewrittenImage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RewrittenImage.
+func (in *RewrittenImage) DeepCopy() *RewrittenImage {
+	if in == nil {
+		return nil
+	}
+	out := new(RewrittenImage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RolloutStrategy) DeepCopyInto(out *RolloutStrategy) {
 	*out = *in
@@ -2542,6 +3136,21 @@ func (in *SemVerPolicy) DeepCopy() *SemVerPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SopsOptions) DeepCopyInto(out *SopsOptions) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SopsOptions.
+func (in *SopsOptions) DeepCopy() *SopsOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(SopsOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StatusBase) DeepCopyInto(out *StatusBase) {
 	*out = *in
@@ -2603,6 +3212,42 @@ func (in *StatusDisplay) DeepCopy() *StatusDisplay {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetSelection) DeepCopyInto(out *TargetSelection) {
+	*out = *in
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetSelection.
+func (in *TargetSelection) DeepCopy() *TargetSelection {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetSelection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpstreamSecretKeySelector) DeepCopyInto(out *UpstreamSecretKeySelector) {
+	*out = *in
+	out.LocalObjectReference = in.LocalObjectReference
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpstreamSecretKeySelector.
+func (in *UpstreamSecretKeySelector) DeepCopy() *UpstreamSecretKeySelector {
+	if in == nil {
+		return nil
+	}
+	out := new(UpstreamSecretKeySelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ValuesFrom) DeepCopyInto(out *ValuesFrom) {
 	*out = *in
@@ -2616,6 +3261,16 @@ func (in *ValuesFrom) DeepCopyInto(out *ValuesFrom) {
 		*out = new(SecretKeySelector)
 		**out = **in
 	}
+	if in.UpstreamSecretKeyRef != nil {
+		in, out := &in.UpstreamSecretKeyRef, &out.UpstreamSecretKeyRef
+		*out = new(UpstreamSecretKeySelector)
+		**out = **in
+	}
+	if in.ExternalSecretRef != nil {
+		in, out := &in.ExternalSecretRef, &out.ExternalSecretRef
+		*out = new(ExternalSecretRef)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ValuesFrom.
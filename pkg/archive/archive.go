@@ -0,0 +1,447 @@
+// Package archive polls and downloads the resources of GitRepos, which use an archive served over HTTP, an
+// S3-compatible bucket or an OCI artifact as their source instead of a git repository.
+package archive
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/hashicorp/go-getter/v2"
+
+	v1alpha1 "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+const defaultRegion = "us-east-1"
+
+var (
+	// maxUnpackedSize limits the total size of the files unpacked from an archive.
+	maxUnpackedSize int64 = 1 << 30
+	// maxUnpackedFiles limits the number of files and directories unpacked from an archive.
+	maxUnpackedFiles = 100000
+)
+
+// Source is an archive served over HTTP, the objects under a prefix of an S3-compatible bucket or an OCI artifact.
+type Source struct {
+	// Type is v1alpha1.ArchiveTypeHTTP, v1alpha1.ArchiveTypeS3 or v1alpha1.ArchiveTypeOCI.
+	Type string
+	// URL is the URL of the archive, "s3://<bucket>/<prefix>" or "oci://<registry>/<repository>".
+	URL string
+
+	// Endpoint and Region configure the S3 client. Insecure connects to the S3 endpoint or the OCI registry over
+	// plain HTTP.
+	Endpoint string
+	Region   string
+	Insecure bool
+
+	// Username and Password are used for basic auth, or as access key ID and secret access key for S3.
+	Username string
+	Password string
+
+	CABundle        []byte
+	InsecureSkipTLS bool
+	Timeout         time.Duration
+
+	// Tag is the tag or digest of an OCI artifact, "latest" if empty. TagSemver selects the highest tag matching the
+	// semver constraint instead.
+	Tag       string
+	TagSemver string
+	// CosignPublicKey is the PEM encoded key, which OCI artifacts must be signed with, if set.
+	CosignPublicKey []byte
+	// ExpectedRevision is the revision an OCI artifact must have when it is downloaded, if set.
+	ExpectedRevision string
+}
+
+// Latest returns the revision of the current content of the source, like Revision. For OCI artifacts following a
+// semver constraint, it also returns the selected tag.
+func (s *Source) Latest(ctx context.Context) (string, string, error) {
+	if s.Type == v1alpha1.ArchiveTypeOCI {
+		return s.ociLatest(ctx)
+	}
+
+	revision, err := s.Revision(ctx)
+	return "", revision, err
+}
+
+// Revision returns an identifier of the current content of the source, which changes whenever the content changes.
+// It is computed from the ETags, or the checksum of the archive if the server does not send an ETag. For OCI
+// artifacts it is the beginning of their digest.
+func (s *Source) Revision(ctx context.Context) (string, error) {
+	switch s.Type {
+	case v1alpha1.ArchiveTypeHTTP:
+		return s.httpRevision(ctx)
+	case v1alpha1.ArchiveTypeS3:
+		return s.s3Revision(ctx)
+	case v1alpha1.ArchiveTypeOCI:
+		_, revision, err := s.ociLatest(ctx)
+		return revision, err
+	default:
+		return "", fmt.Errorf("unsupported archive type %q", s.Type)
+	}
+}
+
+// Download downloads the content of the source into dst, unpacking archives, and returns its revision.
+func (s *Source) Download(ctx context.Context, dst string) (string, error) {
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return "", err
+	}
+
+	switch s.Type {
+	case v1alpha1.ArchiveTypeHTTP:
+		return s.httpDownload(ctx, dst)
+	case v1alpha1.ArchiveTypeS3:
+		return s.s3Download(ctx, dst)
+	case v1alpha1.ArchiveTypeOCI:
+		return s.ociDownload(ctx, dst)
+	default:
+		return "", fmt.Errorf("unsupported archive type %q", s.Type)
+	}
+}
+
+func (s *Source) httpClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		InsecureSkipVerify: s.InsecureSkipTLS, //nolint:gosec // configured by the user
+		MinVersion:         tls.VersionTLS12,
+	}
+	if len(s.CABundle) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		pool.AppendCertsFromPEM(s.CABundle)
+		transport.TLSClientConfig.RootCAs = pool
+	}
+
+	return &http.Client{Transport: transport, Timeout: s.Timeout}
+}
+
+func (s *Source) httpRequest(ctx context.Context, method string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.Username != "" || s.Password != "" {
+		req.SetBasicAuth(s.Username, s.Password)
+	}
+
+	resp, err := s.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to %s %s: %s", method, redact(s.URL), resp.Status)
+	}
+
+	return resp, nil
+}
+
+func (s *Source) httpRevision(ctx context.Context) (string, error) {
+	resp, err := s.httpRequest(ctx, http.MethodHead)
+	if err == nil {
+		resp.Body.Close()
+		if etag := resp.Header.Get("ETag"); etag != "" {
+			return revision("etag", etag), nil
+		}
+	}
+
+	// Some servers neither send ETags nor support HEAD requests, fall back to the checksum of the archive.
+	resp, err = s.httpRequest(ctx, http.MethodGet)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, resp.Body); err != nil {
+		return "", err
+	}
+
+	return revision("sha256", hex.EncodeToString(h.Sum(nil))), nil
+}
+
+func (s *Source) httpDownload(ctx context.Context, dst string) (string, error) {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := s.httpRequest(ctx, http.MethodGet)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	tmp, err := os.CreateTemp("", "fleet-archive-")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, h), resp.Body); err != nil {
+		return "", err
+	}
+
+	if err := unpack(tmp.Name(), path.Base(u.Path), dst); err != nil {
+		return "", err
+	}
+
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		return revision("etag", etag), nil
+	}
+	return revision("sha256", hex.EncodeToString(h.Sum(nil))), nil
+}
+
+func (s *Source) s3Client() *s3.Client {
+	region := s.Region
+	if region == "" {
+		region = defaultRegion
+	}
+
+	return s3.New(s3.Options{
+		Region:     region,
+		HTTPClient: s.httpClient(),
+	}, func(o *s3.Options) {
+		if s.Username != "" || s.Password != "" {
+			o.Credentials = credentials.NewStaticCredentialsProvider(s.Username, s.Password, "")
+		}
+		if s.Endpoint != "" {
+			scheme := "https://"
+			if s.Insecure {
+				scheme = "http://"
+			}
+			o.BaseEndpoint = aws.String(scheme + strings.TrimPrefix(strings.TrimPrefix(s.Endpoint, "https://"), "http://"))
+			// S3-compatible services like MinIO do not support virtual hosted buckets by default
+			o.UsePathStyle = true
+		}
+	})
+}
+
+// s3Objects returns the keys, ETags and sizes of the objects under the prefix, sorted by key. A non-empty prefix is a
+// directory, e.g. "prod" matches "prod/cm.yaml" but not "production/cm.yaml", unless it is the key of a single
+// object.
+func (s *Source) s3Objects(ctx context.Context, c *s3.Client) (string, string, []s3Object, error) {
+	bucket, prefix, err := parseS3URL(s.URL)
+	if err != nil {
+		return "", "", nil, err
+	}
+	dir := prefix
+	if dir != "" && !strings.HasSuffix(dir, "/") {
+		dir += "/"
+	}
+
+	var objects []s3Object
+	p := s3.NewListObjectsV2Paginator(c, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return "", "", nil, fmt.Errorf("failed to list objects in %s: %w", s.URL, err)
+		}
+		for _, o := range page.Contents {
+			key := aws.ToString(o.Key)
+			obj := s3Object{key: key, etag: aws.ToString(o.ETag), size: aws.ToInt64(o.Size)}
+			if key == prefix && !strings.HasSuffix(key, "/") {
+				// the prefix is the key of a single object
+				return bucket, prefix, []s3Object{obj}, nil
+			}
+			if strings.HasSuffix(key, "/") || !strings.HasPrefix(key, dir) {
+				// folder placeholder or sibling of the prefix, like "production/" for "prod"
+				continue
+			}
+			objects = append(objects, obj)
+		}
+	}
+	if len(objects) == 0 {
+		return "", "", nil, fmt.Errorf("no objects found in %s", s.URL)
+	}
+
+	return bucket, prefix, objects, nil
+}
+
+func (s *Source) s3Revision(ctx context.Context) (string, error) {
+	_, _, objects, err := s.s3Objects(ctx, s.s3Client())
+	if err != nil {
+		return "", err
+	}
+
+	return s3Revision(objects), nil
+}
+
+func (s *Source) s3Download(ctx context.Context, dst string) (string, error) {
+	c := s.s3Client()
+	bucket, prefix, objects, err := s.s3Objects(ctx, c)
+	if err != nil {
+		return "", err
+	}
+
+	// the limits of unpacked archives apply to the objects, too
+	if len(objects) > maxUnpackedFiles {
+		return "", fmt.Errorf("too many objects in %s: %d, limit is %d", s.URL, len(objects), maxUnpackedFiles)
+	}
+	var size int64
+	for _, o := range objects {
+		size += o.size
+	}
+	if size > maxUnpackedSize {
+		return "", fmt.Errorf("objects in %s are larger than limit: %d, limit is %d", s.URL, size, maxUnpackedSize)
+	}
+
+	for _, o := range objects {
+		rel := strings.TrimPrefix(strings.TrimPrefix(o.key, prefix), "/")
+		if rel == "" {
+			// the prefix is the key of a single object
+			rel = path.Base(o.key)
+		}
+		if !filepath.IsLocal(rel) {
+			return "", fmt.Errorf("invalid object key %q", o.key)
+		}
+
+		if err := s.s3DownloadObject(ctx, c, bucket, o, filepath.Join(dst, filepath.FromSlash(rel))); err != nil {
+			return "", err
+		}
+	}
+
+	return s3Revision(objects), nil
+}
+
+// s3DownloadObject downloads the object to dst, or unpacks it into the folder of dst if it is an archive. The object
+// must not be larger than listed, so the size limit cannot be bypassed.
+func (s *Source) s3DownloadObject(ctx context.Context, c *s3.Client, bucket string, o s3Object, dst string) error {
+	key := o.key
+	out, err := c.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if err != nil {
+		return fmt.Errorf("failed to get object %s: %w", key, err)
+	}
+	defer out.Body.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	if decompressor(key) == nil {
+		f, err := os.Create(dst)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return copyObject(f, out.Body, o)
+	}
+
+	tmp, err := os.CreateTemp("", "fleet-archive-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	if err := copyObject(tmp, out.Body, o); err != nil {
+		return err
+	}
+
+	// archives are unpacked into the folder they are stored in
+	return unpack(tmp.Name(), key, filepath.Dir(dst))
+}
+
+// copyObject copies at most the listed size of the object, plus one byte to detect larger objects.
+func copyObject(w io.Writer, body io.Reader, o s3Object) error {
+	n, err := io.Copy(w, io.LimitReader(body, o.size+1))
+	if err != nil {
+		return err
+	}
+	if n > o.size {
+		return fmt.Errorf("object %s is larger than its listed size %d", o.key, o.size)
+	}
+	return nil
+}
+
+type s3Object struct {
+	key  string
+	etag string
+	size int64
+}
+
+func s3Revision(objects []s3Object) string {
+	var b strings.Builder
+	for _, o := range objects {
+		fmt.Fprintf(&b, "%s\x00%s\n", o.key, o.etag)
+	}
+	return revision("s3", b.String())
+}
+
+// parseS3URL returns the bucket and prefix of an "s3://<bucket>/<prefix>" URL.
+func parseS3URL(s string) (string, string, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", "", err
+	}
+	if u.Scheme != "s3" || u.Host == "" {
+		return "", "", fmt.Errorf("invalid S3 URL %q, expected s3://<bucket>/<prefix>", s)
+	}
+
+	return u.Host, strings.TrimPrefix(u.Path, "/"), nil
+}
+
+// revision hashes the value into an identifier, which is as long as a git commit hash and can be used as label value.
+func revision(kind, value string) string {
+	h := sha256.Sum256([]byte(kind + ":" + value))
+	return hex.EncodeToString(h[:20])
+}
+
+// decompressor returns the decompressor for the extension of the file name, or nil if it is not an archive. The
+// decompressor fails if the archive exceeds maxUnpackedSize or maxUnpackedFiles.
+func decompressor(name string) getter.Decompressor {
+	name = strings.ToLower(name)
+	var (
+		d   getter.Decompressor
+		ext string
+	)
+	for e, dec := range getter.LimitedDecompressors(maxUnpackedFiles, maxUnpackedSize) {
+		// prefer the longest matching extension, e.g. "tar.gz" over "gz"
+		if strings.HasSuffix(name, "."+e) && len(e) > len(ext) {
+			d, ext = dec, e
+		}
+	}
+	return d
+}
+
+// unpack unpacks the archive in src into the dst directory. The archive format is derived from name.
+func unpack(src, name, dst string) error {
+	d := decompressor(name)
+	if d == nil {
+		return fmt.Errorf("unsupported archive format of %q", name)
+	}
+
+	if err := d.Decompress(dst, src, true, 0); err != nil {
+		return fmt.Errorf("failed to unpack %q: %w", name, err)
+	}
+
+	return nil
+}
+
+// redact removes credentials from the URL for error messages.
+func redact(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return s
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,338 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	v1alpha1 "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+func tarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestHTTPArchive(t *testing.T) {
+	archive := tarGz(t, map[string]string{"app/cm.yaml": "kind: ConfigMap"})
+	etag := `"v1"`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); !ok || u != "user" || p != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if etag != "" {
+			w.Header().Set("ETag", etag)
+		}
+		_, _ = w.Write(archive)
+	}))
+	defer srv.Close()
+
+	s := &Source{Type: v1alpha1.ArchiveTypeHTTP, URL: srv.URL + "/config.tar.gz", Username: "user", Password: "pass"}
+
+	rev, err := s.Revision(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rev) != 40 {
+		t.Errorf("expected revision as long as a commit hash, got %q", rev)
+	}
+
+	dst := t.TempDir()
+	downloaded, err := s.Download(context.TODO(), dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if downloaded != rev {
+		t.Errorf("expected revision of the download %q to match %q", downloaded, rev)
+	}
+	if c := readFile(t, filepath.Join(dst, "app", "cm.yaml")); c != "kind: ConfigMap" {
+		t.Errorf("unexpected content %q", c)
+	}
+
+	etag = `"v2"`
+	if rev2, err := s.Revision(context.TODO()); err != nil || rev2 == rev {
+		t.Errorf("expected new revision for new ETag, got %q, %v", rev2, err)
+	}
+
+	// without ETag the checksum is used
+	etag = ""
+	rev3, err := s.Revision(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if downloaded, err := s.Download(context.TODO(), t.TempDir()); err != nil || downloaded != rev3 {
+		t.Errorf("expected revision of the download %q to match %q: %v", downloaded, rev3, err)
+	}
+
+	s.Password = "wrong"
+	if _, err := s.Revision(context.TODO()); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("expected unauthorized error, got %v", err)
+	}
+}
+
+func TestHTTPArchiveUnsupportedFormat(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("kind: ConfigMap"))
+	}))
+	defer srv.Close()
+
+	s := &Source{Type: v1alpha1.ArchiveTypeHTTP, URL: srv.URL + "/cm.yaml"}
+	if _, err := s.Download(context.TODO(), t.TempDir()); err == nil || !strings.Contains(err.Error(), "unsupported archive format") {
+		t.Errorf("expected unsupported format error, got %v", err)
+	}
+}
+
+func TestUnpackLimits(t *testing.T) {
+	defer func(size int64, files int) { maxUnpackedSize, maxUnpackedFiles = size, files }(maxUnpackedSize, maxUnpackedFiles)
+	src := filepath.Join(t.TempDir(), "archive.tar.gz")
+	if err := os.WriteFile(src, tarGz(t, map[string]string{
+		"a.yaml": strings.Repeat("a", 100),
+		"b.yaml": strings.Repeat("b", 100),
+		"c.yaml": strings.Repeat("c", 100),
+	}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	maxUnpackedSize, maxUnpackedFiles = 1000, 10
+	if err := unpack(src, src, t.TempDir()); err != nil {
+		t.Errorf("expected archive within the limits to be unpacked, got %v", err)
+	}
+
+	maxUnpackedSize, maxUnpackedFiles = 250, 10
+	if err := unpack(src, src, t.TempDir()); err == nil || !strings.Contains(err.Error(), "larger than limit") {
+		t.Errorf("expected size limit error, got %v", err)
+	}
+
+	maxUnpackedSize, maxUnpackedFiles = 1000, 2
+	if err := unpack(src, src, t.TempDir()); err == nil || !strings.Contains(err.Error(), "too many files") {
+		t.Errorf("expected files limit error, got %v", err)
+	}
+}
+
+type listBucketResult struct {
+	XMLName  xml.Name `xml:"ListBucketResult"`
+	Name     string
+	Prefix   string
+	KeyCount int
+	Contents []struct {
+		Key  string
+		ETag string
+		Size int
+	}
+}
+
+// fakeS3 serves ListObjectsV2 and GetObject requests for path style URLs of a single bucket.
+func fakeS3(bucket string, objects map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, ok := strings.CutPrefix(r.URL.Path, "/"+bucket)
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		key = strings.TrimPrefix(key, "/")
+
+		if key == "" && r.URL.Query().Get("list-type") == "2" {
+			prefix := r.URL.Query().Get("prefix")
+			res := listBucketResult{Name: bucket, Prefix: prefix}
+			var keys []string
+			for k := range objects {
+				if strings.HasPrefix(k, prefix) {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				res.Contents = append(res.Contents, struct {
+					Key  string
+					ETag string
+					Size int
+				}{Key: k, ETag: fmt.Sprintf(`"%x"`, len(objects[k])), Size: len(objects[k])})
+			}
+			res.KeyCount = len(keys)
+			w.Header().Set("Content-Type", "application/xml")
+			_ = xml.NewEncoder(w).Encode(res)
+			return
+		}
+
+		content, ok := objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(content))
+	}))
+}
+
+func TestS3Bucket(t *testing.T) {
+	objects := map[string]string{
+		"prod/app/cm.yaml":      "kind: ConfigMap",
+		"prod/bundle.tar.gz":    string(tarGz(t, map[string]string{"other/fleet.yaml": "namespace: other"})),
+		"prod/folder/":          "",
+		"staging/app/cm.yaml":   "kind: Secret",
+		"staging/app/more.yaml": "kind: Secret",
+	}
+	srv := fakeS3("config", objects)
+	defer srv.Close()
+
+	s := &Source{
+		Type:     v1alpha1.ArchiveTypeS3,
+		URL:      "s3://config/prod/",
+		Endpoint: srv.URL,
+		Insecure: true,
+		Username: "access",
+		Password: "secret",
+	}
+
+	rev, err := s.Revision(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := t.TempDir()
+	downloaded, err := s.Download(context.TODO(), dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if downloaded != rev {
+		t.Errorf("expected revision of the download %q to match %q", downloaded, rev)
+	}
+	if c := readFile(t, filepath.Join(dst, "app", "cm.yaml")); c != "kind: ConfigMap" {
+		t.Errorf("unexpected content %q", c)
+	}
+	if c := readFile(t, filepath.Join(dst, "other", "fleet.yaml")); c != "namespace: other" {
+		t.Errorf("expected archive to be unpacked, got %q", c)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "staging")); !os.IsNotExist(err) {
+		t.Errorf("expected objects outside of the prefix not to be downloaded")
+	}
+
+	objects["prod/app/cm.yaml"] = "kind: ConfigMap\nmetadata: {}"
+	if rev2, err := s.Revision(context.TODO()); err != nil || rev2 == rev {
+		t.Errorf("expected new revision for changed object, got %q, %v", rev2, err)
+	}
+
+	s.URL = "s3://config/missing/"
+	if _, err := s.Revision(context.TODO()); err == nil {
+		t.Errorf("expected error for empty prefix")
+	}
+}
+
+func TestS3Prefix(t *testing.T) {
+	objects := map[string]string{
+		"prod/cm.yaml":       "kind: ConfigMap",
+		"prod/app/more.yaml": "kind: Secret",
+		"production/cm.yaml": "kind: Secret",
+		"prod.yaml":          "kind: Secret",
+	}
+	srv := fakeS3("config", objects)
+	defer srv.Close()
+
+	tests := map[string]struct {
+		url      string
+		expected []string
+	}{
+		"directory":          {url: "s3://config/prod", expected: []string{"app/more.yaml", "cm.yaml"}},
+		"directory with /":   {url: "s3://config/prod/", expected: []string{"app/more.yaml", "cm.yaml"}},
+		"single object":      {url: "s3://config/prod/cm.yaml", expected: []string{"cm.yaml"}},
+		"single object file": {url: "s3://config/prod.yaml", expected: []string{"prod.yaml"}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := &Source{Type: v1alpha1.ArchiveTypeS3, URL: tc.url, Endpoint: srv.URL, Insecure: true}
+			dst := t.TempDir()
+			if _, err := s.Download(context.TODO(), dst); err != nil {
+				t.Fatal(err)
+			}
+			var files []string
+			err := filepath.WalkDir(dst, func(path string, d fs.DirEntry, err error) error {
+				if err != nil || d.IsDir() {
+					return err
+				}
+				rel, err := filepath.Rel(dst, path)
+				files = append(files, filepath.ToSlash(rel))
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(tc.expected, files) {
+				t.Errorf("expected files %v, got %v", tc.expected, files)
+			}
+		})
+	}
+}
+
+func TestS3Limits(t *testing.T) {
+	defer func(size int64, files int) { maxUnpackedSize, maxUnpackedFiles = size, files }(maxUnpackedSize, maxUnpackedFiles)
+	srv := fakeS3("config", map[string]string{
+		"prod/a.yaml": strings.Repeat("a", 100),
+		"prod/b.yaml": strings.Repeat("b", 100),
+		"prod/c.yaml": strings.Repeat("c", 100),
+	})
+	defer srv.Close()
+	s := &Source{Type: v1alpha1.ArchiveTypeS3, URL: "s3://config/prod", Endpoint: srv.URL, Insecure: true}
+
+	maxUnpackedSize, maxUnpackedFiles = 1000, 10
+	if _, err := s.Download(context.TODO(), t.TempDir()); err != nil {
+		t.Errorf("expected objects within the limits to be downloaded, got %v", err)
+	}
+
+	maxUnpackedSize, maxUnpackedFiles = 250, 10
+	if _, err := s.Download(context.TODO(), t.TempDir()); err == nil || !strings.Contains(err.Error(), "larger than limit") {
+		t.Errorf("expected size limit error, got %v", err)
+	}
+
+	maxUnpackedSize, maxUnpackedFiles = 1000, 2
+	if _, err := s.Download(context.TODO(), t.TempDir()); err == nil || !strings.Contains(err.Error(), "too many objects") {
+		t.Errorf("expected objects limit error, got %v", err)
+	}
+}
+
+func TestParseS3URL(t *testing.T) {
+	bucket, prefix, err := parseS3URL("s3://config/prod/app")
+	if err != nil || bucket != "config" || prefix != "prod/app" {
+		t.Errorf("unexpected bucket %q, prefix %q, %v", bucket, prefix, err)
+	}
+	if _, _, err := parseS3URL("https://config/prod"); err == nil {
+		t.Errorf("expected error for non S3 URL")
+	}
+}
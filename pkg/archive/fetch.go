@@ -0,0 +1,75 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rancher/fleet/internal/config"
+	v1alpha1 "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/cert"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Latest returns the revision of the current content of the gitrepo's archive source. For OCI artifacts following a
+// semver constraint, it also returns the selected tag.
+func Latest(ctx context.Context, gitrepo *v1alpha1.GitRepo, client client.Client) (string, string, error) {
+	s, err := NewSource(ctx, gitrepo, client)
+	if err != nil {
+		return "", "", err
+	}
+
+	return s.Latest(ctx)
+}
+
+// NewSource returns the archive source of the gitrepo, with the credentials from its client secret.
+func NewSource(ctx context.Context, gitrepo *v1alpha1.GitRepo, client client.Client) (*Source, error) {
+	archive := gitrepo.Spec.Archive
+	if archive == nil {
+		return nil, fmt.Errorf("gitrepo %s/%s has no archive source", gitrepo.Namespace, gitrepo.Name)
+	}
+
+	s := &Source{
+		Type:            archive.Type,
+		URL:             gitrepo.Spec.Repo,
+		Endpoint:        archive.Endpoint,
+		Region:          archive.Region,
+		Insecure:        archive.Insecure,
+		InsecureSkipTLS: gitrepo.Spec.InsecureSkipTLSverify,
+		Timeout:         config.Get().GitClientTimeout.Duration,
+		Tag:             gitrepo.Spec.Revision,
+		TagSemver:       gitrepo.Spec.TagSemver,
+		CosignPublicKey: []byte(archive.CosignPublicKey),
+	}
+
+	if gitrepo.Spec.ClientSecretName != "" {
+		var secret corev1.Secret
+		err := client.Get(ctx, types.NamespacedName{
+			Namespace: gitrepo.Namespace,
+			Name:      gitrepo.Spec.ClientSecretName,
+		}, &secret)
+		if err != nil {
+			return nil, err
+		}
+		if secret.Type != corev1.SecretTypeBasicAuth {
+			return nil, fmt.Errorf("secret %s/%s for archive sources must be of type %s", secret.Namespace, secret.Name, corev1.SecretTypeBasicAuth)
+		}
+		s.Username = string(secret.Data[corev1.BasicAuthUsernameKey])
+		s.Password = string(secret.Data[corev1.BasicAuthPasswordKey])
+	}
+
+	// Fall back to Rancher-configured CA bundles if no CA bundle is specified in the GitRepo
+	s.CABundle = gitrepo.Spec.CABundle
+	if len(s.CABundle) == 0 {
+		cab, err := cert.GetRancherCABundle(ctx, client)
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, err
+		}
+		s.CABundle = cab
+	}
+
+	return s, nil
+}
@@ -0,0 +1,345 @@
+package archive
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+const (
+	ociURLPrefix = "oci://"
+	defaultTag   = "latest"
+
+	// cosignSignatureAnnotation holds the base64 encoded signature of a layer of a cosign signature manifest.
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+)
+
+// ociRepository is the part of an OCI repository, which is needed to follow and pull artifacts.
+type ociRepository interface {
+	oras.ReadOnlyTarget
+	registry.TagLister
+}
+
+// newOCIRepository returns the repository of the source. It is a variable to replace the registry in tests.
+var newOCIRepository = func(s *Source) (ociRepository, error) {
+	ref, ok := strings.CutPrefix(s.URL, ociURLPrefix)
+	if !ok {
+		return nil, fmt.Errorf("invalid OCI URL %q, expected oci://<registry>/<repository>", s.URL)
+	}
+
+	repo, err := remote.NewRepository(ref)
+	if err != nil {
+		return nil, err
+	}
+	repo.PlainHTTP = s.Insecure
+
+	client := &auth.Client{
+		Client: s.httpClient(),
+		Cache:  auth.NewCache(),
+	}
+	if s.Username != "" || s.Password != "" {
+		client.Credential = auth.StaticCredential(repo.Reference.Registry, auth.Credential{
+			Username: s.Username,
+			Password: s.Password,
+		})
+	}
+	repo.Client = client
+
+	return repo, nil
+}
+
+// ociLatest resolves the tag, or the highest tag matching the semver constraint, to the digest of the artifact.
+// It returns the selected tag and the revision, which is the beginning of the digest.
+func (s *Source) ociLatest(ctx context.Context) (string, string, error) {
+	repo, err := newOCIRepository(s)
+	if err != nil {
+		return "", "", err
+	}
+
+	tag, err := s.ociTag(ctx, repo)
+	if err != nil {
+		return "", "", err
+	}
+
+	desc, err := repo.Resolve(ctx, tag)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve %s:%s: %w", s.URL, tag, err)
+	}
+
+	if s.TagSemver == "" {
+		tag = ""
+	}
+	return tag, ociRevision(desc), nil
+}
+
+// ociTag returns the tag, or digest, to pull.
+func (s *Source) ociTag(ctx context.Context, repo ociRepository) (string, error) {
+	if s.TagSemver == "" {
+		if s.Tag == "" {
+			return defaultTag, nil
+		}
+		return s.Tag, nil
+	}
+
+	c, err := semver.NewConstraint(s.TagSemver)
+	if err != nil {
+		return "", fmt.Errorf("invalid tagSemver constraint %q: %w", s.TagSemver, err)
+	}
+
+	var (
+		latest *semver.Version
+		tag    string
+	)
+	err = repo.Tags(ctx, "", func(tags []string) error {
+		for _, t := range tags {
+			v, err := semver.NewVersion(t)
+			if err != nil || !c.Check(v) {
+				continue
+			}
+			if latest == nil || v.GreaterThan(latest) {
+				latest, tag = v, t
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list tags of %s: %w", s.URL, err)
+	}
+	if tag == "" {
+		return "", fmt.Errorf("no tag of %s matches %q", s.URL, s.TagSemver)
+	}
+
+	return tag, nil
+}
+
+// ociDownload pulls the artifact and extracts its layers into dst. If an expected revision is set, the digest of the
+// artifact must match it, which fails if the tag was moved since it was resolved.
+func (s *Source) ociDownload(ctx context.Context, dst string) (string, error) {
+	repo, err := newOCIRepository(s)
+	if err != nil {
+		return "", err
+	}
+
+	tag, err := s.ociTag(ctx, repo)
+	if err != nil {
+		return "", err
+	}
+
+	// the manifest's digest is verified against the descriptor the tag resolved to
+	desc, b, err := oras.FetchBytes(ctx, repo, tag, oras.DefaultFetchBytesOptions)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s:%s: %w", s.URL, tag, err)
+	}
+	revision := ociRevision(desc)
+	if s.ExpectedRevision != "" && revision != s.ExpectedRevision {
+		return "", fmt.Errorf("digest %s of %s:%s does not match the expected revision %s", desc.Digest, s.URL, tag, s.ExpectedRevision)
+	}
+
+	if len(s.CosignPublicKey) > 0 {
+		if err := verifyCosignSignature(ctx, repo, desc, s.CosignPublicKey); err != nil {
+			return "", err
+		}
+	}
+
+	if desc.MediaType != ocispec.MediaTypeImageManifest {
+		return "", fmt.Errorf("unsupported media type %q of %s:%s, expected an OCI image manifest", desc.MediaType, s.URL, tag)
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		return "", err
+	}
+
+	for _, layer := range manifest.Layers {
+		if err := extractLayer(ctx, repo, layer, dst); err != nil {
+			return "", err
+		}
+	}
+
+	return revision, nil
+}
+
+// extractLayer unpacks tar layers into dst. Other layers are written to the file named by their title annotation,
+// like ORAS pushes files.
+func extractLayer(ctx context.Context, repo ociRepository, layer ocispec.Descriptor, dst string) error {
+	rc, err := repo.Fetch(ctx, layer)
+	if err != nil {
+		return fmt.Errorf("failed to fetch layer %s: %w", layer.Digest, err)
+	}
+	defer rc.Close()
+
+	tmp, err := os.CreateTemp("", "fleet-layer-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	vr := content.NewVerifyReader(rc, layer)
+	if _, err := io.Copy(tmp, vr); err != nil {
+		return err
+	}
+	if err := vr.Verify(); err != nil {
+		return fmt.Errorf("failed to verify layer %s: %w", layer.Digest, err)
+	}
+
+	title := layer.Annotations[ocispec.AnnotationTitle]
+	if format := layerFormat(layer.MediaType); format != "" {
+		return unpack(tmp.Name(), "layer."+format, dst)
+	}
+	if title == "" {
+		return fmt.Errorf("unsupported layer %s of media type %q without title", layer.Digest, layer.MediaType)
+	}
+	if decompressor(title) != nil {
+		return unpack(tmp.Name(), title, dst)
+	}
+	if !filepath.IsLocal(title) {
+		return fmt.Errorf("invalid layer title %q", title)
+	}
+
+	name := filepath.Join(dst, filepath.FromSlash(title))
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(f, tmp)
+	return err
+}
+
+// layerFormat returns the archive format of tar layers, e.g. "application/vnd.cncf.flux.content.v1.tar+gzip" or
+// "application/vnd.oci.image.layer.v1.tar", or an empty string for other media types.
+func layerFormat(mediaType string) string {
+	switch {
+	case strings.HasSuffix(mediaType, "tar+gzip"), strings.HasSuffix(mediaType, "tar.gzip"):
+		return "tar.gz"
+	case strings.HasSuffix(mediaType, "tar+zstd"):
+		return "tar.zst"
+	case strings.HasSuffix(mediaType, ".tar"):
+		return "tar"
+	default:
+		return ""
+	}
+}
+
+// ociRevision returns the beginning of the digest, which is as long as a git commit hash and can be used as label
+// value.
+func ociRevision(desc ocispec.Descriptor) string {
+	encoded := desc.Digest.Encoded()
+	if len(encoded) > 40 {
+		return encoded[:40]
+	}
+	return encoded
+}
+
+// verifyCosignSignature verifies that the artifact was signed by the key with cosign. Signatures are stored by cosign
+// in the same repository, with the tag "<algorithm>-<digest>.sig". Only key based signatures are supported.
+func verifyCosignSignature(ctx context.Context, repo ociRepository, desc ocispec.Descriptor, publicKey []byte) error {
+	key, err := parsePublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+
+	sigTag := strings.Replace(desc.Digest.String(), ":", "-", 1) + ".sig"
+	_, b, err := oras.FetchBytes(ctx, repo, sigTag, oras.DefaultFetchBytesOptions)
+	if errors.Is(err, errdef.ErrNotFound) {
+		return fmt.Errorf("no cosign signature found for %s", desc.Digest)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch cosign signature of %s: %w", desc.Digest, err)
+	}
+
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		return err
+	}
+
+	for _, layer := range manifest.Layers {
+		sig, ok := layer.Annotations[cosignSignatureAnnotation]
+		if !ok {
+			continue
+		}
+		rawSig, err := base64.StdEncoding.DecodeString(sig)
+		if err != nil {
+			continue
+		}
+		payload, err := content.FetchAll(ctx, repo, layer)
+		if err != nil {
+			return fmt.Errorf("failed to fetch cosign signature payload of %s: %w", desc.Digest, err)
+		}
+		if !verifySignature(key, payload, rawSig) {
+			continue
+		}
+
+		var p struct {
+			Critical struct {
+				Image struct {
+					Digest string `json:"docker-manifest-digest"`
+				} `json:"image"`
+			} `json:"critical"`
+		}
+		if err := json.Unmarshal(payload, &p); err != nil {
+			continue
+		}
+		if p.Critical.Image.Digest == desc.Digest.String() {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no valid cosign signature found for %s", desc.Digest)
+}
+
+func parsePublicKey(b []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("failed to decode cosign public key, expected PEM")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cosign public key: %w", err)
+	}
+
+	return key, nil
+}
+
+func verifySignature(key crypto.PublicKey, payload, sig []byte) bool {
+	h := sha256.Sum256(payload)
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(k, h[:], sig)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, h[:], sig) == nil
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, payload, sig)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,168 @@
+package archive
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/memory"
+
+	v1alpha1 "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+// memoryRepository is an in-memory OCI repository, which lists the tags it was tagged with.
+type memoryRepository struct {
+	*memory.Store
+	tags []string
+}
+
+func (m *memoryRepository) Tags(_ context.Context, _ string, fn func(tags []string) error) error {
+	return fn(m.tags)
+}
+
+func (m *memoryRepository) push(t *testing.T, tag, mediaType string, blob []byte, annotations map[string]string) ocispec.Descriptor {
+	t.Helper()
+	ctx := context.TODO()
+	layer, err := oras.PushBytes(ctx, m, mediaType, blob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layer.Annotations = annotations
+	desc, err := oras.PackManifest(ctx, m, oras.PackManifestVersion1_1, "application/vnd.example.config", oras.PackManifestOptions{
+		Layers: []ocispec.Descriptor{layer},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Tag(ctx, desc, tag); err != nil {
+		t.Fatal(err)
+	}
+	m.tags = append(m.tags, tag)
+	return desc
+}
+
+func useRepository(t *testing.T, repo *memoryRepository) {
+	orig := newOCIRepository
+	newOCIRepository = func(*Source) (ociRepository, error) { return repo, nil }
+	t.Cleanup(func() { newOCIRepository = orig })
+}
+
+func TestOCIArtifact(t *testing.T) {
+	repo := &memoryRepository{Store: memory.New()}
+	useRepository(t, repo)
+
+	v1 := repo.push(t, "v1.0.0", "application/vnd.cncf.flux.content.v1.tar+gzip", tarGz(t, map[string]string{"app/cm.yaml": "v1"}), nil)
+	v12 := repo.push(t, "v1.2.0", "application/vnd.oci.image.layer.v1.tar", nil, nil)
+	repo.push(t, "v2.0.0", "application/vnd.cncf.flux.content.v1.tar+gzip", tarGz(t, map[string]string{"app/cm.yaml": "v2"}), nil)
+	repo.push(t, "latest", "application/yaml", []byte("kind: ConfigMap"), map[string]string{ocispec.AnnotationTitle: "app/cm.yaml"})
+
+	s := &Source{Type: v1alpha1.ArchiveTypeOCI, URL: "oci://registry.example.com/config", TagSemver: "^1.0.0"}
+	tag, rev, err := s.Latest(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag != "v1.2.0" || rev != v12.Digest.Encoded()[:40] {
+		t.Errorf("expected highest matching tag v1.2.0 with revision %s, got %s, %s", v12.Digest.Encoded()[:40], tag, rev)
+	}
+
+	s = &Source{Type: v1alpha1.ArchiveTypeOCI, URL: "oci://registry.example.com/config", Tag: "v1.0.0", ExpectedRevision: ociRevision(v1)}
+	dst := t.TempDir()
+	if rev, err := s.Download(context.TODO(), dst); err != nil || rev != ociRevision(v1) {
+		t.Fatalf("expected download of revision %s, got %s, %v", ociRevision(v1), rev, err)
+	}
+	if c := readFile(t, filepath.Join(dst, "app", "cm.yaml")); c != "v1" {
+		t.Errorf("unexpected content %q", c)
+	}
+
+	s.Tag = "v2.0.0"
+	if _, err := s.Download(context.TODO(), t.TempDir()); err == nil || !strings.Contains(err.Error(), "does not match the expected revision") {
+		t.Errorf("expected error for moved tag, got %v", err)
+	}
+
+	// files pushed by ORAS are written to their title
+	s = &Source{Type: v1alpha1.ArchiveTypeOCI, URL: "oci://registry.example.com/config"}
+	dst = t.TempDir()
+	if _, err := s.Download(context.TODO(), dst); err != nil {
+		t.Fatal(err)
+	}
+	if c := readFile(t, filepath.Join(dst, "app", "cm.yaml")); c != "kind: ConfigMap" {
+		t.Errorf("unexpected content %q", c)
+	}
+}
+
+func TestOCIArtifactCosign(t *testing.T) {
+	repo := &memoryRepository{Store: memory.New()}
+	useRepository(t, repo)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub})
+
+	sign := func(desc ocispec.Descriptor, key *ecdsa.PrivateKey) {
+		payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"registry.example.com/config"},"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":null}`, desc.Digest))
+		h := sha256.Sum256(payload)
+		sig, err := ecdsa.SignASN1(rand.Reader, key, h[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		repo.push(t, strings.Replace(desc.Digest.String(), ":", "-", 1)+".sig", "application/vnd.dev.cosign.simplesigning.v1+json", payload,
+			map[string]string{cosignSignatureAnnotation: base64.StdEncoding.EncodeToString(sig)})
+	}
+
+	signed := repo.push(t, "signed", "application/vnd.oci.image.layer.v1.tar+gzip", tarGz(t, map[string]string{"cm.yaml": "signed"}), nil)
+	sign(signed, key)
+	unsigned := repo.push(t, "unsigned", "application/vnd.oci.image.layer.v1.tar+gzip", tarGz(t, map[string]string{"cm.yaml": "unsigned"}), nil)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrongKey := repo.push(t, "wrong-key", "application/vnd.oci.image.layer.v1.tar+gzip", tarGz(t, map[string]string{"cm.yaml": "wrong"}), nil)
+	sign(wrongKey, otherKey)
+
+	s := &Source{Type: v1alpha1.ArchiveTypeOCI, URL: "oci://registry.example.com/config", CosignPublicKey: publicKey}
+
+	s.Tag = "signed"
+	if _, err := s.Download(context.TODO(), t.TempDir()); err != nil {
+		t.Errorf("expected signed artifact to be verified, got %v", err)
+	}
+
+	s.Tag = "unsigned"
+	if _, err := s.Download(context.TODO(), t.TempDir()); err == nil || !strings.Contains(err.Error(), "no cosign signature found for "+unsigned.Digest.String()) {
+		t.Errorf("expected missing signature error, got %v", err)
+	}
+
+	s.Tag = "wrong-key"
+	if _, err := s.Download(context.TODO(), t.TempDir()); err == nil || !strings.Contains(err.Error(), "no valid cosign signature") {
+		t.Errorf("expected invalid signature error, got %v", err)
+	}
+}
+
+func TestLayerFormat(t *testing.T) {
+	for mediaType, expected := range map[string]string{
+		"application/vnd.cncf.flux.content.v1.tar+gzip": "tar.gz",
+		"application/vnd.oci.image.layer.v1.tar":        "tar",
+		"application/vnd.oci.image.layer.v1.tar+zstd":   "tar.zst",
+		"application/yaml":                              "",
+	} {
+		if f := layerFormat(mediaType); f != expected {
+			t.Errorf("expected format %q for %s, got %q", expected, mediaType, f)
+		}
+	}
+}
@@ -16,6 +16,7 @@ const (
 	ContentPurgeInterval           = time.Minute * 5
 	CreateClusterSecretTimeout     = time.Minute * 30
 	DefaultClusterCheckInterval    = time.Minute * 15
+	DefaultGeneratorInterval       = time.Hour
 	DefaultImageInterval           = time.Minute * 15
 	DefaultRequeueAfter            = time.Second * 5
 	DefaultResyncAgent             = time.Minute * 30
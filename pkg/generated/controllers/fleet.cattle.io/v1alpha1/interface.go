@@ -40,6 +40,7 @@ type Interface interface {
 	ClusterRegistrationToken() ClusterRegistrationTokenController
 	Content() ContentController
 	GitRepo() GitRepoController
+	GitRepoGenerator() GitRepoGeneratorController
 	GitRepoRestriction() GitRepoRestrictionController
 	HelmOp() HelmOpController
 	ImageScan() ImageScanController
@@ -92,6 +93,10 @@ func (v *version) GitRepo() GitRepoController {
 	return generic.NewController[*v1alpha1.GitRepo, *v1alpha1.GitRepoList](schema.GroupVersionKind{Group: "fleet.cattle.io", Version: "v1alpha1", Kind: "GitRepo"}, "gitrepos", true, v.controllerFactory)
 }
 
+func (v *version) GitRepoGenerator() GitRepoGeneratorController {
+	return generic.NewController[*v1alpha1.GitRepoGenerator, *v1alpha1.GitRepoGeneratorList](schema.GroupVersionKind{Group: "fleet.cattle.io", Version: "v1alpha1", Kind: "GitRepoGenerator"}, "gitrepogenerators", true, v.controllerFactory)
+}
+
 func (v *version) GitRepoRestriction() GitRepoRestrictionController {
 	return generic.NewController[*v1alpha1.GitRepoRestriction, *v1alpha1.GitRepoRestrictionList](schema.GroupVersionKind{Group: "fleet.cattle.io", Version: "v1alpha1", Kind: "GitRepoRestriction"}, "gitreporestrictions", true, v.controllerFactory)
 }
@@ -0,0 +1,225 @@
+package git
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+
+	v1alpha1 "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+// Cache keeps a bare clone per repository URL and scope below Dir. The clones are fetched incrementally and used to
+// check out commits, without cloning the whole repository for every sync.
+//
+// Clones are not shared between scopes, see CacheScope, so commits fetched with the credentials of one gitrepo can't
+// be checked out by gitrepos in other namespaces or with other credentials.
+type Cache struct {
+	Dir string
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// CacheScope returns the scope of the cached clones used by the gitrepo, its namespace and client secret.
+func CacheScope(gitrepo *v1alpha1.GitRepo) string {
+	return gitrepo.Namespace + "/" + clientSecretName(gitrepo)
+}
+
+// Checkout fetches the commit into the cached clone of the remote in the scope, unless it is already known, and
+// checks it out into dst. Access to the remote is verified, even if the commit is known already.
+func (c *Cache) Checkout(ctx context.Context, scope string, r *Remote, commit, dst string) error {
+	if !plumbing.IsHash(commit) {
+		return fmt.Errorf("invalid commit %q", commit)
+	}
+	hash := plumbing.NewHash(commit)
+
+	// the credentials might have been revoked since the commit was fetched
+	if _, err := r.Lister.List(false); err != nil {
+		return fmt.Errorf("failed to access %q: %w", r.URL, err)
+	}
+
+	unlock := c.lock(scope, r.URL)
+	defer unlock()
+
+	repo, err := c.open(scope, r.URL)
+	if err != nil {
+		return err
+	}
+	if err := fetchCommit(ctx, repo, r, hash); err != nil {
+		return err
+	}
+
+	// the worktree shares the storage, including the index, with the cached clone
+	wt, err := gogit.Open(repo.Storer, osfs.New(dst))
+	if err != nil {
+		return err
+	}
+	w, err := wt.Worktree()
+	if err != nil {
+		return err
+	}
+	if err := w.Checkout(&gogit.CheckoutOptions{Hash: hash, Force: true}); err != nil {
+		return fmt.Errorf("failed to check out commit %q: %w", commit, err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dst, ".gitmodules")); err == nil {
+		return errors.New("git submodules are not supported by the git fetcher")
+	}
+
+	return nil
+}
+
+// ChangedPaths returns the paths changed between the commits from and to, which have to be in the cached clone of
+// the repository in the scope already.
+func (c *Cache) ChangedPaths(scope, url, from, to string) ([]string, error) {
+	if !plumbing.IsHash(from) || !plumbing.IsHash(to) {
+		return nil, fmt.Errorf("invalid commits %q and %q", from, to)
+	}
+
+	unlock := c.lock(scope, url)
+	defer unlock()
+
+	repo, err := c.open(scope, url)
+	if err != nil {
+		return nil, err
+	}
+
+	return DiffCommits(repo, plumbing.NewHash(from), plumbing.NewHash(to))
+}
+
+// DiffCommits returns the sorted names of the files changed between the commits from and to in the repository.
+func DiffCommits(r *gogit.Repository, from, to plumbing.Hash) ([]string, error) {
+	fromCommit, err := r.CommitObject(from)
+	if err != nil {
+		return nil, err
+	}
+	toCommit, err := r.CommitObject(to)
+	if err != nil {
+		return nil, err
+	}
+
+	fromTree, err := fromCommit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	toTree, err := toCommit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	changes, err := object.DiffTree(fromTree, toTree)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]struct{}{}
+	for _, change := range changes {
+		for _, name := range []string{change.From.Name, change.To.Name} {
+			if name != "" {
+				names[name] = struct{}{}
+			}
+		}
+	}
+
+	return slices.Sorted(maps.Keys(names)), nil
+}
+
+// lock locks the cached clone of the url in the scope and returns the func to unlock it.
+func (c *Cache) lock(scope, url string) func() {
+	key := cacheKey(scope, url)
+
+	c.mu.Lock()
+	if c.locks == nil {
+		c.locks = map[string]*sync.Mutex{}
+	}
+	l, ok := c.locks[key]
+	if !ok {
+		l = &sync.Mutex{}
+		c.locks[key] = l
+	}
+	c.mu.Unlock()
+
+	l.Lock()
+	return l.Unlock
+}
+
+// open opens the cached bare clone of the url in the scope, it is initialized if it does not exist yet.
+func (c *Cache) open(scope, url string) (*gogit.Repository, error) {
+	storer := filesystem.NewStorage(osfs.New(filepath.Join(c.Dir, cacheKey(scope, url))), cache.NewObjectLRUDefault())
+
+	repo, err := gogit.Open(storer, nil)
+	if errors.Is(err, gogit.ErrRepositoryNotExists) {
+		repo, err = gogit.Init(storer, nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cached clone of %q: %w", url, err)
+	}
+
+	if _, err := repo.Remote(gogit.DefaultRemoteName); errors.Is(err, gogit.ErrRemoteNotFound) {
+		_, err = repo.CreateRemote(&config.RemoteConfig{Name: gogit.DefaultRemoteName, URLs: []string{url}})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return repo, nil
+}
+
+// cacheKey returns the name of the directory of the cached clone.
+func cacheKey(scope, url string) string {
+	sum := sha256.Sum256([]byte(scope + "\x00" + url))
+	return hex.EncodeToString(sum[:])
+}
+
+// fetchCommit fetches all branches and tags, so only missing objects are transferred, if the commit is not in the
+// repository yet. Commits which are not reachable from those, e.g. of pull requests, are fetched directly.
+func fetchCommit(ctx context.Context, repo *gogit.Repository, r *Remote, hash plumbing.Hash) error {
+	if _, err := repo.CommitObject(hash); err == nil {
+		return nil
+	}
+
+	opts := &gogit.FetchOptions{
+		RemoteName: gogit.DefaultRemoteName,
+		RefSpecs: []config.RefSpec{
+			"+refs/heads/*:refs/remotes/origin/*",
+			"+refs/tags/*:refs/tags/*",
+		},
+		Tags:  gogit.NoTags,
+		Force: true,
+	}
+	if lister, ok := r.Lister.(*GoGitRemoteLister); ok {
+		opts.Auth = lister.Auth
+		opts.CABundle = lister.CABundle
+		opts.InsecureSkipTLS = lister.InsecureSkipTLS
+	}
+
+	err := repo.FetchContext(ctx, opts)
+	if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("failed to fetch %q: %w", r.URL, err)
+	}
+	if _, err := repo.CommitObject(hash); err == nil {
+		return nil
+	}
+
+	opts.RefSpecs = []config.RefSpec{config.RefSpec(hash.String() + ":refs/fleet/" + hash.String())}
+	err = repo.FetchContext(ctx, opts)
+	if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("failed to fetch commit %q: %w", hash, err)
+	}
+
+	return nil
+}
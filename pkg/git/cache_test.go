@@ -0,0 +1,105 @@
+package git_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rancher/fleet/pkg/git"
+)
+
+var _ = Describe("git's Cache tests", func() {
+	var (
+		upstream string
+		repo     *gogit.Repository
+		cache    *git.Cache
+		remote   *git.Remote
+	)
+
+	const scope = "fleet-local/auth"
+
+	commit := func(file, content string) string {
+		Expect(os.WriteFile(filepath.Join(upstream, file), []byte(content), 0600)).To(Succeed())
+		w, err := repo.Worktree()
+		Expect(err).ToNot(HaveOccurred())
+		_, err = w.Add(file)
+		Expect(err).ToNot(HaveOccurred())
+		h, err := w.Commit("update "+file, &gogit.CommitOptions{
+			Author: &object.Signature{Name: "fleet", Email: "fleet@example.com", When: time.Now()},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		return h.String()
+	}
+
+	BeforeEach(func() {
+		upstream = GinkgoT().TempDir()
+		var err error
+		repo, err = gogit.PlainInit(upstream, false)
+		Expect(err).ToNot(HaveOccurred())
+
+		cache = &git.Cache{Dir: GinkgoT().TempDir()}
+		remote = &git.Remote{URL: upstream, Lister: &git.GoGitRemoteLister{URL: upstream}}
+	})
+
+	It("checks out commits and fetches new ones incrementally", func() {
+		first := commit("a.yaml", "a")
+
+		dst := GinkgoT().TempDir()
+		Expect(cache.Checkout(context.TODO(), scope, remote, first, dst)).To(Succeed())
+		Expect(filepath.Join(dst, "a.yaml")).To(BeAnExistingFile())
+
+		second := commit("b.yaml", "b")
+
+		dst = GinkgoT().TempDir()
+		Expect(cache.Checkout(context.TODO(), scope, remote, second, dst)).To(Succeed())
+		Expect(filepath.Join(dst, "a.yaml")).To(BeAnExistingFile())
+		Expect(filepath.Join(dst, "b.yaml")).To(BeAnExistingFile())
+
+		// the previous commit is still in the cache
+		dst = GinkgoT().TempDir()
+		Expect(cache.Checkout(context.TODO(), scope, remote, first, dst)).To(Succeed())
+		Expect(filepath.Join(dst, "b.yaml")).ToNot(BeAnExistingFile())
+
+		paths, err := cache.ChangedPaths(scope, upstream, first, second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(paths).To(Equal([]string{"b.yaml"}))
+	})
+
+	It("returns an error for unknown commits", func() {
+		commit("a.yaml", "a")
+
+		err := cache.Checkout(context.TODO(), scope, remote, "0123456789012345678901234567890123456789", GinkgoT().TempDir())
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("doesn't serve cached commits without access to the remote", func() {
+		first := commit("a.yaml", "a")
+		Expect(cache.Checkout(context.TODO(), scope, remote, first, GinkgoT().TempDir())).To(Succeed())
+
+		noAccess := &git.Remote{URL: upstream, Lister: failingLister{}}
+		err := cache.Checkout(context.TODO(), scope, noAccess, first, GinkgoT().TempDir())
+		Expect(err).To(MatchError(ContainSubstring("failed to access")))
+	})
+
+	It("doesn't share cached commits between scopes", func() {
+		first := commit("a.yaml", "a")
+		Expect(cache.Checkout(context.TODO(), scope, remote, first, GinkgoT().TempDir())).To(Succeed())
+
+		// the commit can't be fetched from the remote of the other scope
+		missing := filepath.Join(GinkgoT().TempDir(), "missing")
+		other := &git.Remote{URL: missing, Lister: &git.GoGitRemoteLister{URL: upstream}}
+		Expect(cache.Checkout(context.TODO(), "other/auth", other, first, GinkgoT().TempDir())).ToNot(Succeed())
+	})
+})
+
+type failingLister struct{}
+
+func (failingLister) List(bool) ([]*git.RemoteRef, error) {
+	return nil, errors.New("authentication required")
+}
@@ -2,12 +2,15 @@ package git
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/rancher/fleet/internal/config"
+	"github.com/rancher/fleet/internal/gitcredentials"
 	"github.com/rancher/fleet/internal/ssh"
 	v1alpha1 "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	"github.com/rancher/fleet/pkg/cert"
 
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
@@ -22,6 +25,8 @@ type KnownHostsGetter interface {
 
 type Fetch struct {
 	KnownHosts KnownHostsGetter
+	// Credentials mints tokens for client secrets configuring a credential provider.
+	Credentials *gitcredentials.Cache
 }
 
 func NewFetch() *Fetch {
@@ -29,23 +34,80 @@ func NewFetch() *Fetch {
 }
 
 func (f *Fetch) LatestCommit(ctx context.Context, gitrepo *v1alpha1.GitRepo, client client.Client) (string, error) {
-	secretName := config.DefaultGitCredentialsSecretName
+	if gitrepo.Spec.TagSemver != "" {
+		_, commit, err := f.LatestTag(ctx, gitrepo, client)
+		return commit, err
+	}
+
+	r, err := f.remote(ctx, gitrepo, client)
+	if err != nil {
+		return "", err
+	}
+
+	if gitrepo.Spec.Revision != "" {
+		return r.RevisionCommit(gitrepo.Spec.Revision)
+	}
+
+	branch := gitrepo.Spec.Branch
+	if branch == "" {
+		branch = "master"
+	}
+	return r.LatestBranchCommit(ctx, branch)
+}
+
+// LatestTag returns the highest tag matching the semver constraint of the gitrepo and the commit it points to.
+func (f *Fetch) LatestTag(ctx context.Context, gitrepo *v1alpha1.GitRepo, client client.Client) (string, string, error) {
+	r, err := f.remote(ctx, gitrepo, client)
+	if err != nil {
+		return "", "", err
+	}
+
+	return r.LatestTagCommit(gitrepo.Spec.TagSemver)
+}
+
+// Checkout checks out the commit of the gitrepo into dst, using the cached clone of its repository.
+func (f *Fetch) Checkout(ctx context.Context, gitrepo *v1alpha1.GitRepo, client client.Client, cache *Cache, commit, dst string) error {
+	r, err := f.remote(ctx, gitrepo, client)
+	if err != nil {
+		return err
+	}
+
+	if err := cache.Checkout(ctx, CacheScope(gitrepo), r, commit, dst); err != nil {
+		return err
+	}
+	if !gitrepo.Spec.LFS {
+		return nil
+	}
+
+	var opts LFSOptions
+	if lister, ok := r.Lister.(*GoGitRemoteLister); ok {
+		opts = LFSOptions{Auth: lister.Auth, CABundle: lister.CABundle, InsecureSkipTLS: lister.InsecureSkipTLS}
+	}
+	return FetchLFSObjects(ctx, r.URL, dst, opts)
+}
+
+// clientSecretName returns the name of the secret with the credentials of the gitrepo.
+func clientSecretName(gitrepo *v1alpha1.GitRepo) string {
 	if gitrepo.Spec.ClientSecretName != "" {
-		secretName = gitrepo.Spec.ClientSecretName
+		return gitrepo.Spec.ClientSecretName
 	}
+	return config.DefaultGitCredentialsSecretName
+}
+
+func (f *Fetch) remote(ctx context.Context, gitrepo *v1alpha1.GitRepo, client client.Client) (*Remote, error) {
 	var secret corev1.Secret
 	err := client.Get(ctx, types.NamespacedName{
 		Namespace: gitrepo.Namespace,
-		Name:      secretName,
+		Name:      clientSecretName(gitrepo),
 	}, &secret)
 
 	if err != nil && !errors.IsNotFound(err) {
-		return "", err
+		return nil, err
 	}
 
-	branch := gitrepo.Spec.Branch
-	if branch == "" {
-		branch = "master"
+	auth, err := f.providerAuth(ctx, &secret)
+	if err != nil {
+		return nil, err
 	}
 
 	// Fall back to Rancher-configured CA bundles if no CA bundle is specified in the GitRepo
@@ -53,7 +115,7 @@ func (f *Fetch) LatestCommit(ctx context.Context, gitrepo *v1alpha1.GitRepo, cli
 	if len(cabundle) == 0 {
 		cab, err := cert.GetRancherCABundle(ctx, client)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 
 		cabundle = cab
@@ -63,7 +125,7 @@ func (f *Fetch) LatestCommit(ctx context.Context, gitrepo *v1alpha1.GitRepo, cli
 	if f.KnownHosts != nil && f.KnownHosts.IsStrict() && ssh.Is(gitrepo.Spec.Repo) {
 		kh, err := f.KnownHosts.GetWithSecret(ctx, client, &secret)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 
 		// known_hosts data may come from sources other than the secret, such as a config map.
@@ -75,20 +137,29 @@ func (f *Fetch) LatestCommit(ctx context.Context, gitrepo *v1alpha1.GitRepo, cli
 		secret.Data["known_hosts"] = nil
 	}
 
-	r, err := NewRemote(gitrepo.Spec.Repo, &options{
+	return NewRemote(gitrepo.Spec.Repo, &options{
 		CABundle:          cabundle,
 		Credential:        &secret,
+		Auth:              auth,
 		InsecureTLSVerify: gitrepo.Spec.InsecureSkipTLSverify,
 		KnownHosts:        knownHosts,
 		Timeout:           config.Get().GitClientTimeout.Duration,
 		log:               log.FromContext(ctx),
 	})
-	if err != nil {
-		return "", err
-	}
+}
 
-	if gitrepo.Spec.Revision != "" {
-		return r.RevisionCommit(gitrepo.Spec.Revision)
+// providerAuth returns the token of the credential provider configured by the secret, or nil if the secret does not
+// configure one.
+func (f *Fetch) providerAuth(ctx context.Context, secret *corev1.Secret) (transport.AuthMethod, error) {
+	if !gitcredentials.HasProvider(secret) {
+		return nil, nil
 	}
-	return r.LatestBranchCommit(ctx, branch)
+	if f.Credentials == nil {
+		return nil, fmt.Errorf("credential providers are not enabled, cannot use secret %s/%s", secret.Namespace, secret.Name)
+	}
+	token, err := f.Credentials.Token(ctx, secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token from credential provider of secret %s/%s: %w", secret.Namespace, secret.Name, err)
+	}
+	return token.AuthMethod(), nil
 }
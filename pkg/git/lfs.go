@@ -0,0 +1,399 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	httpgit "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gossh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	giturls "github.com/rancher/fleet/pkg/git-urls"
+	cryptossh "golang.org/x/crypto/ssh"
+)
+
+const (
+	lfsMediaType      = "application/vnd.git-lfs+json"
+	lfsPointerVersion = "version https://git-lfs.github.com/spec/v1"
+	// lfsMaxPointerSize is the maximum size of a pointer file, larger files are never pointers.
+	lfsMaxPointerSize = 1024
+	// lfsBatchSize is the number of objects requested from the batch API at once.
+	lfsBatchSize = 100
+)
+
+// LFSOptions configures how Git LFS objects are downloaded. The credentials of the repository are used.
+type LFSOptions struct {
+	Auth            transport.AuthMethod
+	CABundle        []byte
+	InsecureSkipTLS bool
+}
+
+type lfsPointer struct {
+	OID  string `json:"oid"`
+	Size int64  `json:"size"`
+}
+
+type lfsAction struct {
+	Href   string            `json:"href"`
+	Header map[string]string `json:"header,omitempty"`
+}
+
+type lfsBatchRequest struct {
+	Operation string       `json:"operation"`
+	Transfers []string     `json:"transfers"`
+	Objects   []lfsPointer `json:"objects"`
+}
+
+type lfsBatchResponse struct {
+	Objects []struct {
+		lfsPointer
+		Actions struct {
+			Download *lfsAction `json:"download"`
+		} `json:"actions"`
+		Error *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	} `json:"objects"`
+}
+
+// FetchLFSObjects replaces the Git LFS pointer files in dir, the checked out worktree of the repository at repoURL,
+// with the objects they point to. Files which are not checked out, e.g. outside of the directories of a sparse
+// checkout, are not downloaded.
+func FetchLFSObjects(ctx context.Context, repoURL, dir string, opts LFSOptions) error {
+	pointers, err := findLFSPointers(dir)
+	if err != nil {
+		return err
+	}
+	if len(pointers) == 0 {
+		return nil
+	}
+
+	endpoint, err := lfsEndpoint(ctx, repoURL, opts.Auth)
+	if err != nil {
+		return err
+	}
+	client, err := lfsHTTPClient(opts)
+	if err != nil {
+		return err
+	}
+
+	objects := make([]lfsPointer, 0, len(pointers))
+	for p := range pointers {
+		objects = append(objects, p)
+	}
+	for start := 0; start < len(objects); start += lfsBatchSize {
+		end := min(start+lfsBatchSize, len(objects))
+		res, err := endpoint.batch(ctx, client, objects[start:end])
+		if err != nil {
+			return err
+		}
+		for _, o := range res.Objects {
+			if o.Error != nil {
+				return fmt.Errorf("failed to download LFS object %s: %d %s", o.OID, o.Error.Code, o.Error.Message)
+			}
+			if o.Actions.Download == nil {
+				// the object does not need to be downloaded, e.g. an empty file
+				continue
+			}
+			paths, ok := pointers[o.lfsPointer]
+			if !ok {
+				return fmt.Errorf("LFS batch response contains object %s of size %d, which was not requested", o.OID, o.Size)
+			}
+			if err := endpoint.download(ctx, client, o.lfsPointer, *o.Actions.Download, paths); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// findLFSPointers returns the paths of the pointer files in dir by the objects they point to.
+func findLFSPointers(dir string) (map[lfsPointer][]string, error) {
+	pointers := map[lfsPointer][]string{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() > lfsMaxPointerSize {
+			return nil
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if p, ok := parseLFSPointer(b); ok {
+			pointers[p] = append(pointers[p], path)
+		}
+		return nil
+	})
+	return pointers, err
+}
+
+// parseLFSPointer parses a pointer file, as specified by https://github.com/git-lfs/git-lfs/blob/main/docs/spec.md.
+func parseLFSPointer(b []byte) (lfsPointer, bool) {
+	if !bytes.HasPrefix(b, []byte(lfsPointerVersion+"\n")) {
+		return lfsPointer{}, false
+	}
+
+	var p lfsPointer
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		key, value, _ := strings.Cut(s.Text(), " ")
+		switch key {
+		case "oid":
+			oid, ok := strings.CutPrefix(value, "sha256:")
+			if !ok || len(oid) != sha256.Size*2 {
+				return lfsPointer{}, false
+			}
+			p.OID = oid
+		case "size":
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || size < 0 {
+				return lfsPointer{}, false
+			}
+			p.Size = size
+		}
+	}
+
+	return p, p.OID != ""
+}
+
+// lfsServer is the LFS API of a repository.
+type lfsServer struct {
+	url    string
+	header map[string]string
+	auth   *httpgit.BasicAuth
+}
+
+// lfsEndpoint returns the LFS API of the repository. For SSH repositories, the endpoint and its credentials are
+// requested with git-lfs-authenticate, like the git-lfs client does.
+func lfsEndpoint(ctx context.Context, repoURL string, auth transport.AuthMethod) (*lfsServer, error) {
+	u, err := giturls.Parse(repoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme == "ssh" {
+		if keys, ok := auth.(*gossh.PublicKeys); ok {
+			return sshLFSAuthenticate(ctx, u, keys)
+		}
+		u.Scheme, u.User, u.Host = "https", nil, u.Hostname()
+	}
+
+	server := &lfsServer{url: lfsURL(u)}
+	if basic, ok := auth.(*httpgit.BasicAuth); ok {
+		server.auth = basic
+	}
+	return server, nil
+}
+
+// lfsURL returns the default LFS API URL of a repository, "<repo>.git/info/lfs".
+func lfsURL(u *url.URL) string {
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	if !strings.HasSuffix(u.Path, ".git") {
+		u.Path += ".git"
+	}
+	u.Path += "/info/lfs"
+	return u.String()
+}
+
+// sshLFSAuthenticate runs git-lfs-authenticate on the SSH server of the repository, which returns the LFS API and
+// the headers to authenticate with it.
+func sshLFSAuthenticate(ctx context.Context, u *url.URL, auth *gossh.PublicKeys) (*lfsServer, error) {
+	cfg, err := auth.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "22")
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	c, chans, reqs, err := cryptossh.NewClientConn(conn, host, cfg)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	client := cryptossh.NewClient(c, chans, reqs)
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	path := strings.TrimPrefix(u.Path, "/")
+	out, err := session.Output("git-lfs-authenticate '" + strings.ReplaceAll(path, "'", `'\''`) + "' download")
+	if err != nil {
+		return nil, fmt.Errorf("failed to authenticate for LFS with %s: %w", u.Hostname(), err)
+	}
+
+	var res lfsAction
+	if err := json.Unmarshal(out, &res); err != nil {
+		return nil, fmt.Errorf("failed to parse git-lfs-authenticate response: %w", err)
+	}
+	return &lfsServer{url: res.Href, header: res.Header}, nil
+}
+
+func (s *lfsServer) batch(ctx context.Context, client *http.Client, objects []lfsPointer) (*lfsBatchResponse, error) {
+	b, err := json.Marshal(lfsBatchRequest{Operation: "download", Transfers: []string{"basic"}, Objects: objects})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(s.url, "/")+"/objects/batch", bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", lfsMediaType)
+	req.Header.Set("Content-Type", lfsMediaType)
+	s.authorize(req, s.header)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("LFS batch request to %s failed: %s", req.URL.Redacted(), resp.Status)
+	}
+
+	var res lfsBatchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, fmt.Errorf("failed to parse LFS batch response: %w", err)
+	}
+	return &res, nil
+}
+
+// download downloads the object and writes it to the paths of its pointer files, after verifying it.
+func (s *lfsServer) download(ctx context.Context, client *http.Client, p lfsPointer, action lfsAction, paths []string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, action.Href, nil)
+	if err != nil {
+		return err
+	}
+	s.authorize(req, action.Header)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download LFS object %s: %s", p.OID, resp.Status)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(paths[0]), ".lfs-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	// read at most one byte more than the pointer's size, so larger objects are
+	// detected without writing them to disk
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, h), io.LimitReader(resp.Body, p.Size+1))
+	if err != nil {
+		return err
+	}
+	if n != p.Size {
+		return fmt.Errorf("LFS object %s does not match the size of its pointer %d", p.OID, p.Size)
+	}
+	if hex.EncodeToString(h.Sum(nil)) != p.OID {
+		return fmt.Errorf("LFS object %s does not match its pointer", p.OID)
+	}
+
+	for _, path := range paths {
+		if err := copyFile(tmp, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// authorize sets the headers of the action. The credentials of the repository are only sent to the LFS API itself,
+// if the action does not carry its own authorization, e.g. a pre-signed URL of an object storage.
+func (s *lfsServer) authorize(req *http.Request, header map[string]string) {
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	if s.auth == nil || req.Header.Get("Authorization") != "" {
+		return
+	}
+	if api, err := url.Parse(s.url); err != nil || api.Host != req.URL.Host {
+		return
+	}
+	req.SetBasicAuth(s.auth.Username, s.auth.Password)
+}
+
+func copyFile(src *os.File, dst string) error {
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	info, err := os.Stat(dst)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(dst, os.O_WRONLY|os.O_TRUNC, info.Mode())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, src); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func lfsHTTPClient(opts LFSOptions) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		InsecureSkipVerify: opts.InsecureSkipTLS, // #nosec G402
+	}
+	if len(opts.CABundle) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(opts.CABundle) {
+			return nil, fmt.Errorf("failed to parse CA bundle")
+		}
+		transport.TLSClientConfig.RootCAs = pool
+	}
+	return &http.Client{Transport: transport}, nil
+}
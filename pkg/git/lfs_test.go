@@ -0,0 +1,139 @@
+package git_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	httpgit "github.com/go-git/go-git/v5/plumbing/transport/http"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rancher/fleet/pkg/git"
+)
+
+var _ = Describe("git's LFS tests", func() {
+	const chart = "chart tarball content"
+
+	var (
+		srv      *httptest.Server
+		oid      string
+		dir      string
+		requests []string
+	)
+
+	BeforeEach(func() {
+		sum := sha256.Sum256([]byte(chart))
+		oid = hex.EncodeToString(sum[:])
+		requests = nil
+
+		srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.Method+" "+r.URL.Path)
+			if u, p, ok := r.BasicAuth(); !ok || u != "user" || p != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			switch r.URL.Path {
+			case "/example/charts.git/info/lfs/objects/batch":
+				w.Header().Set("Content-Type", "application/vnd.git-lfs+json")
+				fmt.Fprintf(w, `{"objects":[{"oid":%q,"size":%d,"actions":{"download":{"href":%q}}}]}`,
+					oid, len(chart), "http://"+r.Host+"/objects/"+oid)
+			case "/objects/" + oid:
+				_, _ = w.Write([]byte(chart))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		DeferCleanup(srv.Close)
+
+		dir = GinkgoT().TempDir()
+		pointer := fmt.Sprintf("version https://git-lfs.github.com/spec/v1\noid sha256:%s\nsize %d\n", oid, len(chart))
+		Expect(os.MkdirAll(filepath.Join(dir, "charts"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "charts", "app.tgz"), []byte(pointer), 0600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "charts", "copy.tgz"), []byte(pointer), 0600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "fleet.yaml"), []byte("helm:\n  chart: charts/app.tgz\n"), 0600)).To(Succeed())
+	})
+
+	It("replaces pointer files with their objects", func() {
+		err := git.FetchLFSObjects(context.TODO(), srv.URL+"/example/charts", dir, git.LFSOptions{
+			Auth: &httpgit.BasicAuth{Username: "user", Password: "pass"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		for _, name := range []string{"app.tgz", "copy.tgz"} {
+			b, err := os.ReadFile(filepath.Join(dir, "charts", name))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(b)).To(Equal(chart))
+		}
+		b, err := os.ReadFile(filepath.Join(dir, "fleet.yaml"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(b)).To(ContainSubstring("helm:"))
+		Expect(requests).To(Equal([]string{"POST /example/charts.git/info/lfs/objects/batch", "GET /objects/" + oid}))
+	})
+
+	It("does not contact the server without pointer files", func() {
+		Expect(os.RemoveAll(filepath.Join(dir, "charts"))).To(Succeed())
+		Expect(git.FetchLFSObjects(context.TODO(), srv.URL+"/example/charts", dir, git.LFSOptions{})).To(Succeed())
+		Expect(requests).To(BeEmpty())
+	})
+
+	It("fails for objects not matching their pointer", func() {
+		wrong := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				_ = json.NewEncoder(w).Encode(map[string]any{"objects": []map[string]any{{
+					"oid": oid, "size": len(chart),
+					"actions": map[string]any{"download": map[string]any{"href": "http://" + r.Host + "/object"}},
+				}}})
+				return
+			}
+			_, _ = w.Write([]byte("tampered content!!!!!"))
+		}))
+		defer wrong.Close()
+
+		err := git.FetchLFSObjects(context.TODO(), wrong.URL+"/example/charts.git", dir, git.LFSOptions{})
+		Expect(err).To(MatchError(ContainSubstring("does not match its pointer")))
+	})
+	It("fails for objects larger than their pointer", func() {
+		large := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				_ = json.NewEncoder(w).Encode(map[string]any{"objects": []map[string]any{{
+					"oid": oid, "size": len(chart),
+					"actions": map[string]any{"download": map[string]any{"href": "http://" + r.Host + "/object"}},
+				}}})
+				return
+			}
+			_, _ = w.Write([]byte(chart + strings.Repeat("x", 1<<20)))
+		}))
+		defer large.Close()
+
+		err := git.FetchLFSObjects(context.TODO(), large.URL+"/example/charts.git", dir, git.LFSOptions{})
+		Expect(err).To(MatchError(ContainSubstring("does not match the size of its pointer")))
+	})
+
+	It("fails for objects which were not requested", func() {
+		unexpected := strings.Repeat("0", 64)
+		downloaded := false
+		other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				_ = json.NewEncoder(w).Encode(map[string]any{"objects": []map[string]any{{
+					"oid": unexpected, "size": 1,
+					"actions": map[string]any{"download": map[string]any{"href": "http://" + r.Host + "/object"}},
+				}}})
+				return
+			}
+			downloaded = true
+			_, _ = w.Write([]byte("x"))
+		}))
+		defer other.Close()
+
+		err := git.FetchLFSObjects(context.TODO(), other.URL+"/example/charts.git", dir, git.LFSOptions{})
+		Expect(err).To(MatchError(ContainSubstring("object " + unexpected + " of size 1, which was not requested")))
+		Expect(downloaded).To(BeFalse())
+	})
+})
@@ -55,3 +55,19 @@ func (mr *MockGitFetcherMockRecorder) LatestCommit(arg0, arg1, arg2 any) *gomock
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LatestCommit", reflect.TypeOf((*MockGitFetcher)(nil).LatestCommit), arg0, arg1, arg2)
 }
+
+// LatestTag mocks base method.
+func (m *MockGitFetcher) LatestTag(arg0 context.Context, arg1 *v1alpha1.GitRepo, arg2 client.Client) (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LatestTag", arg0, arg1, arg2)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// LatestTag indicates an expected call of LatestTag.
+func (mr *MockGitFetcherMockRecorder) LatestTag(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LatestTag", reflect.TypeOf((*MockGitFetcher)(nil).LatestTag), arg0, arg1, arg2)
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Masterminds/semver/v3"
 	gogit "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing/transport"
@@ -16,7 +17,9 @@ import (
 )
 
 type options struct {
-	Credential        *corev1.Secret
+	Credential *corev1.Secret
+	// Auth, if set, is used instead of the credential, e.g. for tokens minted by a credential provider.
+	Auth              transport.AuthMethod
 	CABundle          []byte
 	InsecureTLSVerify bool
 	KnownHosts        string
@@ -74,9 +77,13 @@ type Remote struct {
 }
 
 func NewRemote(url string, opts *options) (*Remote, error) {
-	auth, err := GetAuthFromSecret(url, opts.Credential, opts.KnownHosts)
-	if err != nil {
-		return nil, err
+	auth := opts.Auth
+	if auth == nil {
+		var err error
+		auth, err = GetAuthFromSecret(url, opts.Credential, opts.KnownHosts)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// parse the url to make sure it is valid and convert scp-like urls to ssh
@@ -165,6 +172,53 @@ func (r *Remote) LatestBranchCommit(ctx context.Context, branch string) (string,
 	return "", fmt.Errorf("commit not found for branch: %s", branch)
 }
 
+// LatestTagCommit returns the highest tag matching the given semver constraint and the commit it points to.
+// Tags which are not valid semantic versions are ignored.
+func (r *Remote) LatestTagCommit(constraint string) (string, string, error) {
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid tagSemver constraint %q: %w", constraint, err)
+	}
+
+	refs, err := r.Lister.List(true)
+	if err != nil {
+		return "", "", err
+	}
+
+	var (
+		latest *semver.Version
+		tag    string
+	)
+	commits := map[string]string{}
+	for _, ref := range refs {
+		name, ok := strings.CutPrefix(ref.Name, "refs/tags/")
+		if !ok {
+			continue
+		}
+		// annotated tags are listed twice, the peeled form points to the commit
+		if name, ok := strings.CutSuffix(name, "^{}"); ok {
+			commits[name] = ref.Hash
+			continue
+		}
+		if _, ok := commits[name]; !ok {
+			commits[name] = ref.Hash
+		}
+
+		v, err := semver.NewVersion(name)
+		if err != nil || !c.Check(v) {
+			continue
+		}
+		if latest == nil || v.GreaterThan(latest) {
+			latest, tag = v, name
+		}
+	}
+	if latest == nil {
+		return "", "", fmt.Errorf("no tag found matching %q", constraint)
+	}
+
+	return tag, commits[tag], nil
+}
+
 func formatRefForBranch(branch string) string {
 	return fmt.Sprintf("refs/heads/%s", branch)
 }
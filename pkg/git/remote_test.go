@@ -210,3 +210,52 @@ var _ = Describe("git's LatestBranchCommit tests", func() {
 		})
 	})
 })
+
+var _ = Describe("git's LatestTagCommit tests", func() {
+	var (
+		gitRemote  *git.Remote
+		fakeLister *FakeRemoteLister
+	)
+
+	BeforeEach(func() {
+		fakeLister = &FakeRemoteLister{
+			RetValues: []*git.RemoteRef{
+				{Name: "refs/heads/main", Hash: "bdb35e1950b5829c88df134810a0aa9a7da9bc22"},
+				{Name: "refs/tags/v1.4.0", Hash: "1111111111111111111111111111111111111111"},
+				{Name: "refs/tags/v1.5.2", Hash: "2222222222222222222222222222222222222222"},
+				{Name: "refs/tags/v1.5.2^{}", Hash: "3333333333333333333333333333333333333333"},
+				{Name: "refs/tags/1.10.0", Hash: "4444444444444444444444444444444444444444"},
+				{Name: "refs/tags/v2.0.0", Hash: "5555555555555555555555555555555555555555"},
+				{Name: "refs/tags/release-candidate", Hash: "6666666666666666666666666666666666666666"},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		gitRemote = &git.Remote{Lister: fakeLister}
+	})
+
+	It("returns the highest matching tag and the commit of the annotated tag", func() {
+		tag, commit, err := gitRemote.LatestTagCommit(">=1.4.0 <1.10.0")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tag).To(Equal("v1.5.2"))
+		Expect(commit).To(Equal("3333333333333333333333333333333333333333"))
+	})
+
+	It("compares tags by semantic version", func() {
+		tag, commit, err := gitRemote.LatestTagCommit(">=1.4.0 <2.0.0")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tag).To(Equal("1.10.0"))
+		Expect(commit).To(Equal("4444444444444444444444444444444444444444"))
+	})
+
+	It("returns an error if no tag matches", func() {
+		_, _, err := gitRemote.LatestTagCommit(">=3.0.0")
+		Expect(err).To(MatchError(`no tag found matching ">=3.0.0"`))
+	})
+
+	It("returns an error for an invalid constraint", func() {
+		_, _, err := gitRemote.LatestTagCommit("not a constraint")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,185 @@
+package webhook
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// registryKey is the key of the value the Authorization header of registry webhooks must have, if a webhook
+	// secret is used.
+	registryKey = "registry"
+
+	distributionEventsMediaType = "application/vnd.docker.distribution.events.v1+json"
+	harborPushEvent             = "PUSH_ARTIFACT"
+	dockerHub                   = "docker.io"
+)
+
+var errRegistryAuthorizationFailed = errors.New("registry webhook authorization failed")
+
+// ArchiveFetcher resolves the latest revision of a gitrepo's archive source and, for OCI artifacts following a semver
+// constraint, the selected tag.
+type ArchiveFetcher func(ctx context.Context, gitrepo *fleet.GitRepo, client client.Client) (string, string, error)
+
+// registryEvent is the part of the push events of container registries, which identifies the pushed repositories.
+// Supported are the notifications of the CNCF distribution registry, Harbor and Docker Hub webhooks.
+type registryEvent struct {
+	// distribution
+	Events []struct {
+		Action string `json:"action"`
+		Target struct {
+			Repository string `json:"repository"`
+		} `json:"target"`
+		Request struct {
+			Host string `json:"host"`
+		} `json:"request"`
+	} `json:"events"`
+
+	// Harbor
+	Type      string `json:"type"`
+	EventData struct {
+		Resources []struct {
+			ResourceURL string `json:"resource_url"`
+		} `json:"resources"`
+	} `json:"event_data"`
+
+	// Docker Hub
+	PushData *struct {
+		Tag string `json:"tag"`
+	} `json:"push_data"`
+	Repository struct {
+		RepoName string `json:"repo_name"`
+	} `json:"repository"`
+}
+
+// parseRegistryEvent returns the repositories, e.g. "ghcr.io/example/config", of a registry push event. It returns
+// false if the request is not a registry event.
+func parseRegistryEvent(r *http.Request, body []byte) ([]string, bool) {
+	var event registryEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, false
+	}
+
+	var repos []string
+	switch {
+	case strings.HasPrefix(r.Header.Get("Content-Type"), distributionEventsMediaType) || len(event.Events) > 0:
+		for _, e := range event.Events {
+			if e.Action != "push" || e.Target.Repository == "" {
+				continue
+			}
+			repos = append(repos, e.Request.Host+"/"+e.Target.Repository)
+		}
+	case event.Type == harborPushEvent:
+		for _, res := range event.EventData.Resources {
+			repos = append(repos, trimReference(res.ResourceURL))
+		}
+	case event.PushData != nil && event.Repository.RepoName != "":
+		repos = append(repos, dockerHub+"/"+event.Repository.RepoName)
+	default:
+		return nil, false
+	}
+
+	return repos, true
+}
+
+// handleRegistryEvent updates the webhook commit of the gitrepos following OCI artifacts in the pushed repositories.
+// As payloads differ between registries and the pushed tag is not necessarily the one a gitrepo follows, the
+// artifacts are resolved like the polling job does.
+func (w *Webhook) handleRegistryEvent(ctx context.Context, rw http.ResponseWriter, r *http.Request, repos []string) {
+	var gitRepoList fleet.GitRepoList
+	if err := w.client.List(ctx, &gitRepoList); err != nil {
+		w.logAndReturn(rw, err)
+		return
+	}
+
+	for _, gitrepo := range gitRepoList.Items {
+		if gitrepo.Spec.Archive == nil || gitrepo.Spec.Archive.Type != fleet.ArchiveTypeOCI {
+			continue
+		}
+		ref := normalizeRepository(strings.TrimPrefix(gitrepo.Spec.Repo, "oci://"))
+		matches := false
+		for _, repo := range repos {
+			if normalizeRepository(repo) == ref {
+				matches = true
+				break
+			}
+		}
+		if !matches {
+			continue
+		}
+
+		secret, err := w.getSecret(ctx, gitrepo)
+		if err != nil {
+			w.logAndReturn(rw, err)
+			return
+		}
+		if secret != nil {
+			value, err := getValue(secret, registryKey)
+			if err != nil {
+				w.logAndReturn(rw, err)
+				return
+			}
+			if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(value)) != 1 {
+				w.logAndReturn(rw, errRegistryAuthorizationFailed)
+				return
+			}
+		}
+
+		if w.archives == nil {
+			continue
+		}
+		tag, revision, err := w.archives(ctx, &gitrepo, w.client)
+		if err != nil {
+			w.logAndReturn(rw, err)
+			return
+		}
+		if revision == gitrepo.Status.WebhookCommit && tag == gitrepo.Status.Tag {
+			continue
+		}
+
+		if err := w.updateWebhookCommit(ctx, gitrepo, revision, tag); err != nil {
+			w.logAndReturn(rw, err)
+			return
+		}
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	_, _ = rw.Write([]byte("succeeded"))
+}
+
+// trimReference removes the tag or digest from a reference, e.g. "harbor.example.com/library/config:v1".
+func trimReference(ref string) string {
+	if i := strings.Index(ref, "@"); i >= 0 {
+		ref = ref[:i]
+	}
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		ref = ref[:i]
+	}
+	return ref
+}
+
+// normalizeRepository returns the repository in a form, which can be compared, e.g. "docker.io/library/nginx" for
+// "index.docker.io/nginx".
+func normalizeRepository(repo string) string {
+	repo = strings.ToLower(strings.TrimSuffix(repo, "/"))
+	registry, path, ok := strings.Cut(repo, "/")
+	if !ok {
+		return repo
+	}
+	switch registry {
+	case "index.docker.io", "registry-1.docker.io":
+		registry = dockerHub
+	}
+	if registry == dockerHub && !strings.Contains(path, "/") {
+		path = "library/" + path
+	}
+	return registry + "/" + path
+}
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/go-logr/logr"
 	"github.com/go-playground/webhooks/v6/azuredevops"
 	"github.com/go-playground/webhooks/v6/bitbucket"
@@ -23,6 +24,7 @@ import (
 	"github.com/gorilla/mux"
 
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/archive"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -42,17 +44,28 @@ const (
 	tagRefPrefix    = "refs/tags/"
 )
 
+// TagFetcher resolves the highest tag matching the semver constraint of a gitrepo and the commit it points to.
+type TagFetcher interface {
+	LatestTag(ctx context.Context, gitrepo *fleet.GitRepo, client client.Client) (string, string, error)
+}
+
 type Webhook struct {
 	client    client.Client
 	namespace string
 	log       logr.Logger
+	// fetcher is used for gitrepos following tags by a semver constraint, webhooks for them are ignored if it is nil.
+	fetcher TagFetcher
+	// archives resolves the OCI artifacts of gitrepos, when registry webhooks are received.
+	archives ArchiveFetcher
 }
 
-func New(namespace string, client client.Client) (*Webhook, error) {
+func New(namespace string, client client.Client, fetcher TagFetcher) (*Webhook, error) {
 	webhook := &Webhook{
 		client:    client,
 		namespace: namespace,
 		log:       ctrl.Log.WithName("webhook"),
+		fetcher:   fetcher,
+		archives:  archive.Latest,
 	}
 
 	return webhook, nil
@@ -79,6 +92,10 @@ func (w *Webhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 		r.Body = io.NopCloser(bytes.NewBuffer(body))
 		payload, err = parseWebhook(r, nil)
 		if payload == nil && err == nil {
+			if repos, ok := parseRegistryEvent(r, body); ok {
+				w.handleRegistryEvent(ctx, rw, r, repos)
+				return
+			}
 			w.log.V(1).Info("Ignoring unknown webhook event")
 			return
 		}
@@ -91,7 +108,7 @@ func (w *Webhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	revision, branch, _, repoURLs := parsePayload(payload)
+	revision, branch, tag, repoURLs := parsePayload(payload)
 
 	var gitRepoList fleet.GitRepoList
 	err = w.client.List(ctx, &gitRepoList, &client.ListOptions{LabelSelector: labels.Everything()})
@@ -120,63 +137,57 @@ func (w *Webhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 			if gitrepo.Spec.Revision != "" {
 				continue
 			}
+			// the commit of gitrepos with additional sources combines the commits of all of them, it is
+			// resolved by polling
+			if len(gitrepo.Spec.Sources) > 0 {
+				continue
+			}
 
 			if !repoRegexp.MatchString(gitrepo.Spec.Repo) {
 				continue
 			}
 
-			if gitrepo.Spec.Branch != "" {
-				// we check if the branch from webhook matches gitrepo's branch
-				if branch == "" || branch != gitrepo.Spec.Branch {
+			// the commit is resolved per gitrepo, a tag resolved for one gitrepo must not be used for the next
+			commit, selectedTag, verified := revision, "", false
+			if gitrepo.Spec.TagSemver != "" {
+				if !w.matchesTagSemver(gitrepo, tag) {
 					continue
 				}
-			}
-
-			if gitrepo.Status.WebhookCommit != revision && revision != "" {
-				// before updating the gitrepo check if a secret was
-				// defined and, if so, verify that it is correct
-				secret, err := w.getSecret(ctx, gitrepo)
-				if err != nil {
+				// verify the payload before contacting the remote, unauthenticated requests must not
+				// trigger requests to git servers
+				if err := w.verifyPayload(ctx, r, body, gitrepo); err != nil {
 					w.logAndReturn(rw, err)
 					return
 				}
-				if secret != nil {
-					// At this point we know that a secret is defined and exists.
-					// Parse the request again (this time with secret)
-					// We need to parse twice because in the first parsing we didn't
-					// know the gitrepo associated with the webhook payload.
-					// The first parsing is used to get the gitrepo and, if a secret is
-					// defined in the gitrepo, it takes precedence over the global one.
-					r.Body = io.NopCloser(bytes.NewBuffer(body))
-					_, err = parseWebhook(r, secret)
-					if err != nil {
-						w.logAndReturn(rw, err)
-						return
-					}
-				}
-
-				var gitRepoFromCluster fleet.GitRepo
-				err = w.client.Get(
-					ctx,
-					types.NamespacedName{
-						Name:      gitrepo.Name,
-						Namespace: gitrepo.Namespace,
-					}, &gitRepoFromCluster,
-				)
+				verified = true
+				// the pushed tag is not necessarily the highest matching one, and for annotated tags the
+				// revision is not the commit. Resolve the tag, like the polling job does.
+				var err error
+				selectedTag, commit, err = w.fetcher.LatestTag(ctx, &gitrepo, w.client)
 				if err != nil {
 					w.logAndReturn(rw, err)
 					return
 				}
-				orig := gitRepoFromCluster.DeepCopy()
-				gitRepoFromCluster.Status.WebhookCommit = revision
-				// if PollingInterval is not set and webhook is configured, set it to 1 hour
-				if gitRepoFromCluster.Spec.PollingInterval == nil {
-					gitRepoFromCluster.Spec.PollingInterval = &metav1.Duration{
-						Duration: webhookDefaultSyncInterval * time.Second,
+				if selectedTag == gitrepo.Status.Tag {
+					continue
+				}
+			} else if gitrepo.Spec.Branch != "" {
+				// we check if the branch from webhook matches gitrepo's branch
+				if branch == "" || branch != gitrepo.Spec.Branch {
+					continue
+				}
+			}
+
+			if gitrepo.Status.WebhookCommit != commit && commit != "" {
+				// before updating the gitrepo verify the payload
+				if !verified {
+					if err := w.verifyPayload(ctx, r, body, gitrepo); err != nil {
+						w.logAndReturn(rw, err)
+						return
 					}
 				}
-				p := client.MergeFrom(orig)
-				if err := w.client.Status().Patch(ctx, &gitRepoFromCluster, p); err != nil {
+
+				if err := w.updateWebhookCommit(ctx, gitrepo, commit, selectedTag); err != nil {
 					w.logAndReturn(rw, err)
 					return
 				}
@@ -187,9 +198,57 @@ func (w *Webhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 	_, _ = rw.Write([]byte("succeeded"))
 }
 
-func HandleHooks(ctx context.Context, namespace string, client client.Client, clientCache cache.Cache) (http.Handler, error) {
+// verifyPayload checks if a secret was defined for the gitrepo and, if so, verifies the payload with it.
+func (w *Webhook) verifyPayload(ctx context.Context, r *http.Request, body []byte, gitrepo fleet.GitRepo) error {
+	secret, err := w.getSecret(ctx, gitrepo)
+	if err != nil {
+		return err
+	}
+	if secret == nil {
+		return nil
+	}
+	// At this point we know that a secret is defined and exists.
+	// Parse the request again (this time with secret)
+	// We need to parse twice because in the first parsing we didn't
+	// know the gitrepo associated with the webhook payload.
+	// The first parsing is used to get the gitrepo and, if a secret is
+	// defined in the gitrepo, it takes precedence over the global one.
+	r.Body = io.NopCloser(bytes.NewBuffer(body))
+	_, err = parseWebhook(r, secret)
+	return err
+}
+
+// updateWebhookCommit sets the webhook commit, and the tag if not empty, in the status of the gitrepo.
+func (w *Webhook) updateWebhookCommit(ctx context.Context, gitrepo fleet.GitRepo, revision, tag string) error {
+	var gitRepoFromCluster fleet.GitRepo
+	err := w.client.Get(
+		ctx,
+		types.NamespacedName{
+			Name:      gitrepo.Name,
+			Namespace: gitrepo.Namespace,
+		}, &gitRepoFromCluster,
+	)
+	if err != nil {
+		return err
+	}
+	orig := gitRepoFromCluster.DeepCopy()
+	gitRepoFromCluster.Status.WebhookCommit = revision
+	if tag != "" {
+		gitRepoFromCluster.Status.Tag = tag
+	}
+	// if PollingInterval is not set and webhook is configured, set it to 1 hour
+	if gitRepoFromCluster.Spec.PollingInterval == nil {
+		gitRepoFromCluster.Spec.PollingInterval = &metav1.Duration{
+			Duration: webhookDefaultSyncInterval * time.Second,
+		}
+	}
+	p := client.MergeFrom(orig)
+	return w.client.Status().Patch(ctx, &gitRepoFromCluster, p)
+}
+
+func HandleHooks(ctx context.Context, namespace string, client client.Client, clientCache cache.Cache, fetcher TagFetcher) (http.Handler, error) {
 	root := mux.NewRouter()
-	webhook, err := New(namespace, client)
+	webhook, err := New(namespace, client, fetcher)
 	if err != nil {
 		return nil, err
 	}
@@ -199,6 +258,22 @@ func HandleHooks(ctx context.Context, namespace string, client client.Client, cl
 	return root, nil
 }
 
+// matchesTagSemver returns true if the tag from the webhook satisfies the semver constraint of the gitrepo.
+func (w *Webhook) matchesTagSemver(gitrepo fleet.GitRepo, tag string) bool {
+	if w.fetcher == nil || tag == "" {
+		return false
+	}
+	constraint, err := semver.NewConstraint(gitrepo.Spec.TagSemver)
+	if err != nil {
+		return false
+	}
+	v, err := semver.NewVersion(tag)
+	if err != nil {
+		return false
+	}
+	return constraint.Check(v)
+}
+
 func (w *Webhook) logAndReturn(rw http.ResponseWriter, err error) {
 	w.log.Error(err, "Webhook processing failed")
 	rw.WriteHeader(getErrorCodeFromErr(err))
@@ -241,7 +316,8 @@ func getErrorCodeFromErr(err error) int {
 		errors.Is(err, gitlab.ErrGitLabTokenVerificationFailed),
 		errors.Is(err, bitbucket.ErrUUIDVerificationFailed),
 		errors.Is(err, bitbucketserver.ErrHMACVerificationFailed),
-		errors.Is(err, azuredevops.ErrBasicAuthVerificationFailed):
+		errors.Is(err, azuredevops.ErrBasicAuthVerificationFailed),
+		errors.Is(err, errRegistryAuthorizationFailed):
 
 		return http.StatusUnauthorized
 	case
@@ -109,6 +109,114 @@ func TestAzureDevopsWebhook(t *testing.T) {
 	}
 }
 
+type fakeTagFetcher struct {
+	tag, commit string
+}
+
+func (f fakeTagFetcher) LatestTag(context.Context, *v1alpha1.GitRepo, client.Client) (string, string, error) {
+	return f.tag, f.commit, nil
+}
+
+func TestAzureDevopsWebhookTagSemver(t *testing.T) {
+	const tagCommit = "4444444444444444444444444444444444444444"
+	const repoURL = "https://dev.azure.com/fleet/git-test/_git/git-test"
+	gitRepo := func(name, constraint string) *v1alpha1.GitRepo {
+		return &v1alpha1.GitRepo{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       v1alpha1.GitRepoSpec{Repo: repoURL, TagSemver: constraint},
+		}
+	}
+	matching, other := gitRepo("matching", ">=1.4.0 <2.0.0"), gitRepo("other", ">=2.0.0")
+	// listed after matching, it must get the pushed commit, not the tag resolved for matching
+	plain := gitRepo("plain", "")
+	scheme := runtime.NewScheme()
+	utilruntime.Must(corev1.AddToScheme(scheme))
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
+
+	client := cfake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(matching, other, plain).WithStatusSubresource(matching, other, plain).Build()
+	w := &Webhook{client: client, fetcher: fakeTagFetcher{tag: "v1.5.0", commit: tagCommit}}
+	jsonBody := []byte(`{"eventType":"git.push","publisherId":"tfs","resource":{"refUpdates":[{"name":"refs/tags/v1.5.0","oldObjectId":"0000000000000000000000000000000000000000","newObjectId":"f00c3a181697bb3829a6462e931c7456bbed557b"}],"repository":{"id":"xxx","name":"git-test","remoteUrl":"` + repoURL + `"}}}`)
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, repoURL, bytes.NewReader(jsonBody))
+	if err != nil {
+		t.Errorf("unexpected err %v", err)
+	}
+	req.Header = http.Header{"X-Vss-Activityid": []string{"xxx"}}
+
+	w.ServeHTTP(&responseWriter{}, req)
+
+	updated := &v1alpha1.GitRepo{}
+	if err := client.Get(context.TODO(), types.NamespacedName{Name: matching.Name}, updated); err != nil {
+		t.Errorf("unexpected err %v", err)
+	}
+	if updated.Status.WebhookCommit != tagCommit || updated.Status.Tag != "v1.5.0" {
+		t.Errorf("expected webhook commit %v for tag v1.5.0, but got %v for tag %v", tagCommit, updated.Status.WebhookCommit, updated.Status.Tag)
+	}
+
+	if err := client.Get(context.TODO(), types.NamespacedName{Name: other.Name}, updated); err != nil {
+		t.Errorf("unexpected err %v", err)
+	}
+	if updated.Status.WebhookCommit != "" || updated.Status.Tag != "" {
+		t.Errorf("expected gitrepo with non matching constraint not to be updated, got commit %v for tag %v", updated.Status.WebhookCommit, updated.Status.Tag)
+	}
+
+	if err := client.Get(context.TODO(), types.NamespacedName{Name: plain.Name}, updated); err != nil {
+		t.Errorf("unexpected err %v", err)
+	}
+	if updated.Status.WebhookCommit != "f00c3a181697bb3829a6462e931c7456bbed557b" || updated.Status.Tag != "" {
+		t.Errorf("expected gitrepo without constraint to get the pushed commit, got commit %v for tag %v", updated.Status.WebhookCommit, updated.Status.Tag)
+	}
+}
+
+type countingTagFetcher struct {
+	calls int
+}
+
+func (f *countingTagFetcher) LatestTag(context.Context, *v1alpha1.GitRepo, client.Client) (string, string, error) {
+	f.calls++
+	return "v1.5.0", "4444444444444444444444444444444444444444", nil
+}
+
+func TestGitHubWrongSecretTagSemver(t *testing.T) {
+	gitRepo := &v1alpha1.GitRepo{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec: v1alpha1.GitRepoSpec{
+			Repo:      "https://github.com/example/repo",
+			TagSemver: ">=1.0.0",
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: webhookSecretName, Namespace: "default"},
+		Data:       map[string][]byte{"github": []byte("badsecret")},
+	}
+	scheme := runtime.NewScheme()
+	utilruntime.Must(corev1.AddToScheme(scheme))
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
+	client := cfake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(gitRepo, secret).Build()
+
+	fetcher := &countingTagFetcher{}
+	w := &Webhook{client: client, namespace: "default", fetcher: fetcher}
+
+	jsonBody := []byte(`{"ref":"refs/tags/v1.5.0","after":"af69d162de5a276abc86e0686b2b44033cd3f442","repository":{"html_url":"https://github.com/example/repo"}}`)
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "/", bytes.NewReader(jsonBody))
+	if err != nil {
+		t.Fatalf("Failed to create HTTP request: %v", err)
+	}
+	req.Header.Set("X-Github-Event", "push")
+	mac256 := hmac.New(sha256.New, []byte("supersecretvalue"))
+	mac256.Write(jsonBody)
+	req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac256.Sum(nil)))
+
+	rr := httptest.NewRecorder()
+	w.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusUnauthorized {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnauthorized)
+	}
+	if fetcher.calls != 0 {
+		t.Errorf("expected tags not to be fetched for unverified payloads, got %d calls", fetcher.calls)
+	}
+}
+
 func TestAzureDevopsWebhookWithURLSpacing(t *testing.T) {
 	cases := []struct {
 		name    string
@@ -782,3 +890,104 @@ func TestErrorReadingRequest(t *testing.T) {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusInternalServerError)
 	}
 }
+
+func TestRegistryWebhook(t *testing.T) {
+	ociRepo := func(name, repo string) *v1alpha1.GitRepo {
+		return &v1alpha1.GitRepo{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "fleet-local"},
+			Spec: v1alpha1.GitRepoSpec{
+				Repo:          repo,
+				Archive:       &v1alpha1.ArchiveSource{Type: v1alpha1.ArchiveTypeOCI},
+				WebhookSecret: "registry-webhook",
+			},
+		}
+	}
+	harbor := ociRepo("harbor", "oci://harbor.example.com/library/config")
+	hub := ociRepo("hub", "oci://docker.io/example/config")
+	other := ociRepo("other", "oci://harbor.example.com/library/other")
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "registry-webhook", Namespace: "fleet-local"},
+		Data:       map[string][]byte{registryKey: []byte("Bearer token")},
+	}
+
+	tests := map[string]struct {
+		body          string
+		authorization string
+		contentType   string
+		expectedCode  int
+		updated       []string
+	}{
+		"harbor": {
+			body:          `{"type":"PUSH_ARTIFACT","event_data":{"resources":[{"tag":"v1","resource_url":"harbor.example.com/library/config:v1"}]}}`,
+			authorization: "Bearer token",
+			expectedCode:  http.StatusOK,
+			updated:       []string{"harbor"},
+		},
+		"distribution": {
+			body:          `{"events":[{"action":"push","target":{"repository":"library/config","tag":"v1"},"request":{"host":"harbor.example.com"}}]}`,
+			authorization: "Bearer token",
+			contentType:   distributionEventsMediaType,
+			expectedCode:  http.StatusOK,
+			updated:       []string{"harbor"},
+		},
+		"docker hub": {
+			body:          `{"push_data":{"tag":"latest"},"repository":{"repo_name":"example/config"}}`,
+			authorization: "Bearer token",
+			expectedCode:  http.StatusOK,
+			updated:       []string{"hub"},
+		},
+		"wrong authorization": {
+			body:          `{"type":"PUSH_ARTIFACT","event_data":{"resources":[{"resource_url":"harbor.example.com/library/config:v1"}]}}`,
+			authorization: "Bearer wrong",
+			expectedCode:  http.StatusUnauthorized,
+		},
+		"pull": {
+			body:         `{"events":[{"action":"pull","target":{"repository":"library/config"},"request":{"host":"harbor.example.com"}}]}`,
+			contentType:  distributionEventsMediaType,
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			utilruntime.Must(corev1.AddToScheme(scheme))
+			utilruntime.Must(v1alpha1.AddToScheme(scheme))
+			objs := []client.Object{harbor.DeepCopy(), hub.DeepCopy(), other.DeepCopy(), secret.DeepCopy()}
+			c := cfake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).WithStatusSubresource(&v1alpha1.GitRepo{}).Build()
+			w := &Webhook{client: c, archives: func(ctx context.Context, gitrepo *v1alpha1.GitRepo, _ client.Client) (string, string, error) {
+				return "", "rev-" + gitrepo.Name, nil
+			}}
+
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(test.body))
+			req.Header.Set("Authorization", test.authorization)
+			if test.contentType != "" {
+				req.Header.Set("Content-Type", test.contentType)
+			}
+			rec := httptest.NewRecorder()
+			w.ServeHTTP(rec, req)
+			assert.Equal(t, rec.Code, test.expectedCode)
+
+			for _, name := range []string{"harbor", "hub", "other"} {
+				gitrepo := &v1alpha1.GitRepo{}
+				if err := c.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: "fleet-local"}, gitrepo); err != nil {
+					t.Fatal(err)
+				}
+				expected := ""
+				for _, u := range test.updated {
+					if u == name {
+						expected = "rev-" + name
+					}
+				}
+				assert.Equal(t, gitrepo.Status.WebhookCommit, expected)
+			}
+		})
+	}
+}
+
+func TestNormalizeRepository(t *testing.T) {
+	assert.Equal(t, normalizeRepository("index.docker.io/nginx"), "docker.io/library/nginx")
+	assert.Equal(t, normalizeRepository("GHCR.io/Example/Config/"), "ghcr.io/example/config")
+	assert.Equal(t, trimReference("harbor.example.com:8443/library/config:v1"), "harbor.example.com:8443/library/config")
+	assert.Equal(t, trimReference("harbor.example.com/library/config@sha256:abc"), "harbor.example.com/library/config")
+}